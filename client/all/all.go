@@ -1,6 +1,9 @@
 package all
 
 import (
+	_ "github.com/sagan/ptool/client/deluge"
+	_ "github.com/sagan/ptool/client/mock"
 	_ "github.com/sagan/ptool/client/qbittorrent"
+	_ "github.com/sagan/ptool/client/rtorrent"
 	_ "github.com/sagan/ptool/client/transmission"
 )
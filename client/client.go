@@ -72,6 +72,7 @@ type Torrent struct {
 	Seeders            int64 // Cnt of seeders (including self client, if it's seeding), returned by tracker
 	Leechers           int64
 	Meta               map[string]int64
+	Client             string // name of the client which owns the torrent. Set by client group only
 }
 
 type TorrentContentFile struct {
@@ -103,6 +104,21 @@ type TorrentTracker struct {
 
 type TorrentTrackers []TorrentTracker
 
+type TorrentPeer struct {
+	Address       string  // "ip:port". IPv6 address is enclosed in brackets: "[ip]:port"
+	Ip            string  // ip address (without port)
+	Port          int64   // peer port
+	Client        string  // peer client name, e.g. "qBittorrent/4.6.2"
+	PeerId        string  // peer id prefix (Azureus-style client id), e.g. "-qB4620-". May be empty if unknown
+	Progress      float64 // peer's progress of this torrent. [0, 1]
+	DownloadSpeed int64   // download speed from this peer (/s)
+	UploadSpeed   int64   // upload speed to this peer (/s)
+	Downloaded    int64   // total downloaded from this peer. -1 if unknown
+	Uploaded      int64   // total uploaded to this peer. -1 if unknown
+	Flags         string  // client specific peer flags, e.g. qb "D U K E P"
+	Country       string  // country code. May be empty if unknown
+}
+
 type TorrentOption struct {
 	Name               string // if not empty, set name of torrent in client to this value
 	Category           string
@@ -116,9 +132,14 @@ type TorrentOption struct {
 	SkipChecking       bool
 	Pause              bool
 	Resume             bool // use only in ModifyTorrent, to start a paused torrent
-	SequentialDownload bool // qb only
+	SequentialDownload bool // qb / de / tr (4.1+) only
+	// qb 5.1+ only. Action when share limits are reached. See ShareLimitActions. Empty: not set.
+	ShareLimitAction string
 }
 
+// Available values of TorrentOption.ShareLimitAction.
+var ShareLimitActions = []string{"Default", "Stop", "Remove", "RemoveWithContent", "EnableSuperSeeding"}
+
 type TorrentCategory struct {
 	Name     string `json:"name"`
 	SavePath string `json:"savePath"`
@@ -164,6 +185,8 @@ type Client interface {
 	GetTorrentContents(infoHash string) ([]*TorrentContentFile, error)
 	PurgeCache()
 	GetStatus() (*Status, error)
+	// Get free disk space of a path (directory) on client host. Not all clients support it.
+	GetFreeSpace(path string) (int64, error)
 	GetName() string
 	GetClientConfig() *config.ClientConfigStruct
 	SetConfig(variable string, value string) error
@@ -172,6 +195,14 @@ type Client interface {
 	EditTorrentTracker(infoHash string, oldTracker string, newTracker string, replaceHost bool) error
 	AddTorrentTrackers(infoHash string, trackers []string, oldTracker string, removeExisting bool) error
 	RemoveTorrentTrackers(infoHash string, trackers []string) error
+	// Get connected peers of a torrent.
+	GetTorrentPeers(infoHash string) ([]*TorrentPeer, error)
+	// Ban peers permanently. peers: list of "ip:port" addresses. Not all clients support it.
+	BanPeers(peers []string) error
+	// Rename a file of torrent. oldPath & newPath: file path relative to torrent save path, using "/" as sep.
+	RenameTorrentFile(infoHash string, oldPath string, newPath string) error
+	// Rename a folder of torrent (e.g. the root folder). Paths are relative to torrent save path.
+	RenameTorrentFolder(infoHash string, oldPath string, newPath string) error
 	// QB only, priority: 0	Do not download; 1	Normal priority; 6	High priority; 7	Maximal priority
 	SetFilePriority(infoHash string, fileIndexes []int64, priority int64) error
 	Cached() bool
@@ -278,15 +309,25 @@ func Find(name string) (*RegInfo, error) {
 	return nil, fmt.Errorf("didn't find client %q", name)
 }
 
+// Return true if name is a client or client group.
 func ClientExists(name string) bool {
 	clientConfig := config.GetClientConfig(name)
-	return clientConfig != nil
+	return clientConfig != nil || config.GetClientGroupClients(name) != nil
 }
 
+// Create a client instance. If name is a client group, create the aggregating client of the group.
 func CreateClient(name string) (Client, error) {
 	if clients[name] != nil {
 		return clients[name], nil
 	}
+	if clientNames := config.GetClientGroupClients(name); clientNames != nil {
+		clientInstance, err := NewGroupClient(name, clientNames)
+		if err != nil {
+			return nil, err
+		}
+		clients[name] = clientInstance
+		return clientInstance, nil
+	}
 	clientConfig := config.GetClientConfig(name)
 	if clientConfig == nil {
 		return nil, fmt.Errorf("client %s not existed", name)
@@ -510,6 +551,38 @@ func PrintTorrentTrackers(trackers TorrentTrackers) {
 	}
 }
 
+func PrintTorrentPeers(peers []*TorrentPeer, showRaw bool) {
+	fmt.Printf("Peers (%d):\n", len(peers))
+	fmt.Printf("%-45s  %-25s  %-8s  %-10s  %-10s  %-10s  %-10s  %s\n",
+		"Address", "Client", "Progress", "DlSpeed", "UpSpeed", "Downloaded", "Uploaded", "Flags")
+	for _, peer := range peers {
+		fmt.Printf("%-45s  ", peer.Address)
+		util.PrintStringInWidth(os.Stdout, peer.Client, 25, true)
+		downloaded := "-"
+		uploaded := "-"
+		if showRaw {
+			if peer.Downloaded >= 0 {
+				downloaded = fmt.Sprint(peer.Downloaded)
+			}
+			if peer.Uploaded >= 0 {
+				uploaded = fmt.Sprint(peer.Uploaded)
+			}
+			fmt.Printf("  %-8.4f  %-10d  %-10d  %-10s  %-10s  %s\n",
+				peer.Progress, peer.DownloadSpeed, peer.UploadSpeed, downloaded, uploaded, peer.Flags)
+		} else {
+			if peer.Downloaded >= 0 {
+				downloaded = util.BytesSize(float64(peer.Downloaded))
+			}
+			if peer.Uploaded >= 0 {
+				uploaded = util.BytesSize(float64(peer.Uploaded))
+			}
+			fmt.Printf("  %-8s  %-10s  %-10s  %-10s  %-10s  %s\n", fmt.Sprintf("%d%%", int(peer.Progress*100)),
+				util.BytesSize(float64(peer.DownloadSpeed)), util.BytesSize(float64(peer.UploadSpeed)),
+				downloaded, uploaded, peer.Flags)
+		}
+	}
+}
+
 func PrintTorrentFiles(files []*TorrentContentFile, showRaw bool) {
 	fmt.Printf("Files (%d):\n", len(files))
 	fmt.Printf("%-5s  %-5s  %-10s  %-5s  %s\n", "No.", "Index", "Size", "Done?", "Path")
@@ -546,6 +619,9 @@ func (torrent *Torrent) Print() {
 	}
 	fmt.Printf("Torrent name: %s\n", torrent.Name)
 	fmt.Printf("- InfoHash: %s\n", torrent.InfoHash)
+	if torrent.Client != "" {
+		fmt.Printf("- Client: %s\n", torrent.Client)
+	}
 	fmt.Printf("- Size: %s (%d)", util.BytesSize(float64(torrent.Size)), torrent.Size)
 	if torrent.Size != torrent.SizeTotal {
 		fmt.Printf(" (partial)")
@@ -581,6 +657,11 @@ func PrintTorrents(output io.Writer, torrents []*Torrent, filter string, showSum
 		width = config.CLIENT_TORRENTS_WIDTH
 	}
 	widthExcludingName := 105 // 40+6+5+6+6+5+5+16+8*2
+	// torrents of client group
+	showClient := slices.ContainsFunc(torrents, func(t *Torrent) bool { return t.Client != "" })
+	if showClient {
+		widthExcludingName += 12
+	}
 	widthName := width - widthExcludingName
 	cnt := int64(0)
 	var cntPaused, cntDownloading, cntSeeding, cntCompleted, cntOthers int64
@@ -589,8 +670,12 @@ func PrintTorrents(output io.Writer, torrents []*Torrent, filter string, showSum
 	largestSize := int64(-1)
 	sizeUnfinished := int64(0)
 	if showSum < 2 {
-		fmt.Fprintf(output, "%-*s  %-40s  %-6s  %-5s  %-6s  %-6s  %-5s  %-5s  %-16s\n",
+		fmt.Fprintf(output, "%-*s  %-40s  %-6s  %-5s  %-6s  %-6s  %-5s  %-5s  %-16s",
 			widthName, "Name", "InfoHash", "Size", "State", "↓S(/s)", "↑S(/s)", "Seeds", "Peers", "Tracker")
+		if showClient {
+			fmt.Fprintf(output, "  %-10s", "Client")
+		}
+		fmt.Fprintf(output, "\n")
 	}
 	for _, torrent := range torrents {
 		if filter != "" && !torrent.MatchFilter(filter) {
@@ -638,7 +723,7 @@ func PrintTorrents(output io.Writer, torrents []*Torrent, filter string, showSum
 		remain := util.PrintStringInWidth(output, name, int64(widthName), true)
 		// 目前遇到的tracker域名最长的: "wintersakura.net"
 		trackerBaseDomain, _ := util.StringPrefixInWidth(torrent.TrackerBaseDomain, 16)
-		fmt.Fprintf(output, "  %-40s  %-6s  %-5s  %-6s  %-6s  %-5d  %-5d  %-16s\n",
+		fmt.Fprintf(output, "  %-40s  %-6s  %-5s  %-6s  %-6s  %-5d  %-5d  %-16s",
 			torrent.InfoHash,
 			util.BytesSizeAround(float64(torrent.Size)),
 			torrent.StateIconText(),
@@ -648,6 +733,11 @@ func PrintTorrents(output io.Writer, torrents []*Torrent, filter string, showSum
 			torrent.Leechers,
 			trackerBaseDomain,
 		)
+		if showClient {
+			clientName, _ := util.StringPrefixInWidth(torrent.Client, 10)
+			fmt.Fprintf(output, "  %-10s", clientName)
+		}
+		fmt.Fprintf(output, "\n")
 		if dense {
 			for {
 				remain = strings.TrimSpace(remain)
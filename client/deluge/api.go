@@ -0,0 +1,211 @@
+package deluge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/util"
+)
+
+type apiRequest struct {
+	Method string `json:"method"`
+	Params []any  `json:"params"`
+	Id     int64  `json:"id"`
+}
+
+type apiError struct {
+	Message string `json:"message"`
+	Code    int64  `json:"code"`
+}
+
+type apiResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *apiError       `json:"error"`
+	Id     int64           `json:"id"`
+}
+
+type apiTorrentTracker struct {
+	Url  string `json:"url"`
+	Tier int64  `json:"tier"`
+}
+
+type apiTorrentFile struct {
+	Index  int64  `json:"index"`
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Offset int64  `json:"offset"`
+}
+
+// Deluge torrent status. Speed limits are in KiB/s, -1 means unlimited.
+type apiTorrentInfo struct {
+	Hash                string               `json:"hash"`
+	Name                string               `json:"name"`
+	State               string               `json:"state"` // Allocating|Checking|Downloading|Seeding|Paused|Error|Queued|Moving
+	Progress            float64              `json:"progress"`
+	IsFinished          bool                 `json:"is_finished"`
+	TotalDone           int64                `json:"total_done"`
+	TotalWanted         int64                `json:"total_wanted"`
+	TotalSize           int64                `json:"total_size"`
+	DownloadLocation    string               `json:"download_location"`
+	Label               string               `json:"label"`
+	Tracker             string               `json:"tracker"`
+	TrackerStatus       string               `json:"tracker_status"`
+	Trackers            []*apiTorrentTracker `json:"trackers"`
+	TimeAdded           float64              `json:"time_added"`
+	CompletedTime       float64              `json:"completed_time"`
+	TimeSinceTransfer   int64                `json:"time_since_transfer"`
+	DownloadPayloadRate int64                `json:"download_payload_rate"`
+	UploadPayloadRate   int64                `json:"upload_payload_rate"`
+	MaxDownloadSpeed    float64              `json:"max_download_speed"`
+	MaxUploadSpeed      float64              `json:"max_upload_speed"`
+	AllTimeDownload     int64                `json:"all_time_download"`
+	TotalUploaded       int64                `json:"total_uploaded"`
+	TotalSeeds          int64                `json:"total_seeds"`
+	TotalPeers          int64                `json:"total_peers"`
+	Files               []*apiTorrentFile    `json:"files"`
+	FileProgress        []float64            `json:"file_progress"`
+	FilePriorities      []int64              `json:"file_priorities"`
+}
+
+type apiTorrentPeer struct {
+	Client    string  `json:"client"`
+	Country   string  `json:"country"`
+	DownSpeed int64   `json:"down_speed"`
+	Ip        string  `json:"ip"` // "ip:port"
+	Progress  float64 `json:"progress"`
+	Seed      int64   `json:"seed"` // non-zero if peer is a seeder
+	UpSpeed   int64   `json:"up_speed"`
+}
+
+// ptool side meta data of a torrent, which Deluge can't store natively.
+type torrentMeta struct {
+	Tags []string         `json:"tags,omitempty"`
+	Meta map[string]int64 `json:"meta,omitempty"`
+}
+
+// Key in meta file that stores client level tags (created by CreateTags, e.g. "_noadd"),
+// which are not attached to any torrent. It never collides with a torrent info-hash.
+const clientMetaKey = "_client"
+
+// keys of torrent status requested in sync.
+var torrentStatusKeys = []string{
+	"hash", "name", "state", "progress", "is_finished", "total_done", "total_wanted", "total_size",
+	"download_location", "label", "tracker", "tracker_status", "trackers", "time_added", "completed_time",
+	"time_since_transfer", "download_payload_rate", "upload_payload_rate", "max_download_speed", "max_upload_speed",
+	"all_time_download", "total_uploaded", "total_seeds", "total_peers",
+}
+
+// keys of torrent status that are additionally requested for a single torrent.
+var torrentDetailStatusKeys = []string{"files", "file_progress", "file_priorities"}
+
+func (dtorrent *apiTorrentInfo) ToTorrentState() string {
+	switch dtorrent.State {
+	case "Downloading":
+		return "downloading"
+	case "Seeding":
+		return "seeding"
+	case "Paused":
+		if dtorrent.IsFinished {
+			return "completed"
+		}
+		return "paused"
+	case "Queued":
+		if dtorrent.IsFinished {
+			return "seeding"
+		}
+		return "downloading"
+	case "Checking", "Allocating", "Moving":
+		return "checking"
+	case "Error":
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+func (dtorrent *apiTorrentInfo) Sep() string {
+	if strings.Contains(dtorrent.DownloadLocation, `\`) {
+		return `\`
+	}
+	return "/"
+}
+
+// Deluge does not report content path. Assume it's the torrent root folder / file inside save path.
+func (dtorrent *apiTorrentInfo) ContentPath() string {
+	return strings.TrimSuffix(dtorrent.DownloadLocation, dtorrent.Sep()) + dtorrent.Sep() + dtorrent.Name
+}
+
+func (dtorrent *apiTorrentInfo) ToTorrent(meta *torrentMeta) *client.Torrent {
+	downloadSpeedLimit := int64(-1)
+	uploadSpeedLimit := int64(-1)
+	if dtorrent.MaxDownloadSpeed > 0 {
+		downloadSpeedLimit = int64(dtorrent.MaxDownloadSpeed * 1024)
+	}
+	if dtorrent.MaxUploadSpeed > 0 {
+		uploadSpeedLimit = int64(dtorrent.MaxUploadSpeed * 1024)
+	}
+	activityTime := int64(0)
+	if dtorrent.TimeSinceTransfer >= 0 {
+		activityTime = util.Now() - dtorrent.TimeSinceTransfer
+	}
+	ctime := int64(0)
+	if dtorrent.IsFinished {
+		ctime = int64(dtorrent.CompletedTime)
+	}
+	tracker := dtorrent.Tracker
+	if tracker == "" && len(dtorrent.Trackers) > 0 {
+		tracker = dtorrent.Trackers[0].Url
+	}
+	torrent := &client.Torrent{
+		InfoHash:           dtorrent.Hash,
+		Name:               dtorrent.Name,
+		TrackerDomain:      util.ParseUrlHostname(tracker),
+		TrackerBaseDomain:  util.GetUrlDomain(tracker),
+		Tracker:            tracker,
+		State:              dtorrent.ToTorrentState(),
+		LowLevelState:      dtorrent.State,
+		Atime:              int64(dtorrent.TimeAdded),
+		Ctime:              ctime,
+		ActivityTime:       activityTime,
+		Category:           dtorrent.Label,
+		SavePath:           dtorrent.DownloadLocation,
+		ContentPath:        dtorrent.ContentPath(),
+		Tags:               []string{},
+		Downloaded:         dtorrent.AllTimeDownload,
+		DownloadSpeed:      dtorrent.DownloadPayloadRate,
+		DownloadSpeedLimit: downloadSpeedLimit,
+		Uploaded:           dtorrent.TotalUploaded,
+		UploadSpeed:        dtorrent.UploadPayloadRate,
+		UploadedSpeedLimit: uploadSpeedLimit,
+		Size:               dtorrent.TotalWanted,
+		SizeTotal:          dtorrent.TotalSize,
+		SizeCompleted:      dtorrent.TotalDone,
+		Seeders:            dtorrent.TotalSeeds,
+		Leechers:           dtorrent.TotalPeers,
+		Meta:               map[string]int64{},
+	}
+	if meta != nil {
+		torrent.Tags = util.CopySlice(meta.Tags)
+		for key, value := range meta.Meta {
+			torrent.Meta[key] = value
+		}
+	}
+	return torrent
+}
+
+// Deluge file priority: 0 Skip; 1 Low; 4 Normal; 7 High.
+// Convert qb style file priority to Deluge one.
+func toDelugeFilePriority(priority int64) (int64, error) {
+	switch priority {
+	case 0:
+		return 0, nil
+	case 1:
+		return 4, nil
+	case 6, 7:
+		return 7, nil
+	default:
+		return 0, fmt.Errorf("invalid file priority %d", priority)
+	}
+}
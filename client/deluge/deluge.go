@@ -0,0 +1,1213 @@
+package deluge
+
+// Deluge (v2.x) Web JSON-RPC API.
+// https://deluge.readthedocs.io/en/latest/reference/webapi.html
+// https://deluge.readthedocs.io/en/latest/reference/api.html
+// Category is mapped to Deluge label (Label plugin). Deluge label only allows lowercase chars.
+// Deluge does not have torrent tags, torrent tags and meta are stored in a local file in ptool config dir.
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/natefinch/atomic"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/util"
+)
+
+const (
+	DEFAULT_PASSWORD = "deluge"
+	// Deluge JSON-RPC error code when session is not logined.
+	ERROR_CODE_NOT_AUTHENTICATED = 1
+)
+
+type Client struct {
+	Name                      string
+	ClientConfig              *config.ClientConfigStruct
+	Config                    *config.ConfigStruct
+	HttpClient                *http.Client
+	Logined                   bool
+	requestId                 int64
+	datatime                  int64
+	torrents                  map[string]*apiTorrentInfo
+	labels                    []string
+	datatimeMeta              int64
+	coreConfig                map[string]any
+	sessionStatus             map[string]float64
+	freeSpace                 int64
+	metas                     map[string]*torrentMeta // info-hash => meta. nil if not loaded
+	unfinishedSize            int64
+	unfinishedDownloadingSize int64
+	contentPathTorrents       map[string][]*apiTorrentInfo
+}
+
+var (
+	ErrNotAuthenticated = errors.New("not authenticated")
+	ErrNotImplemented   = fmt.Errorf("not implemented yet: %w", errors.ErrUnsupported)
+)
+
+func (dclient *Client) apiUrl() string {
+	apiUrl := strings.TrimSuffix(dclient.ClientConfig.Url, "/")
+	if !strings.HasSuffix(apiUrl, "/json") {
+		apiUrl += "/json"
+	}
+	return apiUrl
+}
+
+// Send a raw JSON-RPC request. If result is not nil, unmarshal response result into it.
+func (dclient *Client) rawCall(method string, result any, params ...any) error {
+	if params == nil {
+		params = []any{}
+	}
+	dclient.requestId++
+	payload, err := json.Marshal(&apiRequest{
+		Method: method,
+		Params: params,
+		Id:     dclient.requestId,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, dclient.apiUrl(), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := dclient.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("%s response %d status", method, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var res apiResponse
+	if err = json.Unmarshal(body, &res); err != nil {
+		return fmt.Errorf("%s invalid response: %w", method, err)
+	}
+	if res.Error != nil {
+		if res.Error.Code == ERROR_CODE_NOT_AUTHENTICATED {
+			return ErrNotAuthenticated
+		}
+		return fmt.Errorf("%s error: %s (code %d)", method, res.Error.Message, res.Error.Code)
+	}
+	if result != nil && len(res.Result) > 0 {
+		return json.Unmarshal(res.Result, result)
+	}
+	return nil
+}
+
+// Call a Deluge RPC method. Automatically (re-)login if required.
+func (dclient *Client) call(method string, result any, params ...any) error {
+	if err := dclient.login(); err != nil {
+		return fmt.Errorf("login error: %w", err)
+	}
+	err := dclient.rawCall(method, result, params...)
+	if err == ErrNotAuthenticated {
+		// session expired
+		dclient.Logined = false
+		if err = dclient.login(); err != nil {
+			return fmt.Errorf("login error: %w", err)
+		}
+		err = dclient.rawCall(method, result, params...)
+	}
+	return err
+}
+
+// Login to Deluge Web, and make sure web UI is connected to a daemon.
+func (dclient *Client) login() error {
+	if dclient.Logined {
+		return nil
+	}
+	password := dclient.ClientConfig.Password
+	if password == "" {
+		password = DEFAULT_PASSWORD
+	}
+	var ok bool
+	if err := dclient.rawCall("auth.login", &ok, password); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("incorrect password")
+	}
+	var connected bool
+	if err := dclient.rawCall("web.connected", &connected); err != nil {
+		return err
+	}
+	if !connected {
+		// [[id, host, port, username]]
+		var hosts [][]any
+		if err := dclient.rawCall("web.get_hosts", &hosts); err != nil {
+			return err
+		}
+		if len(hosts) == 0 || len(hosts[0]) == 0 {
+			return fmt.Errorf("deluge web is not connected to any daemon and no host available")
+		}
+		if err := dclient.rawCall("web.connect", nil, hosts[0][0]); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+	}
+	dclient.Logined = true
+	return nil
+}
+
+func (dclient *Client) metaFile() string {
+	return filepath.Join(config.ConfigDir, "deluge-"+dclient.Name+".json")
+}
+
+func (dclient *Client) loadMetas() error {
+	if dclient.metas != nil {
+		return nil
+	}
+	metas := map[string]*torrentMeta{}
+	contents, err := os.ReadFile(dclient.metaFile())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read meta file: %w", err)
+		}
+	} else if err = json.Unmarshal(contents, &metas); err != nil {
+		return fmt.Errorf("failed to parse meta file: %w", err)
+	}
+	dclient.metas = metas
+	return nil
+}
+
+func (dclient *Client) saveMetas() error {
+	if dclient.metas == nil {
+		return nil
+	}
+	contents, err := json.Marshal(dclient.metas)
+	if err != nil {
+		return err
+	}
+	return atomic.WriteFile(dclient.metaFile(), bytes.NewReader(contents))
+}
+
+// Update ptool side tags & meta of torrents and persist them.
+func (dclient *Client) updateMetas(infoHashes []string, addTags []string, removeTags []string,
+	meta map[string]int64) error {
+	if err := dclient.loadMetas(); err != nil {
+		return err
+	}
+	for _, infoHash := range infoHashes {
+		tmeta := dclient.metas[infoHash]
+		if tmeta == nil {
+			tmeta = &torrentMeta{}
+		}
+		tags := util.Filter(tmeta.Tags, func(tag string) bool {
+			return !slices.Contains(removeTags, tag)
+		})
+		for _, tag := range addTags {
+			if tag != "" && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		tmeta.Tags = tags
+		if len(meta) > 0 {
+			tmeta.Meta = meta
+		}
+		if len(tmeta.Tags) == 0 && len(tmeta.Meta) == 0 {
+			delete(dclient.metas, infoHash)
+		} else {
+			dclient.metas[infoHash] = tmeta
+		}
+	}
+	return dclient.saveMetas()
+}
+
+func (dclient *Client) sync() error {
+	if dclient.datatime > 0 {
+		return nil
+	}
+	if err := dclient.loadMetas(); err != nil {
+		return err
+	}
+	torrents := map[string]*apiTorrentInfo{}
+	if err := dclient.call("core.get_torrents_status", &torrents, map[string]any{}, torrentStatusKeys); err != nil {
+		return err
+	}
+	dclient.datatime = util.Now()
+	dclient.torrents = torrents
+	dclient.buildDerivative()
+	return nil
+}
+
+func (dclient *Client) buildDerivative() {
+	unfinishedSize := int64(0)
+	unfinishedDownloadingSize := int64(0)
+	contentPathTorrents := map[string][]*apiTorrentInfo{}
+	for hash, torrent := range dclient.torrents {
+		torrent.Hash = hash
+		usize := torrent.TotalWanted - torrent.TotalDone
+		unfinishedSize += usize
+		if torrent.State != "Paused" {
+			unfinishedDownloadingSize += usize
+		}
+		contentPathTorrents[torrent.ContentPath()] = append(contentPathTorrents[torrent.ContentPath()], torrent)
+	}
+	dclient.unfinishedSize = unfinishedSize
+	dclient.unfinishedDownloadingSize = unfinishedDownloadingSize
+	dclient.contentPathTorrents = contentPathTorrents
+}
+
+func (dclient *Client) syncMeta() error {
+	if dclient.datatimeMeta > 0 {
+		return nil
+	}
+	coreConfig := map[string]any{}
+	if err := dclient.call("core.get_config", &coreConfig); err != nil {
+		return err
+	}
+	sessionStatus := map[string]float64{}
+	if err := dclient.call("core.get_session_status", &sessionStatus,
+		[]string{"payload_download_rate", "payload_upload_rate"}); err != nil {
+		return err
+	}
+	freeSpace := int64(-1)
+	if downloadLocation, ok := coreConfig["download_location"].(string); ok {
+		if err := dclient.call("core.get_free_space", &freeSpace, downloadLocation); err != nil {
+			log.Debugf("deluge get free space error: %v", err)
+			freeSpace = -1
+		}
+	}
+	var labels []string
+	if err := dclient.call("label.get_labels", &labels); err != nil {
+		log.Debugf("deluge get labels error (label plugin not enabled?): %v", err)
+	}
+	dclient.datatimeMeta = util.Now()
+	dclient.coreConfig = coreConfig
+	dclient.sessionStatus = sessionStatus
+	dclient.freeSpace = freeSpace
+	dclient.labels = labels
+	return nil
+}
+
+// get a torrent info. return error if torrent not found
+func (dclient *Client) getTorrent(infoHash string) (*apiTorrentInfo, error) {
+	if err := dclient.sync(); err != nil {
+		return nil, err
+	}
+	dtorrent := dclient.torrents[infoHash]
+	if dtorrent == nil {
+		return nil, fmt.Errorf("torrent not found")
+	}
+	return dtorrent, nil
+}
+
+func (dclient *Client) getAllInfoHashes() []string {
+	infoHashes := []string{}
+	for infoHash := range dclient.torrents {
+		infoHashes = append(infoHashes, infoHash)
+	}
+	return infoHashes
+}
+
+func (dclient *Client) toTorrent(dtorrent *apiTorrentInfo) *client.Torrent {
+	return dtorrent.ToTorrent(dclient.metas[dtorrent.Hash])
+}
+
+// Deluge label only allows [a-z0-9_-.] chars.
+func normalizeLabel(category string) string {
+	if category == constants.NONE {
+		return ""
+	}
+	return strings.ToLower(category)
+}
+
+// create label if not exists
+func (dclient *Client) ensureLabel(label string) error {
+	if label == "" {
+		return nil
+	}
+	if err := dclient.syncMeta(); err != nil {
+		return err
+	}
+	if slices.Contains(dclient.labels, label) {
+		return nil
+	}
+	if err := dclient.call("label.add", nil, label); err != nil {
+		return fmt.Errorf("failed to create label %s: %w", label, err)
+	}
+	dclient.labels = append(dclient.labels, label)
+	return nil
+}
+
+func (dclient *Client) setTorrentsLabel(infoHashes []string, category string) error {
+	label := normalizeLabel(category)
+	if err := dclient.ensureLabel(label); err != nil {
+		return err
+	}
+	for _, infoHash := range infoHashes {
+		if err := dclient.call("label.set_torrent", nil, infoHash, label); err != nil {
+			return err
+		}
+		if dclient.torrents[infoHash] != nil {
+			dclient.torrents[infoHash].Label = label
+		}
+	}
+	return nil
+}
+
+func (dclient *Client) setTorrentsOptions(infoHashes []string, options map[string]any) error {
+	if len(infoHashes) == 0 || len(options) == 0 {
+		return nil
+	}
+	return dclient.call("core.set_torrent_options", nil, infoHashes, options)
+}
+
+func (dclient *Client) setTorrentTrackers(infoHash string, trackers []string) error {
+	dtrackers := []*apiTorrentTracker{}
+	for i, tracker := range trackers {
+		dtrackers = append(dtrackers, &apiTorrentTracker{Url: tracker, Tier: int64(i)})
+	}
+	err := dclient.call("core.set_torrent_trackers", nil, infoHash, dtrackers)
+	if err == nil && dclient.torrents[infoHash] != nil {
+		dclient.torrents[infoHash].Trackers = dtrackers
+	}
+	return err
+}
+
+func (dclient *Client) ExportTorrentFile(infoHash string) ([]byte, error) {
+	// Deluge keeps .torrent files in "<config_dir>/state/" dir.
+	if dclient.ClientConfig.LocalTorrentsPath != "" {
+		return os.ReadFile(filepath.Join(dclient.ClientConfig.LocalTorrentsPath, infoHash+".torrent"))
+	}
+	return nil, fmt.Errorf("unsupported: localTorrentsPath (Deluge state dir) is not configured")
+}
+
+func (dclient *Client) GetTorrent(infoHash string) (*client.Torrent, error) {
+	if err := dclient.sync(); err != nil {
+		return nil, err
+	}
+	dtorrent := dclient.torrents[infoHash]
+	if dtorrent == nil {
+		return nil, nil
+	}
+	return dclient.toTorrent(dtorrent), nil
+}
+
+func (dclient *Client) GetTorrents(stateFilter string, category string, showAll bool) ([]*client.Torrent, error) {
+	if err := dclient.sync(); err != nil {
+		return nil, err
+	}
+	torrents := []*client.Torrent{}
+	for _, dtorrent := range dclient.torrents {
+		torrent := dclient.toTorrent(dtorrent)
+		if category != "" {
+			if category == constants.NONE {
+				if torrent.Category != "" {
+					continue
+				}
+			} else if normalizeLabel(category) != torrent.Category {
+				continue
+			}
+		}
+		if !showAll && torrent.DownloadSpeed < 1024 && torrent.UploadSpeed < 1024 {
+			continue
+		}
+		if !torrent.MatchStateFilter(stateFilter) {
+			continue
+		}
+		torrents = append(torrents, torrent)
+	}
+	return torrents, nil
+}
+
+func (dclient *Client) GetTorrentsByContentPath(contentPath string) ([]*client.Torrent, error) {
+	if err := dclient.sync(); err != nil {
+		return nil, err
+	}
+	torrents := []*client.Torrent{}
+	for _, dtorrent := range dclient.contentPathTorrents[contentPath] {
+		torrents = append(torrents, dclient.toTorrent(dtorrent))
+	}
+	return torrents, nil
+}
+
+func (dclient *Client) AddTorrent(torrentContent []byte, option *client.TorrentOption, meta map[string]int64) error {
+	options := map[string]any{
+		"add_paused": option.Pause,
+	}
+	if option.SavePath != "" {
+		options["download_location"] = option.SavePath
+	}
+	if option.Name != "" {
+		// Deluge 2.x only. It only changes the display name of torrent.
+		options["name"] = option.Name
+	}
+	if option.UploadSpeedLimit > 0 {
+		options["max_upload_speed"] = float64(option.UploadSpeedLimit) / 1024
+	}
+	if option.DownloadSpeedLimit > 0 {
+		options["max_download_speed"] = float64(option.DownloadSpeedLimit) / 1024
+	}
+	if option.RatioLimit > 0 {
+		options["stop_at_ratio"] = true
+		options["stop_ratio"] = option.RatioLimit
+	}
+	if option.SkipChecking {
+		options["seed_mode"] = true
+	}
+	if option.SequentialDownload {
+		options["sequential_download"] = true
+	}
+	var infoHash string
+	var err error
+	if util.IsUrl(string(torrentContent)) {
+		err = dclient.call("core.add_torrent_url", &infoHash, string(torrentContent), options)
+	} else if util.IsTorrentUrl(string(torrentContent)) {
+		err = dclient.call("core.add_torrent_magnet", &infoHash, string(torrentContent), options)
+	} else {
+		err = dclient.call("core.add_torrent_file", &infoHash, "ptool.torrent",
+			base64.StdEncoding.EncodeToString(torrentContent), options)
+	}
+	if err != nil {
+		return err
+	}
+	if infoHash == "" {
+		return fmt.Errorf("failed to add torrent: no info-hash returned")
+	}
+	if option.Category != "" && option.Category != constants.NONE {
+		if err = dclient.setTorrentsLabel([]string{infoHash}, option.Category); err != nil {
+			log.Debugf("deluge set torrent %s label error: %v", infoHash, err)
+		}
+	}
+	if len(option.Tags) > 0 || len(meta) > 0 {
+		if err = dclient.updateMetas([]string{infoHash}, option.Tags, nil, meta); err != nil {
+			return err
+		}
+	}
+	// torrent list is stale now
+	dclient.datatime = 0
+	return nil
+}
+
+func (dclient *Client) ModifyTorrent(infoHash string, option *client.TorrentOption, meta map[string]int64) error {
+	dtorrent, err := dclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	torrent := dclient.toTorrent(dtorrent)
+
+	options := map[string]any{}
+	if option.Name != "" && option.Name != torrent.Name {
+		options["name"] = option.Name
+	}
+	if option.DownloadSpeedLimit != 0 && option.DownloadSpeedLimit != torrent.DownloadSpeedLimit {
+		if option.DownloadSpeedLimit > 0 {
+			options["max_download_speed"] = float64(option.DownloadSpeedLimit) / 1024
+		} else {
+			options["max_download_speed"] = -1
+		}
+	}
+	if option.UploadSpeedLimit != 0 && option.UploadSpeedLimit != torrent.UploadedSpeedLimit {
+		if option.UploadSpeedLimit > 0 {
+			options["max_upload_speed"] = float64(option.UploadSpeedLimit) / 1024
+		} else {
+			options["max_upload_speed"] = -1
+		}
+	}
+	if option.RatioLimit > 0 {
+		options["stop_at_ratio"] = true
+		options["stop_ratio"] = option.RatioLimit
+	}
+	if option.SequentialDownload {
+		options["sequential_download"] = true
+	}
+	if err = dclient.setTorrentsOptions([]string{infoHash}, options); err != nil {
+		return err
+	}
+
+	if option.Category != "" && normalizeLabel(option.Category) != torrent.Category {
+		if err = dclient.setTorrentsLabel([]string{infoHash}, option.Category); err != nil {
+			return err
+		}
+	}
+	if len(option.Tags) > 0 || len(option.RemoveTags) > 0 || len(meta) > 0 {
+		if err = dclient.updateMetas([]string{infoHash}, option.Tags, option.RemoveTags, meta); err != nil {
+			return err
+		}
+	}
+	if option.SavePath != "" && option.SavePath != torrent.SavePath {
+		if err = dclient.SetTorrentsSavePath([]string{infoHash}, option.SavePath); err != nil {
+			return err
+		}
+	}
+	if option.Pause {
+		err = dclient.PauseTorrents([]string{infoHash})
+	} else if option.Resume {
+		err = dclient.ResumeTorrents([]string{infoHash})
+	}
+	return err
+}
+
+func (dclient *Client) DeleteTorrents(infoHashes []string, deleteFiles bool) error {
+	if len(infoHashes) == 0 {
+		return nil
+	}
+	// returns a list of [torrent_id, error_msg] for torrents which failed to be removed.
+	var errs [][]any
+	if err := dclient.call("core.remove_torrents", &errs, infoHashes, deleteFiles); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		log.Debugf("deluge remove torrents errors: %v", errs)
+	}
+	if dclient.Cached() {
+		for _, infoHash := range infoHashes {
+			delete(dclient.torrents, infoHash)
+		}
+		dclient.buildDerivative()
+	}
+	if err := dclient.loadMetas(); err != nil {
+		return err
+	}
+	for _, infoHash := range infoHashes {
+		delete(dclient.metas, infoHash)
+	}
+	if err := dclient.saveMetas(); err != nil {
+		return fmt.Errorf("failed to save meta file: %w", err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to remove %d torrents: %v", len(errs), errs[0])
+	}
+	return nil
+}
+
+func (dclient *Client) PauseTorrents(infoHashes []string) error {
+	return dclient.call("core.pause_torrents", nil, infoHashes)
+}
+
+func (dclient *Client) ResumeTorrents(infoHashes []string) error {
+	return dclient.call("core.resume_torrents", nil, infoHashes)
+}
+
+func (dclient *Client) RecheckTorrents(infoHashes []string) error {
+	return dclient.call("core.force_recheck", nil, infoHashes)
+}
+
+func (dclient *Client) ReannounceTorrents(infoHashes []string) error {
+	return dclient.call("core.force_reannounce", nil, infoHashes)
+}
+
+func (dclient *Client) AddTagsToTorrents(infoHashes []string, tags []string) error {
+	return dclient.updateMetas(infoHashes, tags, nil, nil)
+}
+
+func (dclient *Client) RemoveTagsFromTorrents(infoHashes []string, tags []string) error {
+	return dclient.updateMetas(infoHashes, nil, tags, nil)
+}
+
+func (dclient *Client) SetTorrentsSavePath(infoHashes []string, savePath string) error {
+	return dclient.call("core.move_storage", nil, infoHashes, savePath)
+}
+
+func (dclient *Client) PauseAllTorrents() error {
+	if err := dclient.sync(); err != nil {
+		return err
+	}
+	return dclient.PauseTorrents(dclient.getAllInfoHashes())
+}
+
+func (dclient *Client) ResumeAllTorrents() error {
+	if err := dclient.sync(); err != nil {
+		return err
+	}
+	return dclient.ResumeTorrents(dclient.getAllInfoHashes())
+}
+
+func (dclient *Client) RecheckAllTorrents() error {
+	if err := dclient.sync(); err != nil {
+		return err
+	}
+	return dclient.RecheckTorrents(dclient.getAllInfoHashes())
+}
+
+func (dclient *Client) ReannounceAllTorrents() error {
+	if err := dclient.sync(); err != nil {
+		return err
+	}
+	return dclient.ReannounceTorrents(dclient.getAllInfoHashes())
+}
+
+func (dclient *Client) AddTagsToAllTorrents(tags []string) error {
+	if err := dclient.sync(); err != nil {
+		return err
+	}
+	return dclient.AddTagsToTorrents(dclient.getAllInfoHashes(), tags)
+}
+
+func (dclient *Client) RemoveTagsFromAllTorrents(tags []string) error {
+	if err := dclient.sync(); err != nil {
+		return err
+	}
+	return dclient.RemoveTagsFromTorrents(dclient.getAllInfoHashes(), tags)
+}
+
+func (dclient *Client) SetAllTorrentsSavePath(savePath string) error {
+	if err := dclient.sync(); err != nil {
+		return err
+	}
+	return dclient.SetTorrentsSavePath(dclient.getAllInfoHashes(), savePath)
+}
+
+func (dclient *Client) GetTags() ([]string, error) {
+	if err := dclient.sync(); err != nil {
+		return nil, err
+	}
+	tags := []string{}
+	if dclient.metas[clientMetaKey] != nil {
+		tags = append(tags, dclient.metas[clientMetaKey].Tags...)
+	}
+	for infoHash := range dclient.torrents {
+		if dclient.metas[infoHash] == nil {
+			continue
+		}
+		for _, tag := range dclient.metas[infoHash].Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags, nil
+}
+
+// Client level tags are stored in meta file, as Deluge has no native tags.
+func (dclient *Client) CreateTags(tags ...string) error {
+	return dclient.updateMetas([]string{clientMetaKey}, tags, nil, nil)
+}
+
+func (dclient *Client) DeleteTags(tags ...string) error {
+	if err := dclient.updateMetas([]string{clientMetaKey}, nil, tags, nil); err != nil {
+		return err
+	}
+	return dclient.RemoveTagsFromAllTorrents(tags)
+}
+
+// Return true if tag exists in client, either as a client level tag or attached to any torrent.
+func (dclient *Client) hasTag(tag string) bool {
+	tags, err := dclient.GetTags()
+	return err == nil && slices.Contains(tags, tag)
+}
+
+// Category save path is mapped to the "move completed" path of Deluge label.
+func (dclient *Client) MakeCategory(category string, savePath string) error {
+	label := normalizeLabel(category)
+	if label == "" {
+		return fmt.Errorf("invalid category name")
+	}
+	if err := dclient.ensureLabel(label); err != nil {
+		return err
+	}
+	if savePath == "" {
+		return nil
+	}
+	return dclient.call("label.set_options", nil, label, map[string]any{
+		"apply_move_completed": true,
+		"move_completed":       true,
+		"move_completed_path":  savePath,
+	})
+}
+
+func (dclient *Client) DeleteCategories(categories []string) error {
+	if err := dclient.syncMeta(); err != nil {
+		return err
+	}
+	for _, category := range categories {
+		label := normalizeLabel(category)
+		if !slices.Contains(dclient.labels, label) {
+			continue
+		}
+		if err := dclient.call("label.remove", nil, label); err != nil {
+			return err
+		}
+		dclient.labels = util.Filter(dclient.labels, func(l string) bool { return l != label })
+	}
+	return nil
+}
+
+func (dclient *Client) GetCategories() ([]*client.TorrentCategory, error) {
+	if err := dclient.syncMeta(); err != nil {
+		return nil, err
+	}
+	cats := []*client.TorrentCategory{}
+	for _, label := range dclient.labels {
+		cat := &client.TorrentCategory{Name: label}
+		options := map[string]any{}
+		if err := dclient.call("label.get_options", &options, label); err == nil {
+			if applied, _ := options["apply_move_completed"].(bool); applied {
+				cat.SavePath, _ = options["move_completed_path"].(string)
+			}
+		}
+		cats = append(cats, cat)
+	}
+	return cats, nil
+}
+
+func (dclient *Client) SetTorrentsCatetory(infoHashes []string, category string) error {
+	return dclient.setTorrentsLabel(infoHashes, category)
+}
+
+func (dclient *Client) SetAllTorrentsCatetory(category string) error {
+	if err := dclient.sync(); err != nil {
+		return err
+	}
+	return dclient.setTorrentsLabel(dclient.getAllInfoHashes(), category)
+}
+
+// Deluge does not support per-torrent seeding time limit, seedingTimeLimit is ignored.
+func (dclient *Client) SetTorrentsShareLimits(infoHashes []string, ratioLimit float64, seedingTimeLimit int64) error {
+	if seedingTimeLimit != 0 {
+		log.Warnf("Deluge does not support seeding time limit, ignore it")
+	}
+	options := map[string]any{}
+	if ratioLimit > 0 {
+		options["stop_at_ratio"] = true
+		options["stop_ratio"] = ratioLimit
+	} else if ratioLimit < 0 {
+		options["stop_at_ratio"] = false
+	}
+	return dclient.setTorrentsOptions(infoHashes, options)
+}
+
+func (dclient *Client) SetAllTorrentsShareLimits(ratioLimit float64, seedingTimeLimit int64) error {
+	if err := dclient.sync(); err != nil {
+		return err
+	}
+	return dclient.SetTorrentsShareLimits(dclient.getAllInfoHashes(), ratioLimit, seedingTimeLimit)
+}
+
+func (dclient *Client) TorrentRootPathExists(rootFolder string) bool {
+	if rootFolder == "" {
+		return false
+	}
+	if err := dclient.sync(); err != nil {
+		return false
+	}
+	for _, torrent := range dclient.torrents {
+		if torrent.Name == rootFolder {
+			return true
+		}
+	}
+	return false
+}
+
+func (dclient *Client) getTorrentDetail(infoHash string) (*apiTorrentInfo, error) {
+	var dtorrent *apiTorrentInfo
+	if err := dclient.call("core.get_torrent_status", &dtorrent, infoHash, torrentDetailStatusKeys); err != nil {
+		return nil, err
+	}
+	// Deluge returns an empty dict for non-existent torrent
+	if dtorrent == nil || dtorrent.Files == nil {
+		return nil, fmt.Errorf("torrent not found")
+	}
+	return dtorrent, nil
+}
+
+func (dclient *Client) RenameTorrentFile(infoHash string, oldPath string, newPath string) error {
+	dtorrent, err := dclient.getTorrentDetail(infoHash)
+	if err != nil {
+		return err
+	}
+	index := slices.IndexFunc(dtorrent.Files, func(file *apiTorrentFile) bool {
+		return file.Path == oldPath
+	})
+	if index == -1 {
+		return fmt.Errorf("file %q not found in torrent", oldPath)
+	}
+	return dclient.call("core.rename_files", nil, infoHash, [][]any{{dtorrent.Files[index].Index, newPath}})
+}
+
+// Deluge folder paths end with "/".
+func (dclient *Client) RenameTorrentFolder(infoHash string, oldPath string, newPath string) error {
+	err := dclient.call("core.rename_folder", nil, infoHash,
+		strings.TrimSuffix(oldPath, "/")+"/", strings.TrimSuffix(newPath, "/")+"/")
+	if err == nil && dclient.Cached() {
+		dclient.datatime = 0
+	}
+	return err
+}
+
+func (dclient *Client) GetTorrentPeers(infoHash string) ([]*client.TorrentPeer, error) {
+	var status *struct {
+		Peers []*apiTorrentPeer `json:"peers"`
+	}
+	if err := dclient.call("core.get_torrent_status", &status, infoHash, []string{"peers"}); err != nil {
+		return nil, err
+	}
+	if status == nil || status.Peers == nil {
+		return nil, fmt.Errorf("torrent not found")
+	}
+	peers := []*client.TorrentPeer{}
+	for _, dpeer := range status.Peers {
+		ip, portStr, _ := net.SplitHostPort(dpeer.Ip)
+		flags := ""
+		if dpeer.Seed != 0 {
+			flags = "S"
+		}
+		peers = append(peers, &client.TorrentPeer{
+			Address:       dpeer.Ip,
+			Ip:            ip,
+			Port:          util.ParseInt(portStr),
+			Client:        dpeer.Client,
+			Progress:      dpeer.Progress,
+			DownloadSpeed: dpeer.DownSpeed,
+			UploadSpeed:   dpeer.UpSpeed,
+			Downloaded:    -1,
+			Uploaded:      -1,
+			Flags:         flags,
+			Country:       dpeer.Country,
+		})
+	}
+	return peers, nil
+}
+
+func (dclient *Client) GetFreeSpace(path string) (int64, error) {
+	freeSpace := int64(0)
+	if err := dclient.call("core.get_free_space", &freeSpace, path); err != nil {
+		return 0, err
+	}
+	return freeSpace, nil
+}
+
+// Deluge core does not support banning peers. Use the Blocklist plugin instead.
+func (dclient *Client) BanPeers(peers []string) error {
+	return ErrNotImplemented
+}
+
+func (dclient *Client) GetTorrentContents(infoHash string) ([]*client.TorrentContentFile, error) {
+	dtorrent, err := dclient.getTorrentDetail(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	files := []*client.TorrentContentFile{}
+	for i, dfile := range dtorrent.Files {
+		progress := float64(0)
+		if i < len(dtorrent.FileProgress) {
+			progress = dtorrent.FileProgress[i]
+		}
+		ignored := false
+		if i < len(dtorrent.FilePriorities) {
+			ignored = dtorrent.FilePriorities[i] == 0
+		}
+		files = append(files, &client.TorrentContentFile{
+			Index:    dfile.Index,
+			Path:     dfile.Path,
+			Size:     dfile.Size,
+			Progress: progress,
+			Ignored:  ignored,
+			Complete: progress == 1,
+		})
+	}
+	return files, nil
+}
+
+func (dclient *Client) PurgeCache() {
+	dclient.datatime = 0
+	dclient.datatimeMeta = 0
+	dclient.torrents = nil
+	dclient.labels = nil
+	dclient.coreConfig = nil
+	dclient.sessionStatus = nil
+	dclient.metas = nil
+	dclient.unfinishedSize = 0
+	dclient.unfinishedDownloadingSize = 0
+	dclient.contentPathTorrents = nil
+}
+
+func (dclient *Client) GetStatus() (*client.Status, error) {
+	if err := dclient.sync(); err != nil {
+		return nil, err
+	}
+	if err := dclient.syncMeta(); err != nil {
+		return nil, err
+	}
+	downloadSpeedLimit := int64(0)
+	uploadSpeedLimit := int64(0)
+	if limit, ok := dclient.coreConfig["max_download_speed"].(float64); ok && limit > 0 {
+		downloadSpeedLimit = int64(limit * 1024)
+	}
+	if limit, ok := dclient.coreConfig["max_upload_speed"].(float64); ok && limit > 0 {
+		uploadSpeedLimit = int64(limit * 1024)
+	}
+	return &client.Status{
+		DownloadSpeed:             int64(dclient.sessionStatus["payload_download_rate"]),
+		UploadSpeed:               int64(dclient.sessionStatus["payload_upload_rate"]),
+		DownloadSpeedLimit:        downloadSpeedLimit,
+		UploadSpeedLimit:          uploadSpeedLimit,
+		FreeSpaceOnDisk:           dclient.freeSpace,
+		UnfinishedSize:            dclient.unfinishedSize,
+		UnfinishedDownloadingSize: dclient.unfinishedDownloadingSize,
+		NoAdd:                     dclient.hasTag(config.NOADD_TAG),
+		NoDel:                     dclient.hasTag(config.NODEL_TAG),
+	}, nil
+}
+
+func (dclient *Client) GetName() string {
+	return dclient.Name
+}
+
+func (dclient *Client) GetClientConfig() *config.ClientConfigStruct {
+	return dclient.ClientConfig
+}
+
+func (dclient *Client) setCoreConfig(values map[string]any) error {
+	err := dclient.call("core.set_config", nil, values)
+	if err == nil {
+		dclient.datatimeMeta = 0
+	}
+	return err
+}
+
+func (dclient *Client) SetConfig(variable string, value string) error {
+	if strings.HasPrefix(variable, "de_") && len(variable) > 3 {
+		argValue, _ := util.String2Any(value)
+		return dclient.setCoreConfig(map[string]any{variable[3:]: argValue})
+	}
+	switch variable {
+	case "global_download_speed_limit", "global_upload_speed_limit":
+		limit := float64(-1)
+		if v := util.ParseInt(value); v > 0 {
+			limit = float64(v) / 1024
+		}
+		key := "max_download_speed"
+		if variable == "global_upload_speed_limit" {
+			key = "max_upload_speed"
+		}
+		return dclient.setCoreConfig(map[string]any{key: limit})
+	case "free_disk_space", "global_download_speed", "global_upload_speed":
+		return fmt.Errorf("%s is read-only", variable)
+	case "save_path":
+		return dclient.setCoreConfig(map[string]any{"download_location": value})
+	default:
+		return nil
+	}
+}
+
+func (dclient *Client) GetConfig(variable string) (string, error) {
+	if err := dclient.syncMeta(); err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(variable, "de_") && len(variable) > 3 {
+		value, ok := dclient.coreConfig[variable[3:]]
+		if !ok {
+			return "", nil
+		}
+		return fmt.Sprint(value), nil
+	}
+	switch variable {
+	case "global_download_speed_limit", "global_upload_speed_limit", "free_disk_space",
+		"global_download_speed", "global_upload_speed":
+		status, err := dclient.GetStatus()
+		if err != nil {
+			return "", err
+		}
+		switch variable {
+		case "global_download_speed_limit":
+			return fmt.Sprint(status.DownloadSpeedLimit), nil
+		case "global_upload_speed_limit":
+			return fmt.Sprint(status.UploadSpeedLimit), nil
+		case "free_disk_space":
+			return fmt.Sprint(status.FreeSpaceOnDisk), nil
+		case "global_download_speed":
+			return fmt.Sprint(status.DownloadSpeed), nil
+		default:
+			return fmt.Sprint(status.UploadSpeed), nil
+		}
+	case "save_path":
+		downloadLocation, _ := dclient.coreConfig["download_location"].(string)
+		return downloadLocation, nil
+	default:
+		return "", nil
+	}
+}
+
+// Deluge only reports the status of current working tracker.
+func (dclient *Client) GetTorrentTrackers(infoHash string) (client.TorrentTrackers, error) {
+	dtorrent, err := dclient.getTorrent(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	trackers := client.TorrentTrackers{}
+	for _, dtracker := range dtorrent.Trackers {
+		status := "notcontacted"
+		msg := ""
+		if dtracker.Url == dtorrent.Tracker {
+			msg = dtorrent.TrackerStatus
+			if strings.Contains(dtorrent.TrackerStatus, "Error") {
+				status = "error"
+			} else if strings.Contains(dtorrent.TrackerStatus, "OK") {
+				status = "working"
+			} else if strings.Contains(dtorrent.TrackerStatus, "Sent") {
+				status = "updating"
+			} else {
+				status = "unknown"
+			}
+		}
+		trackers = append(trackers, client.TorrentTracker{
+			Url:    dtracker.Url,
+			Status: status,
+			Msg:    msg,
+		})
+	}
+	return trackers, nil
+}
+
+func (dclient *Client) EditTorrentTracker(infoHash string, oldTracker string, newTracker string, replaceHost bool) error {
+	dtorrent, err := dclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	trackers := util.Map(dtorrent.Trackers, func(t *apiTorrentTracker) string { return t.Url })
+	index := -1
+	newTrackerUrl := newTracker
+	for i, tracker := range trackers {
+		if replaceHost {
+			oldTrackerUrlObj, err := url.Parse(tracker)
+			if err != nil || oldTrackerUrlObj.Host != oldTracker {
+				continue
+			}
+			index = i
+			if !util.IsUrl(newTracker) {
+				oldTrackerUrlObj.Host = newTracker
+				newTrackerUrl = oldTrackerUrlObj.String()
+			}
+			break
+		} else if tracker == oldTracker {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return fmt.Errorf("torrent %s old tracker %s does NOT exist", infoHash, oldTracker)
+	}
+	if trackers[index] == newTrackerUrl {
+		return nil
+	}
+	trackers[index] = newTrackerUrl
+	return dclient.setTorrentTrackers(infoHash, util.UniqueSlice(trackers))
+}
+
+func (dclient *Client) AddTorrentTrackers(infoHash string, trackers []string,
+	oldTracker string, removeExisting bool) error {
+	dtorrent, err := dclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	existingTrackers := util.Map(dtorrent.Trackers, func(t *apiTorrentTracker) string { return t.Url })
+	if oldTracker != "" {
+		if !slices.ContainsFunc(existingTrackers, func(tracker string) bool {
+			return util.MatchUrlWithHostOrUrl(tracker, oldTracker)
+		}) {
+			return nil
+		}
+	}
+	var newTrackers []string
+	if removeExisting {
+		newTrackers = trackers
+	} else {
+		newTrackers = append(existingTrackers, trackers...)
+	}
+	newTrackers = util.UniqueSlice(newTrackers)
+	if slices.Equal(newTrackers, existingTrackers) {
+		return nil
+	}
+	return dclient.setTorrentTrackers(infoHash, newTrackers)
+}
+
+func (dclient *Client) RemoveTorrentTrackers(infoHash string, trackers []string) error {
+	dtorrent, err := dclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	existingTrackers := util.Map(dtorrent.Trackers, func(t *apiTorrentTracker) string { return t.Url })
+	newTrackers := util.Filter(existingTrackers, func(tracker string) bool {
+		return !slices.Contains(trackers, tracker)
+	})
+	if len(newTrackers) == len(existingTrackers) {
+		return nil
+	}
+	return dclient.setTorrentTrackers(infoHash, newTrackers)
+}
+
+func (dclient *Client) SetFilePriority(infoHash string, fileIndexes []int64, priority int64) error {
+	dpriority, err := toDelugeFilePriority(priority)
+	if err != nil {
+		return err
+	}
+	dtorrent, err := dclient.getTorrentDetail(infoHash)
+	if err != nil {
+		return err
+	}
+	priorities := util.CopySlice(dtorrent.FilePriorities)
+	for _, index := range fileIndexes {
+		if index < 0 || index >= int64(len(priorities)) {
+			return fmt.Errorf("invalid file index %d", index)
+		}
+		priorities[index] = dpriority
+	}
+	return dclient.setTorrentsOptions([]string{infoHash}, map[string]any{"file_priorities": priorities})
+}
+
+func (dclient *Client) Cached() bool {
+	return dclient.datatime > 0
+}
+
+func (dclient *Client) Close() {
+	if dclient.Logined {
+		dclient.rawCall("auth.delete_session", nil)
+		dclient.Logined = false
+	}
+	dclient.PurgeCache()
+}
+
+func NewClient(name string, clientConfig *config.ClientConfigStruct, config *config.ConfigStruct) (
+	client.Client, error) {
+	if !util.IsUrl(clientConfig.Url) {
+		return nil, fmt.Errorf("invalid deluge web url: %s", clientConfig.Url)
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		Name:         name,
+		ClientConfig: clientConfig,
+		Config:       config,
+		HttpClient: &http.Client{
+			Jar: jar,
+		},
+	}, nil
+}
+
+func init() {
+	client.Register(&client.RegInfo{
+		Name:    "deluge",
+		Creator: NewClient,
+	})
+}
+
+var (
+	_ client.Client = (*Client)(nil)
+)
@@ -0,0 +1,612 @@
+package client
+
+// Aggregating client of a client group.
+// It fans out calls to all member clients in parallel and merges the results.
+// Operations on specific torrents are routed to the member client(s) which own the torrent.
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/util"
+)
+
+type GroupClient struct {
+	Name         string
+	ClientConfig *config.ClientConfigStruct
+	clients      []Client
+	owners       map[string][]Client // infoHash => member clients which have the torrent
+	mu           sync.Mutex
+}
+
+// Create the aggregating client of a client group.
+func NewGroupClient(name string, clientNames []string) (*GroupClient, error) {
+	if len(clientNames) == 0 {
+		return nil, fmt.Errorf("client group %s is empty", name)
+	}
+	gclient := &GroupClient{
+		Name: name,
+		ClientConfig: &config.ClientConfigStruct{
+			Type: "group",
+			Name: name,
+		},
+	}
+	for _, clientName := range util.UniqueSlice(clientNames) {
+		clientInstance, err := CreateClient(clientName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client %s: %w", clientName, err)
+		}
+		gclient.clients = append(gclient.clients, clientInstance)
+	}
+	return gclient, nil
+}
+
+// Call fn on each member client in parallel. Return joined errors.
+func (gclient *GroupClient) each(fn func(clientInstance Client) error) error {
+	return gclient.eachOf(gclient.clients, fn)
+}
+
+func (gclient *GroupClient) eachOf(clients []Client, fn func(clientInstance Client) error) error {
+	errs := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for i, clientInstance := range clients {
+		wg.Add(1)
+		go func(i int, clientInstance Client) {
+			defer wg.Done()
+			if err := fn(clientInstance); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", clientInstance.GetName(), err)
+			}
+		}(i, clientInstance)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Return member clients which own the torrent.
+func (gclient *GroupClient) ownersOf(infoHash string) ([]Client, error) {
+	if err := gclient.loadOwners(); err != nil {
+		return nil, err
+	}
+	gclient.mu.Lock()
+	defer gclient.mu.Unlock()
+	owners := gclient.owners[infoHash]
+	if len(owners) == 0 {
+		return nil, fmt.Errorf("torrent %s not found in any client of group", infoHash)
+	}
+	return owners, nil
+}
+
+// Return the first member client which owns the torrent.
+func (gclient *GroupClient) ownerOf(infoHash string) (Client, error) {
+	owners, err := gclient.ownersOf(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	return owners[0], nil
+}
+
+func (gclient *GroupClient) loadOwners() error {
+	gclient.mu.Lock()
+	loaded := gclient.owners != nil
+	gclient.mu.Unlock()
+	if loaded {
+		return nil
+	}
+	_, err := gclient.GetTorrents("", "", true)
+	return err
+}
+
+// Group infoHashes by owner clients and call fn for each owner client in parallel.
+func (gclient *GroupClient) route(infoHashes []string,
+	fn func(clientInstance Client, infoHashes []string) error) error {
+	if len(infoHashes) == 0 {
+		return nil
+	}
+	clients := []Client{}
+	clientInfoHashes := map[Client][]string{}
+	for _, infoHash := range infoHashes {
+		owners, err := gclient.ownersOf(infoHash)
+		if err != nil {
+			return err
+		}
+		for _, owner := range owners {
+			if clientInfoHashes[owner] == nil {
+				clients = append(clients, owner)
+			}
+			clientInfoHashes[owner] = append(clientInfoHashes[owner], infoHash)
+		}
+	}
+	return gclient.eachOf(clients, func(clientInstance Client) error {
+		return fn(clientInstance, clientInfoHashes[clientInstance])
+	})
+}
+
+// Call fn for each owner client of the torrent in parallel.
+func (gclient *GroupClient) routeOne(infoHash string, fn func(clientInstance Client) error) error {
+	owners, err := gclient.ownersOf(infoHash)
+	if err != nil {
+		return err
+	}
+	return gclient.eachOf(owners, fn)
+}
+
+func (gclient *GroupClient) ExportTorrentFile(infoHash string) ([]byte, error) {
+	owner, err := gclient.ownerOf(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	return owner.ExportTorrentFile(infoHash)
+}
+
+func (gclient *GroupClient) GetTorrent(infoHash string) (*Torrent, error) {
+	owner, err := gclient.ownerOf(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	torrent, err := owner.GetTorrent(infoHash)
+	if torrent != nil {
+		torrent.Client = owner.GetName()
+	}
+	return torrent, err
+}
+
+func (gclient *GroupClient) GetTorrents(stateFilter string, category string, showAll bool) ([]*Torrent, error) {
+	results := make([][]*Torrent, len(gclient.clients))
+	err := gclient.each(func(clientInstance Client) error {
+		torrents, err := clientInstance.GetTorrents("", "", true)
+		if err != nil {
+			return err
+		}
+		index := slices.Index(gclient.clients, clientInstance)
+		results[index] = torrents
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	owners := map[string][]Client{}
+	torrents := []*Torrent{}
+	for i, clientTorrents := range results {
+		for _, torrent := range clientTorrents {
+			owners[torrent.InfoHash] = append(owners[torrent.InfoHash], gclient.clients[i])
+			if category != "" {
+				if category == constants.NONE {
+					if torrent.Category != "" {
+						continue
+					}
+				} else if category != torrent.Category {
+					continue
+				}
+			}
+			if !showAll && torrent.DownloadSpeed < 1024 && torrent.UploadSpeed < 1024 {
+				continue
+			}
+			if !torrent.MatchStateFilter(stateFilter) {
+				continue
+			}
+			torrent.Client = gclient.clients[i].GetName()
+			torrents = append(torrents, torrent)
+		}
+	}
+	gclient.mu.Lock()
+	gclient.owners = owners
+	gclient.mu.Unlock()
+	return torrents, nil
+}
+
+func (gclient *GroupClient) GetTorrentsByContentPath(contentPath string) ([]*Torrent, error) {
+	var mu sync.Mutex
+	torrents := []*Torrent{}
+	err := gclient.each(func(clientInstance Client) error {
+		clientTorrents, err := clientInstance.GetTorrentsByContentPath(contentPath)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, torrent := range clientTorrents {
+			torrent.Client = clientInstance.GetName()
+			torrents = append(torrents, torrent)
+		}
+		return nil
+	})
+	return torrents, err
+}
+
+// It's ambiguous which member client should a torrent be added to, so it's not supported.
+func (gclient *GroupClient) AddTorrent(torrentContent []byte, option *TorrentOption, meta map[string]int64) error {
+	return fmt.Errorf("can not add torrent to client group %s. Add it to a member client instead", gclient.Name)
+}
+
+func (gclient *GroupClient) ModifyTorrent(infoHash string, option *TorrentOption, meta map[string]int64) error {
+	return gclient.routeOne(infoHash, func(clientInstance Client) error {
+		return clientInstance.ModifyTorrent(infoHash, option, meta)
+	})
+}
+
+func (gclient *GroupClient) DeleteTorrents(infoHashes []string, deleteFiles bool) error {
+	err := gclient.route(infoHashes, func(clientInstance Client, infoHashes []string) error {
+		return clientInstance.DeleteTorrents(infoHashes, deleteFiles)
+	})
+	gclient.mu.Lock()
+	gclient.owners = nil
+	gclient.mu.Unlock()
+	return err
+}
+
+func (gclient *GroupClient) PauseTorrents(infoHashes []string) error {
+	return gclient.route(infoHashes, func(clientInstance Client, infoHashes []string) error {
+		return clientInstance.PauseTorrents(infoHashes)
+	})
+}
+
+func (gclient *GroupClient) ResumeTorrents(infoHashes []string) error {
+	return gclient.route(infoHashes, func(clientInstance Client, infoHashes []string) error {
+		return clientInstance.ResumeTorrents(infoHashes)
+	})
+}
+
+func (gclient *GroupClient) RecheckTorrents(infoHashes []string) error {
+	return gclient.route(infoHashes, func(clientInstance Client, infoHashes []string) error {
+		return clientInstance.RecheckTorrents(infoHashes)
+	})
+}
+
+func (gclient *GroupClient) ReannounceTorrents(infoHashes []string) error {
+	return gclient.route(infoHashes, func(clientInstance Client, infoHashes []string) error {
+		return clientInstance.ReannounceTorrents(infoHashes)
+	})
+}
+
+func (gclient *GroupClient) AddTagsToTorrents(infoHashes []string, tags []string) error {
+	return gclient.route(infoHashes, func(clientInstance Client, infoHashes []string) error {
+		return clientInstance.AddTagsToTorrents(infoHashes, tags)
+	})
+}
+
+func (gclient *GroupClient) RemoveTagsFromTorrents(infoHashes []string, tags []string) error {
+	return gclient.route(infoHashes, func(clientInstance Client, infoHashes []string) error {
+		return clientInstance.RemoveTagsFromTorrents(infoHashes, tags)
+	})
+}
+
+func (gclient *GroupClient) SetTorrentsSavePath(infoHashes []string, savePath string) error {
+	return gclient.route(infoHashes, func(clientInstance Client, infoHashes []string) error {
+		return clientInstance.SetTorrentsSavePath(infoHashes, savePath)
+	})
+}
+
+func (gclient *GroupClient) PauseAllTorrents() error {
+	return gclient.each(func(clientInstance Client) error {
+		return clientInstance.PauseAllTorrents()
+	})
+}
+
+func (gclient *GroupClient) ResumeAllTorrents() error {
+	return gclient.each(func(clientInstance Client) error {
+		return clientInstance.ResumeAllTorrents()
+	})
+}
+
+func (gclient *GroupClient) RecheckAllTorrents() error {
+	return gclient.each(func(clientInstance Client) error {
+		return clientInstance.RecheckAllTorrents()
+	})
+}
+
+func (gclient *GroupClient) ReannounceAllTorrents() error {
+	return gclient.each(func(clientInstance Client) error {
+		return clientInstance.ReannounceAllTorrents()
+	})
+}
+
+func (gclient *GroupClient) AddTagsToAllTorrents(tags []string) error {
+	return gclient.each(func(clientInstance Client) error {
+		return clientInstance.AddTagsToAllTorrents(tags)
+	})
+}
+
+func (gclient *GroupClient) RemoveTagsFromAllTorrents(tags []string) error {
+	return gclient.each(func(clientInstance Client) error {
+		return clientInstance.RemoveTagsFromAllTorrents(tags)
+	})
+}
+
+func (gclient *GroupClient) SetAllTorrentsSavePath(savePath string) error {
+	return gclient.each(func(clientInstance Client) error {
+		return clientInstance.SetAllTorrentsSavePath(savePath)
+	})
+}
+
+// Return union of tags of all member clients.
+func (gclient *GroupClient) GetTags() ([]string, error) {
+	var mu sync.Mutex
+	tags := []string{}
+	err := gclient.each(func(clientInstance Client) error {
+		clientTags, err := clientInstance.GetTags()
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, tag := range clientTags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		return nil
+	})
+	slices.Sort(tags)
+	return tags, err
+}
+
+func (gclient *GroupClient) CreateTags(tags ...string) error {
+	return gclient.each(func(clientInstance Client) error {
+		return clientInstance.CreateTags(tags...)
+	})
+}
+
+func (gclient *GroupClient) DeleteTags(tags ...string) error {
+	return gclient.each(func(clientInstance Client) error {
+		return clientInstance.DeleteTags(tags...)
+	})
+}
+
+func (gclient *GroupClient) MakeCategory(category string, savePath string) error {
+	return gclient.each(func(clientInstance Client) error {
+		return clientInstance.MakeCategory(category, savePath)
+	})
+}
+
+func (gclient *GroupClient) DeleteCategories(categories []string) error {
+	return gclient.each(func(clientInstance Client) error {
+		return clientInstance.DeleteCategories(categories)
+	})
+}
+
+// Return union of categories of all member clients.
+// If a category exists in multiple clients, the save path of first client is used.
+func (gclient *GroupClient) GetCategories() ([]*TorrentCategory, error) {
+	results := make([][]*TorrentCategory, len(gclient.clients))
+	err := gclient.each(func(clientInstance Client) error {
+		categories, err := clientInstance.GetCategories()
+		if err != nil {
+			return err
+		}
+		results[slices.Index(gclient.clients, clientInstance)] = categories
+		return nil
+	})
+	categories := []*TorrentCategory{}
+	for _, clientCategories := range results {
+		for _, category := range clientCategories {
+			if !slices.ContainsFunc(categories, func(c *TorrentCategory) bool { return c.Name == category.Name }) {
+				categories = append(categories, category)
+			}
+		}
+	}
+	return categories, err
+}
+
+func (gclient *GroupClient) SetTorrentsCatetory(infoHashes []string, category string) error {
+	return gclient.route(infoHashes, func(clientInstance Client, infoHashes []string) error {
+		return clientInstance.SetTorrentsCatetory(infoHashes, category)
+	})
+}
+
+func (gclient *GroupClient) SetAllTorrentsCatetory(category string) error {
+	return gclient.each(func(clientInstance Client) error {
+		return clientInstance.SetAllTorrentsCatetory(category)
+	})
+}
+
+func (gclient *GroupClient) SetTorrentsShareLimits(infoHashes []string,
+	ratioLimit float64, seedingTimeLimit int64) error {
+	return gclient.route(infoHashes, func(clientInstance Client, infoHashes []string) error {
+		return clientInstance.SetTorrentsShareLimits(infoHashes, ratioLimit, seedingTimeLimit)
+	})
+}
+
+func (gclient *GroupClient) SetAllTorrentsShareLimits(ratioLimit float64, seedingTimeLimit int64) error {
+	return gclient.each(func(clientInstance Client) error {
+		return clientInstance.SetAllTorrentsShareLimits(ratioLimit, seedingTimeLimit)
+	})
+}
+
+func (gclient *GroupClient) TorrentRootPathExists(rootFolder string) bool {
+	return slices.ContainsFunc(gclient.clients, func(clientInstance Client) bool {
+		return clientInstance.TorrentRootPathExists(rootFolder)
+	})
+}
+
+func (gclient *GroupClient) GetTorrentContents(infoHash string) ([]*TorrentContentFile, error) {
+	owner, err := gclient.ownerOf(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	return owner.GetTorrentContents(infoHash)
+}
+
+func (gclient *GroupClient) PurgeCache() {
+	for _, clientInstance := range gclient.clients {
+		clientInstance.PurgeCache()
+	}
+	gclient.mu.Lock()
+	gclient.owners = nil
+	gclient.mu.Unlock()
+}
+
+// Return the sum status of all member clients.
+// FreeSpaceOnDisk is -1 (unknown) if it's unknown in any client.
+// NoAdd / NoDel is true if it's true in any client.
+func (gclient *GroupClient) GetStatus() (*Status, error) {
+	results := make([]*Status, len(gclient.clients))
+	err := gclient.each(func(clientInstance Client) error {
+		status, err := clientInstance.GetStatus()
+		if err != nil {
+			return err
+		}
+		results[slices.Index(gclient.clients, clientInstance)] = status
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{}
+	for _, clientStatus := range results {
+		if status.FreeSpaceOnDisk >= 0 {
+			if clientStatus.FreeSpaceOnDisk >= 0 {
+				status.FreeSpaceOnDisk += clientStatus.FreeSpaceOnDisk
+			} else {
+				status.FreeSpaceOnDisk = -1
+			}
+		}
+		status.UnfinishedSize += clientStatus.UnfinishedSize
+		status.UnfinishedDownloadingSize += clientStatus.UnfinishedDownloadingSize
+		status.DownloadSpeed += clientStatus.DownloadSpeed
+		status.UploadSpeed += clientStatus.UploadSpeed
+		status.DownloadSpeedLimit += max(clientStatus.DownloadSpeedLimit, 0)
+		status.UploadSpeedLimit += max(clientStatus.UploadSpeedLimit, 0)
+		status.NoAdd = status.NoAdd || clientStatus.NoAdd
+		status.NoDel = status.NoDel || clientStatus.NoDel
+	}
+	return status, nil
+}
+
+// The path is ambiguous for a group of clients.
+func (gclient *GroupClient) GetFreeSpace(path string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (gclient *GroupClient) GetName() string {
+	return gclient.Name
+}
+
+func (gclient *GroupClient) GetClientConfig() *config.ClientConfigStruct {
+	return gclient.ClientConfig
+}
+
+func (gclient *GroupClient) SetConfig(variable string, value string) error {
+	return gclient.each(func(clientInstance Client) error {
+		return clientInstance.SetConfig(variable, value)
+	})
+}
+
+// Return the config value if it's the same in all member clients.
+func (gclient *GroupClient) GetConfig(variable string) (string, error) {
+	results := make([]string, len(gclient.clients))
+	err := gclient.each(func(clientInstance Client) error {
+		value, err := clientInstance.GetConfig(variable)
+		results[slices.Index(gclient.clients, clientInstance)] = value
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i] != results[0] {
+			return "", fmt.Errorf("%s value differs among clients of group: %q (%s) != %q (%s)", variable,
+				results[0], gclient.clients[0].GetName(), results[i], gclient.clients[i].GetName())
+		}
+	}
+	return results[0], nil
+}
+
+func (gclient *GroupClient) GetTorrentTrackers(infoHash string) (TorrentTrackers, error) {
+	owner, err := gclient.ownerOf(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	return owner.GetTorrentTrackers(infoHash)
+}
+
+func (gclient *GroupClient) EditTorrentTracker(infoHash string,
+	oldTracker string, newTracker string, replaceHost bool) error {
+	return gclient.routeOne(infoHash, func(clientInstance Client) error {
+		return clientInstance.EditTorrentTracker(infoHash, oldTracker, newTracker, replaceHost)
+	})
+}
+
+func (gclient *GroupClient) AddTorrentTrackers(infoHash string, trackers []string,
+	oldTracker string, removeExisting bool) error {
+	return gclient.routeOne(infoHash, func(clientInstance Client) error {
+		return clientInstance.AddTorrentTrackers(infoHash, trackers, oldTracker, removeExisting)
+	})
+}
+
+func (gclient *GroupClient) RemoveTorrentTrackers(infoHash string, trackers []string) error {
+	return gclient.routeOne(infoHash, func(clientInstance Client) error {
+		return clientInstance.RemoveTorrentTrackers(infoHash, trackers)
+	})
+}
+
+func (gclient *GroupClient) GetTorrentPeers(infoHash string) ([]*TorrentPeer, error) {
+	owner, err := gclient.ownerOf(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	return owner.GetTorrentPeers(infoHash)
+}
+
+// Members that do not support banning peers are skipped. Fail only if no member bans the peers.
+func (gclient *GroupClient) BanPeers(peers []string) error {
+	var cntBanned atomic.Int64
+	err := gclient.each(func(clientInstance Client) error {
+		if err := clientInstance.BanPeers(peers); err != nil {
+			if errors.Is(err, errors.ErrUnsupported) {
+				return nil
+			}
+			return err
+		}
+		cntBanned.Add(1)
+		return nil
+	})
+	if cntBanned.Load() == 0 {
+		if err == nil {
+			err = fmt.Errorf("ban peers: %w by any client of group", errors.ErrUnsupported)
+		}
+		return err
+	}
+	if err != nil {
+		log.Warnf("Failed to ban peers in some clients of group %s: %v", gclient.Name, err)
+	}
+	return nil
+}
+
+func (gclient *GroupClient) RenameTorrentFile(infoHash string, oldPath string, newPath string) error {
+	return gclient.routeOne(infoHash, func(clientInstance Client) error {
+		return clientInstance.RenameTorrentFile(infoHash, oldPath, newPath)
+	})
+}
+
+func (gclient *GroupClient) RenameTorrentFolder(infoHash string, oldPath string, newPath string) error {
+	return gclient.routeOne(infoHash, func(clientInstance Client) error {
+		return clientInstance.RenameTorrentFolder(infoHash, oldPath, newPath)
+	})
+}
+
+func (gclient *GroupClient) SetFilePriority(infoHash string, fileIndexes []int64, priority int64) error {
+	return gclient.routeOne(infoHash, func(clientInstance Client) error {
+		return clientInstance.SetFilePriority(infoHash, fileIndexes, priority)
+	})
+}
+
+func (gclient *GroupClient) Cached() bool {
+	return slices.ContainsFunc(gclient.clients, func(clientInstance Client) bool {
+		return clientInstance.Cached()
+	})
+}
+
+// Member clients are closed by themselves.
+func (gclient *GroupClient) Close() {
+}
+
+var (
+	_ Client = (*GroupClient)(nil)
+)
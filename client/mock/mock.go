@@ -0,0 +1,791 @@
+package mock
+
+// An in-memory mock client, for dry runs, tests and simulations of brush and other strategies.
+// Torrents, tags and categories are kept in memory,
+// and optionally loaded from / persisted to a JSON state file ("mockStateFile" client config).
+// Download / upload activities of torrents are simulated over a virtual clock.
+// Each time the state is loaded, the virtual clock is advanced by the real time elapsed since last save
+// (multiplied by "mock_time_scale"). Use "mock_tick" config to manually advance it.
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/util"
+	"github.com/sagan/ptool/util/torrentutil"
+)
+
+type Client struct {
+	Name         string
+	ClientConfig *config.ClientConfigStruct
+	Config       *config.ConfigStruct
+	stateFile    string
+	state        *mockState
+}
+
+// Convert a virtual clock timestamp to real time.
+// Makes the ages of torrents consistent with the real current time that callers use.
+func (mclient *Client) realTime(t int64) int64 {
+	if t <= 0 {
+		return t
+	}
+	return t - (mclient.state.Clock - util.Now())
+}
+
+func (mclient *Client) save() error {
+	return mclient.state.save(mclient.stateFile, util.Now())
+}
+
+func (mclient *Client) toTorrent(mtorrent *mockTorrent) *client.Torrent {
+	tracker := ""
+	if len(mtorrent.Trackers) > 0 {
+		tracker = mtorrent.Trackers[0]
+	}
+	downloadSpeedLimit := int64(-1)
+	uploadSpeedLimit := int64(-1)
+	if mtorrent.DownloadLimit > 0 {
+		downloadSpeedLimit = mtorrent.DownloadLimit
+	}
+	if mtorrent.UploadLimit > 0 {
+		uploadSpeedLimit = mtorrent.UploadLimit
+	}
+	state := mtorrent.state()
+	return &client.Torrent{
+		InfoHash:           mtorrent.InfoHash,
+		Name:               mtorrent.Name,
+		TrackerDomain:      util.ParseUrlHostname(tracker),
+		TrackerBaseDomain:  util.GetUrlDomain(tracker),
+		Tracker:            tracker,
+		State:              state,
+		LowLevelState:      state,
+		Atime:              mclient.realTime(mtorrent.Atime),
+		Ctime:              mclient.realTime(mtorrent.Ctime),
+		ActivityTime:       mclient.realTime(mtorrent.ActivityTime),
+		Category:           mtorrent.Category,
+		SavePath:           mtorrent.SavePath,
+		ContentPath:        mtorrent.contentPath(),
+		Tags:               util.CopySlice(mtorrent.Tags),
+		Downloaded:         mtorrent.Downloaded,
+		DownloadSpeed:      mtorrent.DownloadSpeed,
+		DownloadSpeedLimit: downloadSpeedLimit,
+		Uploaded:           mtorrent.Uploaded,
+		UploadSpeed:        mtorrent.UploadSpeed,
+		UploadedSpeedLimit: uploadSpeedLimit,
+		Size:               mtorrent.size(),
+		SizeTotal:          mtorrent.sizeTotal(),
+		SizeCompleted:      mtorrent.completed(),
+		Seeders:            mtorrent.Seeders,
+		Leechers:           mtorrent.currentLeechers(mclient.state.Clock),
+		Meta:               util.CopyMap(mtorrent.Meta, true),
+	}
+}
+
+func (mtorrent *mockTorrent) contentPath() string {
+	return strings.TrimSuffix(mtorrent.SavePath, "/") + "/" + mtorrent.RootName
+}
+
+func (mclient *Client) getTorrent(infoHash string) (*mockTorrent, error) {
+	mtorrent := mclient.state.Torrents[infoHash]
+	if mtorrent == nil {
+		return nil, fmt.Errorf("torrent %s not found", infoHash)
+	}
+	return mtorrent, nil
+}
+
+// Return torrents of infoHashes. If infoHashes is nil, return all torrents.
+func (mclient *Client) getTorrents(infoHashes []string) []*mockTorrent {
+	torrents := []*mockTorrent{}
+	if infoHashes == nil {
+		for _, mtorrent := range mclient.state.Torrents {
+			torrents = append(torrents, mtorrent)
+		}
+	} else {
+		for _, infoHash := range infoHashes {
+			if mtorrent := mclient.state.Torrents[infoHash]; mtorrent != nil {
+				torrents = append(torrents, mtorrent)
+			}
+		}
+	}
+	return torrents
+}
+
+func (mclient *Client) ExportTorrentFile(infoHash string) ([]byte, error) {
+	mtorrent, err := mclient.getTorrent(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	return mtorrent.Content, nil
+}
+
+func (mclient *Client) GetTorrent(infoHash string) (*client.Torrent, error) {
+	mtorrent := mclient.state.Torrents[infoHash]
+	if mtorrent == nil {
+		return nil, nil
+	}
+	return mclient.toTorrent(mtorrent), nil
+}
+
+func (mclient *Client) GetTorrents(stateFilter string, category string, showAll bool) ([]*client.Torrent, error) {
+	torrents := []*client.Torrent{}
+	for _, mtorrent := range mclient.state.Torrents {
+		torrent := mclient.toTorrent(mtorrent)
+		if category != "" {
+			if category == constants.NONE {
+				if torrent.Category != "" {
+					continue
+				}
+			} else if category != torrent.Category {
+				continue
+			}
+		}
+		if !showAll && torrent.DownloadSpeed < 1024 && torrent.UploadSpeed < 1024 {
+			continue
+		}
+		if !torrent.MatchStateFilter(stateFilter) {
+			continue
+		}
+		torrents = append(torrents, torrent)
+	}
+	return torrents, nil
+}
+
+func (mclient *Client) GetTorrentsByContentPath(contentPath string) ([]*client.Torrent, error) {
+	torrents := []*client.Torrent{}
+	for _, mtorrent := range mclient.state.Torrents {
+		if mtorrent.contentPath() == contentPath {
+			torrents = append(torrents, mclient.toTorrent(mtorrent))
+		}
+	}
+	return torrents, nil
+}
+
+func (mclient *Client) AddTorrent(torrentContent []byte, option *client.TorrentOption, meta map[string]int64) error {
+	if util.IsTorrentUrl(string(torrentContent)) {
+		return fmt.Errorf("mock client does not support adding torrent by url or magnet")
+	}
+	tinfo, err := torrentutil.ParseTorrent(torrentContent)
+	if err != nil {
+		return fmt.Errorf("failed to parse torrent: %w", err)
+	}
+	if mclient.state.Torrents[tinfo.InfoHash] != nil {
+		return nil
+	}
+	savePath := option.SavePath
+	category := ""
+	if option.Category != "" && option.Category != constants.NONE {
+		category = option.Category
+		if mclient.state.Categories[category] == nil {
+			return fmt.Errorf("category %q does not exist", category)
+		}
+		if savePath == "" {
+			savePath = mclient.state.Categories[category].SavePath
+		}
+	}
+	if savePath == "" {
+		savePath = mclient.state.Settings.SavePath
+	}
+	name := option.Name
+	if name == "" {
+		name = tinfo.Info.Name
+	}
+	now := mclient.state.Clock
+	mtorrent := &mockTorrent{
+		InfoHash:         tinfo.InfoHash,
+		Name:             name,
+		SavePath:         savePath,
+		RootName:         tinfo.ContentPath,
+		Category:         category,
+		Tags:             util.UniqueSlice(util.CopySlice(option.Tags)),
+		Meta:             util.CopyMap(meta, true),
+		Trackers:         util.CopySlice(tinfo.Trackers),
+		Atime:            now,
+		DownloadLimit:    option.DownloadSpeedLimit,
+		UploadLimit:      option.UploadSpeedLimit,
+		RatioLimit:       option.RatioLimit,
+		SeedingTimeLimit: option.SeedingTimeLimit,
+		Paused:           option.Pause,
+		Content:          torrentContent,
+	}
+	for _, file := range tinfo.Files {
+		mfile := &mockFile{
+			Path:     file.Path,
+			Size:     file.Size,
+			Priority: 1,
+		}
+		if option.SkipChecking {
+			// assume contents already exist on disk
+			mfile.Completed = file.Size
+		}
+		mtorrent.Files = append(mtorrent.Files, mfile)
+	}
+	if option.SkipChecking {
+		mtorrent.Ctime = now
+	}
+	mtorrent.initSwarm(mclient.state.Settings)
+	mclient.state.Torrents[mtorrent.InfoHash] = mtorrent
+	return mclient.save()
+}
+
+func (mclient *Client) ModifyTorrent(infoHash string, option *client.TorrentOption, meta map[string]int64) error {
+	mtorrent, err := mclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	if option.Name != "" {
+		mtorrent.Name = option.Name
+	}
+	if option.Category != "" {
+		if option.Category == constants.NONE {
+			mtorrent.Category = ""
+		} else if mclient.state.Categories[option.Category] == nil {
+			return fmt.Errorf("category %q does not exist", option.Category)
+		} else {
+			mtorrent.Category = option.Category
+		}
+	}
+	if len(option.Tags) > 0 {
+		mtorrent.Tags = util.UniqueSlice(append(mtorrent.Tags, option.Tags...))
+	}
+	if len(option.RemoveTags) > 0 {
+		mtorrent.Tags = util.Filter(mtorrent.Tags, func(tag string) bool {
+			return !slices.Contains(option.RemoveTags, tag)
+		})
+	}
+	if len(meta) > 0 {
+		mtorrent.Meta = util.CopyMap(meta, true)
+	}
+	if option.DownloadSpeedLimit != 0 {
+		mtorrent.DownloadLimit = max(option.DownloadSpeedLimit, 0)
+	}
+	if option.UploadSpeedLimit != 0 {
+		mtorrent.UploadLimit = max(option.UploadSpeedLimit, 0)
+	}
+	if option.RatioLimit != 0 {
+		mtorrent.RatioLimit = max(option.RatioLimit, 0)
+	}
+	if option.SeedingTimeLimit != 0 {
+		mtorrent.SeedingTimeLimit = max(option.SeedingTimeLimit, 0)
+	}
+	if option.SavePath != "" {
+		mtorrent.SavePath = option.SavePath
+	}
+	if option.Pause {
+		mtorrent.Paused = true
+	} else if option.Resume {
+		mtorrent.Paused = false
+	}
+	return mclient.save()
+}
+
+func (mclient *Client) DeleteTorrents(infoHashes []string, deleteFiles bool) error {
+	for _, infoHash := range infoHashes {
+		delete(mclient.state.Torrents, infoHash)
+	}
+	return mclient.save()
+}
+
+func (mclient *Client) setPaused(infoHashes []string, paused bool) error {
+	for _, mtorrent := range mclient.getTorrents(infoHashes) {
+		mtorrent.Paused = paused
+		if paused {
+			mtorrent.DownloadSpeed = 0
+			mtorrent.UploadSpeed = 0
+		}
+	}
+	return mclient.save()
+}
+
+func (mclient *Client) PauseTorrents(infoHashes []string) error {
+	return mclient.setPaused(infoHashes, true)
+}
+
+func (mclient *Client) ResumeTorrents(infoHashes []string) error {
+	return mclient.setPaused(infoHashes, false)
+}
+
+func (mclient *Client) RecheckTorrents(infoHashes []string) error {
+	return nil
+}
+
+func (mclient *Client) ReannounceTorrents(infoHashes []string) error {
+	return nil
+}
+
+func (mclient *Client) AddTagsToTorrents(infoHashes []string, tags []string) error {
+	for _, mtorrent := range mclient.getTorrents(infoHashes) {
+		mtorrent.Tags = util.UniqueSlice(append(mtorrent.Tags, tags...))
+	}
+	mclient.state.Tags = util.UniqueSlice(append(mclient.state.Tags, tags...))
+	return mclient.save()
+}
+
+func (mclient *Client) RemoveTagsFromTorrents(infoHashes []string, tags []string) error {
+	for _, mtorrent := range mclient.getTorrents(infoHashes) {
+		mtorrent.Tags = util.Filter(mtorrent.Tags, func(tag string) bool {
+			return !slices.Contains(tags, tag)
+		})
+	}
+	return mclient.save()
+}
+
+func (mclient *Client) SetTorrentsSavePath(infoHashes []string, savePath string) error {
+	for _, mtorrent := range mclient.getTorrents(infoHashes) {
+		mtorrent.SavePath = savePath
+	}
+	return mclient.save()
+}
+
+func (mclient *Client) PauseAllTorrents() error {
+	return mclient.PauseTorrents(nil)
+}
+
+func (mclient *Client) ResumeAllTorrents() error {
+	return mclient.ResumeTorrents(nil)
+}
+
+func (mclient *Client) RecheckAllTorrents() error {
+	return nil
+}
+
+func (mclient *Client) ReannounceAllTorrents() error {
+	return nil
+}
+
+func (mclient *Client) AddTagsToAllTorrents(tags []string) error {
+	return mclient.AddTagsToTorrents(nil, tags)
+}
+
+func (mclient *Client) RemoveTagsFromAllTorrents(tags []string) error {
+	return mclient.RemoveTagsFromTorrents(nil, tags)
+}
+
+func (mclient *Client) SetAllTorrentsSavePath(savePath string) error {
+	return mclient.SetTorrentsSavePath(nil, savePath)
+}
+
+func (mclient *Client) GetTags() ([]string, error) {
+	tags := util.CopySlice(mclient.state.Tags)
+	for _, mtorrent := range mclient.state.Torrents {
+		tags = append(tags, mtorrent.Tags...)
+	}
+	return util.UniqueSlice(tags), nil
+}
+
+func (mclient *Client) CreateTags(tags ...string) error {
+	mclient.state.Tags = util.UniqueSlice(append(mclient.state.Tags, tags...))
+	return mclient.save()
+}
+
+func (mclient *Client) DeleteTags(tags ...string) error {
+	mclient.state.Tags = util.Filter(mclient.state.Tags, func(tag string) bool {
+		return !slices.Contains(tags, tag)
+	})
+	return mclient.RemoveTagsFromAllTorrents(tags)
+}
+
+func (mclient *Client) MakeCategory(category string, savePath string) error {
+	if category == "" || category == constants.NONE {
+		return fmt.Errorf("invalid category name %q", category)
+	}
+	mclient.state.Categories[category] = &client.TorrentCategory{
+		Name:     category,
+		SavePath: savePath,
+	}
+	return mclient.save()
+}
+
+func (mclient *Client) DeleteCategories(categories []string) error {
+	for _, category := range categories {
+		delete(mclient.state.Categories, category)
+	}
+	for _, mtorrent := range mclient.state.Torrents {
+		if slices.Contains(categories, mtorrent.Category) {
+			mtorrent.Category = ""
+		}
+	}
+	return mclient.save()
+}
+
+func (mclient *Client) GetCategories() ([]*client.TorrentCategory, error) {
+	cats := []*client.TorrentCategory{}
+	for _, category := range mclient.state.Categories {
+		cats = append(cats, &client.TorrentCategory{
+			Name:     category.Name,
+			SavePath: category.SavePath,
+		})
+	}
+	return cats, nil
+}
+
+func (mclient *Client) SetTorrentsCatetory(infoHashes []string, category string) error {
+	if category == constants.NONE {
+		category = ""
+	} else if category != "" && mclient.state.Categories[category] == nil {
+		return fmt.Errorf("category %q does not exist", category)
+	}
+	for _, mtorrent := range mclient.getTorrents(infoHashes) {
+		mtorrent.Category = category
+	}
+	return mclient.save()
+}
+
+func (mclient *Client) SetAllTorrentsCatetory(category string) error {
+	return mclient.SetTorrentsCatetory(nil, category)
+}
+
+func (mclient *Client) SetTorrentsShareLimits(infoHashes []string, ratioLimit float64, seedingTimeLimit int64) error {
+	for _, mtorrent := range mclient.getTorrents(infoHashes) {
+		mtorrent.RatioLimit = ratioLimit
+		mtorrent.SeedingTimeLimit = seedingTimeLimit
+	}
+	return mclient.save()
+}
+
+func (mclient *Client) SetAllTorrentsShareLimits(ratioLimit float64, seedingTimeLimit int64) error {
+	return mclient.SetTorrentsShareLimits(nil, ratioLimit, seedingTimeLimit)
+}
+
+func (mclient *Client) TorrentRootPathExists(rootFolder string) bool {
+	if rootFolder == "" {
+		return false
+	}
+	for _, mtorrent := range mclient.state.Torrents {
+		if mtorrent.RootName == rootFolder {
+			return true
+		}
+	}
+	return false
+}
+
+func (mclient *Client) GetTorrentContents(infoHash string) ([]*client.TorrentContentFile, error) {
+	mtorrent, err := mclient.getTorrent(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	files := []*client.TorrentContentFile{}
+	for i, mfile := range mtorrent.Files {
+		progress := float64(1)
+		if mfile.Size > 0 {
+			progress = float64(mfile.Completed) / float64(mfile.Size)
+		}
+		files = append(files, &client.TorrentContentFile{
+			Index:    int64(i),
+			Path:     mfile.Path,
+			Size:     mfile.Size,
+			Progress: progress,
+			Ignored:  mfile.Priority == 0,
+			Complete: mfile.Completed == mfile.Size,
+		})
+	}
+	return files, nil
+}
+
+// Mock client has no cache: all data are always in memory.
+func (mclient *Client) PurgeCache() {
+}
+
+func (mclient *Client) Cached() bool {
+	return true
+}
+
+// The mock client has only one virtual disk.
+func (mclient *Client) GetFreeSpace(path string) (int64, error) {
+	return mclient.state.freeSpace(), nil
+}
+
+func (mclient *Client) GetStatus() (*client.Status, error) {
+	status := &client.Status{
+		FreeSpaceOnDisk:    mclient.state.freeSpace(),
+		DownloadSpeedLimit: mclient.state.Settings.DownloadSpeedLimit,
+		UploadSpeedLimit:   mclient.state.Settings.UploadSpeedLimit,
+	}
+	for _, mtorrent := range mclient.state.Torrents {
+		status.DownloadSpeed += mtorrent.DownloadSpeed
+		status.UploadSpeed += mtorrent.UploadSpeed
+		usize := mtorrent.size() - mtorrent.completed()
+		status.UnfinishedSize += usize
+		if !mtorrent.Paused {
+			status.UnfinishedDownloadingSize += usize
+		}
+	}
+	if slices.Contains(mclient.state.Tags, config.NOADD_TAG) {
+		status.NoAdd = true
+	}
+	if slices.Contains(mclient.state.Tags, config.NODEL_TAG) {
+		status.NoDel = true
+	}
+	return status, nil
+}
+
+func (mclient *Client) GetName() string {
+	return mclient.Name
+}
+
+func (mclient *Client) GetClientConfig() *config.ClientConfigStruct {
+	return mclient.ClientConfig
+}
+
+// Mock specific variables (with "mock_" prefix):
+// mock_time (read-only): current virtual clock;
+// mock_tick (write-only): advance the virtual clock by seconds and simulate torrents activities;
+// mock_disk_size, mock_download_speed, mock_upload_speed: simulation parameters;
+// mock_time_scale: virtual seconds elapsed per real second between runs.
+func (mclient *Client) SetConfig(variable string, value string) error {
+	settings := mclient.state.Settings
+	switch variable {
+	case "global_download_speed_limit":
+		settings.DownloadSpeedLimit = max(util.ParseInt(value), 0)
+	case "global_upload_speed_limit":
+		settings.UploadSpeedLimit = max(util.ParseInt(value), 0)
+	case "save_path":
+		settings.SavePath = value
+	case "free_disk_space", "global_download_speed", "global_upload_speed", "mock_time":
+		return fmt.Errorf("%s is read-only", variable)
+	case "mock_tick":
+		seconds := util.ParseInt(value)
+		if seconds <= 0 {
+			return fmt.Errorf("invalid mock_tick value %q", value)
+		}
+		mclient.state.advance(seconds)
+	case "mock_disk_size":
+		size, _ := util.RAMInBytes(value)
+		if size <= 0 {
+			return fmt.Errorf("invalid mock_disk_size value %q", value)
+		}
+		settings.DiskSize = size
+	case "mock_download_speed":
+		speed, _ := util.RAMInBytes(value)
+		settings.DownloadSpeed = max(speed, 0)
+	case "mock_upload_speed":
+		speed, _ := util.RAMInBytes(value)
+		settings.UploadSpeed = max(speed, 0)
+	case "mock_time_scale":
+		scale, err := strconv.ParseFloat(value, 64)
+		if err != nil || scale < 0 {
+			return fmt.Errorf("invalid mock_time_scale value %q", value)
+		}
+		settings.TimeScale = scale
+	default:
+		return nil
+	}
+	return mclient.save()
+}
+
+func (mclient *Client) GetConfig(variable string) (string, error) {
+	settings := mclient.state.Settings
+	switch variable {
+	case "global_download_speed_limit":
+		return fmt.Sprint(settings.DownloadSpeedLimit), nil
+	case "global_upload_speed_limit":
+		return fmt.Sprint(settings.UploadSpeedLimit), nil
+	case "free_disk_space":
+		return fmt.Sprint(mclient.state.freeSpace()), nil
+	case "global_download_speed", "global_upload_speed":
+		status, err := mclient.GetStatus()
+		if err != nil {
+			return "", err
+		}
+		if variable == "global_download_speed" {
+			return fmt.Sprint(status.DownloadSpeed), nil
+		}
+		return fmt.Sprint(status.UploadSpeed), nil
+	case "save_path":
+		return settings.SavePath, nil
+	case "mock_time":
+		return fmt.Sprint(mclient.state.Clock), nil
+	case "mock_disk_size":
+		return fmt.Sprint(settings.DiskSize), nil
+	case "mock_download_speed":
+		return fmt.Sprint(settings.DownloadSpeed), nil
+	case "mock_upload_speed":
+		return fmt.Sprint(settings.UploadSpeed), nil
+	case "mock_time_scale":
+		return fmt.Sprint(settings.TimeScale), nil
+	default:
+		return "", nil
+	}
+}
+
+func (mclient *Client) GetTorrentTrackers(infoHash string) (client.TorrentTrackers, error) {
+	mtorrent, err := mclient.getTorrent(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	trackers := client.TorrentTrackers{}
+	for _, tracker := range mtorrent.Trackers {
+		trackers = append(trackers, client.TorrentTracker{
+			Url:    tracker,
+			Status: "working",
+		})
+	}
+	return trackers, nil
+}
+
+func (mclient *Client) EditTorrentTracker(infoHash string, oldTracker string, newTracker string,
+	replaceHost bool) error {
+	mtorrent, err := mclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	index := client.TorrentTrackers(util.Map(mtorrent.Trackers, func(tracker string) client.TorrentTracker {
+		return client.TorrentTracker{Url: tracker}
+	})).FindIndex(oldTracker)
+	if index == -1 {
+		return fmt.Errorf("torrent %s old tracker %s does NOT exist", infoHash, oldTracker)
+	}
+	if replaceHost && !util.IsUrl(newTracker) {
+		mtorrent.Trackers[index] = strings.Replace(mtorrent.Trackers[index], "//"+oldTracker, "//"+newTracker, 1)
+	} else {
+		mtorrent.Trackers[index] = newTracker
+	}
+	return mclient.save()
+}
+
+func (mclient *Client) AddTorrentTrackers(infoHash string, trackers []string,
+	oldTracker string, removeExisting bool) error {
+	mtorrent, err := mclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	if oldTracker != "" && !slices.ContainsFunc(mtorrent.Trackers, func(tracker string) bool {
+		return util.MatchUrlWithHostOrUrl(tracker, oldTracker)
+	}) {
+		return nil
+	}
+	if removeExisting {
+		mtorrent.Trackers = util.UniqueSlice(util.CopySlice(trackers))
+	} else {
+		mtorrent.Trackers = util.UniqueSlice(append(mtorrent.Trackers, trackers...))
+	}
+	return mclient.save()
+}
+
+func (mclient *Client) RemoveTorrentTrackers(infoHash string, trackers []string) error {
+	mtorrent, err := mclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	mtorrent.Trackers = util.Filter(mtorrent.Trackers, func(tracker string) bool {
+		return !slices.Contains(trackers, tracker)
+	})
+	return mclient.save()
+}
+
+func (mclient *Client) RenameTorrentFile(infoHash string, oldPath string, newPath string) error {
+	mtorrent, err := mclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	index := slices.IndexFunc(mtorrent.Files, func(file *mockFile) bool {
+		return file.Path == oldPath
+	})
+	if index == -1 {
+		return fmt.Errorf("file %q not found in torrent", oldPath)
+	}
+	mtorrent.Files[index].Path = newPath
+	if len(mtorrent.Files) == 1 && mtorrent.RootName == oldPath {
+		mtorrent.RootName = newPath
+	}
+	return mclient.save()
+}
+
+func (mclient *Client) RenameTorrentFolder(infoHash string, oldPath string, newPath string) error {
+	mtorrent, err := mclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	oldPath = strings.TrimSuffix(oldPath, "/") + "/"
+	newPath = strings.TrimSuffix(newPath, "/") + "/"
+	found := false
+	for _, file := range mtorrent.Files {
+		if strings.HasPrefix(file.Path, oldPath) {
+			file.Path = newPath + file.Path[len(oldPath):]
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("folder %q not found in torrent", oldPath)
+	}
+	if mtorrent.RootName+"/" == oldPath {
+		mtorrent.RootName = strings.TrimSuffix(newPath, "/")
+	}
+	return mclient.save()
+}
+
+// Peers are not simulated.
+func (mclient *Client) GetTorrentPeers(infoHash string) ([]*client.TorrentPeer, error) {
+	if _, err := mclient.getTorrent(infoHash); err != nil {
+		return nil, err
+	}
+	return []*client.TorrentPeer{}, nil
+}
+
+func (mclient *Client) BanPeers(peers []string) error {
+	return nil
+}
+
+func (mclient *Client) SetFilePriority(infoHash string, fileIndexes []int64, priority int64) error {
+	mtorrent, err := mclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	for _, index := range fileIndexes {
+		if index < 0 || index >= int64(len(mtorrent.Files)) {
+			return fmt.Errorf("invalid file index %d", index)
+		}
+		mtorrent.Files[index].Priority = priority
+	}
+	return mclient.save()
+}
+
+func (mclient *Client) Close() {
+	mclient.save()
+}
+
+// Relative path of state file is resolved against ptool config dir.
+func getStateFile(clientConfig *config.ClientConfigStruct) string {
+	stateFile := clientConfig.MockStateFile
+	if stateFile != "" && !filepath.IsAbs(stateFile) {
+		stateFile = filepath.Join(config.ConfigDir, stateFile)
+	}
+	return stateFile
+}
+
+func NewClient(name string, clientConfig *config.ClientConfigStruct, config *config.ConfigStruct) (
+	client.Client, error) {
+	stateFile := getStateFile(clientConfig)
+	now := util.Now()
+	state, err := loadState(stateFile, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load mock state file: %w", err)
+	}
+	// simulate what happened since last run
+	if elapsed := int64(float64(now-state.RealTime) * state.Settings.TimeScale); elapsed > 0 {
+		state.advance(elapsed)
+	}
+	state.RealTime = now
+	return &Client{
+		Name:         name,
+		ClientConfig: clientConfig,
+		Config:       config,
+		stateFile:    stateFile,
+		state:        state,
+	}, nil
+}
+
+func init() {
+	client.Register(&client.RegInfo{
+		Name:    "mock",
+		Creator: NewClient,
+	})
+}
+
+var (
+	_ client.Client = (*Client)(nil)
+)
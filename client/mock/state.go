@@ -0,0 +1,327 @@
+package mock
+
+// Persistent state & simulation of mock client.
+// All timestamps in state are in the virtual clock.
+
+import (
+	"bytes"
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"os"
+
+	"github.com/natefinch/atomic"
+
+	"github.com/sagan/ptool/client"
+)
+
+const (
+	DEFAULT_DISK_SIZE      = 1024 * 1024 * 1024 * 1024 // 1 TiB
+	DEFAULT_DOWNLOAD_SPEED = 10 * 1024 * 1024          // 10 MiB/s
+	DEFAULT_UPLOAD_SPEED   = 5 * 1024 * 1024           // 5 MiB/s
+	DEFAULT_SAVE_PATH      = "/downloads"
+	// Swarm popularity of a torrent halves every this seconds after it's added.
+	POPULARITY_HALF_LIFE = 6 * 3600
+	// Max count of simulation steps when advancing the virtual clock.
+	MAX_STEPS = 200
+	MIN_STEP  = 60
+)
+
+type mockSettings struct {
+	DiskSize           int64   `json:"disk_size"`
+	DownloadSpeed      int64   `json:"download_speed"` // max simulated download speed of a single torrent
+	UploadSpeed        int64   `json:"upload_speed"`   // max simulated upload speed of a single torrent
+	DownloadSpeedLimit int64   `json:"download_speed_limit"`
+	UploadSpeedLimit   int64   `json:"upload_speed_limit"`
+	TimeScale          float64 `json:"time_scale"` // virtual seconds elapsed per real second between runs
+	SavePath           string  `json:"save_path"`
+}
+
+type mockFile struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	Priority  int64  `json:"priority"` // 0: do not download
+	Completed int64  `json:"completed"`
+}
+
+type mockTorrent struct {
+	InfoHash          string           `json:"info_hash"`
+	Name              string           `json:"name"`
+	SavePath          string           `json:"save_path"`
+	RootName          string           `json:"root_name"` // root folder or single file name
+	Category          string           `json:"category"`
+	Tags              []string         `json:"tags"`
+	Meta              map[string]int64 `json:"meta"`
+	Trackers          []string         `json:"trackers"`
+	Files             []*mockFile      `json:"files"`
+	Atime             int64            `json:"atime"`
+	Ctime             int64            `json:"ctime"`
+	ActivityTime      int64            `json:"activity_time"`
+	Downloaded        int64            `json:"downloaded"`
+	Uploaded          int64            `json:"uploaded"`
+	DownloadSpeed     int64            `json:"download_speed"` // speed in last simulation step
+	UploadSpeed       int64            `json:"upload_speed"`
+	DownloadLimit     int64            `json:"download_limit"` // <= 0: no limit
+	UploadLimit       int64            `json:"upload_limit"`
+	RatioLimit        float64          `json:"ratio_limit"`
+	SeedingTimeLimit  int64            `json:"seeding_time_limit"`
+	Paused            bool             `json:"paused"`
+	Seeders           int64            `json:"seeders"` // initial swarm size
+	Leechers          int64            `json:"leechers"`
+	PeakDownloadSpeed int64            `json:"peak_download_speed"`
+	PeakUploadSpeed   int64            `json:"peak_upload_speed"`
+	Content           []byte           `json:"content"` // .torrent file contents
+}
+
+type mockState struct {
+	Clock      int64                              `json:"clock"`     // virtual clock
+	RealTime   int64                              `json:"real_time"` // real time when the state was last saved
+	Settings   *mockSettings                      `json:"settings"`
+	Tags       []string                           `json:"tags"`
+	Categories map[string]*client.TorrentCategory `json:"categories"`
+	Torrents   map[string]*mockTorrent            `json:"torrents"`
+}
+
+func newState(now int64) *mockState {
+	return &mockState{
+		Clock:    now,
+		RealTime: now,
+		Settings: &mockSettings{
+			DiskSize:      DEFAULT_DISK_SIZE,
+			DownloadSpeed: DEFAULT_DOWNLOAD_SPEED,
+			UploadSpeed:   DEFAULT_UPLOAD_SPEED,
+			TimeScale:     1,
+			SavePath:      DEFAULT_SAVE_PATH,
+		},
+		Tags:       []string{},
+		Categories: map[string]*client.TorrentCategory{},
+		Torrents:   map[string]*mockTorrent{},
+	}
+}
+
+// Load state from file. If file does not exist, return a new state. Missing settings are reset to default.
+func loadState(filename string, now int64) (*mockState, error) {
+	state := newState(now)
+	if filename == "" {
+		return state, nil
+	}
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	defaultSettings := state.Settings
+	if err = json.Unmarshal(contents, state); err != nil {
+		return nil, err
+	}
+	if state.Settings == nil {
+		state.Settings = defaultSettings
+	}
+	if state.Categories == nil {
+		state.Categories = map[string]*client.TorrentCategory{}
+	}
+	if state.Torrents == nil {
+		state.Torrents = map[string]*mockTorrent{}
+	}
+	for infoHash, torrent := range state.Torrents {
+		torrent.InfoHash = infoHash
+	}
+	return state, nil
+}
+
+func (state *mockState) save(filename string, now int64) error {
+	if filename == "" {
+		return nil
+	}
+	state.RealTime = now
+	contents, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return atomic.WriteFile(filename, bytes.NewReader(contents))
+}
+
+func (state *mockState) usedSpace() int64 {
+	used := int64(0)
+	for _, torrent := range state.Torrents {
+		used += torrent.completed()
+	}
+	return used
+}
+
+func (state *mockState) freeSpace() int64 {
+	return max(state.Settings.DiskSize-state.usedSpace(), 0)
+}
+
+// Advance the virtual clock by seconds and simulate all torrents' activities in the meantime.
+func (state *mockState) advance(seconds int64) {
+	step := max(MIN_STEP, (seconds+MAX_STEPS-1)/MAX_STEPS)
+	for seconds > 0 {
+		dt := min(step, seconds)
+		state.step(dt)
+		seconds -= dt
+	}
+}
+
+func (state *mockState) step(dt int64) {
+	downloadSpeeds := map[string]int64{}
+	uploadSpeeds := map[string]int64{}
+	totalDownloadSpeed := int64(0)
+	totalUploadSpeed := int64(0)
+	for infoHash, torrent := range state.Torrents {
+		downloadSpeed, uploadSpeed := torrent.wantedSpeeds(state.Clock)
+		downloadSpeeds[infoHash] = downloadSpeed
+		uploadSpeeds[infoHash] = uploadSpeed
+		totalDownloadSpeed += downloadSpeed
+		totalUploadSpeed += uploadSpeed
+	}
+	downloadFactor := 1.0
+	uploadFactor := 1.0
+	if state.Settings.DownloadSpeedLimit > 0 && totalDownloadSpeed > state.Settings.DownloadSpeedLimit {
+		downloadFactor = float64(state.Settings.DownloadSpeedLimit) / float64(totalDownloadSpeed)
+	}
+	if state.Settings.UploadSpeedLimit > 0 && totalUploadSpeed > state.Settings.UploadSpeedLimit {
+		uploadFactor = float64(state.Settings.UploadSpeedLimit) / float64(totalUploadSpeed)
+	}
+	freeSpace := state.freeSpace()
+	now := state.Clock + dt
+	for infoHash, torrent := range state.Torrents {
+		downloaded := min(int64(float64(downloadSpeeds[infoHash])*downloadFactor)*dt, freeSpace)
+		downloaded = torrent.download(downloaded)
+		freeSpace -= downloaded
+		uploaded := int64(float64(uploadSpeeds[infoHash])*uploadFactor) * dt
+		torrent.Uploaded += uploaded
+		torrent.DownloadSpeed = downloaded / dt
+		torrent.UploadSpeed = uploaded / dt
+		if downloaded > 0 || uploaded > 0 {
+			torrent.ActivityTime = now
+		}
+		if torrent.Ctime <= 0 && torrent.completed() == torrent.size() {
+			torrent.Ctime = now
+		}
+		if torrent.Ctime > 0 && !torrent.Paused {
+			if torrent.RatioLimit > 0 && float64(torrent.Uploaded) >= torrent.RatioLimit*float64(torrent.size()) ||
+				torrent.SeedingTimeLimit > 0 && now-torrent.Ctime >= torrent.SeedingTimeLimit {
+				torrent.Paused = true
+			}
+		}
+	}
+	state.Clock = now
+}
+
+// Return a deterministic pseudo random generator for a torrent.
+func torrentRand(infoHash string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(infoHash))
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
+
+// Initialize simulated swarm of a newly added torrent.
+func (torrent *mockTorrent) initSwarm(settings *mockSettings) {
+	r := torrentRand(torrent.InfoHash)
+	torrent.Seeders = 1 + r.Int63n(50)
+	torrent.Leechers = r.Int63n(100)
+	torrent.PeakDownloadSpeed = int64(float64(settings.DownloadSpeed) * (0.2 + 0.8*r.Float64()))
+	torrent.PeakUploadSpeed = int64(float64(settings.UploadSpeed) * r.Float64() *
+		min(1, float64(torrent.Leechers)/20))
+}
+
+// Swarm popularity of torrent at time now. Range: (0, 1].
+func (torrent *mockTorrent) popularity(now int64) float64 {
+	age := max(now-torrent.Atime, 0)
+	return math.Pow(0.5, float64(age)/POPULARITY_HALF_LIFE)
+}
+
+func (torrent *mockTorrent) currentLeechers(now int64) int64 {
+	return int64(float64(torrent.Leechers) * torrent.popularity(now))
+}
+
+func (torrent *mockTorrent) wantedSpeeds(now int64) (downloadSpeed int64, uploadSpeed int64) {
+	if torrent.Paused {
+		return 0, 0
+	}
+	popularity := torrent.popularity(now)
+	size := torrent.size()
+	completed := torrent.completed()
+	if completed < size {
+		downloadSpeed = torrent.PeakDownloadSpeed
+		if torrent.DownloadLimit > 0 {
+			downloadSpeed = min(downloadSpeed, torrent.DownloadLimit)
+		}
+	}
+	progress := 1.0
+	if size > 0 {
+		progress = float64(completed) / float64(size)
+	}
+	// can only upload pieces already downloaded
+	uploadSpeed = int64(float64(torrent.PeakUploadSpeed) * popularity * min(1, progress*2))
+	if torrent.UploadLimit > 0 {
+		uploadSpeed = min(uploadSpeed, torrent.UploadLimit)
+	}
+	return
+}
+
+// Download at most bytes to wanted files, return actually downloaded bytes.
+func (torrent *mockTorrent) download(bytes int64) int64 {
+	downloaded := int64(0)
+	for _, file := range torrent.Files {
+		if bytes <= 0 {
+			break
+		}
+		if file.Priority == 0 || file.Completed >= file.Size {
+			continue
+		}
+		n := min(bytes, file.Size-file.Completed)
+		file.Completed += n
+		bytes -= n
+		downloaded += n
+	}
+	torrent.Downloaded += downloaded
+	return downloaded
+}
+
+// size of wanted files.
+func (torrent *mockTorrent) size() int64 {
+	size := int64(0)
+	for _, file := range torrent.Files {
+		if file.Priority != 0 {
+			size += file.Size
+		}
+	}
+	return size
+}
+
+func (torrent *mockTorrent) sizeTotal() int64 {
+	size := int64(0)
+	for _, file := range torrent.Files {
+		size += file.Size
+	}
+	return size
+}
+
+func (torrent *mockTorrent) completed() int64 {
+	completed := int64(0)
+	for _, file := range torrent.Files {
+		if file.Priority != 0 {
+			completed += file.Completed
+		}
+	}
+	return completed
+}
+
+func (torrent *mockTorrent) state() string {
+	complete := torrent.completed() == torrent.size()
+	if torrent.Paused {
+		if complete {
+			return "completed"
+		}
+		return "paused"
+	}
+	if complete {
+		return "seeding"
+	}
+	return "downloading"
+}
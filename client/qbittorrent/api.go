@@ -1,6 +1,8 @@
 package qbittorrent
 
 import (
+	"encoding/json"
+	"strconv"
 	"strings"
 
 	"github.com/sagan/ptool/client"
@@ -24,6 +26,28 @@ type apiTorrentTracker struct {
 	Msg            string `yaml:"msg"`            // Tracker message (there is no way of knowing what this message is - it's up to tracker admins)
 }
 
+// https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-4.1)#get-torrent-peers-data
+type apiTorrentPeers struct {
+	Rid         int64                      `json:"rid"`
+	Full_update bool                       `json:"full_update"`
+	Peers       map[string]*apiTorrentPeer `json:"peers"` // key: "ip:port"
+}
+
+type apiTorrentPeer struct {
+	Client         string  `json:"client"`         // Peer client name and version
+	Connection     string  `json:"connection"`     // Connection type. e.g. "BT", "μTP"
+	Country_code   string  `json:"country_code"`   // Peer country code. Empty if geoip database is disabled
+	Dl_speed       int64   `json:"dl_speed"`       // Download speed from this peer (bytes/s)
+	Downloaded     int64   `json:"downloaded"`     // Total data downloaded from this peer (bytes)
+	Flags          string  `json:"flags"`          // Peer flags. e.g. "D U K E P"
+	Ip             string  `json:"ip"`             // Peer ip
+	Peer_id_client string  `json:"peer_id_client"` // Peer id client part (qb 4.6+). e.g. "-qB4620-"
+	Port           int64   `json:"port"`           // Peer port
+	Progress       float64 `json:"progress"`       // Peer progress (percentage/100)
+	Up_speed       int64   `json:"up_speed"`       // Upload speed to this peer (bytes/s)
+	Uploaded       int64   `json:"uploaded"`       // Total data uploaded to this peer (bytes)
+}
+
 type apiTorrentContent struct {
 	Index        int64   `json:"index"`        // File index
 	Name         string  `json:"name"`         // File name (including relative path)
@@ -42,6 +66,20 @@ type apiSyncMaindata struct {
 	Torrents     map[string]*apiTorrentInfo         `json:"torrents"`
 }
 
+// Response of "api/v2/sync/maindata?rid=<rid>".
+// If Full_update is false, it only contains changed items and changed fields of them.
+type apiSyncMaindataDelta struct {
+	Rid                int64                      `json:"rid"`
+	Full_update        bool                       `json:"full_update"`
+	Server_state       json.RawMessage            `json:"server_state"`
+	Tags               []string                   `json:"tags"` // added tags
+	Tags_removed       []string                   `json:"tags_removed"`
+	Categories         map[string]json.RawMessage `json:"categories"` // added or changed categories
+	Categories_removed []string                   `json:"categories_removed"`
+	Torrents           map[string]json.RawMessage `json:"torrents"` // added or changed torrents
+	Torrents_removed   []string                   `json:"torrents_removed"`
+}
+
 type apiTransferInfo struct {
 	Free_space_on_disk int64  `json:"free_space_on_disk"`
 	Dl_info_speed      int64  `json:"dl_info_speed"`     //Global download rate (bytes/s)
@@ -136,12 +174,16 @@ type apiTorrentInfo struct {
 	Uploaded           int64   `json:"uploaded"`           //	integer	Amount of data uploaded
 	Uploaded_session   int64   `json:"uploaded_session"`   //	integer	Amount of data uploaded this session
 	Upspeed            int64   `json:"upspeed"`            //	integer	Torrent upload speed (bytes/s)
+
+	// qb 4.6+. Inactive seeding time limit (minutes). -2: use global limit; -1: no limit. nil if not supported
+	Inactive_seeding_time_limit *int64 `json:"inactive_seeding_time_limit"`
 }
 
 type apiPreferences struct {
 	Locale                                 string         `json:"locale"`                                 // Currently selected language (e.g. en_GB for English)
 	Create_subfolder_enabled               bool           `json:"create_subfolder_enabled"`               // True if a subfolder should be created when adding a torrent
 	Start_paused_enabled                   bool           `json:"start_paused_enabled"`                   // True if torrents should be added in a Paused state
+	Add_stopped_enabled                    bool           `json:"add_stopped_enabled"`                    // qb 5.0+ (renamed from start_paused_enabled). True if torrents should be added in a Stopped state
 	Auto_delete_mode                       int64          `json:"auto_delete_mode"`                       // TODO
 	Preallocate_all                        bool           `json:"preallocate_all"`                        // True if disk space should be pre-allocated for all files
 	Incomplete_files_ext                   bool           `json:"incomplete_files_ext"`                   // True if ".!qB" should be appended to incomplete files
@@ -287,9 +329,24 @@ type apiPreferences struct {
 	Utp_tcp_mixed_mode                     int64          `json:"utp_tcp_mixed_mode"`                     // μTP-TCP mixed mode algorithm (see list of possible values below)
 }
 
+// qb 5.0 renamed "paused" torrent states to "stopped". legacy (4.x) state => 5.x state.
+var legacyStates = map[string]string{
+	"pausedUP": "stoppedUP",
+	"pausedDL": "stoppedDL",
+}
+
+// Return the qb 5.x equivalent of torrent state, so both 4.x and 5.x states can be handled in the same way.
+func (qt *apiTorrentInfo) normalizedState() string {
+	if state, ok := legacyStates[qt.State]; ok {
+		return state
+	}
+	return qt.State
+}
+
 func (qt *apiTorrentInfo) CanResume() bool {
-	return qt.State == "pausedUP" || qt.State == "stoppedUP" || qt.State == "pausedDL" || qt.State == "stoppedDL" ||
-		qt.State == "queuedUP" || qt.State == "queuedDL" || qt.State == "error"
+	state := qt.normalizedState()
+	return state == "stoppedUP" || state == "stoppedDL" || state == "queuedUP" || state == "queuedDL" ||
+		state == "error"
 }
 
 func (qt *apiTorrentInfo) CanPause() bool {
@@ -298,16 +355,16 @@ func (qt *apiTorrentInfo) CanPause() bool {
 
 func (qbtorrent *apiTorrentInfo) ToTorrentState() string {
 	state := ""
-	switch qbtorrent.State {
+	switch qbtorrent.normalizedState() {
 	case "stalledUP", "queuedUP", "forcedUP", "uploading":
 		state = "seeding"
-	case "metaDL", "allocating", "stalledDL", "queuedDL", "forcedDL", "downloading":
+	case "metaDL", "forcedMetaDL", "allocating", "stalledDL", "queuedDL", "forcedDL", "downloading":
 		state = "downloading"
-	case "pausedUP", "stoppedUP":
+	case "stoppedUP":
 		state = "completed"
-	case "pausedDL", "stoppedDL":
+	case "stoppedDL":
 		state = "paused"
-	case "checkingUP", "checkingDL", "checkingResumeData":
+	case "checkingUP", "checkingDL", "checkingResumeData", "moving":
 		state = "checking"
 	case "error", "missingFiles", "unknown":
 		state = "error"
@@ -376,3 +433,25 @@ func (qbtorrent *apiTorrentInfo) Sep() string {
 	}
 	return `/`
 }
+
+// Compare two qb WebAPI versions (e.g. "2.11.2"). Return -1, 0 or 1.
+func compareApiVersion(a string, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		av, bv := int64(0), int64(0)
+		if i < len(as) {
+			av, _ = strconv.ParseInt(as[i], 10, 64)
+		}
+		if i < len(bs) {
+			bv, _ = strconv.ParseInt(bs[i], 10, 64)
+		}
+		if av != bv {
+			if av < bv {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
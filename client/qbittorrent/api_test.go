@@ -0,0 +1,25 @@
+package qbittorrent
+
+import "testing"
+
+func TestCompareApiVersion(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"2.11.0", "2.11.0", 0},
+		{"2.11", "2.11.0", 0},
+		{"2.11.2", "2.11.0", 1},
+		{"2.9.3", "2.11.0", -1},
+		{"2.10.4", API_VERSION_5_0, -1},
+		{"2.11.0", API_VERSION_5_0, 0},
+		{"3.0", "2.11.2", 1},
+		{"2.8.19", "2.8.2", 1},
+	}
+	for _, tt := range tests {
+		if got := compareApiVersion(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareApiVersion(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ package qbittorrent
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -17,6 +18,7 @@ import (
 	"reflect"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -28,6 +30,14 @@ import (
 	"github.com/sagan/ptool/util/torrentutil"
 )
 
+// WebAPI version of qb 5.0.0, which renamed "pause" / "resume" to "stop" / "start".
+const API_VERSION_5_0 = "2.11.0"
+
+// qb 5.0 renamed some preferences. legacy (4.x) name => 5.x name.
+var preferenceRenames = map[string]string{
+	"start_paused_enabled": "add_stopped_enabled",
+}
+
 type Client struct {
 	Name                      string
 	ClientConfig              *config.ClientConfigStruct
@@ -37,6 +47,8 @@ type Client struct {
 	preferences               *apiPreferences
 	Logined                   bool
 	datatime                  int64
+	rid                       int64  // maindata response id, used for incremental sync
+	apiVersion                string // WebAPI version, e.g. "2.11.2". Detected on first use
 	unfinishedSize            int64
 	unfinishedDownloadingSize int64
 	contentPathTorrents       map[string][]*apiTorrentInfo
@@ -58,30 +70,82 @@ func (qbclient *Client) SetAllTorrentsShareLimits(ratioLimit float64, seedingTim
 	return qbclient.SetTorrentsShareLimits([]string{"all"}, ratioLimit, seedingTimeLimit)
 }
 
+// qb resets the inactive seeding time limit of torrents if it's not provided in request,
+// so torrents are grouped by their current inactive seeding time limit, which is sent back unchanged.
 func (qbclient *Client) SetTorrentsShareLimits(infoHashes []string, ratioLimit float64, seedingTimeLimit int64) error {
+	if err := qbclient.sync(); err != nil {
+		return err
+	}
+	if len(infoHashes) == 1 && infoHashes[0] == "all" {
+		infoHashes = nil
+		for infoHash := range qbclient.data.Torrents {
+			infoHashes = append(infoHashes, infoHash)
+		}
+	}
+	groups := map[int64][]string{}
+	for _, infoHash := range infoHashes {
+		limit := qbInactiveSeedingTimeLimit(qbclient.data.Torrents[infoHash])
+		groups[limit] = append(groups[limit], infoHash)
+	}
+	ratioLimit = qbRatioLimit(ratioLimit)
+	seedingTimeLimit = qbSeedingTimeLimit(seedingTimeLimit)
+	for inactiveSeedingTimeLimit, infoHashes := range groups {
+		err := qbclient.setShareLimits(infoHashes, ratioLimit, seedingTimeLimit, inactiveSeedingTimeLimit, "")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ratioLimit & seedingTimeLimit & inactiveSeedingTimeLimit are qb values (time limits in minutes).
+// inactiveSeedingTimeLimit: qb 4.6+ only, always sent as qb resets it if missing.
+// shareLimitAction: qb 5.1+ only, empty means not changing it.
+func (qbclient *Client) setShareLimits(infoHashes []string, ratioLimit float64, seedingTimeLimit int64,
+	inactiveSeedingTimeLimit int64, shareLimitAction string) error {
 	if len(infoHashes) == 0 {
 		return nil
 	}
 	data := url.Values{
 		"hashes": {strings.Join(infoHashes, "|")},
 	}
-	if ratioLimit == 0 {
-		ratioLimit = -2 // use global limit
-	}
 	data.Add("ratioLimit", fmt.Sprint(ratioLimit))
-	seedingTimeLimitValue := seedingTimeLimit
-	if seedingTimeLimitValue == 0 {
-		seedingTimeLimitValue = -2 //use global limit
-	} else if seedingTimeLimitValue > 0 {
-		seedingTimeLimitValue = seedingTimeLimitValue/60 + 1
-	}
-	data.Add("seedingTimeLimit", fmt.Sprint(seedingTimeLimitValue))
+	data.Add("seedingTimeLimit", fmt.Sprint(seedingTimeLimit))
 	// The maximum amount of time (minutes) the torrent is allowed to seed while being inactive.
 	// -2 means the global limit should be used, -1 means no limit.
-	data.Add("inactiveSeedingTimeLimit", fmt.Sprint(-2))
+	data.Add("inactiveSeedingTimeLimit", fmt.Sprint(inactiveSeedingTimeLimit))
+	if shareLimitAction != "" {
+		data.Add("shareLimitAction", shareLimitAction)
+	}
 	return qbclient.apiPost("api/v2/torrents/setShareLimits", data)
 }
 
+// Return the current inactive seeding time limit of torrent. -2 (use global limit) if unknown.
+func qbInactiveSeedingTimeLimit(qbtorrent *apiTorrentInfo) int64 {
+	if qbtorrent == nil || qbtorrent.Inactive_seeding_time_limit == nil {
+		return -2
+	}
+	return *qbtorrent.Inactive_seeding_time_limit
+}
+
+// Convert ratio limit to qb value. 0 => -2 (use global limit).
+func qbRatioLimit(ratioLimit float64) float64 {
+	if ratioLimit == 0 {
+		return -2
+	}
+	return ratioLimit
+}
+
+// Convert seeding time limit (seconds) to qb value (minutes). 0 => -2 (use global limit).
+func qbSeedingTimeLimit(seedingTimeLimit int64) int64 {
+	if seedingTimeLimit == 0 {
+		return -2
+	} else if seedingTimeLimit > 0 {
+		return seedingTimeLimit/60 + 1
+	}
+	return seedingTimeLimit
+}
+
 func (qbclient *Client) apiPost(apiUrl string, data url.Values) error {
 	resp, err := qbclient.HttpClient.PostForm(qbclient.ClientConfig.Url+apiUrl, data)
 	if err != nil {
@@ -101,16 +165,21 @@ func (qbclient *Client) apiPost(apiUrl string, data url.Values) error {
 	return nil
 }
 
-func (qbclient *Client) apiRequest(apiPath string, v any) error {
+// Request a qb API and return the raw response body.
+func (qbclient *Client) apiRequestRaw(apiPath string) ([]byte, error) {
 	resp, err := qbclient.HttpClient.Get(qbclient.ClientConfig.Url + apiPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("apiRequest %s response %d status", apiPath, resp.StatusCode)
+		return nil, fmt.Errorf("apiRequest %s response %d status", apiPath, resp.StatusCode)
 	}
-	body, err := io.ReadAll(resp.Body)
+	return io.ReadAll(resp.Body)
+}
+
+func (qbclient *Client) apiRequest(apiPath string, v any) error {
+	body, err := qbclient.apiRequestRaw(apiPath)
 	if err != nil {
 		return err
 	}
@@ -145,6 +214,57 @@ func (qbclient *Client) login() error {
 	return err
 }
 
+// Return qb WebAPI version (e.g. "2.11.2"). It's detected only once.
+func (qbclient *Client) getApiVersion() (string, error) {
+	if qbclient.apiVersion != "" {
+		return qbclient.apiVersion, nil
+	}
+	err := qbclient.login()
+	if err != nil {
+		return "", fmt.Errorf("login error: %w", err)
+	}
+	body, err := qbclient.apiRequestRaw("api/v2/app/webapiVersion")
+	if err != nil {
+		return "", err
+	}
+	version := strings.TrimSpace(string(body))
+	if version == "" {
+		return "", fmt.Errorf("empty webapi version")
+	}
+	log.Tracef("qb %s webapi version: %s", qbclient.Name, version)
+	qbclient.apiVersion = version
+	return version, nil
+}
+
+// Return true if qb is legacy (< 5.0) version, which uses "pause" / "resume" API instead of "stop" / "start".
+// If "qbittorrentV4" of client config is set, it's always true without detection.
+func (qbclient *Client) isLegacy() (bool, error) {
+	if qbclient.ClientConfig.QbittorrentV4 {
+		return true, nil
+	}
+	version, err := qbclient.getApiVersion()
+	if err != nil {
+		return false, fmt.Errorf("failed to detect qb version: %w", err)
+	}
+	return compareApiVersion(version, API_VERSION_5_0) < 0, nil
+}
+
+// Return the name of preference for current qb version. Both legacy (4.x) and 5.x names are accepted.
+func (qbclient *Client) preferenceName(name string) string {
+	legacy, err := qbclient.isLegacy()
+	if err != nil {
+		return name
+	}
+	for legacyName, newName := range preferenceRenames {
+		if legacy && name == newName {
+			return legacyName
+		} else if !legacy && name == legacyName {
+			return newName
+		}
+	}
+	return name
+}
+
 func (qbclient *Client) GetName() string {
 	return qbclient.Name
 }
@@ -210,11 +330,10 @@ func (qbclient *Client) AddTorrent(torrentContent []byte, option *client.Torrent
 			mp.WriteField("ratioLimit", fmt.Sprint(option.RatioLimit))
 		}
 		if option.SeedingTimeLimit != 0 {
-			value := option.SeedingTimeLimit
-			if value > 0 {
-				value = value/60 + 1
-			}
-			mp.WriteField("seedingTimeLimit", fmt.Sprint(value))
+			mp.WriteField("seedingTimeLimit", fmt.Sprint(qbSeedingTimeLimit(option.SeedingTimeLimit)))
+		}
+		if option.ShareLimitAction != "" {
+			mp.WriteField("shareLimitAction", option.ShareLimitAction)
 		}
 	}
 	mp.Close()
@@ -241,7 +360,11 @@ func (qbclient *Client) PauseTorrents(infoHashes []string) error {
 	data := url.Values{
 		"hashes": {strings.Join(infoHashes, "|")},
 	}
-	if qbclient.ClientConfig.QbittorrentV4 {
+	legacy, err := qbclient.isLegacy()
+	if err != nil {
+		return err
+	}
+	if legacy {
 		return qbclient.apiPost("api/v2/torrents/pause", data)
 	}
 	return qbclient.apiPost("api/v2/torrents/stop", data)
@@ -258,7 +381,11 @@ func (qbclient *Client) ResumeTorrents(infoHashes []string) error {
 	data := url.Values{
 		"hashes": {strings.Join(infoHashes, "|")},
 	}
-	if qbclient.ClientConfig.QbittorrentV4 {
+	legacy, err := qbclient.isLegacy()
+	if err != nil {
+		return err
+	}
+	if legacy {
 		return qbclient.apiPost("api/v2/torrents/resume", data)
 	}
 	return qbclient.apiPost("api/v2/torrents/start", data)
@@ -488,9 +615,11 @@ func (qbclient *Client) DeleteTorrents(infoHashes []string, deleteFiles bool) (e
 	err = qbclient.apiPost("api/v2/torrents/delete", data)
 	if err == nil && qbclient.Cached() {
 		for _, infoHash := range infoHashes {
-			delete(qbclient.data.Torrents, infoHash)
+			if torrent := qbclient.data.Torrents[infoHash]; torrent != nil {
+				qbclient.removeDerivative(torrent)
+				delete(qbclient.data.Torrents, infoHash)
+			}
 		}
-		qbclient.buildDerivative()
 	}
 	return
 }
@@ -608,8 +737,18 @@ func (qbclient *Client) ModifyTorrent(infoHash string,
 		}
 	}
 
-	if option.RatioLimit != 0 || option.SeedingTimeLimit != 0 {
-		err := qbclient.SetTorrentsShareLimits([]string{infoHash}, option.RatioLimit, option.SeedingTimeLimit)
+	if option.RatioLimit != 0 || option.SeedingTimeLimit != 0 || option.ShareLimitAction != "" {
+		// keep current limits of torrent that are not changed
+		ratioLimit := qbtorrent.Ratio_limit
+		seedingTimeLimit := qbtorrent.Seeding_time_limit
+		if option.RatioLimit != 0 {
+			ratioLimit = option.RatioLimit
+		}
+		if option.SeedingTimeLimit != 0 {
+			seedingTimeLimit = qbSeedingTimeLimit(option.SeedingTimeLimit)
+		}
+		err := qbclient.setShareLimits([]string{infoHash}, ratioLimit, seedingTimeLimit,
+			qbInactiveSeedingTimeLimit(qbtorrent), option.ShareLimitAction)
 		if err != nil {
 			return err
 		}
@@ -634,6 +773,7 @@ func (qbclient *Client) PurgeCache() {
 	qbclient.unfinishedSize = 0
 	qbclient.unfinishedDownloadingSize = 0
 	qbclient.datatime = 0
+	qbclient.rid = 0
 	qbclient.contentPathTorrents = nil
 }
 
@@ -641,41 +781,148 @@ func (qbclient *Client) Cached() bool {
 	return qbclient.datatime > 0
 }
 
+// Fetch maindata from qb. If there is cached data, only fetch changes since last sync (using rid)
+// and merge them into cache. Cached data is considered fresh forever,
+// unless "syncMaxAge" of client config is set, in which case it's refreshed after that age.
 func (qbclient *Client) sync() error {
-	if qbclient.datatime > 0 {
+	now := util.Now()
+	if qbclient.datatime > 0 && (qbclient.ClientConfig.SyncMaxAge <= 0 ||
+		now-qbclient.datatime < qbclient.ClientConfig.SyncMaxAge) {
 		return nil
 	}
 	err := qbclient.login()
 	if err != nil {
 		return fmt.Errorf("login error: %w", err)
 	}
-	err = qbclient.apiRequest("api/v2/sync/maindata", &qbclient.data)
+	apiPath := "api/v2/sync/maindata"
+	if qbclient.data != nil {
+		apiPath += fmt.Sprintf("?rid=%d", qbclient.rid)
+	}
+	var delta *apiSyncMaindataDelta
+	err = qbclient.apiRequest(apiPath, &delta)
 	if err != nil {
 		return err
 	}
-	qbclient.datatime = util.Now()
-	qbclient.buildDerivative()
+	if delta == nil {
+		return fmt.Errorf("invalid maindata response")
+	}
+	if err = qbclient.mergeMaindata(delta); err != nil {
+		qbclient.PurgeCache()
+		return fmt.Errorf("failed to merge maindata: %w", err)
+	}
+	qbclient.rid = delta.Rid
+	qbclient.datatime = now
+	return nil
+}
+
+// Merge a (full or partial) maindata response into cache, and update derivatives accordingly.
+func (qbclient *Client) mergeMaindata(delta *apiSyncMaindataDelta) error {
+	fullUpdate := delta.Full_update || qbclient.data == nil
+	if fullUpdate {
+		qbclient.data = &apiSyncMaindata{
+			Server_state: &apiTransferInfo{},
+			Tags:         []string{},
+			Categories:   map[string]*client.TorrentCategory{},
+			Torrents:     map[string]*apiTorrentInfo{},
+		}
+	}
+	data := qbclient.data
+	if len(delta.Server_state) > 0 {
+		if err := json.Unmarshal(delta.Server_state, data.Server_state); err != nil {
+			return err
+		}
+	}
+	data.Tags = util.UniqueSlice(append(data.Tags, delta.Tags...))
+	if len(delta.Tags_removed) > 0 {
+		data.Tags = util.Filter(data.Tags, func(tag string) bool {
+			return !slices.Contains(delta.Tags_removed, tag)
+		})
+	}
+	for name, raw := range delta.Categories {
+		category := data.Categories[name]
+		if category == nil {
+			category = &client.TorrentCategory{}
+			data.Categories[name] = category
+		}
+		if err := json.Unmarshal(raw, category); err != nil {
+			return err
+		}
+	}
+	for _, name := range delta.Categories_removed {
+		delete(data.Categories, name)
+	}
+	if fullUpdate {
+		for hash, raw := range delta.Torrents {
+			torrent := &apiTorrentInfo{}
+			if err := json.Unmarshal(raw, torrent); err != nil {
+				return err
+			}
+			data.Torrents[hash] = torrent
+		}
+		qbclient.buildDerivative()
+		return nil
+	}
+	for hash, raw := range delta.Torrents {
+		torrent := data.Torrents[hash]
+		if torrent != nil {
+			qbclient.removeDerivative(torrent)
+		} else {
+			torrent = &apiTorrentInfo{}
+			data.Torrents[hash] = torrent
+		}
+		// only changed fields are present in partial data
+		if err := json.Unmarshal(raw, torrent); err != nil {
+			return err
+		}
+		torrent.Hash = hash
+		qbclient.addDerivative(torrent)
+	}
+	for _, hash := range delta.Torrents_removed {
+		if torrent := data.Torrents[hash]; torrent != nil {
+			qbclient.removeDerivative(torrent)
+			delete(data.Torrents, hash)
+		}
+	}
 	return nil
 }
 
 func (qbclient *Client) buildDerivative() {
-	unfinishedSize := int64(0)
-	unfinishedDownloadingSize := int64(0)
-	contentPathTorrents := map[string][]*apiTorrentInfo{}
+	qbclient.unfinishedSize = 0
+	qbclient.unfinishedDownloadingSize = 0
+	qbclient.contentPathTorrents = map[string][]*apiTorrentInfo{}
 	// make hash available in torrent itself as well as map key
 	for hash, torrent := range qbclient.data.Torrents {
 		torrent.Hash = hash
-		qbclient.data.Torrents[hash] = torrent
-		usize := torrent.Size - torrent.Completed
-		unfinishedSize += usize
-		if torrent.State != "pausedDL" {
-			unfinishedDownloadingSize += usize
-		}
-		contentPathTorrents[torrent.ContentPath()] = append(contentPathTorrents[torrent.ContentPath()], torrent)
+		qbclient.addDerivative(torrent)
+	}
+}
+
+func (qbclient *Client) addDerivative(torrent *apiTorrentInfo) {
+	usize := torrent.Size - torrent.Completed
+	qbclient.unfinishedSize += usize
+	if torrent.normalizedState() != "stoppedDL" {
+		qbclient.unfinishedDownloadingSize += usize
+	}
+	contentPath := torrent.ContentPath()
+	qbclient.contentPathTorrents[contentPath] = append(qbclient.contentPathTorrents[contentPath], torrent)
+}
+
+// Revert what addDerivative does. Must be called before torrent is changed or deleted.
+func (qbclient *Client) removeDerivative(torrent *apiTorrentInfo) {
+	usize := torrent.Size - torrent.Completed
+	qbclient.unfinishedSize -= usize
+	if torrent.normalizedState() != "stoppedDL" {
+		qbclient.unfinishedDownloadingSize -= usize
+	}
+	contentPath := torrent.ContentPath()
+	torrents := slices.DeleteFunc(qbclient.contentPathTorrents[contentPath], func(t *apiTorrentInfo) bool {
+		return t == torrent
+	})
+	if len(torrents) > 0 {
+		qbclient.contentPathTorrents[contentPath] = torrents
+	} else {
+		delete(qbclient.contentPathTorrents, contentPath)
 	}
-	qbclient.unfinishedSize = unfinishedSize
-	qbclient.unfinishedDownloadingSize = unfinishedDownloadingSize
-	qbclient.contentPathTorrents = contentPathTorrents
 }
 
 func (qbclient *Client) TorrentRootPathExists(rootFolder string) bool {
@@ -748,6 +995,7 @@ func (qbclient *Client) setPreferences(preferences map[string]any) error {
 	dataStr := "json=" + string(data)
 	_, err = qbclient.HttpClient.Post(qbclient.ClientConfig.Url+"api/v2/app/setPreferences",
 		"application/x-www-form-urlencoded", strings.NewReader(dataStr))
+	qbclient.preferences = nil
 	return err
 }
 
@@ -773,8 +1021,12 @@ func (qbclient *Client) GetConfig(variable string) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		value := reflect.Indirect(reflect.ValueOf(preferences)).FieldByName(util.Capitalize(variable[3:])).Interface()
-		return fmt.Sprint(value), nil
+		field := reflect.Indirect(reflect.ValueOf(preferences)).FieldByName(
+			util.Capitalize(qbclient.preferenceName(variable[3:])))
+		if !field.IsValid() {
+			return "", nil
+		}
+		return fmt.Sprint(field.Interface()), nil
 	}
 
 	switch variable {
@@ -786,6 +1038,9 @@ func (qbclient *Client) GetConfig(variable string) (string, error) {
 		v := 0
 		err = qbclient.apiRequest("api/v2/transfer/uploadLimit", &v)
 		return fmt.Sprint(v), err
+	case "alt_speed_enabled":
+		enabled, err := qbclient.getAltSpeedEnabled()
+		return fmt.Sprint(enabled), err
 	case "free_disk_space":
 		status, err := qbclient.GetStatus()
 		if err != nil {
@@ -822,7 +1077,7 @@ func (qbclient *Client) SetConfig(variable string, value string) error {
 	}
 	if strings.HasPrefix(variable, "qb_") && len(variable) > 3 {
 		data := map[string]any{}
-		data[variable[3:]], _ = util.String2Any(value)
+		data[qbclient.preferenceName(variable[3:])], _ = util.String2Any(value)
 		return qbclient.setPreferences(data)
 	}
 	switch variable {
@@ -842,6 +1097,16 @@ func (qbclient *Client) SetConfig(variable string, value string) error {
 			err = qbclient.apiPost("api/v2/transfer/setUploadLimit", data)
 			return err
 		}
+	case "alt_speed_enabled":
+		// qb only provides a toggle API
+		enabled, err := qbclient.getAltSpeedEnabled()
+		if err != nil {
+			return err
+		}
+		if wanted, _ := strconv.ParseBool(value); enabled == wanted {
+			return nil
+		}
+		return qbclient.apiPost("api/v2/transfer/toggleSpeedLimitsMode", nil)
 	case "free_disk_space", "global_download_speed", "global_upload_speed":
 		return fmt.Errorf("%s is read-only", variable)
 	case "save_path":
@@ -851,6 +1116,13 @@ func (qbclient *Client) SetConfig(variable string, value string) error {
 	}
 }
 
+// Return true if alternative speed limits are enabled.
+func (qbclient *Client) getAltSpeedEnabled() (bool, error) {
+	mode := 0
+	err := qbclient.apiRequest("api/v2/transfer/speedLimitsMode", &mode)
+	return mode == 1, err
+}
+
 func (qbclient *Client) exportTorrentFileFromLocalTorrentsPath(infoHash string) ([]byte, error) {
 	torrentFile := filepath.Join(qbclient.ClientConfig.LocalTorrentsPath, infoHash+".torrent")
 	contents, err := os.ReadFile(torrentFile)
@@ -1107,6 +1379,92 @@ func (qbclient *Client) RemoveTorrentTrackers(infoHash string, trackers []string
 	return qbclient.apiPost("api/v2/torrents/removeTrackers", data)
 }
 
+func (qbclient *Client) RenameTorrentFile(infoHash string, oldPath string, newPath string) error {
+	err := qbclient.login()
+	if err != nil {
+		return fmt.Errorf("login error: %w", err)
+	}
+	data := url.Values{
+		"hash":    {infoHash},
+		"oldPath": {oldPath},
+		"newPath": {newPath},
+	}
+	return qbclient.apiPost("api/v2/torrents/renameFile", data)
+}
+
+func (qbclient *Client) RenameTorrentFolder(infoHash string, oldPath string, newPath string) error {
+	err := qbclient.login()
+	if err != nil {
+		return fmt.Errorf("login error: %w", err)
+	}
+	data := url.Values{
+		"hash":    {infoHash},
+		"oldPath": {oldPath},
+		"newPath": {newPath},
+	}
+	err = qbclient.apiPost("api/v2/torrents/renameFolder", data)
+	if err == nil {
+		// content path of torrent may change. Fetch changes in next sync.
+		qbclient.datatime = 0
+	}
+	return err
+}
+
+func (qbclient *Client) GetTorrentPeers(infoHash string) ([]*client.TorrentPeer, error) {
+	err := qbclient.login()
+	if err != nil {
+		return nil, fmt.Errorf("login error: %w", err)
+	}
+	var qbTorrentPeers *apiTorrentPeers
+	err = qbclient.apiRequest("api/v2/sync/torrentPeers?hash="+infoHash, &qbTorrentPeers)
+	if err != nil {
+		return nil, err
+	}
+	peers := []*client.TorrentPeer{}
+	if qbTorrentPeers == nil {
+		return peers, nil
+	}
+	for address, qbpeer := range qbTorrentPeers.Peers {
+		peers = append(peers, &client.TorrentPeer{
+			Address:       address,
+			Ip:            qbpeer.Ip,
+			Port:          qbpeer.Port,
+			Client:        qbpeer.Client,
+			PeerId:        qbpeer.Peer_id_client,
+			Progress:      qbpeer.Progress,
+			DownloadSpeed: qbpeer.Dl_speed,
+			UploadSpeed:   qbpeer.Up_speed,
+			Downloaded:    qbpeer.Downloaded,
+			Uploaded:      qbpeer.Uploaded,
+			Flags:         qbpeer.Flags,
+			Country:       qbpeer.Country_code,
+		})
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Address < peers[j].Address
+	})
+	return peers, nil
+}
+
+// qBittorrent Web API only reports free space of the default save path.
+func (qbclient *Client) GetFreeSpace(path string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (qbclient *Client) BanPeers(peers []string) error {
+	if len(peers) == 0 {
+		return nil
+	}
+	err := qbclient.login()
+	if err != nil {
+		return fmt.Errorf("login error: %w", err)
+	}
+	data := url.Values{
+		"peers": {strings.Join(peers, "|")},
+	}
+	return qbclient.apiPost("api/v2/transfer/banPeers", data)
+}
+
 func (qbclient *Client) SetFilePriority(infoHash string, fileIndexes []int64, priority int64) error {
 	if len(fileIndexes) == 0 {
 		return fmt.Errorf("must provide at least fileIndex")
@@ -0,0 +1,89 @@
+package qbittorrent
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestMergeMaindata(t *testing.T) {
+	parse := func(str string) *apiSyncMaindataDelta {
+		var delta *apiSyncMaindataDelta
+		if err := json.Unmarshal([]byte(str), &delta); err != nil {
+			t.Fatalf("failed to parse maindata: %v", err)
+		}
+		return delta
+	}
+	qbclient := &Client{}
+	full := parse(`{"rid":1,"full_update":true,
+		"server_state":{"free_space_on_disk":1000,"up_info_speed":10},
+		"tags":["a","b"],
+		"categories":{"movies":{"name":"movies","savePath":"/data/movies"}},
+		"torrents":{
+			"aaa":{"name":"A","size":100,"completed":40,"state":"downloading",
+				"save_path":"/data","content_path":"/data/A","tags":"a"},
+			"bbb":{"name":"B","size":200,"completed":200,"state":"uploading",
+				"save_path":"/data","content_path":"/data/B"},
+			"ccc":{"name":"C","size":300,"completed":0,"state":"pausedDL",
+				"save_path":"/data","content_path":"/data/C"}
+		}}`)
+	if err := qbclient.mergeMaindata(full); err != nil {
+		t.Fatalf("full update: %v", err)
+	}
+	if len(qbclient.data.Torrents) != 3 || qbclient.data.Torrents["aaa"].Hash != "aaa" {
+		t.Errorf("full update: unexpected torrents %v", qbclient.data.Torrents)
+	}
+	if qbclient.unfinishedSize != 360 || qbclient.unfinishedDownloadingSize != 60 {
+		t.Errorf("full update: got unfinished size %d / %d, want 360 / 60",
+			qbclient.unfinishedSize, qbclient.unfinishedDownloadingSize)
+	}
+
+	partial := parse(`{"rid":2,
+		"server_state":{"up_info_speed":20},
+		"tags":["c"],
+		"tags_removed":["b"],
+		"categories":{"tv":{"name":"tv","savePath":"/data/tv"}},
+		"categories_removed":["movies"],
+		"torrents":{
+			"aaa":{"completed":100,"state":"uploading"},
+			"ddd":{"name":"D","size":50,"completed":10,"state":"downloading",
+				"save_path":"/data","content_path":"/data/D"}
+		},
+		"torrents_removed":["bbb","unknown"]}`)
+	if err := qbclient.mergeMaindata(partial); err != nil {
+		t.Fatalf("partial update: %v", err)
+	}
+	data := qbclient.data
+	if data.Server_state.Free_space_on_disk != 1000 || data.Server_state.Up_info_speed != 20 {
+		t.Errorf("partial update: unexpected server state %+v", data.Server_state)
+	}
+	slices.Sort(data.Tags)
+	if !slices.Equal(data.Tags, []string{"a", "c"}) {
+		t.Errorf("partial update: got tags %v, want [a c]", data.Tags)
+	}
+	if data.Categories["movies"] != nil || data.Categories["tv"] == nil {
+		t.Errorf("partial update: unexpected categories %v", data.Categories)
+	}
+	if len(data.Torrents) != 3 || data.Torrents["bbb"] != nil || data.Torrents["ddd"] == nil {
+		t.Errorf("partial update: unexpected torrents %v", data.Torrents)
+	}
+	// changed fields are merged, others are preserved
+	if torrent := data.Torrents["aaa"]; torrent.Name != "A" || torrent.Size != 100 || torrent.Completed != 100 ||
+		torrent.State != "uploading" || torrent.Tags != "a" {
+		t.Errorf("partial update: unexpected torrent %+v", torrent)
+	}
+	if qbclient.unfinishedSize != 340 || qbclient.unfinishedDownloadingSize != 40 {
+		t.Errorf("partial update: got unfinished size %d / %d, want 340 / 40",
+			qbclient.unfinishedSize, qbclient.unfinishedDownloadingSize)
+	}
+	if qbclient.contentPathTorrents["/data/B"] != nil || len(qbclient.contentPathTorrents["/data/D"]) != 1 {
+		t.Errorf("partial update: unexpected content path torrents %v", qbclient.contentPathTorrents)
+	}
+
+	if err := qbclient.mergeMaindata(parse(`{"rid":3,"full_update":true,"torrents":{}}`)); err != nil {
+		t.Fatalf("second full update: %v", err)
+	}
+	if len(qbclient.data.Torrents) != 0 || len(qbclient.data.Tags) != 0 || qbclient.unfinishedSize != 0 {
+		t.Errorf("second full update: cache is not reset")
+	}
+}
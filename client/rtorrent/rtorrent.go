@@ -0,0 +1,1365 @@
+package rtorrent
+
+// rTorrent XML-RPC API.
+// https://rtorrent-docs.readthedocs.io/en/latest/cmd-ref.html
+// Client url:
+//   - "http://localhost/RPC2" : HTTP(S) XML-RPC endpoint (e.g. provided by ruTorrent or nginx). Basic auth supported.
+//   - "scgi://localhost:5000" : SCGI over tcp ("network.scgi.open_port").
+//   - "unix:///home/user/.rtorrent/rpc.socket" or "scgi:///path/to/rpc.socket": SCGI over unix socket.
+// Category is stored in d.custom1 (same as ruTorrent label, url-encoded).
+// Tags (and meta) are stored in d.custom=ptool_tags, as comma-separated url-encoded list.
+// Client level tags (e.g. "_noadd"), which rTorrent can't store, are persisted in a meta file in ptool config dir.
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/natefinch/atomic"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/util"
+)
+
+const (
+	TAGS_FIELD    = "ptool_tags"
+	ADDTIME_FIELD = "addtime" // ruTorrent compatible
+)
+
+var (
+	ErrNotImplemented = fmt.Errorf("not implemented yet: %w", errors.ErrUnsupported)
+)
+
+type Client struct {
+	Name                      string
+	ClientConfig              *config.ClientConfigStruct
+	Config                    *config.ConfigStruct
+	transport                 xmlrpcTransport
+	datatime                  int64
+	datatimeMeta              int64
+	torrents                  map[string]*rtTorrent
+	downloadRate              int64
+	uploadRate                int64
+	downloadRateLimit         int64
+	uploadRateLimit           int64
+	defaultDirectory          string
+	freeSpace                 int64
+	unfinishedSize            int64
+	unfinishedDownloadingSize int64
+	contentPathTorrents       map[string][]*rtTorrent
+	clientTags                []string // client level tags in meta file. nil if not loaded
+}
+
+// Content of client meta file.
+type clientMeta struct {
+	Tags []string `json:"tags,omitempty"`
+}
+
+type rtTorrent struct {
+	Hash           string // lowercase
+	Name           string
+	State          int64 // 0: stopped; 1: started
+	Complete       bool
+	IsActive       bool
+	IsOpen         bool
+	Hashing        int64
+	IsMultiFile    bool
+	SizeBytes      int64
+	SelectedBytes  int64
+	CompletedBytes int64
+	DownRate       int64
+	UpRate         int64
+	DownTotal      int64
+	UpTotal        int64
+	Directory      string
+	BasePath       string
+	Category       string
+	Tags           []string
+	AddTime        int64
+	Started        int64
+	Finished       int64
+	Message        string
+	PeersComplete  int64
+	PeersAccounted int64
+	Trackers       []string
+}
+
+// fields of d.multicall2, must be in same order of rtTorrent parsing in sync.
+var torrentFields = []string{
+	"d.hash=", "d.name=", "d.state=", "d.complete=", "d.is_active=", "d.is_open=", "d.hashing=", "d.is_multi_file=",
+	"d.size_bytes=", "d.selected_size_bytes=", "d.completed_bytes=", "d.down.rate=", "d.up.rate=", "d.down.total=",
+	"d.up.total=", "d.directory=", "d.base_path=", "d.custom1=", "d.custom=" + TAGS_FIELD, "d.custom=" + ADDTIME_FIELD,
+	"d.load_date=", "d.timestamp.started=", "d.timestamp.finished=", "d.message=", "d.peers_complete=",
+	"d.peers_accounted=",
+}
+
+// ruTorrent style (rawurlencode) escape
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func unescape(s string) string {
+	if us, err := url.QueryUnescape(s); err == nil {
+		return us
+	}
+	return s
+}
+
+func encodeTags(tags []string) string {
+	return strings.Join(util.Map(tags, escape), ",")
+}
+
+func decodeTags(value string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(value, ",") {
+		if tag != "" {
+			tags = append(tags, unescape(tag))
+		}
+	}
+	return tags
+}
+
+// quote a command argument value.
+func quote(value string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), `"`, `\"`) + `"`
+}
+
+func (rtclient *Client) call(method string, params ...any) (any, error) {
+	body, err := encodeMethodCall(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	response, err := rtclient.transport.Do(body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeMethodResponse(response)
+}
+
+// Call multiple methods in one request. Return results of each call (faults are returned as *xmlrpcFault)
+func (rtclient *Client) multicall(calls [][]any) ([]any, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	xcalls := []any{}
+	for _, call := range calls {
+		xcalls = append(xcalls, map[string]any{
+			"methodName": call[0],
+			"params":     call[1:],
+		})
+	}
+	result, err := rtclient.call("system.multicall", xcalls)
+	if err != nil {
+		return nil, err
+	}
+	list, _ := result.([]any)
+	results := []any{}
+	for _, item := range list {
+		// each result is either a single-item array, or a fault struct
+		if arr, ok := item.([]any); ok && len(arr) > 0 {
+			results = append(results, arr[0])
+		} else if m, ok := item.(map[string]any); ok {
+			results = append(results, &xmlrpcFault{Code: toInt64(m["faultCode"]), String: toString(m["faultString"])})
+		} else {
+			results = append(results, nil)
+		}
+	}
+	return results, nil
+}
+
+// call a method on each of the torrents
+func (rtclient *Client) callEach(method string, infoHashes []string, params ...any) error {
+	calls := [][]any{}
+	for _, infoHash := range infoHashes {
+		calls = append(calls, append([]any{method, strings.ToUpper(infoHash)}, params...))
+	}
+	results, err := rtclient.multicall(calls)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		if fault, ok := result.(*xmlrpcFault); ok {
+			return fault
+		}
+	}
+	return nil
+}
+
+func (rtclient *Client) Sync() error {
+	if rtclient.datatime > 0 {
+		return nil
+	}
+	now := util.Now()
+	result, err := rtclient.call("d.multicall2", append([]any{"", "main"}, util.Map(torrentFields,
+		func(f string) any { return f })...)...)
+	if err != nil {
+		return err
+	}
+	list, _ := result.([]any)
+	torrents := map[string]*rtTorrent{}
+	trackerCalls := [][]any{}
+	for _, item := range list {
+		fields, ok := item.([]any)
+		if !ok || len(fields) < len(torrentFields) {
+			continue
+		}
+		torrent := &rtTorrent{
+			Hash:           strings.ToLower(toString(fields[0])),
+			Name:           toString(fields[1]),
+			State:          toInt64(fields[2]),
+			Complete:       toInt64(fields[3]) != 0,
+			IsActive:       toInt64(fields[4]) != 0,
+			IsOpen:         toInt64(fields[5]) != 0,
+			Hashing:        toInt64(fields[6]),
+			IsMultiFile:    toInt64(fields[7]) != 0,
+			SizeBytes:      toInt64(fields[8]),
+			SelectedBytes:  toInt64(fields[9]),
+			CompletedBytes: toInt64(fields[10]),
+			DownRate:       toInt64(fields[11]),
+			UpRate:         toInt64(fields[12]),
+			DownTotal:      toInt64(fields[13]),
+			UpTotal:        toInt64(fields[14]),
+			Directory:      toString(fields[15]),
+			BasePath:       toString(fields[16]),
+			Category:       unescape(toString(fields[17])),
+			Tags:           decodeTags(toString(fields[18])),
+			AddTime:        toInt64(strings.TrimSpace(toString(fields[19]))),
+			Started:        toInt64(fields[21]),
+			Finished:       toInt64(fields[22]),
+			Message:        toString(fields[23]),
+			PeersComplete:  toInt64(fields[24]),
+			PeersAccounted: toInt64(fields[25]),
+		}
+		if torrent.AddTime == 0 {
+			torrent.AddTime = toInt64(fields[20])
+		}
+		torrents[torrent.Hash] = torrent
+		trackerCalls = append(trackerCalls, []any{"t.multicall", strings.ToUpper(torrent.Hash), "", "t.url="})
+	}
+	// fetch trackers of all torrents in one request
+	trackerResults, err := rtclient.multicall(trackerCalls)
+	if err != nil {
+		return err
+	}
+	for i, result := range trackerResults {
+		infoHash := strings.ToLower(toString(trackerCalls[i][1]))
+		rows, _ := result.([]any)
+		for _, row := range rows {
+			if cols, ok := row.([]any); ok && len(cols) > 0 {
+				torrents[infoHash].Trackers = append(torrents[infoHash].Trackers, toString(cols[0]))
+			}
+		}
+	}
+	rtclient.datatime = now
+	rtclient.torrents = torrents
+	rtclient.buildDerivative()
+	return nil
+}
+
+func (rtclient *Client) buildDerivative() {
+	unfinishedSize := int64(0)
+	unfinishedDownloadingSize := int64(0)
+	contentPathTorrents := map[string][]*rtTorrent{}
+	for _, torrent := range rtclient.torrents {
+		usize := max(torrent.SelectedBytes-torrent.CompletedBytes, 0)
+		unfinishedSize += usize
+		if torrent.State != 0 && torrent.IsActive {
+			unfinishedDownloadingSize += usize
+		}
+		contentPath := torrent.ContentPath()
+		contentPathTorrents[contentPath] = append(contentPathTorrents[contentPath], torrent)
+	}
+	rtclient.unfinishedSize = unfinishedSize
+	rtclient.unfinishedDownloadingSize = unfinishedDownloadingSize
+	rtclient.contentPathTorrents = contentPathTorrents
+}
+
+func (rtclient *Client) syncMeta() error {
+	if rtclient.datatimeMeta > 0 {
+		return nil
+	}
+	results, err := rtclient.multicall([][]any{
+		{"throttle.global_down.rate", ""},
+		{"throttle.global_up.rate", ""},
+		{"throttle.global_down.max_rate", ""},
+		{"throttle.global_up.max_rate", ""},
+		{"directory.default", ""},
+	})
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		if fault, ok := result.(*xmlrpcFault); ok {
+			return fault
+		}
+	}
+	rtclient.downloadRate = toInt64(results[0])
+	rtclient.uploadRate = toInt64(results[1])
+	rtclient.downloadRateLimit = toInt64(results[2])
+	rtclient.uploadRateLimit = toInt64(results[3])
+	rtclient.defaultDirectory = toString(results[4])
+	rtclient.freeSpace = rtclient.getFreeSpace(rtclient.defaultDirectory)
+	rtclient.datatimeMeta = util.Now()
+	return nil
+}
+
+// rTorrent does not provide a free disk space API, use "df" command on rTorrent host.
+// Fallback to d.free_diskspace of any torrent. Return -1 if unknown.
+func (rtclient *Client) getFreeSpace(directory string) int64 {
+	if directory != "" {
+		freeSpace, err := rtclient.dfFreeSpace(directory)
+		if err == nil {
+			return freeSpace
+		}
+		log.Debugf("rtorrent df error: %v", err)
+	}
+	if err := rtclient.Sync(); err == nil {
+		for infoHash := range rtclient.torrents {
+			if result, err := rtclient.call("d.free_diskspace", strings.ToUpper(infoHash)); err == nil {
+				return toInt64(result)
+			}
+			break
+		}
+	}
+	return -1
+}
+
+// Get free disk space of directory using "df" command on rTorrent host.
+func (rtclient *Client) dfFreeSpace(directory string) (int64, error) {
+	output, err := rtclient.call("execute.capture", "", "df", "-P", "-k", directory)
+	if err != nil {
+		return 0, err
+	}
+	lines := strings.Split(strings.TrimSpace(toString(output)), "\n")
+	// Filesystem 1024-blocks Used Available Capacity Mounted on
+	if fields := strings.Fields(lines[len(lines)-1]); len(lines) >= 2 && len(fields) >= 4 {
+		if available := util.ParseInt(fields[3]); available > 0 {
+			return available * 1024, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid df output: %s", toString(output))
+}
+
+func (torrent *rtTorrent) Sep() string {
+	if strings.Contains(torrent.Directory, `\`) {
+		return `\`
+	}
+	return "/"
+}
+
+// For multi-file torrent, d.directory is the torrent root folder; for single-file torrent, it's the parent folder.
+func (torrent *rtTorrent) ContentPath() string {
+	if torrent.BasePath != "" {
+		return torrent.BasePath
+	}
+	if torrent.IsMultiFile {
+		return torrent.Directory
+	}
+	return strings.TrimSuffix(torrent.Directory, torrent.Sep()) + torrent.Sep() + torrent.Name
+}
+
+func (torrent *rtTorrent) SavePath() string {
+	if torrent.IsMultiFile {
+		if torrent.Sep() == `\` {
+			return filepath.Dir(torrent.Directory)
+		}
+		return path.Dir(torrent.Directory)
+	}
+	return torrent.Directory
+}
+
+func (torrent *rtTorrent) ToTorrentState() string {
+	if torrent.Hashing != 0 {
+		return "checking"
+	}
+	if torrent.State == 0 || !torrent.IsActive {
+		if torrent.Complete {
+			return "completed"
+		}
+		return "paused"
+	}
+	if torrent.Complete {
+		return "seeding"
+	}
+	return "downloading"
+}
+
+func (torrent *rtTorrent) ToTorrent() *client.Torrent {
+	tracker := ""
+	if len(torrent.Trackers) > 0 {
+		tracker = torrent.Trackers[0]
+	}
+	ctime := int64(0)
+	if torrent.Complete {
+		ctime = torrent.Finished
+	}
+	activityTime := max(torrent.Started, torrent.Finished)
+	if torrent.DownRate > 0 || torrent.UpRate > 0 {
+		activityTime = util.Now()
+	}
+	state := torrent.ToTorrentState()
+	if torrent.Message != "" && state != "checking" && !strings.HasPrefix(torrent.Message, "Tracker:") {
+		state = "error"
+	}
+	ctorrent := &client.Torrent{
+		InfoHash:           torrent.Hash,
+		Name:               torrent.Name,
+		TrackerDomain:      util.ParseUrlHostname(tracker),
+		TrackerBaseDomain:  util.GetUrlDomain(tracker),
+		Tracker:            tracker,
+		State:              state,
+		LowLevelState:      fmt.Sprintf("state=%d,active=%t,complete=%t", torrent.State, torrent.IsActive, torrent.Complete),
+		Atime:              torrent.AddTime,
+		Ctime:              ctime,
+		ActivityTime:       activityTime,
+		Category:           torrent.Category,
+		SavePath:           torrent.SavePath(),
+		ContentPath:        torrent.ContentPath(),
+		Tags:               util.CopySlice(torrent.Tags),
+		Downloaded:         torrent.DownTotal,
+		DownloadSpeed:      torrent.DownRate,
+		DownloadSpeedLimit: -1, // rTorrent uses throttle groups instead of per-torrent speed limits
+		Uploaded:           torrent.UpTotal,
+		UploadSpeed:        torrent.UpRate,
+		UploadedSpeedLimit: -1,
+		Size:               torrent.SelectedBytes,
+		SizeTotal:          torrent.SizeBytes,
+		SizeCompleted:      min(torrent.CompletedBytes, torrent.SelectedBytes),
+		Seeders:            torrent.PeersComplete,
+		Leechers:           torrent.PeersAccounted - torrent.PeersComplete,
+	}
+	ctorrent.Meta = ctorrent.GetMetadataFromTags()
+	ctorrent.RemoveSubstituteTags()
+	return ctorrent
+}
+
+// get a torrent info. return error if torrent not found
+func (rtclient *Client) getTorrent(infoHash string) (*rtTorrent, error) {
+	if err := rtclient.Sync(); err != nil {
+		return nil, err
+	}
+	torrent := rtclient.torrents[strings.ToLower(infoHash)]
+	if torrent == nil {
+		return nil, fmt.Errorf("torrent not found")
+	}
+	return torrent, nil
+}
+
+func (rtclient *Client) getAllInfoHashes() []string {
+	infoHashes := []string{}
+	for infoHash := range rtclient.torrents {
+		infoHashes = append(infoHashes, infoHash)
+	}
+	return infoHashes
+}
+
+// Set tags field of torrent. tags include meta tags.
+func (rtclient *Client) setTags(infoHash string, tags []string) error {
+	_, err := rtclient.call("d.custom.set", strings.ToUpper(infoHash), TAGS_FIELD, encodeTags(tags))
+	if err == nil && rtclient.torrents[infoHash] != nil {
+		rtclient.torrents[infoHash].Tags = tags
+	}
+	return err
+}
+
+func (rtclient *Client) updateTags(infoHashes []string, addTags []string, removeTags []string,
+	meta map[string]int64) error {
+	if err := rtclient.Sync(); err != nil {
+		return err
+	}
+	for _, infoHash := range infoHashes {
+		torrent := rtclient.torrents[strings.ToLower(infoHash)]
+		if torrent == nil {
+			continue
+		}
+		tags := util.Filter(torrent.Tags, func(tag string) bool {
+			if len(meta) > 0 && strings.HasPrefix(tag, "meta.") {
+				return false
+			}
+			return !slices.Contains(removeTags, tag)
+		})
+		for _, tag := range addTags {
+			if tag != "" && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		for name, value := range meta {
+			tags = append(tags, client.GenerateTorrentTagFromMetadata(name, value))
+		}
+		if slices.Equal(tags, torrent.Tags) {
+			continue
+		}
+		if err := rtclient.setTags(torrent.Hash, tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rtclient *Client) ExportTorrentFile(infoHash string) ([]byte, error) {
+	// rTorrent session dir keeps <INFOHASH>.torrent files
+	if rtclient.ClientConfig.LocalTorrentsPath != "" {
+		return os.ReadFile(filepath.Join(rtclient.ClientConfig.LocalTorrentsPath,
+			strings.ToUpper(infoHash)+".torrent"))
+	}
+	sessionFile, err := rtclient.call("d.session_file", strings.ToUpper(infoHash))
+	if err != nil {
+		return nil, err
+	}
+	if toString(sessionFile) == "" {
+		return nil, fmt.Errorf("rtorrent session is not enabled")
+	}
+	// read the file on rTorrent host
+	output, err := rtclient.call("execute.capture", "", "base64", "-w", "0", toString(sessionFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(toString(output)))
+}
+
+func (rtclient *Client) GetTorrent(infoHash string) (*client.Torrent, error) {
+	if err := rtclient.Sync(); err != nil {
+		return nil, err
+	}
+	torrent := rtclient.torrents[strings.ToLower(infoHash)]
+	if torrent == nil {
+		return nil, nil
+	}
+	return torrent.ToTorrent(), nil
+}
+
+func (rtclient *Client) GetTorrents(stateFilter string, category string, showAll bool) ([]*client.Torrent, error) {
+	if err := rtclient.Sync(); err != nil {
+		return nil, err
+	}
+	torrents := []*client.Torrent{}
+	for _, rttorrent := range rtclient.torrents {
+		torrent := rttorrent.ToTorrent()
+		if category != "" {
+			if category == constants.NONE {
+				if torrent.Category != "" {
+					continue
+				}
+			} else if category != torrent.Category {
+				continue
+			}
+		}
+		if !showAll && torrent.DownloadSpeed < 1024 && torrent.UploadSpeed < 1024 {
+			continue
+		}
+		if !torrent.MatchStateFilter(stateFilter) {
+			continue
+		}
+		torrents = append(torrents, torrent)
+	}
+	return torrents, nil
+}
+
+func (rtclient *Client) GetTorrentsByContentPath(contentPath string) ([]*client.Torrent, error) {
+	if err := rtclient.Sync(); err != nil {
+		return nil, err
+	}
+	torrents := []*client.Torrent{}
+	for _, torrent := range rtclient.contentPathTorrents[contentPath] {
+		torrents = append(torrents, torrent.ToTorrent())
+	}
+	return torrents, nil
+}
+
+func (rtclient *Client) AddTorrent(torrentContent []byte, option *client.TorrentOption, meta map[string]int64) error {
+	commands := []any{}
+	if option.SavePath != "" {
+		commands = append(commands, "d.directory.set="+quote(option.SavePath))
+	}
+	if option.Category != "" && option.Category != constants.NONE {
+		commands = append(commands, "d.custom1.set="+quote(escape(option.Category)))
+	}
+	tags := util.CopySlice(option.Tags)
+	for name, value := range meta {
+		tags = append(tags, client.GenerateTorrentTagFromMetadata(name, value))
+	}
+	if len(tags) > 0 {
+		commands = append(commands, "d.custom.set="+TAGS_FIELD+","+quote(encodeTags(tags)))
+	}
+	commands = append(commands, fmt.Sprintf("d.custom.set=%s,%d", ADDTIME_FIELD, util.Now()))
+	if option.Name != "" || option.SkipChecking || option.SequentialDownload ||
+		option.DownloadSpeedLimit > 0 || option.UploadSpeedLimit > 0 {
+		log.Debugf("rtorrent does not support some add torrent options, ignore them")
+	}
+	method := "load.raw_start_verbose"
+	if option.Pause {
+		method = "load.raw_verbose"
+	}
+	var content any = torrentContent
+	if util.IsTorrentUrl(string(torrentContent)) {
+		method = "load.start_verbose"
+		if option.Pause {
+			method = "load.verbose"
+		}
+		content = string(torrentContent)
+	}
+	_, err := rtclient.call(method, append([]any{"", content}, commands...)...)
+	if err == nil {
+		// torrent list is stale now
+		rtclient.datatime = 0
+	}
+	return err
+}
+
+func (rtclient *Client) ModifyTorrent(infoHash string, option *client.TorrentOption, meta map[string]int64) error {
+	torrent, err := rtclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	if option.Category != "" {
+		category := option.Category
+		if category == constants.NONE {
+			category = ""
+		}
+		if category != torrent.Category {
+			if err = rtclient.SetTorrentsCatetory([]string{torrent.Hash}, category); err != nil {
+				return err
+			}
+		}
+	}
+	if len(option.Tags) > 0 || len(option.RemoveTags) > 0 || len(meta) > 0 {
+		if err = rtclient.updateTags([]string{torrent.Hash}, option.Tags, option.RemoveTags, meta); err != nil {
+			return err
+		}
+	}
+	if option.SavePath != "" && option.SavePath != torrent.SavePath() {
+		if err = rtclient.SetTorrentsSavePath([]string{torrent.Hash}, option.SavePath); err != nil {
+			return err
+		}
+	}
+	if option.Pause {
+		err = rtclient.PauseTorrents([]string{torrent.Hash})
+	} else if option.Resume {
+		err = rtclient.ResumeTorrents([]string{torrent.Hash})
+	}
+	return err
+}
+
+// rTorrent itself never deletes downloaded files. If deleteFiles is true,
+// the content files are deleted using "rm" command on rTorrent host.
+func (rtclient *Client) DeleteTorrents(infoHashes []string, deleteFiles bool) error {
+	if err := rtclient.Sync(); err != nil {
+		return err
+	}
+	for _, infoHash := range infoHashes {
+		torrent := rtclient.torrents[strings.ToLower(infoHash)]
+		if torrent == nil {
+			continue
+		}
+		contentPath := torrent.ContentPath()
+		if _, err := rtclient.call("d.erase", strings.ToUpper(torrent.Hash)); err != nil {
+			return err
+		}
+		delete(rtclient.torrents, torrent.Hash)
+		// do not delete files shared by other (xseed) torrents
+		if deleteFiles && contentPath != "" && len(rtclient.contentPathTorrents[contentPath]) <= 1 {
+			if _, err := rtclient.call("execute.throw", "", "rm", "-rf", "--", contentPath); err != nil {
+				log.Errorf("Failed to delete torrent %s files %q: %v", torrent.Hash, contentPath, err)
+			}
+		}
+		rtclient.buildDerivative()
+	}
+	return nil
+}
+
+func (rtclient *Client) PauseTorrents(infoHashes []string) error {
+	if err := rtclient.callEach("d.stop", infoHashes); err != nil {
+		return err
+	}
+	return rtclient.callEach("d.close", infoHashes)
+}
+
+func (rtclient *Client) ResumeTorrents(infoHashes []string) error {
+	if err := rtclient.callEach("d.open", infoHashes); err != nil {
+		return err
+	}
+	return rtclient.callEach("d.start", infoHashes)
+}
+
+func (rtclient *Client) RecheckTorrents(infoHashes []string) error {
+	return rtclient.callEach("d.check_hash", infoHashes)
+}
+
+func (rtclient *Client) ReannounceTorrents(infoHashes []string) error {
+	return rtclient.callEach("d.tracker_announce", infoHashes)
+}
+
+func (rtclient *Client) AddTagsToTorrents(infoHashes []string, tags []string) error {
+	return rtclient.updateTags(infoHashes, tags, nil, nil)
+}
+
+func (rtclient *Client) RemoveTagsFromTorrents(infoHashes []string, tags []string) error {
+	return rtclient.updateTags(infoHashes, nil, tags, nil)
+}
+
+// Move torrent contents to new save path on rTorrent host ("mv" command), then update d.directory.
+func (rtclient *Client) SetTorrentsSavePath(infoHashes []string, savePath string) error {
+	if err := rtclient.Sync(); err != nil {
+		return err
+	}
+	for _, infoHash := range infoHashes {
+		torrent := rtclient.torrents[strings.ToLower(infoHash)]
+		if torrent == nil || torrent.SavePath() == savePath {
+			continue
+		}
+		hash := strings.ToUpper(torrent.Hash)
+		started := torrent.State != 0
+		if err := rtclient.PauseTorrents([]string{hash}); err != nil {
+			return err
+		}
+		if _, err := rtclient.call("execute.throw", "", "mkdir", "-p", "--", savePath); err != nil {
+			return err
+		}
+		if _, err := rtclient.call("execute.throw", "", "mv", "--", torrent.ContentPath(), savePath); err != nil {
+			return fmt.Errorf("failed to move torrent %s contents: %w", torrent.Hash, err)
+		}
+		if _, err := rtclient.call("d.directory.set", hash, savePath); err != nil {
+			return err
+		}
+		if started {
+			if err := rtclient.ResumeTorrents([]string{hash}); err != nil {
+				return err
+			}
+		}
+	}
+	rtclient.datatime = 0
+	return nil
+}
+
+func (rtclient *Client) PauseAllTorrents() error {
+	if err := rtclient.Sync(); err != nil {
+		return err
+	}
+	return rtclient.PauseTorrents(rtclient.getAllInfoHashes())
+}
+
+func (rtclient *Client) ResumeAllTorrents() error {
+	if err := rtclient.Sync(); err != nil {
+		return err
+	}
+	return rtclient.ResumeTorrents(rtclient.getAllInfoHashes())
+}
+
+func (rtclient *Client) RecheckAllTorrents() error {
+	if err := rtclient.Sync(); err != nil {
+		return err
+	}
+	return rtclient.RecheckTorrents(rtclient.getAllInfoHashes())
+}
+
+func (rtclient *Client) ReannounceAllTorrents() error {
+	if err := rtclient.Sync(); err != nil {
+		return err
+	}
+	return rtclient.ReannounceTorrents(rtclient.getAllInfoHashes())
+}
+
+func (rtclient *Client) AddTagsToAllTorrents(tags []string) error {
+	if err := rtclient.Sync(); err != nil {
+		return err
+	}
+	return rtclient.AddTagsToTorrents(rtclient.getAllInfoHashes(), tags)
+}
+
+func (rtclient *Client) RemoveTagsFromAllTorrents(tags []string) error {
+	if err := rtclient.Sync(); err != nil {
+		return err
+	}
+	return rtclient.RemoveTagsFromTorrents(rtclient.getAllInfoHashes(), tags)
+}
+
+func (rtclient *Client) SetAllTorrentsSavePath(savePath string) error {
+	if err := rtclient.Sync(); err != nil {
+		return err
+	}
+	return rtclient.SetTorrentsSavePath(rtclient.getAllInfoHashes(), savePath)
+}
+
+func (rtclient *Client) metaFile() string {
+	return filepath.Join(config.ConfigDir, "rtorrent-"+rtclient.Name+".json")
+}
+
+func (rtclient *Client) loadClientTags() error {
+	if rtclient.clientTags != nil {
+		return nil
+	}
+	meta := &clientMeta{}
+	contents, err := os.ReadFile(rtclient.metaFile())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read meta file: %w", err)
+		}
+	} else if err = json.Unmarshal(contents, meta); err != nil {
+		return fmt.Errorf("failed to parse meta file: %w", err)
+	}
+	rtclient.clientTags = append([]string{}, meta.Tags...)
+	return nil
+}
+
+// Update client level tags and persist them.
+func (rtclient *Client) updateClientTags(addTags []string, removeTags []string) error {
+	if err := rtclient.loadClientTags(); err != nil {
+		return err
+	}
+	tags := util.Filter(rtclient.clientTags, func(tag string) bool {
+		return !slices.Contains(removeTags, tag)
+	})
+	for _, tag := range addTags {
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	contents, err := json.Marshal(&clientMeta{Tags: tags})
+	if err != nil {
+		return err
+	}
+	if err = atomic.WriteFile(rtclient.metaFile(), bytes.NewReader(contents)); err != nil {
+		return err
+	}
+	rtclient.clientTags = tags
+	return nil
+}
+
+func (rtclient *Client) GetTags() ([]string, error) {
+	if err := rtclient.Sync(); err != nil {
+		return nil, err
+	}
+	if err := rtclient.loadClientTags(); err != nil {
+		return nil, err
+	}
+	tags := util.CopySlice(rtclient.clientTags)
+	for _, torrent := range rtclient.torrents {
+		for _, tag := range torrent.Tags {
+			if !client.IsSubstituteTag(tag) && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags, nil
+}
+
+// Client level tags are stored in meta file, as rTorrent has no native tags.
+func (rtclient *Client) CreateTags(tags ...string) error {
+	return rtclient.updateClientTags(tags, nil)
+}
+
+func (rtclient *Client) DeleteTags(tags ...string) error {
+	if err := rtclient.updateClientTags(nil, tags); err != nil {
+		return err
+	}
+	return rtclient.RemoveTagsFromAllTorrents(tags)
+}
+
+// Return true if tag exists in client, either as a client level tag or attached to any torrent.
+func (rtclient *Client) hasTag(tag string) bool {
+	tags, err := rtclient.GetTags()
+	return err == nil && slices.Contains(tags, tag)
+}
+
+func (rtclient *Client) MakeCategory(category string, savePath string) error {
+	return fmt.Errorf("unsupported")
+}
+
+func (rtclient *Client) DeleteCategories(categories []string) error {
+	return fmt.Errorf("unsupported")
+}
+
+func (rtclient *Client) GetCategories() ([]*client.TorrentCategory, error) {
+	if err := rtclient.Sync(); err != nil {
+		return nil, err
+	}
+	cats := []*client.TorrentCategory{}
+	catsFlag := map[string]bool{}
+	for _, torrent := range rtclient.torrents {
+		if torrent.Category != "" && !catsFlag[torrent.Category] {
+			cats = append(cats, &client.TorrentCategory{Name: torrent.Category})
+			catsFlag[torrent.Category] = true
+		}
+	}
+	return cats, nil
+}
+
+func (rtclient *Client) SetTorrentsCatetory(infoHashes []string, category string) error {
+	if category == constants.NONE {
+		category = ""
+	}
+	if err := rtclient.callEach("d.custom1.set", infoHashes, escape(category)); err != nil {
+		return err
+	}
+	for _, infoHash := range infoHashes {
+		if torrent := rtclient.torrents[strings.ToLower(infoHash)]; torrent != nil {
+			torrent.Category = category
+		}
+	}
+	return nil
+}
+
+func (rtclient *Client) SetAllTorrentsCatetory(category string) error {
+	if err := rtclient.Sync(); err != nil {
+		return err
+	}
+	return rtclient.SetTorrentsCatetory(rtclient.getAllInfoHashes(), category)
+}
+
+// rTorrent uses ratio groups instead of per-torrent share limits.
+func (rtclient *Client) SetTorrentsShareLimits(infoHashes []string, ratioLimit float64, seedingTimeLimit int64) error {
+	return ErrNotImplemented
+}
+
+func (rtclient *Client) SetAllTorrentsShareLimits(ratioLimit float64, seedingTimeLimit int64) error {
+	return ErrNotImplemented
+}
+
+func (rtclient *Client) TorrentRootPathExists(rootFolder string) bool {
+	if rootFolder == "" {
+		return false
+	}
+	if err := rtclient.Sync(); err != nil {
+		return false
+	}
+	for _, torrent := range rtclient.torrents {
+		if torrent.Name == rootFolder {
+			return true
+		}
+	}
+	return false
+}
+
+func (rtclient *Client) GetTorrentContents(infoHash string) ([]*client.TorrentContentFile, error) {
+	torrent, err := rtclient.getTorrent(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	result, err := rtclient.call("f.multicall", strings.ToUpper(torrent.Hash), "",
+		"f.path=", "f.size_bytes=", "f.completed_chunks=", "f.size_chunks=", "f.priority=")
+	if err != nil {
+		return nil, err
+	}
+	rows, _ := result.([]any)
+	files := []*client.TorrentContentFile{}
+	for i, row := range rows {
+		cols, ok := row.([]any)
+		if !ok || len(cols) < 5 {
+			continue
+		}
+		filepath := toString(cols[0])
+		if torrent.IsMultiFile {
+			filepath = torrent.Name + "/" + filepath
+		}
+		progress := float64(0)
+		if sizeChunks := toInt64(cols[3]); sizeChunks > 0 {
+			progress = float64(toInt64(cols[2])) / float64(sizeChunks)
+		}
+		files = append(files, &client.TorrentContentFile{
+			Index:    int64(i),
+			Path:     filepath,
+			Size:     toInt64(cols[1]),
+			Progress: progress,
+			Ignored:  toInt64(cols[4]) == 0,
+			Complete: progress == 1,
+		})
+	}
+	return files, nil
+}
+
+func (rtclient *Client) PurgeCache() {
+	rtclient.datatime = 0
+	rtclient.datatimeMeta = 0
+	rtclient.torrents = nil
+	rtclient.unfinishedSize = 0
+	rtclient.unfinishedDownloadingSize = 0
+	rtclient.contentPathTorrents = nil
+	rtclient.clientTags = nil
+}
+
+func (rtclient *Client) GetStatus() (*client.Status, error) {
+	if err := rtclient.Sync(); err != nil {
+		return nil, err
+	}
+	if err := rtclient.syncMeta(); err != nil {
+		return nil, err
+	}
+	return &client.Status{
+		DownloadSpeed:             rtclient.downloadRate,
+		UploadSpeed:               rtclient.uploadRate,
+		DownloadSpeedLimit:        rtclient.downloadRateLimit,
+		UploadSpeedLimit:          rtclient.uploadRateLimit,
+		FreeSpaceOnDisk:           rtclient.freeSpace,
+		UnfinishedSize:            rtclient.unfinishedSize,
+		UnfinishedDownloadingSize: rtclient.unfinishedDownloadingSize,
+		NoAdd:                     rtclient.hasTag(config.NOADD_TAG),
+		NoDel:                     rtclient.hasTag(config.NODEL_TAG),
+	}, nil
+}
+
+func (rtclient *Client) GetFreeSpace(path string) (int64, error) {
+	return rtclient.dfFreeSpace(path)
+}
+
+func (rtclient *Client) GetName() string {
+	return rtclient.Name
+}
+
+func (rtclient *Client) GetClientConfig() *config.ClientConfigStruct {
+	return rtclient.ClientConfig
+}
+
+// "rt_*" variable is rTorrent command name with "rt_" prefix. E.g. "rt_network.max_open_files".
+func (rtclient *Client) SetConfig(variable string, value string) error {
+	var err error
+	if strings.HasPrefix(variable, "rt_") && len(variable) > 3 {
+		argValue, _ := util.String2Any(value)
+		_, err = rtclient.call(variable[3:]+".set", "", argValue)
+	} else {
+		switch variable {
+		case "global_download_speed_limit":
+			_, err = rtclient.call("throttle.global_down.max_rate.set", "", max(util.ParseInt(value), 0))
+		case "global_upload_speed_limit":
+			_, err = rtclient.call("throttle.global_up.max_rate.set", "", max(util.ParseInt(value), 0))
+		case "free_disk_space", "global_download_speed", "global_upload_speed":
+			return fmt.Errorf("%s is read-only", variable)
+		case "save_path":
+			_, err = rtclient.call("directory.default.set", "", value)
+		default:
+			return nil
+		}
+	}
+	if err == nil {
+		rtclient.datatimeMeta = 0
+	}
+	return err
+}
+
+func (rtclient *Client) GetConfig(variable string) (string, error) {
+	if strings.HasPrefix(variable, "rt_") && len(variable) > 3 {
+		value, err := rtclient.call(variable[3:], "")
+		if err != nil {
+			return "", err
+		}
+		return toString(value), nil
+	}
+	if err := rtclient.syncMeta(); err != nil {
+		return "", err
+	}
+	switch variable {
+	case "global_download_speed_limit":
+		return fmt.Sprint(rtclient.downloadRateLimit), nil
+	case "global_upload_speed_limit":
+		return fmt.Sprint(rtclient.uploadRateLimit), nil
+	case "free_disk_space":
+		return fmt.Sprint(rtclient.freeSpace), nil
+	case "global_download_speed":
+		return fmt.Sprint(rtclient.downloadRate), nil
+	case "global_upload_speed":
+		return fmt.Sprint(rtclient.uploadRate), nil
+	case "save_path":
+		return rtclient.defaultDirectory, nil
+	default:
+		return "", nil
+	}
+}
+
+func (rtclient *Client) GetTorrentTrackers(infoHash string) (client.TorrentTrackers, error) {
+	torrent, err := rtclient.getTorrent(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	result, err := rtclient.call("t.multicall", strings.ToUpper(torrent.Hash), "",
+		"t.url=", "t.is_enabled=", "t.success_counter=", "t.failed_counter=")
+	if err != nil {
+		return nil, err
+	}
+	rows, _ := result.([]any)
+	trackers := client.TorrentTrackers{}
+	for _, row := range rows {
+		cols, ok := row.([]any)
+		if !ok || len(cols) < 4 {
+			continue
+		}
+		status := "notcontacted"
+		msg := ""
+		if toInt64(cols[1]) == 0 {
+			status = "disabled"
+		} else if toInt64(cols[2]) > 0 {
+			status = "working"
+		} else if toInt64(cols[3]) > 0 {
+			status = "error"
+			msg = torrent.Message
+		}
+		trackers = append(trackers, client.TorrentTracker{
+			Url:    toString(cols[0]),
+			Status: status,
+			Msg:    msg,
+		})
+	}
+	return trackers, nil
+}
+
+// rTorrent does not support renaming files of a loaded torrent.
+func (rtclient *Client) RenameTorrentFile(infoHash string, oldPath string, newPath string) error {
+	return ErrNotImplemented
+}
+
+func (rtclient *Client) RenameTorrentFolder(infoHash string, oldPath string, newPath string) error {
+	return ErrNotImplemented
+}
+
+func (rtclient *Client) GetTorrentPeers(infoHash string) ([]*client.TorrentPeer, error) {
+	torrent, err := rtclient.getTorrent(infoHash)
+	if err != nil {
+		return nil, err
+	}
+	result, err := rtclient.call("p.multicall", strings.ToUpper(torrent.Hash), "",
+		"p.address=", "p.port=", "p.client_version=", "p.completed_percent=", "p.down_rate=", "p.up_rate=",
+		"p.down_total=", "p.up_total=", "p.is_incoming=", "p.is_encrypted=")
+	if err != nil {
+		return nil, err
+	}
+	rows, _ := result.([]any)
+	peers := []*client.TorrentPeer{}
+	for _, row := range rows {
+		cols, ok := row.([]any)
+		if !ok || len(cols) < 10 {
+			continue
+		}
+		ip := toString(cols[0])
+		port := toInt64(cols[1])
+		flags := []string{}
+		if toInt64(cols[8]) != 0 {
+			flags = append(flags, "I")
+		}
+		if toInt64(cols[9]) != 0 {
+			flags = append(flags, "E")
+		}
+		peers = append(peers, &client.TorrentPeer{
+			Address:       net.JoinHostPort(ip, fmt.Sprint(port)),
+			Ip:            ip,
+			Port:          port,
+			Client:        toString(cols[2]),
+			Progress:      float64(toInt64(cols[3])) / 100,
+			DownloadSpeed: toInt64(cols[4]),
+			UploadSpeed:   toInt64(cols[5]),
+			Downloaded:    toInt64(cols[6]),
+			Uploaded:      toInt64(cols[7]),
+			Flags:         strings.Join(flags, " "),
+		})
+	}
+	return peers, nil
+}
+
+// Ban peers' ip by adding them to rTorrent's ipv4 filter. Port part of address is ignored.
+func (rtclient *Client) BanPeers(peers []string) error {
+	calls := [][]any{}
+	for _, peer := range peers {
+		ip := peer
+		if host, _, err := net.SplitHostPort(peer); err == nil {
+			ip = host
+		}
+		// rTorrent only has an IPv4 filter.
+		if addr := net.ParseIP(ip); addr != nil && addr.To4() == nil {
+			log.Warnf("rtorrent client %s can not ban IPv6 peer %s: unsupported", rtclient.Name, peer)
+			continue
+		}
+		calls = append(calls, []any{"ipv4_filter.add_address", "", ip, "unwanted"})
+	}
+	if len(calls) == 0 {
+		return nil
+	}
+	_, err := rtclient.multicall(calls)
+	return err
+}
+
+// rTorrent can not remove a tracker, it's disabled instead.
+func (rtclient *Client) disableTrackers(torrent *rtTorrent, trackers []string) error {
+	calls := [][]any{}
+	for i, tracker := range torrent.Trackers {
+		if slices.Contains(trackers, tracker) {
+			calls = append(calls, []any{"t.is_enabled.set", fmt.Sprintf("%s:t%d", strings.ToUpper(torrent.Hash), i), 0})
+		}
+	}
+	_, err := rtclient.multicall(calls)
+	return err
+}
+
+func (rtclient *Client) insertTrackers(torrent *rtTorrent, trackers []string) error {
+	calls := [][]any{}
+	for _, tracker := range trackers {
+		calls = append(calls, []any{"d.tracker.insert", strings.ToUpper(torrent.Hash), "0", tracker})
+	}
+	_, err := rtclient.multicall(calls)
+	return err
+}
+
+func (rtclient *Client) EditTorrentTracker(infoHash string, oldTracker string, newTracker string, replaceHost bool) error {
+	torrent, err := rtclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	oldTrackerUrl := ""
+	newTrackerUrl := newTracker
+	for _, tracker := range torrent.Trackers {
+		if replaceHost {
+			oldTrackerUrlObj, err := url.Parse(tracker)
+			if err != nil || oldTrackerUrlObj.Host != oldTracker {
+				continue
+			}
+			oldTrackerUrl = tracker
+			if !util.IsUrl(newTracker) {
+				oldTrackerUrlObj.Host = newTracker
+				newTrackerUrl = oldTrackerUrlObj.String()
+			}
+			break
+		} else if tracker == oldTracker {
+			oldTrackerUrl = tracker
+			break
+		}
+	}
+	if oldTrackerUrl == "" {
+		return fmt.Errorf("torrent %s old tracker %s does NOT exist", torrent.Hash, oldTracker)
+	}
+	if oldTrackerUrl == newTrackerUrl {
+		return nil
+	}
+	if err = rtclient.insertTrackers(torrent, []string{newTrackerUrl}); err != nil {
+		return err
+	}
+	return rtclient.disableTrackers(torrent, []string{oldTrackerUrl})
+}
+
+func (rtclient *Client) AddTorrentTrackers(infoHash string, trackers []string,
+	oldTracker string, removeExisting bool) error {
+	torrent, err := rtclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	if oldTracker != "" {
+		if !slices.ContainsFunc(torrent.Trackers, func(tracker string) bool {
+			return util.MatchUrlWithHostOrUrl(tracker, oldTracker)
+		}) {
+			return nil
+		}
+	}
+	newTrackers := util.Filter(trackers, func(tracker string) bool {
+		return !slices.Contains(torrent.Trackers, tracker)
+	})
+	if err = rtclient.insertTrackers(torrent, newTrackers); err != nil {
+		return err
+	}
+	if removeExisting {
+		return rtclient.disableTrackers(torrent, util.Filter(torrent.Trackers, func(tracker string) bool {
+			return !slices.Contains(trackers, tracker)
+		}))
+	}
+	return nil
+}
+
+func (rtclient *Client) RemoveTorrentTrackers(infoHash string, trackers []string) error {
+	torrent, err := rtclient.getTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	return rtclient.disableTrackers(torrent, trackers)
+}
+
+// rTorrent file priority: 0 off; 1 normal; 2 high.
+func (rtclient *Client) SetFilePriority(infoHash string, fileIndexes []int64, priority int64) error {
+	rtpriority := int64(0)
+	switch priority {
+	case 0:
+		rtpriority = 0
+	case 1:
+		rtpriority = 1
+	case 6, 7:
+		rtpriority = 2
+	default:
+		return fmt.Errorf("invalid file priority %d", priority)
+	}
+	hash := strings.ToUpper(infoHash)
+	calls := [][]any{}
+	for _, index := range fileIndexes {
+		calls = append(calls, []any{"f.priority.set", fmt.Sprintf("%s:f%d", hash, index), rtpriority})
+	}
+	calls = append(calls, []any{"d.update_priorities", hash})
+	results, err := rtclient.multicall(calls)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		if fault, ok := result.(*xmlrpcFault); ok {
+			return fault
+		}
+	}
+	return nil
+}
+
+func (rtclient *Client) Cached() bool {
+	return rtclient.datatime > 0
+}
+
+func (rtclient *Client) Close() {
+	rtclient.PurgeCache()
+}
+
+func NewClient(name string, clientConfig *config.ClientConfigStruct, config *config.ConfigStruct) (
+	client.Client, error) {
+	urlObj, err := url.Parse(clientConfig.Url)
+	if err != nil {
+		return nil, err
+	}
+	var transport xmlrpcTransport
+	switch urlObj.Scheme {
+	case "http", "https":
+		transport = &httpTransport{
+			url:        clientConfig.Url,
+			username:   clientConfig.Username,
+			password:   clientConfig.Password,
+			httpClient: &http.Client{Timeout: xmlrpcTimeout()},
+		}
+	case "scgi":
+		if urlObj.Host != "" {
+			transport = &scgiTransport{network: "tcp", address: urlObj.Host}
+		} else {
+			transport = &scgiTransport{network: "unix", address: urlObj.Path}
+		}
+	case "unix":
+		transport = &scgiTransport{network: "unix", address: urlObj.Path}
+	}
+	if transport == nil || (urlObj.Host == "" && urlObj.Path == "") {
+		return nil, fmt.Errorf("invalid rtorrent url: %s", clientConfig.Url)
+	}
+	return &Client{
+		Name:         name,
+		ClientConfig: clientConfig,
+		Config:       config,
+		transport:    transport,
+	}, nil
+}
+
+func init() {
+	client.Register(&client.RegInfo{
+		Name:    "rtorrent",
+		Creator: NewClient,
+	})
+}
+
+var (
+	_ client.Client = (*Client)(nil)
+)
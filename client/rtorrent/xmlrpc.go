@@ -0,0 +1,390 @@
+package rtorrent
+
+// A minimal XML-RPC implementation for rTorrent.
+// Spec: http://xmlrpc.com/spec.md .
+// Supported transports: HTTP(S) (e.g. ruTorrent / nginx "/RPC2" endpoint) and SCGI over tcp / unix socket.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sagan/ptool/config"
+)
+
+// Default network timeout of XML-RPC requests.
+const XMLRPC_TIMEOUT = 30 * time.Second
+
+type xmlrpcFault struct {
+	Code   int64
+	String string
+}
+
+func (fault *xmlrpcFault) Error() string {
+	return fmt.Sprintf("xmlrpc fault %d: %s", fault.Code, fault.String)
+}
+
+type xmlrpcTransport interface {
+	// Send a XML-RPC request body and return the response body
+	Do(body []byte) ([]byte, error)
+}
+
+type httpTransport struct {
+	url        string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+func (t *httpTransport) Do(body []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/xml")
+	if t.username != "" || t.password != "" {
+		req.SetBasicAuth(t.username, t.password)
+	}
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("xmlrpc response %d status", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// SCGI protocol: https://python.ca/scgi/protocol.txt
+type scgiTransport struct {
+	network string // "tcp" or "unix"
+	address string
+}
+
+func (t *scgiTransport) Do(body []byte) ([]byte, error) {
+	timeout := xmlrpcTimeout()
+	conn, err := net.DialTimeout(t.network, t.address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+	headers := "CONTENT_LENGTH\x00" + fmt.Sprint(len(body)) + "\x00SCGI\x001\x00"
+	request := []byte(fmt.Sprintf("%d:%s,", len(headers), headers))
+	request = append(request, body...)
+	if _, err = conn.Write(request); err != nil {
+		return nil, err
+	}
+	response, err := io.ReadAll(bufio.NewReader(conn))
+	if err != nil {
+		return nil, err
+	}
+	// response is a HTTP-like message: "Status: 200 OK\r\nContent-Type: text/xml\r\n\r\n<body>"
+	if index := bytes.Index(response, []byte("\r\n\r\n")); index != -1 {
+		response = response[index+4:]
+	} else if index := bytes.Index(response, []byte("\n\n")); index != -1 {
+		response = response[index+2:]
+	}
+	return response, nil
+}
+
+// Return network timeout of XML-RPC requests: the "--timeout" global flag if set (-1 == infinite),
+// otherwise XMLRPC_TIMEOUT. 0 means no timeout.
+func xmlrpcTimeout() time.Duration {
+	if config.Timeout > 0 {
+		return time.Duration(config.Timeout) * time.Second
+	} else if config.Timeout < 0 {
+		return 0
+	}
+	return XMLRPC_TIMEOUT
+}
+
+// Encode a XML-RPC method call request.
+func encodeMethodCall(method string, params ...any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0"?><methodCall><methodName>`)
+	xml.EscapeText(&buf, []byte(method))
+	buf.WriteString(`</methodName><params>`)
+	for _, param := range params {
+		buf.WriteString("<param>")
+		if err := encodeValue(&buf, param); err != nil {
+			return nil, err
+		}
+		buf.WriteString("</param>")
+	}
+	buf.WriteString(`</params></methodCall>`)
+	return buf.Bytes(), nil
+}
+
+func encodeValue(buf *bytes.Buffer, value any) error {
+	buf.WriteString("<value>")
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString("<string></string>")
+	case string:
+		buf.WriteString("<string>")
+		xml.EscapeText(buf, []byte(v))
+		buf.WriteString("</string>")
+	case int:
+		fmt.Fprintf(buf, "<i8>%d</i8>", v)
+	case int64:
+		fmt.Fprintf(buf, "<i8>%d</i8>", v)
+	case bool:
+		if v {
+			buf.WriteString("<boolean>1</boolean>")
+		} else {
+			buf.WriteString("<boolean>0</boolean>")
+		}
+	case float64:
+		fmt.Fprintf(buf, "<double>%s</double>", strconv.FormatFloat(v, 'f', -1, 64))
+	case []byte:
+		buf.WriteString("<base64>")
+		buf.WriteString(base64.StdEncoding.EncodeToString(v))
+		buf.WriteString("</base64>")
+	case []string:
+		buf.WriteString("<array><data>")
+		for _, item := range v {
+			if err := encodeValue(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("</data></array>")
+	case []any:
+		buf.WriteString("<array><data>")
+		for _, item := range v {
+			if err := encodeValue(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("</data></array>")
+	case map[string]any:
+		buf.WriteString("<struct>")
+		for key, item := range v {
+			buf.WriteString("<member><name>")
+			xml.EscapeText(buf, []byte(key))
+			buf.WriteString("</name>")
+			if err := encodeValue(buf, item); err != nil {
+				return err
+			}
+			buf.WriteString("</member>")
+		}
+		buf.WriteString("</struct>")
+	default:
+		return fmt.Errorf("unsupported xmlrpc value type %T", value)
+	}
+	buf.WriteString("</value>")
+	return nil
+}
+
+// Decode a XML-RPC method response. Returned value is one of following types:
+// nil, string, int64, bool, float64, []byte, []any, map[string]any.
+// If response is a fault, an *xmlrpcFault error is returned.
+func decodeMethodResponse(data []byte) (any, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	isFault := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("invalid xmlrpc response: no value found")
+			}
+			return nil, err
+		}
+		if se, ok := token.(xml.StartElement); ok {
+			switch se.Name.Local {
+			case "fault":
+				isFault = true
+			case "value":
+				value, err := decodeValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				if isFault {
+					fault := &xmlrpcFault{}
+					if m, ok := value.(map[string]any); ok {
+						fault.Code = toInt64(m["faultCode"])
+						fault.String = toString(m["faultString"])
+					}
+					return nil, fault
+				}
+				return value, nil
+			}
+		}
+	}
+}
+
+// decode content of a <value> element. The <value> start element has been consumed.
+func decodeValue(decoder *xml.Decoder) (any, error) {
+	var value any
+	var text strings.Builder
+	typed := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			typed = true
+			switch t.Name.Local {
+			case "array":
+				value, err = decodeArray(decoder)
+			case "struct":
+				value, err = decodeStruct(decoder)
+			default:
+				var s string
+				if s, err = readText(decoder); err == nil {
+					value, err = parseScalar(t.Name.Local, s)
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			if t.Name.Local == "value" {
+				if !typed {
+					// no type element: string by default
+					return text.String(), nil
+				}
+				return value, nil
+			}
+		}
+	}
+}
+
+func decodeArray(decoder *xml.Decoder) ([]any, error) {
+	values := []any{}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "value" {
+				value, err := decodeValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, value)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "array" {
+				return values, nil
+			}
+		}
+	}
+}
+
+func decodeStruct(decoder *xml.Decoder) (map[string]any, error) {
+	values := map[string]any{}
+	name := ""
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "name":
+				if name, err = readText(decoder); err != nil {
+					return nil, err
+				}
+			case "value":
+				value, err := decodeValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				values[name] = value
+			}
+		case xml.EndElement:
+			if t.Name.Local == "struct" {
+				return values, nil
+			}
+		}
+	}
+}
+
+// read char data until current element ends.
+func readText(decoder *xml.Decoder) (string, error) {
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			return text.String(), nil
+		}
+	}
+}
+
+func parseScalar(kind string, s string) (any, error) {
+	switch kind {
+	case "string":
+		return s, nil
+	case "i4", "i8", "int":
+		return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	case "boolean":
+		return strings.TrimSpace(s) == "1", nil
+	case "double":
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	case "base64":
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	case "nil":
+		return nil, nil
+	case "dateTime.iso8601":
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unsupported xmlrpc value type %s", kind)
+	}
+}
+
+func toInt64(value any) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case string:
+		i, _ := strconv.ParseInt(v, 10, 64)
+		return i
+	default:
+		return 0
+	}
+}
+
+func toString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -2,17 +2,23 @@ package transmission
 
 // use https://github.com/hekmon/transmissionrpc
 // protocol: https://github.com/transmission/transmission/blob/3.00/extras/rpc-spec.txt
+// Transmission 4.x: https://github.com/transmission/transmission/blob/main/docs/rpc-spec.md .
+// Some features (labels in torrent-add, bandwidth groups, sequential download)
+// are used only if server rpc-version is high enough.
 
 import (
 	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"reflect"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/ettle/strcase"
@@ -40,8 +46,14 @@ type Client struct {
 	unfinishedDownloadingSize int64
 	contentPathTorrents       map[string][]*transmissionrpc.Torrent
 	lastTorrent               *transmissionrpc.Torrent // a **really** simple cache with capacity of only one
+	rpcVersion                int64                    // server rpc-version. 0 means not fetched yet
 }
 
+const (
+	RPC_VERSION_4_0 = 17 // Transmission 4.0.0: labels in torrent-add, bandwidth groups, trackerList
+	RPC_VERSION_4_1 = 18 // Transmission 4.1.0: sequential_download
+)
+
 func (trclient *Client) GetTorrentsByContentPath(contentPath string) ([]*client.Torrent, error) {
 	if err := trclient.Sync(false); err != nil {
 		return nil, err
@@ -54,7 +66,7 @@ func (trclient *Client) GetTorrentsByContentPath(contentPath string) ([]*client.
 }
 
 var (
-	ErrNotImplemented = errors.New("not implemented yet")
+	ErrNotImplemented = fmt.Errorf("not implemented yet: %w", errors.ErrUnsupported)
 )
 
 // SetAllTorrentsShareLimits implements client.Client.
@@ -112,6 +124,20 @@ func (trclient *Client) getAllInfoHashes() []string {
 	return infoHashes
 }
 
+// Get server rpc-version. Transmission 3.00 is 16, 4.0.x is 17, 4.1.x is 18.
+func (trclient *Client) getRpcVersion() int64 {
+	if trclient.rpcVersion > 0 {
+		return trclient.rpcVersion
+	}
+	sessionArgs, err := trclient.client.SessionArgumentsGet(context.TODO(), []string{"rpc-version"})
+	if err != nil || sessionArgs.RPCVersion == nil {
+		log.Debugf("Failed to get tr rpc-version: %v", err)
+		return 0
+	}
+	trclient.rpcVersion = *sessionArgs.RPCVersion
+	return trclient.rpcVersion
+}
+
 func (trclient *Client) Cached() bool {
 	return trclient.datatime > 0
 }
@@ -126,11 +152,15 @@ func (trclient *Client) Sync(full bool) (err error) {
 	if full {
 		torrents, err = transmissionbt.TorrentGetAll(context.TODO())
 	} else {
-		torrents, err = transmissionbt.TorrentGet(context.TODO(), []string{
+		fields := []string{
 			"activityDate", "addedDate", "doneDate", "downloadDir", "downloadedEver", "downloadLimit", "downloadLimited",
 			"hashString", "id", "labels", "name", "peersGettingFromUs", "peersSendingToUs", "percentDone", "rateDownload",
 			"rateUpload", "sizeWhenDone", "status", "trackers", "totalSize", "uploadedEver", "uploadLimit", "uploadLimited",
-		}, nil)
+		}
+		if trclient.getRpcVersion() >= RPC_VERSION_4_0 {
+			fields = append(fields, "group")
+		}
+		torrents, err = transmissionbt.TorrentGet(context.TODO(), fields, nil)
 	}
 
 	if err != nil {
@@ -186,15 +216,20 @@ func (trclient *Client) syncMeta() error {
 	trclient.datatimeMeta = now
 	trclient.sessionStats = &sessionStats
 	trclient.sessionArgs = &sessionArgs
+	if sessionArgs.RPCVersion != nil {
+		trclient.rpcVersion = *sessionArgs.RPCVersion
+	}
 	trclient.freeSpace = int64(freeSpace / 8) // tr freespace is in bits.
 	return nil
 }
 
+// Transmission RPC does not provide the contents of .torrent file, even in v4.0+ (which only reports the path
+// of it in tr config dir), so the local torrents dir of tr must be configured.
 func (trclient *Client) ExportTorrentFile(infoHash string) ([]byte, error) {
-	if trclient.ClientConfig.LocalTorrentsPath != "" {
-		return os.ReadFile(filepath.Join(trclient.ClientConfig.LocalTorrentsPath, infoHash+".torrent"))
+	if trclient.ClientConfig.LocalTorrentsPath == "" {
+		return nil, fmt.Errorf("unsupported: localTorrentsPath (Transmission torrents dir) is not configured")
 	}
-	return nil, fmt.Errorf("unsupported")
+	return os.ReadFile(filepath.Join(trclient.ClientConfig.LocalTorrentsPath, infoHash+".torrent"))
 }
 
 func (trclient *Client) GetTorrent(infoHash string) (*client.Torrent, error) {
@@ -245,6 +280,10 @@ func (trclient *Client) AddTorrent(torrentContent []byte, option *client.Torrent
 		Paused:      &option.Pause,
 		DownloadDir: downloadDir,
 	}
+	rpcVersion := trclient.getRpcVersion()
+	if option.SequentialDownload && rpcVersion >= RPC_VERSION_4_1 {
+		payload.SequentialDownload = &option.SequentialDownload
+	}
 	if util.IsTorrentUrl(string(torrentContent)) {
 		url := string(torrentContent)
 		payload.Filename = &url
@@ -252,23 +291,16 @@ func (trclient *Client) AddTorrent(torrentContent []byte, option *client.Torrent
 		torrentContentB64 := base64.StdEncoding.EncodeToString(torrentContent)
 		payload.MetaInfo = &torrentContentB64
 	}
-	// returned torrent will only have HashString, ID and Name fields set up.
-	torrent, err := transmissionbt.TorrentAdd(context.TODO(), payload)
-	if err != nil {
-		return err
-	}
-
-	name := option.Name
-	if name != "" {
-		// it's not robust, and will actually rename the root file / folder name on disk
-		err := transmissionbt.TorrentRenamePathHash(context.TODO(), *torrent.HashString, *torrent.Name, name)
-		log.Tracef("rename tr torrent name=%s err=%v", name, err)
-	}
 
+	var group *string
 	labels := util.CopySlice(option.Tags)
 	if option.Category != "" && option.Category != constants.NONE {
 		// use label to simulate category
 		labels = append(labels, client.GenerateTorrentTagFromCategory(option.Category))
+		if rpcVersion >= RPC_VERSION_4_0 {
+			// also put torrent into the bandwidth group of same name
+			group = &option.Category
+		}
 	}
 	for name, value := range meta {
 		labels = append(labels, client.GenerateTorrentTagFromMetadata(name, value))
@@ -291,10 +323,29 @@ func (trclient *Client) AddTorrent(torrentContent []byte, option *client.Torrent
 		}
 		downloadLimited = true
 	}
-	if len(labels) > 0 || uploadLimited || downloadLimited {
+	if rpcVersion >= RPC_VERSION_4_0 && len(labels) > 0 {
+		// tr 4.0+ accepts labels in torrent-add
+		payload.Labels = labels
+		labels = nil
+	}
+	// returned torrent will only have HashString, ID and Name fields set up.
+	torrent, err := transmissionbt.TorrentAdd(context.TODO(), payload)
+	if err != nil {
+		return err
+	}
+
+	name := option.Name
+	if name != "" {
+		// it's not robust, and will actually rename the root file / folder name on disk
+		err := transmissionbt.TorrentRenamePathHash(context.TODO(), *torrent.HashString, *torrent.Name, name)
+		log.Tracef("rename tr torrent name=%s err=%v", name, err)
+	}
+
+	if len(labels) > 0 || group != nil || uploadLimited || downloadLimited {
 		err := transmissionbt.TorrentSet(context.TODO(), transmissionrpc.TorrentSetPayload{
 			IDs:             []int64{*torrent.ID},
 			Labels:          labels,
+			Group:           group,
 			UploadLimited:   &uploadLimited,
 			UploadLimit:     &uploadLimit,
 			DownloadLimit:   &downloadLimit,
@@ -332,6 +383,9 @@ func (trclient *Client) ModifyTorrent(infoHash string, option *client.TorrentOpt
 		if option.Category != "" && torrent.Category != option.Category {
 			categoryTag := client.GenerateTorrentTagFromCategory(option.Category)
 			labels = append(labels, categoryTag)
+			if trclient.getRpcVersion() >= RPC_VERSION_4_0 {
+				payload.Group = &option.Category
+			}
 		} else if torrent.Category != "" {
 			categoryTag := client.GenerateTorrentTagFromCategory(torrent.Category)
 			labels = append(labels, categoryTag)
@@ -390,6 +444,13 @@ func (trclient *Client) ModifyTorrent(infoHash string, option *client.TorrentOpt
 	if option.SavePath != "" {
 		payload.Location = &option.SavePath
 	}
+	if option.SequentialDownload {
+		if trclient.getRpcVersion() < RPC_VERSION_4_1 {
+			log.Warnf("Sequential download requires tr 4.1+, ignore it")
+		} else {
+			payload.SequentialDownload = &option.SequentialDownload
+		}
+	}
 
 	transmissionbt.TorrentSet(context.TODO(), payload)
 
@@ -537,16 +598,28 @@ func (trclient *Client) GetTags() ([]string, error) {
 	return tags, nil
 }
 
+// tr labels are attached to torrents, client level tags can not be created.
 func (trclient *Client) CreateTags(tags ...string) error {
-	return fmt.Errorf("unsupported")
+	return fmt.Errorf("client level tags: %w", errors.ErrUnsupported)
 }
 
 func (trclient *Client) DeleteTags(tags ...string) error {
 	return trclient.RemoveTagsFromAllTorrents(tags)
 }
 
+// In tr 4.0+, create a bandwidth group with the category name.
+// Category itself is still simulated by a "category:<name>" label, savePath is not supported.
 func (trclient *Client) MakeCategory(category string, savePath string) error {
-	return fmt.Errorf("unsupported")
+	if trclient.getRpcVersion() < RPC_VERSION_4_0 {
+		return fmt.Errorf("unsupported")
+	}
+	if savePath != "" {
+		return fmt.Errorf("category savePath is unsupported by tr")
+	}
+	return trclient.client.GroupSet(context.TODO(), transmissionrpc.BandwidthGroup{
+		Name:                category,
+		HonorsSessionLimits: true,
+	})
 }
 
 func (trclient *Client) DeleteCategories(categories []string) error {
@@ -569,6 +642,20 @@ func (trclient *Client) GetCategories() ([]*client.TorrentCategory, error) {
 			catsFlag[cat] = true
 		}
 	}
+	if trclient.getRpcVersion() >= RPC_VERSION_4_0 {
+		groups, err := trclient.client.GroupGet(context.TODO(), nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			if group.Name != "" && !catsFlag[group.Name] {
+				cats = append(cats, &client.TorrentCategory{
+					Name: group.Name,
+				})
+				catsFlag[group.Name] = true
+			}
+		}
+	}
 	return cats, nil
 }
 
@@ -662,6 +749,14 @@ func (trclient *Client) GetStatus() (*client.Status, error) {
 	}, nil
 }
 
+func (trclient *Client) GetFreeSpace(path string) (int64, error) {
+	freeSpace, err := trclient.client.FreeSpace(context.TODO(), path)
+	if err != nil {
+		return 0, err
+	}
+	return int64(freeSpace / 8), nil // tr freespace is in bits.
+}
+
 func (trclient *Client) GetName() string {
 	return trclient.Name
 }
@@ -721,6 +816,11 @@ func (trclient *Client) SetConfig(variable string, value string) error {
 			SpeedLimitUpEnabled: &limited,
 			SpeedLimitUp:        &limit,
 		})
+	case "alt_speed_enabled":
+		enabled, _ := strconv.ParseBool(value)
+		return transmissionbt.SessionArgumentsSet(context.TODO(), transmissionrpc.SessionArguments{
+			AltSpeedEnabled: &enabled,
+		})
 	case "free_disk_space", "global_download_speed", "global_upload_speed":
 		return fmt.Errorf("%s is read-only", variable)
 	case "save_path":
@@ -755,6 +855,8 @@ func (trclient *Client) GetConfig(variable string) (string, error) {
 			return fmt.Sprint(*trclient.sessionArgs.SpeedLimitUp * 1024), nil
 		}
 		return "0", nil
+	case "alt_speed_enabled":
+		return fmt.Sprint(trclient.sessionArgs.AltSpeedEnabled != nil && *trclient.sessionArgs.AltSpeedEnabled), nil
 	case "free_disk_space":
 		status, err := trclient.GetStatus()
 		if err != nil {
@@ -842,6 +944,29 @@ func (trclient *Client) EditTorrentTracker(infoHash string, oldTracker string, n
 	if oldTrackerUrl == newTrackerUrl {
 		return nil
 	}
+	if trclient.getRpcVersion() >= RPC_VERSION_4_0 {
+		// tr 4.0+: replace the whole tracker list, which keeps tiers. Empty line separates tiers.
+		trackerList := ""
+		tier := int64(-1)
+		for i, tracker := range trtorrent.Trackers {
+			if i > 0 {
+				trackerList += "\n"
+				if tracker.Tier != tier {
+					trackerList += "\n"
+				}
+			}
+			tier = tracker.Tier
+			if tracker.ID == oldTrackerId {
+				trackerList += newTrackerUrl
+			} else {
+				trackerList += tracker.Announce
+			}
+		}
+		return trclient.client.TorrentSet(context.TODO(), transmissionrpc.TorrentSetPayload{
+			IDs:         []int64{*trtorrent.ID},
+			TrackerList: &trackerList,
+		})
+	}
 	// this is broken for now as transmission RPC expects trackerReplace to be
 	// a mixed types array of ids (integer) and urls(string)
 	// it's a problem of transmissionrpc library
@@ -904,6 +1029,61 @@ func (trclient *Client) RemoveTorrentTrackers(infoHash string, trackers []string
 	return nil
 }
 
+// Transmission can only rename the last component of a path ("torrent-rename-path").
+func (trclient *Client) renamePath(infoHash string, oldPath string, newPath string) error {
+	oldPath = strings.Trim(oldPath, "/")
+	newPath = strings.Trim(newPath, "/")
+	if path.Dir(oldPath) != path.Dir(newPath) {
+		return fmt.Errorf("tr can only rename the last component of path, can not move %q to %q", oldPath, newPath)
+	}
+	err := trclient.client.TorrentRenamePathHash(context.TODO(), infoHash, oldPath, path.Base(newPath))
+	if err == nil && trclient.Cached() && !strings.Contains(oldPath, "/") {
+		// torrent name (root folder) may change
+		trclient.PurgeCache()
+	}
+	return err
+}
+
+func (trclient *Client) RenameTorrentFile(infoHash string, oldPath string, newPath string) error {
+	return trclient.renamePath(infoHash, oldPath, newPath)
+}
+
+func (trclient *Client) RenameTorrentFolder(infoHash string, oldPath string, newPath string) error {
+	return trclient.renamePath(infoHash, oldPath, newPath)
+}
+
+func (trclient *Client) GetTorrentPeers(infoHash string) ([]*client.TorrentPeer, error) {
+	torrents, err := trclient.client.TorrentGetHashes(context.TODO(), []string{"peers"}, []string{infoHash})
+	if err != nil {
+		return nil, err
+	}
+	if len(torrents) == 0 {
+		return nil, fmt.Errorf("torrent %s not found", infoHash)
+	}
+	peers := []*client.TorrentPeer{}
+	for _, trpeer := range torrents[0].Peers {
+		address := net.JoinHostPort(trpeer.Address, fmt.Sprint(trpeer.Port))
+		peers = append(peers, &client.TorrentPeer{
+			Address:       address,
+			Ip:            trpeer.Address,
+			Port:          trpeer.Port,
+			Client:        trpeer.ClientName,
+			Progress:      trpeer.Progress,
+			DownloadSpeed: trpeer.RateToClient,
+			UploadSpeed:   trpeer.RateToPeer,
+			Downloaded:    -1,
+			Uploaded:      -1,
+			Flags:         trpeer.FlagStr,
+		})
+	}
+	return peers, nil
+}
+
+// Transmission RPC does not support banning peers. Use a blocklist (tr_blocklist_url) instead.
+func (trclient *Client) BanPeers(peers []string) error {
+	return ErrNotImplemented
+}
+
 func (trclient *Client) SetFilePriority(infoHash string, fileIndexes []int64, priority int64) error {
 	return ErrNotImplemented
 }
@@ -1043,6 +1223,10 @@ func tr2Torrent(trtorrent *transmissionrpc.Torrent) *client.Torrent {
 	}
 	torrent.Meta = torrent.GetMetadataFromTags()
 	torrent.Category = torrent.GetCategoryFromTag()
+	if torrent.Category == "" && trtorrent.Group != nil {
+		// tr 4.0+ torrent with a bandwidth group set by other tools
+		torrent.Category = *trtorrent.Group
+	}
 	torrent.RemoveSubstituteTags()
 	return torrent
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"text/template"
 
@@ -74,6 +75,7 @@ var (
 	ratioLimit         = float64(0)
 	seedingTimeLimit   = int64(0)
 	rename             = ""
+	shareLimitAction   = ""
 	addCategory        = ""
 	defaultSite        = ""
 	addTags            = ""
@@ -102,6 +104,8 @@ func init() {
 		"If != 0, the max amount of time (seconds) the torrent should be seeded. Negative value has special meaning")
 	command.Flags().Float64VarP(&ratioLimit, "ratio-limit", "", 0,
 		"If != 0, the max ratio (Up/Dl) the torrent should be seeded until. Negative value has special meaning")
+	command.Flags().StringVarP(&shareLimitAction, "share-limit-action", "", "",
+		`(qBittorrent 5.1+ only) Action when share limits are reached: `+strings.Join(client.ShareLimitActions, "|"))
 	command.Flags().StringVarP(&rename, "rename", "", "", `Rename added torrents. `+
 		`Available variable placeholders: {{.site}}, {{.id}} and more. `+constants.HELP_ARG_TEMPLATE)
 	command.Flags().StringVarP(&addCategory, "add-category", "", "", "Set category of added torrents")
@@ -122,6 +126,13 @@ func add(cmd *cobra.Command, args []string) error {
 	if !useCommentMeta && len(mapSavePaths) > 0 {
 		return fmt.Errorf("--map-save-path must be used with --use-comment-meta flag")
 	}
+	if shareLimitAction != "" && !slices.Contains(client.ShareLimitActions, shareLimitAction) {
+		return fmt.Errorf("invalid share-limit-action %q", shareLimitAction)
+	}
+	if config.GetClientGroupClients(clientName) != nil {
+		return fmt.Errorf("%s is a client group, torrents can not be added to it. Add them to a member client instead",
+			clientName)
+	}
 	torrents, stdinTorrentContents, err := helper.ParseTorrentsFromArgs(args[1:])
 	if err != nil {
 		return err
@@ -136,6 +147,7 @@ func add(cmd *cobra.Command, args []string) error {
 		SequentialDownload: sequentialDownload,
 		RatioLimit:         ratioLimit,
 		SeedingTimeLimit:   seedingTimeLimit,
+		ShareLimitAction:   shareLimitAction,
 	}
 	fixedTags := util.SplitCsv(addTags)
 	var savePathMapper *common.PathMapper
@@ -7,7 +7,7 @@ import (
 	_ "github.com/sagan/ptool/cmd/addtrackers"
 	_ "github.com/sagan/ptool/cmd/alias"
 	_ "github.com/sagan/ptool/cmd/batchdl"
-	_ "github.com/sagan/ptool/cmd/brush"
+	_ "github.com/sagan/ptool/cmd/brush/all"
 	_ "github.com/sagan/ptool/cmd/checktag"
 	_ "github.com/sagan/ptool/cmd/clientctl"
 	_ "github.com/sagan/ptool/cmd/configcmd/all"
@@ -17,6 +17,7 @@ import (
 	_ "github.com/sagan/ptool/cmd/delete"
 	_ "github.com/sagan/ptool/cmd/deletecategories"
 	_ "github.com/sagan/ptool/cmd/deletetags"
+	_ "github.com/sagan/ptool/cmd/details"
 	_ "github.com/sagan/ptool/cmd/dltorrent"
 	_ "github.com/sagan/ptool/cmd/dynamicseeding"
 	_ "github.com/sagan/ptool/cmd/edittorrent"
@@ -27,6 +28,7 @@ import (
 	_ "github.com/sagan/ptool/cmd/gettags"
 	_ "github.com/sagan/ptool/cmd/hardlink/all"
 	_ "github.com/sagan/ptool/cmd/iyuu/all"
+	_ "github.com/sagan/ptool/cmd/login"
 	_ "github.com/sagan/ptool/cmd/maketorrent"
 	_ "github.com/sagan/ptool/cmd/markinvalidtracker"
 	_ "github.com/sagan/ptool/cmd/modifytorrent"
@@ -34,22 +36,26 @@ import (
 	_ "github.com/sagan/ptool/cmd/parsetorrent"
 	_ "github.com/sagan/ptool/cmd/partialdownload"
 	_ "github.com/sagan/ptool/cmd/pause"
+	_ "github.com/sagan/ptool/cmd/peers"
 	_ "github.com/sagan/ptool/cmd/publish"
 	_ "github.com/sagan/ptool/cmd/reannounce"
 	_ "github.com/sagan/ptool/cmd/recheck"
 	_ "github.com/sagan/ptool/cmd/removetags"
 	_ "github.com/sagan/ptool/cmd/removetrackers"
+	_ "github.com/sagan/ptool/cmd/renamefiles"
 	_ "github.com/sagan/ptool/cmd/renametag"
 	_ "github.com/sagan/ptool/cmd/reseed/all"
 	_ "github.com/sagan/ptool/cmd/resume"
 	_ "github.com/sagan/ptool/cmd/run"
 	_ "github.com/sagan/ptool/cmd/search"
+	_ "github.com/sagan/ptool/cmd/serve/all"
 	_ "github.com/sagan/ptool/cmd/setcategory"
 	_ "github.com/sagan/ptool/cmd/setsavepath"
 	_ "github.com/sagan/ptool/cmd/shell"
 	_ "github.com/sagan/ptool/cmd/show"
 	_ "github.com/sagan/ptool/cmd/sites/all"
 	_ "github.com/sagan/ptool/cmd/skipchecking"
+	_ "github.com/sagan/ptool/cmd/speedschedule"
 	_ "github.com/sagan/ptool/cmd/statscmd"
 	_ "github.com/sagan/ptool/cmd/status"
 	_ "github.com/sagan/ptool/cmd/tidyup"
@@ -552,7 +552,7 @@ mainloop:
 				} else if showJson {
 					util.PrintJson(os.Stdout, torrent)
 				} else {
-					site.PrintTorrents(os.Stdout, []*site.Torrent{torrent}, "", now, cntTorrents != 1, dense, nil)
+					site.PrintTorrents(os.Stdout, []*site.Torrent{torrent}, "", now, cntTorrents != 1, dense, nil, nil)
 				}
 				continue
 			}
@@ -0,0 +1,6 @@
+package all
+
+import (
+	_ "github.com/sagan/ptool/cmd/brush"
+	_ "github.com/sagan/ptool/cmd/brush/simulate"
+)
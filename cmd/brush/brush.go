@@ -18,7 +18,7 @@ import (
 	"github.com/sagan/ptool/util/torrentutil"
 )
 
-var command = &cobra.Command{
+var Command = &cobra.Command{
 	Use:         "brush {client} {site | group}...",
 	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "brush"},
 	Short:       "Brush sites using client.",
@@ -33,20 +33,29 @@ var (
 	ordered   = false
 	force     = false
 	maxSites  = int64(0)
+
+	snapshotFile = ""
 )
 
 func init() {
-	command.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Dry run. Do not actually controlling client")
-	command.Flags().BoolVarP(&addPaused, "add-paused", "", false, "Add torrents to client in paused state")
-	command.Flags().BoolVarP(&ordered, "ordered", "", false, "Brush sites provided in order")
-	command.Flags().BoolVarP(&force, "force", "", false, `Force mode. Ignore "`+config.NOADD_TAG+`" flag tag in client`)
-	command.Flags().Int64VarP(&maxSites, "max-sites", "", -1, "Allowed max succcess sites number, -1 == no limit")
-	cmd.RootCmd.AddCommand(command)
+	Command.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Dry run. Do not actually controlling client")
+	Command.Flags().BoolVarP(&addPaused, "add-paused", "", false, "Add torrents to client in paused state")
+	Command.Flags().BoolVarP(&ordered, "ordered", "", false, "Brush sites provided in order")
+	Command.Flags().BoolVarP(&force, "force", "", false, `Force mode. Ignore "`+config.NOADD_TAG+`" flag tag in client`)
+	Command.Flags().Int64VarP(&maxSites, "max-sites", "", -1, "Allowed max succcess sites number, -1 == no limit")
+	Command.Flags().StringVarP(&snapshotFile, "snapshot-file", "", "",
+		`Record site torrents and client status & torrents of each site to this snapshot file (appended), `+
+			`which can be replayed by "ptool brush simulate"`)
+	cmd.RootCmd.AddCommand(Command)
 }
 
 func brush(cmd *cobra.Command, args []string) (err error) {
 	clientName := args[0]
 	sitenames := config.ParseGroupAndOtherNamesWithoutDeduplicate(args[1:]...)
+	if config.GetClientGroupClients(clientName) != nil {
+		return fmt.Errorf("%s is a client group, which can not be used to brush. Brush a member client instead",
+			clientName)
+	}
 	clientInstance, err := client.CreateClient(clientName)
 	if err != nil {
 		return err
@@ -74,6 +83,19 @@ func brush(cmd *cobra.Command, args []string) (err error) {
 			log.Warnf("Failed to create stats db: %v.", err)
 		}
 	}
+	var historyDb *stats.BrushHistoryDb
+	var histories map[string]strategy.TorrentHistory
+	if config.Get().BrushEnableHistory {
+		historyDb, err = stats.NewBrushHistoryDb(filepath.Join(config.ConfigDir, config.BRUSH_HISTORY_FILENAME))
+		if err != nil {
+			log.Warnf("Failed to create brush history db: %v.", err)
+		}
+	}
+
+	brushClientOption, err := strategy.GetBrushClientOptions(clientInstance)
+	if err != nil {
+		return err
+	}
 
 	for i, sitename := range sitenames {
 		siteInstance, err := site.CreateSite(sitename)
@@ -113,7 +135,34 @@ func brush(cmd *cobra.Command, args []string) (err error) {
 			log.Printf("Failed to get client %s torrents: %v ", clientInstance.GetName(), err)
 			continue
 		}
-		brushSiteOption := strategy.GetBrushSiteOptions(siteInstance, util.Now())
+		brushSiteOption, err := strategy.GetBrushSiteOptions(siteInstance, util.Now())
+		if err != nil {
+			log.Errorf("Failed to get brush options of site %s: %v", sitename, err)
+			continue
+		}
+		if brushSiteOption.MinAccountRatio > 0 {
+			if brushSiteOption.AccountStatus, err = siteInstance.GetStatus(); err != nil {
+				brushSiteOption.AccountStatus = nil
+				log.Warnf("Failed to get site %s status, do not add new torrents as account ratio can not be verified: %v",
+					sitename, err)
+			} else if !brushSiteOption.AccountStatus.IsOk() {
+				log.Warnf("Site %s status is invalid, do not add new torrents as account ratio can not be verified",
+					sitename)
+			} else {
+				log.Printf("Site %s account uploaded / downloaded: %s / %s", sitename,
+					util.BytesSize(float64(brushSiteOption.AccountStatus.UserUploaded)),
+					util.BytesSize(float64(brushSiteOption.AccountStatus.UserDownloaded)))
+			}
+		}
+		// record samples & load histories once per run
+		if historyDb != nil && histories == nil {
+			histories = loadHistories(historyDb, clientInstance.GetName(), clientTorrents, brushSiteOption.Now)
+		}
+		for _, savePath := range brushClientOption.SavePaths {
+			savePath.FreeSpace = strategy.GetSavePathFreeSpace(clientInstance, savePath.Path)
+			log.Printf("Brush save path %s: free space %s, min %s", savePath.Path,
+				util.BytesSize(float64(savePath.FreeSpace)), util.BytesSize(float64(savePath.MinDiskSpace)))
+		}
 		brushMaxTorrents := clientInstance.GetClientConfig().BrushMaxTorrents
 		if siteInstance.GetSiteConfig().BrushAllowAddTorrentsPercent != 0 {
 			p := float64(siteInstance.GetSiteConfig().BrushAllowAddTorrentsPercent) / 100.0
@@ -124,7 +173,6 @@ func brush(cmd *cobra.Command, args []string) (err error) {
 		brushSiteOption.AllowAddTorrents = brushMaxTorrents - int64(currentTorrents)
 		log.Printf("Site %s already have %d torrents, max %d, allow %d", sitename,
 			len(getTorrentsOfSite(clientTorrents, sitename)), brushMaxTorrents, brushSiteOption.AllowAddTorrents)
-		brushClientOption := strategy.GetBrushClientOptions(clientInstance)
 		log.Printf(
 			"Brush Options: minDiskSpace=%v, slowUploadSpeedTier=%v, torrentUploadSpeedLimit=%v/s,"+
 				" maxDownloadingTorrents=%d, maxTorrents=%d, minRatio=%f",
@@ -135,7 +183,23 @@ func brush(cmd *cobra.Command, args []string) (err error) {
 			brushClientOption.MaxTorrents,
 			brushClientOption.MinRatio,
 		)
-		result := strategy.Decide(status, clientTorrents, siteTorrents, brushSiteOption, brushClientOption)
+		if snapshotFile != "" {
+			if err := strategy.AppendSnapshot(snapshotFile, &strategy.Snapshot{
+				Time:           brushSiteOption.Now,
+				Client:         clientInstance.GetName(),
+				Site:           sitename,
+				ClientStatus:   status,
+				ClientTorrents: clientTorrents,
+				SiteTorrents:   siteTorrents,
+				SiteOption:     brushSiteOption,
+				ClientOption:   brushClientOption,
+				SiteRules:      siteInstance.GetSiteConfig().BrushRules,
+				ClientRules:    clientInstance.GetClientConfig().BrushRules,
+			}); err != nil {
+				log.Warnf("Failed to record brush snapshot: %v", err)
+			}
+		}
+		result := strategy.Decide(status, clientTorrents, siteTorrents, brushSiteOption, brushClientOption, histories)
 		log.Printf(
 			"Current client %s torrents: %d; Download speed / limit: %s/s / %s/s; "+
 				"Upload speed / limit: %s/s / %s/s;Free disk space: %s;",
@@ -248,8 +312,8 @@ func brush(cmd *cobra.Command, args []string) (err error) {
 		cndAddTorrents := 0
 		addedRootDirs := map[string]bool{}
 		for _, torrent := range result.AddTorrents {
-			log.Printf("Add site %s torrent to client %s: %s / %s / %v",
-				siteInstance.GetName(), clientInstance.GetName(), torrent.Name, torrent.Msg, torrent.Meta)
+			log.Printf("Add site %s torrent to client %s: %s / %s / %v / %s",
+				siteInstance.GetName(), clientInstance.GetName(), torrent.Name, torrent.Msg, torrent.Meta, torrent.SavePath)
 			if dryRun {
 				continue
 			}
@@ -283,6 +347,7 @@ func brush(cmd *cobra.Command, args []string) (err error) {
 				Name:             torrent.Name,
 				Pause:            addPaused,
 				Category:         config.BRUSH_CAT,
+				SavePath:         torrent.SavePath,
 				Tags:             tags,
 				UploadSpeedLimit: siteInstance.GetSiteConfig().TorrentUploadSpeedLimitValue,
 			}
@@ -333,6 +398,37 @@ func brush(cmd *cobra.Command, args []string) (err error) {
 	return nil
 }
 
+// Record current samples of client brush torrents to history db, and return the histories of them.
+func loadHistories(historyDb *stats.BrushHistoryDb, clientName string, clientTorrents []*client.Torrent,
+	now int64) map[string]strategy.TorrentHistory {
+	histories := map[string]strategy.TorrentHistory{}
+	var samples []*stats.BrushTorrentSample
+	var infoHashes []string
+	for _, torrent := range clientTorrents {
+		samples = append(samples, strategy.NewTorrentSample(clientName, torrent, now))
+		infoHashes = append(infoHashes, torrent.InfoHash)
+	}
+	if !dryRun {
+		if err := historyDb.AddSamples(samples); err != nil {
+			log.Warnf("Failed to record brush torrent samples: %v", err)
+		}
+	}
+	torrentsSamples, err := historyDb.GetSamples(clientName, infoHashes...)
+	if err != nil {
+		log.Warnf("Failed to load brush torrent histories: %v", err)
+		return histories
+	}
+	for infoHash, torrentSamples := range torrentsSamples {
+		histories[infoHash] = torrentSamples
+	}
+	if dryRun {
+		for _, sample := range samples {
+			histories[sample.InfoHash] = append(histories[sample.InfoHash], sample)
+		}
+	}
+	return histories
+}
+
 func getTorrentsOfSite(torrents []*client.Torrent, siteName string) []*client.Torrent {
 	var ret []*client.Torrent
 	for _, torrent := range torrents {
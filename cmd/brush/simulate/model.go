@@ -0,0 +1,397 @@
+package simulate
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/cmd/brush/strategy"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+// The upload / download speed model. It's intentionally simple and only meant for comparing configurations.
+const (
+	// Upload speed contributed by each leecher of torrent. Same as the prediction of brush strategy
+	LEECHER_UPLOAD_SPEED = float64(100 * 1024)
+	// Download speed contributed by each seeder of torrent
+	SEEDER_DOWNLOAD_SPEED = float64(1024 * 1024)
+	// Leechers of torrent halve every such seconds, unless refreshed by later site torrents list
+	LEECHERS_HALF_LIFE = float64(12 * 3600)
+)
+
+type Result struct {
+	Name        string
+	Decisions   int64 // number of replayed Decide calls
+	Adds        int64
+	Deletes     int64
+	Uploaded    int64
+	Downloaded  int64 // data written to disk
+	DeletedSize int64 // data deleted from disk
+	Duration    int64
+	Error       error
+}
+
+// A torrent of simulated client.
+type simTorrent struct {
+	*client.Torrent
+	site             string
+	baseSeeders      int64
+	baseLeechers     int64
+	baseTime         int64 // time of base seeders & leechers
+	uploadSpeedLimit int64
+	history          strategy.TorrentHistory // simulated samples, if history is enabled
+}
+
+// Simulated brush torrents of a client.
+type simClient struct {
+	time     int64
+	torrents []*simTorrent
+	added    map[string]bool // "site/downloadUrl" of all site torrents that have been added
+	recorded map[string]bool // names of all recorded brush torrents of the client
+}
+
+// Replay snapshots through strategy.Decide using options of variant.
+// Each client starts with the brush torrents of its first snapshot, which are then maintained by the simulation.
+func Simulate(snapshots []*strategy.Snapshot, variant *Variant) (result *Result) {
+	result = &Result{Name: variant.Name}
+	clients := map[string]*simClient{}
+	for _, snapshot := range snapshots {
+		if clients[snapshot.Client] != nil {
+			continue
+		}
+		sc := &simClient{time: snapshot.Time, added: map[string]bool{}, recorded: map[string]bool{}}
+		for _, torrent := range snapshot.ClientTorrents {
+			t := *torrent
+			t.Meta = util.CopyMap(torrent.Meta, true)
+			sc.torrents = append(sc.torrents, &simTorrent{
+				Torrent:          &t,
+				site:             torrent.GetSiteFromTag(),
+				baseSeeders:      torrent.Seeders,
+				baseLeechers:     torrent.Leechers,
+				baseTime:         snapshot.Time,
+				uploadSpeedLimit: snapshot.SiteOption.TorrentUploadSpeedLimit,
+			})
+		}
+		clients[snapshot.Client] = sc
+	}
+	for _, snapshot := range snapshots {
+		for _, torrent := range snapshot.ClientTorrents {
+			clients[snapshot.Client].recorded[torrent.Name] = true
+		}
+	}
+	for _, snapshot := range snapshots {
+		siteOption, clientOption, err := variant.getOptions(snapshot)
+		if err != nil {
+			result.Error = err
+			return
+		}
+		sc := clients[snapshot.Client]
+		sc.advance(snapshot, clientOption, result)
+		sc.refresh(snapshot)
+
+		status := sc.getStatus(snapshot)
+		clientOption.SavePaths = sc.getSavePaths(snapshot, clientOption.SavePaths)
+		siteTorrents := sc.getSiteTorrents(snapshot)
+		siteOption.AllowAddTorrents += int64(countTorrentsOfSite(snapshot.ClientTorrents, snapshot.Site))
+		if clientOption.MaxTorrents != snapshot.ClientOption.MaxTorrents && snapshot.ClientOption.MaxTorrents > 0 {
+			siteOption.AllowAddTorrents = siteOption.AllowAddTorrents *
+				clientOption.MaxTorrents / snapshot.ClientOption.MaxTorrents
+		}
+		var clientTorrents []*client.Torrent
+		var histories map[string]strategy.TorrentHistory
+		if variant.enableHistory() {
+			histories = map[string]strategy.TorrentHistory{}
+		}
+		for _, torrent := range sc.torrents {
+			clientTorrents = append(clientTorrents, torrent.Torrent)
+			if histories != nil {
+				if len(torrent.history) == 0 || torrent.history[len(torrent.history)-1].Time < snapshot.Time {
+					torrent.history = append(torrent.history,
+						strategy.NewTorrentSample(snapshot.Client, torrent.Torrent, snapshot.Time))
+				}
+				histories[torrent.InfoHash] = torrent.history
+			}
+		}
+		siteOption.AllowAddTorrents -= int64(countTorrentsOfSite(clientTorrents, snapshot.Site))
+
+		decision := strategy.Decide(status, clientTorrents, siteTorrents, siteOption, clientOption, histories)
+		sc.apply(snapshot, siteTorrents, decision, result)
+		result.Decisions++
+	}
+	result.Duration = getDuration(snapshots)
+	return
+}
+
+// Calculate the actual result from the recorded client torrents of snapshots.
+func GetActualResult(snapshots []*strategy.Snapshot) *Result {
+	result := &Result{Name: "(actual)", Decisions: int64(len(snapshots)), Duration: getDuration(snapshots)}
+	lastTorrents := map[string]map[string]*client.Torrent{} // client => infoHash => torrent
+	for _, snapshot := range snapshots {
+		torrents := map[string]*client.Torrent{}
+		for _, torrent := range snapshot.ClientTorrents {
+			torrents[torrent.InfoHash] = torrent
+		}
+		if last := lastTorrents[snapshot.Client]; last != nil {
+			for infoHash, torrent := range torrents {
+				if lastTorrent := last[infoHash]; lastTorrent != nil {
+					result.Uploaded += max(torrent.Uploaded-lastTorrent.Uploaded, 0)
+					result.Downloaded += max(torrent.Downloaded-lastTorrent.Downloaded, 0)
+				} else {
+					result.Adds++
+					result.Downloaded += torrent.Downloaded
+					result.Uploaded += torrent.Uploaded
+				}
+			}
+			for infoHash, lastTorrent := range last {
+				if torrents[infoHash] == nil {
+					result.Deletes++
+					result.DeletedSize += lastTorrent.SizeCompleted
+				}
+			}
+		}
+		lastTorrents[snapshot.Client] = torrents
+	}
+	return result
+}
+
+// Advance torrents of simulated client to the time of snapshot.
+func (sc *simClient) advance(snapshot *strategy.Snapshot, clientOption *strategy.BrushClientOptionStruct,
+	result *Result) {
+	duration := snapshot.Time - sc.time
+	if duration <= 0 {
+		return
+	}
+	uploadSpeeds := make([]float64, len(sc.torrents))
+	downloadSpeeds := make([]float64, len(sc.torrents))
+	totalUploadSpeed := float64(0)
+	totalDownloadSpeed := float64(0)
+	for i, torrent := range sc.torrents {
+		torrent.updatePeers(sc.time)
+		if torrent.State == "error" || torrent.State == "paused" {
+			continue
+		}
+		if torrent.SizeCompleted > 0 {
+			uploadSpeeds[i] = float64(torrent.Leechers) * LEECHER_UPLOAD_SPEED
+			if torrent.uploadSpeedLimit > 0 {
+				uploadSpeeds[i] = min(uploadSpeeds[i], float64(torrent.uploadSpeedLimit))
+			}
+		}
+		if !torrent.IsComplete() && torrent.Meta["stt"] == 0 {
+			downloadSpeeds[i] = float64(max(torrent.Seeders, 1)) * SEEDER_DOWNLOAD_SPEED
+		}
+		totalUploadSpeed += uploadSpeeds[i]
+		totalDownloadSpeed += downloadSpeeds[i]
+	}
+	uploadSpeedLimit := snapshot.ClientStatus.UploadSpeedLimit
+	if uploadSpeedLimit <= 0 {
+		uploadSpeedLimit = clientOption.DefaultUploadSpeedLimit
+	}
+	uploadRatio := min(float64(uploadSpeedLimit)/max(totalUploadSpeed, 1), 1)
+	downloadRatio := float64(1)
+	if snapshot.ClientStatus.DownloadSpeedLimit > 0 {
+		downloadRatio = min(float64(snapshot.ClientStatus.DownloadSpeedLimit)/max(totalDownloadSpeed, 1), 1)
+	}
+	for i, torrent := range sc.torrents {
+		uploadSpeed := int64(uploadSpeeds[i] * uploadRatio)
+		downloadSpeed := int64(downloadSpeeds[i] * downloadRatio)
+		downloaded := min(downloadSpeed*duration, torrent.Size-torrent.SizeCompleted)
+		torrent.Uploaded += uploadSpeed * duration
+		torrent.Downloaded += downloaded
+		torrent.SizeCompleted += downloaded
+		torrent.UploadSpeed = uploadSpeed
+		torrent.DownloadSpeed = downloadSpeed
+		if torrent.IsComplete() && torrent.Ctime <= 0 {
+			torrent.Ctime = snapshot.Time
+			torrent.DownloadSpeed = 0
+			torrent.State = "seeding"
+		}
+		result.Uploaded += uploadSpeed * duration
+		result.Downloaded += downloaded
+	}
+	sc.time = snapshot.Time
+}
+
+// Refresh seeders & leechers of simulated torrents using site torrents of snapshot.
+func (sc *simClient) refresh(snapshot *strategy.Snapshot) {
+	siteTorrents := map[string]*site.Torrent{}
+	for _, siteTorrent := range snapshot.SiteTorrents {
+		siteTorrents[siteTorrent.Name] = siteTorrent
+	}
+	for _, torrent := range sc.torrents {
+		if siteTorrent := siteTorrents[torrent.Name]; siteTorrent != nil && torrent.site == snapshot.Site {
+			torrent.baseSeeders = siteTorrent.Seeders
+			torrent.baseLeechers = siteTorrent.Leechers
+			torrent.baseTime = snapshot.Time
+		}
+		torrent.updatePeers(snapshot.Time)
+	}
+}
+
+// Return the client status as if the client has the simulated torrents instead of the recorded ones.
+// Torrents in brush save paths do not count in the free space of client default save path.
+func (sc *simClient) getStatus(snapshot *strategy.Snapshot) *client.Status {
+	status := *snapshot.ClientStatus
+	for _, torrent := range snapshot.ClientTorrents {
+		status.UploadSpeed -= torrent.UploadSpeed
+		status.DownloadSpeed -= torrent.DownloadSpeed
+		if status.FreeSpaceOnDisk >= 0 && strategy.GetTorrentSavePath(snapshot.ClientOption.SavePaths, torrent) == nil {
+			status.FreeSpaceOnDisk += torrent.SizeCompleted
+		}
+	}
+	for _, torrent := range sc.torrents {
+		status.UploadSpeed += torrent.UploadSpeed
+		status.DownloadSpeed += torrent.DownloadSpeed
+		if status.FreeSpaceOnDisk >= 0 &&
+			strategy.GetTorrentSavePath(snapshot.ClientOption.SavePaths, torrent.Torrent) == nil {
+			status.FreeSpaceOnDisk -= torrent.SizeCompleted
+		}
+	}
+	status.UploadSpeed = max(status.UploadSpeed, 0)
+	status.DownloadSpeed = max(status.DownloadSpeed, 0)
+	if snapshot.ClientStatus.FreeSpaceOnDisk >= 0 {
+		status.FreeSpaceOnDisk = max(status.FreeSpaceOnDisk, 0)
+	}
+	return &status
+}
+
+// Return copies of brush save paths, whose free space reflects the simulated torrents instead of the recorded ones.
+func (sc *simClient) getSavePaths(snapshot *strategy.Snapshot,
+	savePaths []*strategy.BrushSavePathStruct) []*strategy.BrushSavePathStruct {
+	savePaths = util.Map(savePaths, func(savePath *strategy.BrushSavePathStruct) *strategy.BrushSavePathStruct {
+		sp := *savePath
+		return &sp
+	})
+	freeSpaces := map[*strategy.BrushSavePathStruct]int64{}
+	for _, torrent := range snapshot.ClientTorrents {
+		if savePath := strategy.GetTorrentSavePath(savePaths, torrent); savePath != nil {
+			freeSpaces[savePath] += torrent.SizeCompleted
+		}
+	}
+	for _, torrent := range sc.torrents {
+		if savePath := strategy.GetTorrentSavePath(savePaths, torrent.Torrent); savePath != nil {
+			freeSpaces[savePath] -= torrent.SizeCompleted
+		}
+	}
+	for _, savePath := range savePaths {
+		if savePath.FreeSpace >= 0 {
+			savePath.FreeSpace = max(savePath.FreeSpace+freeSpaces[savePath], 0)
+		}
+	}
+	return savePaths
+}
+
+// Return copies of site torrents of snapshot, whose IsActive flag reflects the simulated client:
+// a torrent is active if it has been added by simulation, or it's active but not added by the recorded brush.
+func (sc *simClient) getSiteTorrents(snapshot *strategy.Snapshot) []*site.Torrent {
+	names := map[string]bool{}
+	for _, torrent := range sc.torrents {
+		names[torrent.Name] = true
+	}
+	var siteTorrents []*site.Torrent
+	for _, siteTorrent := range snapshot.SiteTorrents {
+		t := *siteTorrent
+		t.IsActive = sc.added[snapshot.Site+"/"+t.DownloadUrl] || names[t.Name] || t.IsActive && !sc.recorded[t.Name]
+		siteTorrents = append(siteTorrents, &t)
+	}
+	return siteTorrents
+}
+
+// Apply decision of strategy to simulated client.
+func (sc *simClient) apply(snapshot *strategy.Snapshot, siteTorrents []*site.Torrent,
+	decision *strategy.AlgorithmResult, result *Result) {
+	torrents := map[string]*simTorrent{}
+	for _, torrent := range sc.torrents {
+		torrents[torrent.InfoHash] = torrent
+	}
+	for _, deleteTorrent := range decision.DeleteTorrents {
+		if torrent := torrents[deleteTorrent.InfoHash]; torrent != nil {
+			result.Deletes++
+			result.DeletedSize += torrent.SizeCompleted
+			delete(torrents, deleteTorrent.InfoHash)
+		}
+	}
+	for _, modifyTorrent := range append(decision.StallTorrents, decision.ModifyTorrents...) {
+		if torrent := torrents[modifyTorrent.InfoHash]; torrent != nil {
+			torrent.Meta = modifyTorrent.Meta
+		}
+	}
+	for _, resumeTorrent := range decision.ResumeTorrents {
+		if torrent := torrents[resumeTorrent.InfoHash]; torrent != nil {
+			if torrent.IsComplete() {
+				torrent.State = "seeding"
+			} else {
+				torrent.State = "downloading"
+			}
+		}
+	}
+	sc.torrents = util.Filter(sc.torrents, func(torrent *simTorrent) bool {
+		return torrents[torrent.InfoHash] != nil
+	})
+	for _, addTorrent := range decision.AddTorrents {
+		index := slices.IndexFunc(siteTorrents, func(t *site.Torrent) bool {
+			return t.DownloadUrl == addTorrent.DownloadUrl
+		})
+		if index == -1 {
+			continue
+		}
+		siteTorrent := siteTorrents[index]
+		infoHash := siteTorrent.InfoHash
+		if infoHash == "" {
+			infoHash = fmt.Sprintf("simulated:%s/%s", snapshot.Site, addTorrent.DownloadUrl)
+		}
+		sc.torrents = append(sc.torrents, &simTorrent{
+			Torrent: &client.Torrent{
+				InfoHash: infoHash,
+				Name:     siteTorrent.Name,
+				State:    "downloading",
+				Atime:    snapshot.Time,
+				Ctime:    -1,
+				Category: config.BRUSH_CAT,
+				SavePath: addTorrent.SavePath,
+				Tags:     []string{client.GenerateTorrentTagFromSite(snapshot.Site)},
+				Size:     siteTorrent.Size,
+				Seeders:  siteTorrent.Seeders,
+				Leechers: siteTorrent.Leechers,
+				Meta:     util.CopyMap(addTorrent.Meta, true),
+			},
+			site:             snapshot.Site,
+			baseSeeders:      siteTorrent.Seeders,
+			baseLeechers:     siteTorrent.Leechers,
+			baseTime:         snapshot.Time,
+			uploadSpeedLimit: snapshot.SiteOption.TorrentUploadSpeedLimit,
+		})
+		sc.added[snapshot.Site+"/"+addTorrent.DownloadUrl] = true
+		result.Adds++
+	}
+}
+
+// Decay leechers of torrent to time.
+func (torrent *simTorrent) updatePeers(time int64) {
+	torrent.Seeders = torrent.baseSeeders
+	torrent.Leechers = int64(float64(torrent.baseLeechers) *
+		math.Pow(0.5, float64(max(time-torrent.baseTime, 0))/LEECHERS_HALF_LIFE))
+}
+
+func countTorrentsOfSite(torrents []*client.Torrent, sitename string) (cnt int) {
+	for _, torrent := range torrents {
+		if torrent.GetSiteFromTag() == sitename {
+			cnt++
+		}
+	}
+	return
+}
+
+func getDuration(snapshots []*strategy.Snapshot) int64 {
+	if len(snapshots) == 0 {
+		return 0
+	}
+	start, end := snapshots[0].Time, snapshots[0].Time
+	for _, snapshot := range snapshots {
+		start = min(start, snapshot.Time)
+		end = max(end, snapshot.Time)
+	}
+	return end - start
+}
@@ -0,0 +1,220 @@
+package simulate
+
+import (
+	"testing"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/cmd/brush/strategy"
+	"github.com/sagan/ptool/site"
+)
+
+const (
+	KiB = int64(1024)
+	MiB = int64(1024 * 1024)
+	GiB = int64(1024 * 1024 * 1024)
+)
+
+func TestUpdatePeers(t *testing.T) {
+	const base = 100000
+	tests := []struct {
+		time         int64
+		wantLeechers int64
+	}{
+		{base - 3600, 100},
+		{base, 100},
+		{base + 12*3600, 50},
+		{base + 24*3600, 25},
+	}
+	for _, tt := range tests {
+		torrent := &simTorrent{Torrent: &client.Torrent{}, baseSeeders: 3, baseLeechers: 100, baseTime: base}
+		torrent.updatePeers(tt.time)
+		if torrent.Seeders != 3 || torrent.Leechers != tt.wantLeechers {
+			t.Errorf("time %d: got seeders %d leechers %d, want 3 %d", tt.time-base,
+				torrent.Seeders, torrent.Leechers, tt.wantLeechers)
+		}
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	const now = 100000
+	newClient := func() *simClient {
+		return &simClient{time: now, torrents: []*simTorrent{
+			{
+				Torrent: &client.Torrent{InfoHash: "seeding", State: "seeding", Ctime: now - 3600,
+					Size: GiB, SizeCompleted: GiB, Meta: map[string]int64{}},
+				baseLeechers: 10, baseTime: now,
+			},
+			{
+				Torrent: &client.Torrent{InfoHash: "downloading", State: "downloading", Ctime: -1,
+					Size: 10 * MiB, Meta: map[string]int64{}},
+				baseSeeders: 2, baseTime: now,
+			},
+			{
+				Torrent: &client.Torrent{InfoHash: "stalled", State: "downloading", Ctime: -1,
+					Size: GiB, SizeCompleted: MiB, Meta: map[string]int64{"stt": now - 60}},
+				baseSeeders: 1, baseLeechers: 10, baseTime: now, uploadSpeedLimit: 100 * KiB,
+			},
+		}}
+	}
+	tests := []struct {
+		desc             string
+		uploadSpeedLimit int64
+		wantUploaded     int64
+	}{
+		{"unlimited", 10 * MiB, (1000 + 100) * KiB * 10},
+		{"client upload speed limit", 550 * KiB, (500 + 50) * KiB * 10},
+	}
+	for _, tt := range tests {
+		sc := newClient()
+		result := &Result{}
+		sc.advance(&strategy.Snapshot{Time: now + 10, ClientStatus: &client.Status{}},
+			&strategy.BrushClientOptionStruct{DefaultUploadSpeedLimit: tt.uploadSpeedLimit}, result)
+		if result.Uploaded != tt.wantUploaded || result.Downloaded != 10*MiB {
+			t.Errorf("%s: got uploaded %d downloaded %d, want %d %d", tt.desc,
+				result.Uploaded, result.Downloaded, tt.wantUploaded, 10*MiB)
+		}
+		if sc.time != now+10 {
+			t.Errorf("%s: client time is not advanced", tt.desc)
+		}
+		downloading := sc.torrents[1]
+		if !downloading.IsComplete() || downloading.Ctime != now+10 || downloading.State != "seeding" ||
+			downloading.DownloadSpeed != 0 {
+			t.Errorf("%s: torrent should be completed: %+v", tt.desc, downloading.Torrent)
+		}
+		if stalled := sc.torrents[2]; stalled.SizeCompleted != MiB || stalled.DownloadSpeed != 0 {
+			t.Errorf("%s: stalled torrent should not download: %+v", tt.desc, stalled.Torrent)
+		}
+	}
+
+	sc := newClient()
+	result := &Result{}
+	sc.advance(&strategy.Snapshot{Time: now - 10, ClientStatus: &client.Status{}},
+		&strategy.BrushClientOptionStruct{DefaultUploadSpeedLimit: 10 * MiB}, result)
+	if result.Uploaded != 0 || result.Downloaded != 0 || sc.time != now {
+		t.Errorf("earlier snapshot should not advance client: %+v", result)
+	}
+}
+
+func TestApply(t *testing.T) {
+	const now = 100000
+	sc := &simClient{time: now, added: map[string]bool{}, recorded: map[string]bool{}, torrents: []*simTorrent{
+		{Torrent: &client.Torrent{InfoHash: "aaa", Name: "A", Size: GiB, SizeCompleted: MiB}},
+		{Torrent: &client.Torrent{InfoHash: "bbb", Name: "B", State: "paused", Size: GiB, SizeCompleted: GiB}},
+	}}
+	snapshot := &strategy.Snapshot{Time: now, Site: "test",
+		SiteOption: &strategy.BrushSiteOptionStruct{TorrentUploadSpeedLimit: 10 * MiB}}
+	siteTorrents := []*site.Torrent{
+		{Name: "C", DownloadUrl: "download/c", Size: 2 * GiB, Seeders: 1, Leechers: 20},
+		{Name: "D", DownloadUrl: "download/d", InfoHash: "ddd", Size: GiB},
+	}
+	result := &Result{}
+	sc.apply(snapshot, siteTorrents, &strategy.AlgorithmResult{
+		DeleteTorrents: []strategy.AlgorithmOperationTorrent{{InfoHash: "aaa"}, {InfoHash: "unknown"}},
+		ResumeTorrents: []strategy.AlgorithmOperationTorrent{{InfoHash: "bbb"}},
+		ModifyTorrents: []strategy.AlgorithmModifyTorrent{{InfoHash: "bbb", Meta: map[string]int64{"stt": now}}},
+		AddTorrents: []strategy.AlgorithmAddTorrent{
+			{DownloadUrl: "download/c", Meta: map[string]int64{"dcet": now + 86400}},
+			{DownloadUrl: "download/d"},
+			{DownloadUrl: "download/unknown"},
+		},
+	}, result)
+
+	if result.Adds != 2 || result.Deletes != 1 || result.DeletedSize != MiB {
+		t.Errorf("got adds %d deletes %d deleted size %d, want 2 1 %d",
+			result.Adds, result.Deletes, result.DeletedSize, MiB)
+	}
+	if len(sc.torrents) != 3 {
+		t.Fatalf("got %d torrents, want 3", len(sc.torrents))
+	}
+	if torrent := sc.torrents[0]; torrent.InfoHash != "bbb" || torrent.State != "seeding" ||
+		torrent.Meta["stt"] != now {
+		t.Errorf("unexpected resumed torrent %+v", torrent.Torrent)
+	}
+	added := sc.torrents[1]
+	if added.InfoHash != "simulated:test/download/c" || added.Name != "C" || added.State != "downloading" ||
+		added.Atime != now || added.Ctime != -1 || added.Size != 2*GiB || added.GetSiteFromTag() != "test" ||
+		added.Meta["dcet"] != now+86400 {
+		t.Errorf("unexpected added torrent %+v", added.Torrent)
+	}
+	if added.site != "test" || added.baseSeeders != 1 || added.baseLeechers != 20 || added.baseTime != now ||
+		added.uploadSpeedLimit != 10*MiB {
+		t.Errorf("unexpected simulation state of added torrent %+v", added)
+	}
+	if sc.torrents[2].InfoHash != "ddd" {
+		t.Errorf("site torrent info hash should be used: got %q", sc.torrents[2].InfoHash)
+	}
+	if !sc.added["test/download/c"] || !sc.added["test/download/d"] || len(sc.added) != 2 {
+		t.Errorf("unexpected added torrents %v", sc.added)
+	}
+
+	// added site torrents are active in later decisions, even if deleted
+	sc.torrents = nil
+	snapshot.SiteTorrents = siteTorrents
+	for _, siteTorrent := range sc.getSiteTorrents(snapshot) {
+		if !siteTorrent.IsActive {
+			t.Errorf("site torrent %s should be active", siteTorrent.Name)
+		}
+	}
+}
+
+func TestGetActualResult(t *testing.T) {
+	snapshots := []*strategy.Snapshot{
+		{Time: 1000, Client: "local", ClientTorrents: []*client.Torrent{
+			{InfoHash: "aaa", Uploaded: 100, Downloaded: 50},
+			{InfoHash: "ccc", Uploaded: 10, Downloaded: 40, SizeCompleted: 40},
+		}},
+		{Time: 1300, Client: "remote", ClientTorrents: []*client.Torrent{{InfoHash: "xxx", Uploaded: 1000}}},
+		{Time: 1600, Client: "local", ClientTorrents: []*client.Torrent{
+			{InfoHash: "aaa", Uploaded: 300, Downloaded: 60},
+			{InfoHash: "bbb", Uploaded: 10, Downloaded: 20},
+		}},
+	}
+	result := GetActualResult(snapshots)
+	want := Result{Name: "(actual)", Decisions: 3, Adds: 1, Deletes: 1, Uploaded: 210, Downloaded: 30,
+		DeletedSize: 40, Duration: 600}
+	if *result != want {
+		t.Errorf("got %+v, want %+v", *result, want)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	const now = 100000
+	newSnapshot := func(time int64) *strategy.Snapshot {
+		return &strategy.Snapshot{
+			Time:         time,
+			Client:       "local",
+			Site:         "test",
+			ClientStatus: &client.Status{FreeSpaceOnDisk: 100 * GiB, UploadSpeedLimit: 10 * MiB},
+			SiteTorrents: []*site.Torrent{{Name: "A", DownloadUrl: "download/a", Time: now - 600,
+				Size: GiB, Seeders: 1, Leechers: 20, UploadMultiplier: 1}},
+			SiteOption: &strategy.BrushSiteOptionStruct{Site: "test", Now: time, AllowAddTorrents: 1,
+				TorrentMaxSizeLimit: 100 * GiB},
+			ClientOption: &strategy.BrushClientOptionStruct{MinDiskSpace: 5 * GiB, SlowUploadSpeedTier: 100 * KiB,
+				MaxDownloadingTorrents: 5, MaxTorrents: 10, MinRatio: 0.2},
+		}
+	}
+	snapshots := []*strategy.Snapshot{newSnapshot(now), newSnapshot(now + 600)}
+	enableHistory := false
+	variant := &Variant{Name: "test", BrushEnableHistory: &enableHistory}
+	result := Simulate(snapshots, variant)
+	want := Result{Name: "test", Decisions: 2, Adds: 1, Downloaded: 600 * MiB, Duration: 600}
+	if *result != want {
+		t.Errorf("got %+v, want %+v", *result, want)
+	}
+	// recorded snapshots are not modified
+	if snapshots[0].SiteTorrents[0].IsActive || snapshots[1].SiteOption.AllowAddTorrents != 1 {
+		t.Errorf("snapshots are modified")
+	}
+	if again := Simulate(snapshots, variant); *again != *result {
+		t.Errorf("simulation is not deterministic: got %+v, then %+v", *result, *again)
+	}
+
+	variant.BrushTorrentMaxSizeLimit = "500MiB"
+	if result := Simulate(snapshots, variant); result.Adds != 0 || result.Downloaded != 0 {
+		t.Errorf("variant: got %+v, want no torrents added", *result)
+	}
+	variant.BrushTorrentMaxSizeLimit = "invalid"
+	if result := Simulate(snapshots, variant); result.Error == nil {
+		t.Errorf("variant: expected error of invalid size")
+	}
+}
@@ -0,0 +1,131 @@
+package simulate
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/cmd/brush"
+	"github.com/sagan/ptool/cmd/brush/strategy"
+	"github.com/sagan/ptool/util"
+)
+
+var command = &cobra.Command{
+	Use:   "simulate {snapshot-file} [--variants variants.yaml]",
+	Short: "Replay recorded brush snapshots with alternative parameters or rules.",
+	Long: `Replay recorded brush snapshots with alternative parameters or rules.
+Snapshots are recorded by "ptool brush --snapshot-file {snapshot-file} ...". Each snapshot contains the site torrents,
+client status & brush torrents and brush options of a site of a brush run.
+
+Snapshots are replayed through the brush strategy, using the recorded options ("recorded") and each variant.
+A variant overrides some brush options of client & site config, and/or brush rules. --variants file is a yaml list:
+
+- name: more-downloading
+  brushMaxDownloadingTorrents: 10
+  brushMinDiskSpace: 20GiB
+- name: no-small
+  brushRules:
+    - reject: lt .Size (mul 1024 1024 500)
+
+Brush torrent histories are simulated if brushEnableHistory of ptool.toml is enabled,
+which can be overridden by "brushEnableHistory: true|false" of a variant.
+
+Each client starts with its brush torrents of the first snapshot. The simulated torrents are then added / deleted
+according to the decisions, and their transfer is estimated using a simple model:
+each leecher contributes 100KiB/s upload speed (limited by site torrentUploadSpeedLimit and
+client upload speed limit); each seeder contributes 1MiB/s download speed; leechers halve every 12 hours
+unless refreshed by later site torrents list.
+
+It reports the estimated uploaded and downloaded (written to disk) size, deleted size and the number of
+added and deleted torrents of each configuration. The "(actual)" row is calculated from the recorded client torrents,
+which can be used to check the model.`,
+	Args: cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE: simulate,
+}
+
+var (
+	variantsFile = ""
+	clientName   = ""
+)
+
+func init() {
+	command.Flags().StringVarP(&variantsFile, "variants", "", "", "Yaml file of alternative brush configurations")
+	command.Flags().StringVarP(&clientName, "client", "", "", "Only replay snapshots of this client")
+	brush.Command.AddCommand(command)
+}
+
+func simulate(cmd *cobra.Command, args []string) error {
+	snapshots, err := strategy.LoadSnapshots(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load snapshots: %w", err)
+	}
+	if clientName != "" {
+		snapshots = util.Filter(snapshots, func(snapshot *strategy.Snapshot) bool {
+			return snapshot.Client == clientName
+		})
+	}
+	if len(snapshots) == 0 {
+		return fmt.Errorf("no snapshots")
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Time < snapshots[j].Time
+	})
+	variants := []*Variant{{Name: "recorded"}}
+	if variantsFile != "" {
+		moreVariants, err := LoadVariants(variantsFile)
+		if err != nil {
+			return err
+		}
+		variants = append(variants, moreVariants...)
+	}
+
+	clients := map[string]bool{}
+	sites := map[string]bool{}
+	for _, snapshot := range snapshots {
+		clients[snapshot.Client] = true
+		sites[snapshot.Site] = true
+	}
+	fmt.Printf("Snapshots: %d (clients: %d, sites: %d); Time: %s ~ %s (%s)\n\n",
+		len(snapshots), len(clients), len(sites),
+		util.FormatTime(snapshots[0].Time), util.FormatTime(snapshots[len(snapshots)-1].Time),
+		util.GetDurationString(snapshots[len(snapshots)-1].Time-snapshots[0].Time))
+	results := []*Result{GetActualResult(snapshots)}
+	for _, variant := range variants {
+		results = append(results, Simulate(snapshots, variant))
+	}
+
+	errorCnt := int64(0)
+	fmt.Printf("%-20s  %5s  %7s  %10s  %10s  %10s  %10s  %6s\n",
+		"Configuration", "Adds", "Deletes", "Uploaded", "Avg ↑Spd", "Downloaded", "Deleted", "Ratio")
+	for _, result := range results {
+		util.PrintStringInWidth(os.Stdout, result.Name, 20, true)
+		if result.Error != nil {
+			fmt.Printf("  ✕ %v\n", result.Error)
+			errorCnt++
+			continue
+		}
+		averageUploadSpeed := float64(0)
+		if result.Duration > 0 {
+			averageUploadSpeed = float64(result.Uploaded) / float64(result.Duration)
+		}
+		ratio := float64(0)
+		if result.Downloaded > 0 {
+			ratio = float64(result.Uploaded) / float64(result.Downloaded)
+		}
+		fmt.Printf("  %5d  %7d  %10s  %8s/s  %10s  %10s  %6.2f\n",
+			result.Adds,
+			result.Deletes,
+			util.BytesSizeAround(float64(result.Uploaded)),
+			util.BytesSizeAround(averageUploadSpeed),
+			util.BytesSizeAround(float64(result.Downloaded)),
+			util.BytesSizeAround(float64(result.DeletedSize)),
+			ratio,
+		)
+	}
+	if errorCnt > 0 {
+		return fmt.Errorf("%d errors", errorCnt)
+	}
+	return nil
+}
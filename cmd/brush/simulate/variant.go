@@ -0,0 +1,138 @@
+package simulate
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sagan/ptool/cmd/brush/strategy"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/util"
+)
+
+// An alternative brush configuration. Field names are the same as the brush options of client & site config
+// in ptool.toml. Unset (zero value) fields keep the recorded values of snapshots.
+type Variant struct {
+	Name                         string   `yaml:"name"`
+	BrushMinDiskSpace            string   `yaml:"brushMinDiskSpace"`
+	BrushSlowUploadSpeedTier     string   `yaml:"brushSlowUploadSpeedTier"`
+	BrushMaxDownloadingTorrents  int64    `yaml:"brushMaxDownloadingTorrents"`
+	BrushMaxTorrents             int64    `yaml:"brushMaxTorrents"`
+	BrushMinRatio                float64  `yaml:"brushMinRatio"`
+	BrushDefaultUploadSpeedLimit string   `yaml:"brushDefaultUploadSpeedLimit"`
+	TorrentUploadSpeedLimit      string   `yaml:"torrentUploadSpeedLimit"`
+	BrushTorrentMinSizeLimit     string   `yaml:"brushTorrentMinSizeLimit"`
+	BrushTorrentMaxSizeLimit     string   `yaml:"brushTorrentMaxSizeLimit"`
+	BrushAllowNoneFree           *bool    `yaml:"brushAllowNoneFree"`
+	BrushAllowPaid               *bool    `yaml:"brushAllowPaid"`
+	BrushAllowHr                 *bool    `yaml:"brushAllowHr"`
+	BrushAllowZeroSeeders        *bool    `yaml:"brushAllowZeroSeeders"`
+	BrushAcceptAnyFree           *bool    `yaml:"brushAcceptAnyFree"`
+	BrushExcludes                []string `yaml:"brushExcludes"`
+	BrushExcludeTags             []string `yaml:"brushExcludeTags"`
+	BrushMinAccountRatio         float64  `yaml:"brushMinAccountRatio"`
+	// If set (even to an empty list), replace the recorded site and client brush rules.
+	BrushRules []*config.BrushRuleConfigStruct `yaml:"brushRules"`
+	// Whether to simulate brush torrent histories. Default to the brushEnableHistory of ptool.toml.
+	BrushEnableHistory *bool `yaml:"brushEnableHistory"`
+}
+
+// Load variants from a yaml file, which is a list of variants.
+func LoadVariants(file string) ([]*Variant, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var variants []*Variant
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&variants); err != nil {
+		return nil, fmt.Errorf("invalid variants file: %w", err)
+	}
+	for i, variant := range variants {
+		if variant.Name == "" {
+			variant.Name = fmt.Sprintf("#%d", i+1)
+		}
+	}
+	return variants, nil
+}
+
+func (variant *Variant) enableHistory() bool {
+	if variant.BrushEnableHistory != nil {
+		return *variant.BrushEnableHistory
+	}
+	return config.Get().BrushEnableHistory
+}
+
+// Return brush options of snapshot with variant applied. The recorded options are not modified.
+func (variant *Variant) getOptions(snapshot *strategy.Snapshot) (
+	siteOption *strategy.BrushSiteOptionStruct, clientOption *strategy.BrushClientOptionStruct, err error) {
+	siteOption = &strategy.BrushSiteOptionStruct{}
+	clientOption = &strategy.BrushClientOptionStruct{}
+	*siteOption = *snapshot.SiteOption
+	*clientOption = *snapshot.ClientOption
+	siteRules, clientRules := snapshot.SiteRules, snapshot.ClientRules
+	if variant.BrushRules != nil {
+		siteRules, clientRules = variant.BrushRules, nil
+	}
+	if siteOption.Rules, err = strategy.ParseBrushRules(siteRules); err != nil {
+		return nil, nil, err
+	}
+	if clientOption.Rules, err = strategy.ParseBrushRules(clientRules); err != nil {
+		return nil, nil, err
+	}
+	for _, size := range []struct {
+		value  *int64
+		option string
+		name   string
+	}{
+		{&clientOption.MinDiskSpace, variant.BrushMinDiskSpace, "brushMinDiskSpace"},
+		{&clientOption.SlowUploadSpeedTier, variant.BrushSlowUploadSpeedTier, "brushSlowUploadSpeedTier"},
+		{&clientOption.DefaultUploadSpeedLimit, variant.BrushDefaultUploadSpeedLimit, "brushDefaultUploadSpeedLimit"},
+		{&siteOption.TorrentUploadSpeedLimit, variant.TorrentUploadSpeedLimit, "torrentUploadSpeedLimit"},
+		{&siteOption.TorrentMinSizeLimit, variant.BrushTorrentMinSizeLimit, "brushTorrentMinSizeLimit"},
+		{&siteOption.TorrentMaxSizeLimit, variant.BrushTorrentMaxSizeLimit, "brushTorrentMaxSizeLimit"},
+	} {
+		if size.option == "" {
+			continue
+		}
+		if *size.value, err = util.RAMInBytes(size.option); err != nil {
+			return nil, nil, fmt.Errorf("variant %s: invalid %s: %w", variant.Name, size.name, err)
+		}
+	}
+	if variant.BrushMaxDownloadingTorrents > 0 {
+		clientOption.MaxDownloadingTorrents = variant.BrushMaxDownloadingTorrents
+	}
+	if variant.BrushMaxTorrents > 0 {
+		clientOption.MaxTorrents = variant.BrushMaxTorrents
+	}
+	if variant.BrushMinRatio > 0 {
+		clientOption.MinRatio = variant.BrushMinRatio
+	}
+	if variant.BrushMinAccountRatio > 0 {
+		siteOption.MinAccountRatio = variant.BrushMinAccountRatio
+	}
+	for _, flag := range []struct {
+		value  *bool
+		option *bool
+	}{
+		{&siteOption.AllowNoneFree, variant.BrushAllowNoneFree},
+		{&siteOption.AllowPaid, variant.BrushAllowPaid},
+		{&siteOption.AllowHr, variant.BrushAllowHr},
+		{&siteOption.AllowZeroSeeders, variant.BrushAllowZeroSeeders},
+		{&siteOption.AcceptAnyFree, variant.BrushAcceptAnyFree},
+	} {
+		if flag.option != nil {
+			*flag.value = *flag.option
+		}
+	}
+	if variant.BrushExcludes != nil {
+		siteOption.Excludes = variant.BrushExcludes
+	}
+	if variant.BrushExcludeTags != nil {
+		siteOption.ExcludeTags = variant.BrushExcludeTags
+	}
+	return siteOption, clientOption, nil
+}
@@ -0,0 +1,132 @@
+package strategy
+
+import (
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/util/osutil"
+)
+
+// A brush save path of client.
+type BrushSavePathStruct struct {
+	Path         string
+	MinDiskSpace int64
+	FreeSpace    int64 // -1 means unknown
+}
+
+// Disk space accounting of a brush save path, or the client default save path (path is empty).
+type diskSpaceStruct struct {
+	path            string
+	minDiskSpace    int64
+	freespace       int64 // -1 means unknown
+	freespaceChange int64 // estimated free space change after deleting torrents
+	freespaceTarget int64 // target free space when deleting torrents due to insufficient free space
+	addedSize       int64 // total size of new torrents added to this path
+}
+
+func newDiskSpace(path string, minDiskSpace int64, freespace int64) *diskSpaceStruct {
+	return &diskSpaceStruct{
+		path:            path,
+		minDiskSpace:    minDiskSpace,
+		freespace:       freespace,
+		freespaceTarget: min(minDiskSpace*2, minDiskSpace+DELETE_TORRENTS_FREE_DISK_SPACE_TIER),
+	}
+}
+
+// Free space is insufficient and torrents should be deleted to free up space.
+func (ds *diskSpaceStruct) shouldFreeUp() bool {
+	return ds.freespace >= 0 && ds.freespace <= ds.minDiskSpace && ds.freespace+ds.freespaceChange <= ds.freespaceTarget
+}
+
+// Free space is still insufficient after deleting torrents.
+func (ds *diskSpaceStruct) isInsufficient() bool {
+	return ds.freespace >= 0 && ds.freespace+ds.freespaceChange < ds.minDiskSpace
+}
+
+func (ds *diskSpaceStruct) canResume() bool {
+	return ds.freespace+ds.freespaceChange >= max(ds.minDiskSpace, RESUME_TORRENTS_FREE_DISK_SPACE_TIER)
+}
+
+func (ds *diskSpaceStruct) canAdd() bool {
+	return ds.freespace == -1 || ds.freespace+ds.freespaceChange > ds.minDiskSpace
+}
+
+// Estimated free space above min disk space after deleting and adding torrents. Unknown free space is 0.
+func (ds *diskSpaceStruct) headroom() int64 {
+	if ds.freespace == -1 {
+		return 0
+	}
+	return ds.freespace + ds.freespaceChange - ds.addedSize - ds.minDiskSpace
+}
+
+// Return disk space accountings of client default save path (the first one) and brush save paths.
+func getDiskSpaces(clientStatus *client.Status, clientOption *BrushClientOptionStruct) []*diskSpaceStruct {
+	diskSpaces := []*diskSpaceStruct{newDiskSpace("", clientOption.MinDiskSpace, clientStatus.FreeSpaceOnDisk)}
+	for _, savePath := range clientOption.SavePaths {
+		diskSpaces = append(diskSpaces, newDiskSpace(savePath.Path, savePath.MinDiskSpace, savePath.FreeSpace))
+	}
+	return diskSpaces
+}
+
+// Return the disk space accounting of the path that torrent is in.
+func getTorrentDiskSpace(diskSpaces []*diskSpaceStruct, savePaths []*BrushSavePathStruct,
+	torrent *client.Torrent) *diskSpaceStruct {
+	// index is -1 (client default save path) if torrent is not in any brush save path
+	return diskSpaces[slices.Index(savePaths, GetTorrentSavePath(savePaths, torrent))+1]
+}
+
+// Return the disk space accounting of the path with most headroom that new torrents can be added to.
+// Return nil if none.
+func getAddDiskSpace(diskSpaces []*diskSpaceStruct) *diskSpaceStruct {
+	if len(diskSpaces) == 1 {
+		if diskSpaces[0].canAdd() {
+			return diskSpaces[0]
+		}
+		return nil
+	}
+	var best *diskSpaceStruct
+	for _, ds := range diskSpaces[1:] {
+		if ds.canAdd() && (best == nil || ds.headroom() > best.headroom()) {
+			best = ds
+		}
+	}
+	return best
+}
+
+// Return the brush save path that torrent is in. Return nil if it's not in any of them.
+func GetTorrentSavePath(savePaths []*BrushSavePathStruct, torrent *client.Torrent) *BrushSavePathStruct {
+	var matched *BrushSavePathStruct
+	for _, savePath := range savePaths {
+		if isPathUnder(torrent.SavePath, savePath.Path) && (matched == nil || len(savePath.Path) > len(matched.Path)) {
+			matched = savePath
+		}
+	}
+	return matched
+}
+
+// Get free disk space of path on client host. Use the client API if supported,
+// otherwise measure it locally (works only if the path is also accessible on local machine). Return -1 if unknown.
+func GetSavePathFreeSpace(clientInstance client.Client, path string) int64 {
+	freeSpace, err := clientInstance.GetFreeSpace(path)
+	if err == nil {
+		return freeSpace
+	}
+	log.Debugf("Failed to get free space of %s from client %s: %v", path, clientInstance.GetName(), err)
+	freeSpace, err = osutil.GetFreeSpace(path)
+	if err == nil {
+		return freeSpace
+	}
+	log.Warnf("Failed to get free space of brush save path %s of client %s: %v",
+		path, clientInstance.GetName(), err)
+	return -1
+}
+
+// Return true if path is dir or inside dir. Both "/" and "\" are treated as path separator.
+func isPathUnder(path string, dir string) bool {
+	path = strings.TrimRight(path, `/\`)
+	dir = strings.TrimRight(dir, `/\`)
+	return path == dir || strings.HasPrefix(path, dir+"/") || strings.HasPrefix(path, dir+`\`)
+}
@@ -0,0 +1,114 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/sagan/ptool/client"
+)
+
+func TestIsPathUnder(t *testing.T) {
+	tests := []struct {
+		desc string
+		path string
+		dir  string
+		want bool
+	}{
+		{"same dir", "/data/brush", "/data/brush", true},
+		{"trailing slash", "/data/brush/", "/data/brush", true},
+		{"sub dir", "/data/brush/movies", "/data/brush/", true},
+		{"sibling with same prefix", "/data/brush2", "/data/brush", false},
+		{"parent dir", "/data", "/data/brush", false},
+		{"root dir", "/data", "/", true},
+		{"windows path", `D:\brush\movies`, `D:\brush`, true},
+		{"windows sibling", `D:\brush2`, `D:\brush`, false},
+	}
+	for _, tt := range tests {
+		if got := isPathUnder(tt.path, tt.dir); got != tt.want {
+			t.Errorf("%s: isPathUnder(%q, %q) = %t, want %t", tt.desc, tt.path, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestGetTorrentSavePath(t *testing.T) {
+	savePaths := []*BrushSavePathStruct{{Path: "/data"}, {Path: "/data/brush"}, {Path: "/mnt/disk2"}}
+	tests := []struct {
+		desc     string
+		savePath string
+		want     *BrushSavePathStruct
+	}{
+		{"longest match", "/data/brush/a", savePaths[1]},
+		{"outer path", "/data/other", savePaths[0]},
+		{"second disk", "/mnt/disk2", savePaths[2]},
+		{"default path", "/downloads", nil},
+	}
+	for _, tt := range tests {
+		got := GetTorrentSavePath(savePaths, &client.Torrent{SavePath: tt.savePath})
+		if got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestGetAddDiskSpace(t *testing.T) {
+	tests := []struct {
+		desc       string
+		diskSpaces []*diskSpaceStruct
+		want       int // index of wanted disk space, -1 for nil
+	}{
+		{
+			desc:       "default path only",
+			diskSpaces: []*diskSpaceStruct{newDiskSpace("", 5*GiB, 10*GiB)},
+			want:       0,
+		},
+		{
+			desc:       "default path full",
+			diskSpaces: []*diskSpaceStruct{newDiskSpace("", 5*GiB, 5*GiB)},
+			want:       -1,
+		},
+		{
+			desc:       "default path unknown free space",
+			diskSpaces: []*diskSpaceStruct{newDiskSpace("", 5*GiB, -1)},
+			want:       0,
+		},
+		{
+			desc: "save path with most headroom",
+			diskSpaces: []*diskSpaceStruct{
+				newDiskSpace("", 5*GiB, 100*GiB),
+				newDiskSpace("/disk1", 5*GiB, 20*GiB),
+				newDiskSpace("/disk2", 10*GiB, 50*GiB),
+			},
+			want: 2,
+		},
+		{
+			desc: "default path is not used if save paths are set",
+			diskSpaces: []*diskSpaceStruct{
+				newDiskSpace("", 5*GiB, 100*GiB),
+				newDiskSpace("/disk1", 5*GiB, 5*GiB),
+			},
+			want: -1,
+		},
+		{
+			desc: "added size is accounted",
+			diskSpaces: []*diskSpaceStruct{
+				newDiskSpace("", 5*GiB, 100*GiB),
+				newDiskSpace("/disk1", 5*GiB, 20*GiB),
+				func() *diskSpaceStruct {
+					ds := newDiskSpace("/disk2", 5*GiB, 30*GiB)
+					ds.addedSize = 20 * GiB
+					return ds
+				}(),
+			},
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		got := getAddDiskSpace(tt.diskSpaces)
+		var want *diskSpaceStruct
+		if tt.want >= 0 {
+			want = tt.diskSpaces[tt.want]
+		}
+		if got != want {
+			t.Errorf("%s: got %v, want %v", tt.desc, got, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package strategy
+
+import (
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/stats"
+)
+
+const (
+	// Timespan of the short-term moving average of upload speed
+	HISTORY_SHORT_TIMESPAN = SLOW_TORRENTS_CHECK_TIMESPAN
+	// Timespan of the long-term moving average of upload speed. Used to calculate the trend
+	HISTORY_LONG_TIMESPAN = int64(60 * 60)
+	// Max trend (short-term / long-term average upload speed) used in prediction
+	HISTORY_MAX_TREND = float64(2)
+)
+
+// Recorded samples of a brush torrent, in time order.
+type TorrentHistory []*stats.BrushTorrentSample
+
+// Return the moving average upload speed of the last timespan seconds until the latest sample.
+// ok is false if the history does not cover the timespan.
+func (history TorrentHistory) AverageUploadSpeed(timespan int64) (speed int64, ok bool) {
+	if len(history) < 2 {
+		return 0, false
+	}
+	latest := history[len(history)-1]
+	for i := len(history) - 2; i >= 0; i-- {
+		if latest.Time-history[i].Time >= timespan {
+			return max(latest.Uploaded-history[i].Uploaded, 0) / (latest.Time - history[i].Time), true
+		}
+	}
+	return 0, false
+}
+
+// Return the trend of upload speed: short-term / long-term average upload speed.
+// >1 means the upload speed is increasing. ok is false if the history does not cover long-term timespan.
+func (history TorrentHistory) UploadSpeedTrend() (trend float64, ok bool) {
+	shortSpeed, _ := history.AverageUploadSpeed(HISTORY_SHORT_TIMESPAN)
+	longSpeed, ok := history.AverageUploadSpeed(HISTORY_LONG_TIMESPAN)
+	if !ok {
+		return 0, false
+	}
+	if longSpeed == 0 {
+		if shortSpeed > 0 {
+			return HISTORY_MAX_TREND, true
+		}
+		return 1, true
+	}
+	return min(float64(shortSpeed)/float64(longSpeed), HISTORY_MAX_TREND), true
+}
+
+// Predict the future upload speed: short-term moving average upload speed adjusted by trend.
+// ok is false if the history does not cover short-term timespan.
+func (history TorrentHistory) PredictUploadSpeed() (speed int64, ok bool) {
+	speed, ok = history.AverageUploadSpeed(HISTORY_SHORT_TIMESPAN)
+	if !ok {
+		return
+	}
+	if trend, ok := history.UploadSpeedTrend(); ok {
+		speed = int64(float64(speed) * trend)
+	}
+	return speed, true
+}
+
+// Generate a sample of client brush torrent.
+func NewTorrentSample(clientName string, torrent *client.Torrent, now int64) *stats.BrushTorrentSample {
+	return &stats.BrushTorrentSample{
+		Client:        clientName,
+		InfoHash:      torrent.InfoHash,
+		Time:          now,
+		Site:          torrent.GetSiteFromTag(),
+		Uploaded:      torrent.Uploaded,
+		Downloaded:    torrent.Downloaded,
+		SizeCompleted: torrent.SizeCompleted,
+		UploadSpeed:   torrent.UploadSpeed,
+		DownloadSpeed: torrent.DownloadSpeed,
+		Seeders:       torrent.Seeders,
+		Leechers:      torrent.Leechers,
+	}
+}
@@ -0,0 +1,160 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/stats"
+)
+
+const (
+	KiB = int64(1024)
+	MiB = 1024 * KiB
+	GiB = 1024 * MiB
+)
+
+// Generate a history of samples at the given times (relative to now), uploading at the given speeds.
+// speeds[i] is the upload speed between sample i and i+1.
+func newTestHistory(now int64, times []int64, speeds []int64) TorrentHistory {
+	history := TorrentHistory{}
+	uploaded := int64(0)
+	for i, t := range times {
+		if i > 0 {
+			uploaded += speeds[i-1] * (t - times[i-1])
+		}
+		history = append(history, &stats.BrushTorrentSample{Time: now + t, Uploaded: uploaded})
+	}
+	return history
+}
+
+func TestTorrentHistory(t *testing.T) {
+	const now = 100000
+	tests := []struct {
+		desc        string
+		history     TorrentHistory
+		wantAverage int64
+		wantOk      bool
+		wantPredict int64
+	}{
+		{
+			desc:    "empty",
+			history: TorrentHistory{},
+		},
+		{
+			desc:    "not covering short timespan",
+			history: newTestHistory(now, []int64{-600, 0}, []int64{100 * KiB}),
+		},
+		{
+			desc:        "short timespan only",
+			history:     newTestHistory(now, []int64{-900, 0}, []int64{100 * KiB}),
+			wantAverage: 100 * KiB,
+			wantOk:      true,
+			wantPredict: 100 * KiB,
+		},
+		{
+			desc:        "increasing speed",
+			history:     newTestHistory(now, []int64{-3600, -900, 0}, []int64{50 * KiB, 100 * KiB}),
+			wantAverage: 100 * KiB,
+			wantOk:      true,
+			// long-term average is (50*2700 + 100*900) / 3600 = 62.5 KiB/s
+			wantPredict: int64(float64(100*KiB) * (100.0 / 62.5)),
+		},
+		{
+			desc:        "increasing speed trend is capped",
+			history:     newTestHistory(now, []int64{-3600, -900, 0}, []int64{0, 100 * KiB}),
+			wantAverage: 100 * KiB,
+			wantOk:      true,
+			wantPredict: int64(float64(100*KiB) * HISTORY_MAX_TREND),
+		},
+		{
+			desc:        "decreasing speed",
+			history:     newTestHistory(now, []int64{-3600, -900, 0}, []int64{100 * KiB, 0}),
+			wantAverage: 0,
+			wantOk:      true,
+			wantPredict: 0,
+		},
+	}
+	for _, tt := range tests {
+		average, ok := tt.history.AverageUploadSpeed(HISTORY_SHORT_TIMESPAN)
+		if average != tt.wantAverage || ok != tt.wantOk {
+			t.Errorf("%s: AverageUploadSpeed() = %d, %t, want %d, %t", tt.desc, average, ok, tt.wantAverage, tt.wantOk)
+		}
+		predict, ok := tt.history.PredictUploadSpeed()
+		if predict != tt.wantPredict || ok != tt.wantOk {
+			t.Errorf("%s: PredictUploadSpeed() = %d, %t, want %d, %t", tt.desc, predict, ok, tt.wantPredict, tt.wantOk)
+		}
+	}
+}
+
+func TestDecideSlowTorrentByHistory(t *testing.T) {
+	const now = 100000
+	const infoHash = "0123456789abcdef0123456789abcdef01234567"
+	tests := []struct {
+		desc       string
+		history    TorrentHistory
+		wantStall  bool
+		wantModify string
+	}{
+		{
+			desc:      "slow average uploading speed",
+			history:   newTestHistory(now, []int64{-3600, -900, 0}, []int64{20 * KiB, 10 * KiB}),
+			wantStall: true,
+		},
+		{
+			desc:    "fast average uploading speed",
+			history: newTestHistory(now, []int64{-3600, -900, 0}, []int64{1 * MiB, 1 * MiB}),
+		},
+		{
+			desc:       "history does not cover check timespan",
+			history:    newTestHistory(now, []int64{-300, 0}, []int64{10 * KiB}),
+			wantModify: "set slow check time mark",
+		},
+		{
+			desc:       "no history",
+			wantModify: "set slow check time mark",
+		},
+	}
+	for _, tt := range tests {
+		torrent := &client.Torrent{
+			InfoHash:      infoHash,
+			Name:          "test",
+			State:         "downloading",
+			Atime:         now - 7200,
+			Size:          10 * GiB,
+			SizeCompleted: 1 * GiB,
+			DownloadSpeed: 10 * MiB,
+			UploadSpeed:   10 * KiB,
+			Tags:          []string{"site:test"},
+			Meta:          map[string]int64{},
+		}
+		histories := map[string]TorrentHistory{}
+		if tt.history != nil {
+			histories[infoHash] = tt.history
+		}
+		result := Decide(
+			&client.Status{FreeSpaceOnDisk: 100 * GiB, UploadSpeedLimit: 10 * MiB},
+			[]*client.Torrent{torrent}, nil,
+			&BrushSiteOptionStruct{Site: "test", Now: now},
+			&BrushClientOptionStruct{
+				MinDiskSpace:        5 * GiB,
+				SlowUploadSpeedTier: 100 * KiB,
+				MaxTorrents:         100,
+				MinRatio:            0.2,
+			},
+			histories,
+		)
+		if stalled := len(result.StallTorrents) > 0; stalled != tt.wantStall {
+			t.Errorf("%s: stalled = %t, want %t", tt.desc, stalled, tt.wantStall)
+		}
+		modify := ""
+		if len(result.ModifyTorrents) > 0 {
+			modify = result.ModifyTorrents[0].Msg
+		}
+		if modify != tt.wantModify {
+			t.Errorf("%s: modify = %q, want %q", tt.desc, modify, tt.wantModify)
+		}
+		if len(result.DeleteTorrents) > 0 {
+			t.Errorf("%s: unexpected delete %v", tt.desc, result.DeleteTorrents)
+		}
+	}
+}
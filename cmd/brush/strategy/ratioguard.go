@@ -0,0 +1,71 @@
+package strategy
+
+import (
+	"fmt"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/site"
+)
+
+const (
+	// If the projected site account ratio is below MinAccountRatio * this factor,
+	// incomplete brush torrents of the site whose discount has expired or been removed are deleted immediately.
+	ACCOUNT_RATIO_CRITICAL_FACTOR = float64(0.9)
+)
+
+// Result of site account ratio check.
+type accountRatioGuardStruct struct {
+	noAdd    bool // do not add new torrents of the site
+	critical bool // delete incomplete torrents of the site that are no longer free
+	msg      string
+}
+
+// Check site account ratio against MinAccountRatio of site option.
+// The projected ratio counts the remaining size of incomplete brush torrents of the site as downloaded,
+// as they may turn out not to be free or stop being free.
+func checkAccountRatio(clientTorrents []*client.Torrent, siteOption *BrushSiteOptionStruct) (
+	guard *accountRatioGuardStruct) {
+	guard = &accountRatioGuardStruct{}
+	status := siteOption.AccountStatus
+	if siteOption.MinAccountRatio <= 0 {
+		return
+	}
+	if status == nil || !status.IsOk() {
+		guard.noAdd = true
+		guard.msg = fmt.Sprintf("site %s account ratio can not be verified: do not add new torrents", siteOption.Site)
+		return
+	}
+	if status.UserDownloaded <= 0 {
+		return
+	}
+	inflight := int64(0)
+	for _, torrent := range clientTorrents {
+		if torrent.GetSiteFromTag() == siteOption.Site && !torrent.IsComplete() {
+			inflight += torrent.Size - torrent.SizeCompleted
+		}
+	}
+	ratio := float64(status.UserUploaded) / float64(status.UserDownloaded)
+	projectedRatio := float64(status.UserUploaded) / float64(status.UserDownloaded+inflight)
+	if projectedRatio >= siteOption.MinAccountRatio {
+		return
+	}
+	guard.noAdd = true
+	guard.critical = projectedRatio < siteOption.MinAccountRatio*ACCOUNT_RATIO_CRITICAL_FACTOR
+	guard.msg = fmt.Sprintf("site %s account ratio %.3f (projected %.3f) is below min %.3f: do not add new torrents",
+		siteOption.Site, ratio, projectedRatio, siteOption.MinAccountRatio)
+	if guard.critical {
+		guard.msg += "; delete incomplete torrents that are no longer free"
+	}
+	return
+}
+
+// Check whether a client torrent is (still) free to download.
+// If the torrent is in current site torrents list, trust it's discount there (which may have been removed early);
+// otherwise it's considered free unless it has a known discount end time that has passed.
+func isTorrentFree(torrent *client.Torrent, siteTorrent *site.Torrent, now int64) bool {
+	if siteTorrent != nil {
+		return siteTorrent.DownloadMultiplier == 0 &&
+			(siteTorrent.DiscountEndTime <= 0 || siteTorrent.DiscountEndTime > now)
+	}
+	return torrent.Meta["dcet"] <= 0 || torrent.Meta["dcet"] > now
+}
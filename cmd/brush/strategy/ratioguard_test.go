@@ -0,0 +1,126 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/site"
+)
+
+func TestCheckAccountRatio(t *testing.T) {
+	incomplete := func(sitename string, size int64, completed int64) *client.Torrent {
+		return &client.Torrent{Tags: []string{"site:" + sitename}, Size: size, SizeCompleted: completed}
+	}
+	tests := []struct {
+		desc           string
+		minRatio       float64
+		status         *site.Status
+		clientTorrents []*client.Torrent
+		wantNoAdd      bool
+		wantCritical   bool
+	}{
+		{
+			desc:     "disabled",
+			minRatio: 0,
+			status:   &site.Status{UserUploaded: 10, UserDownloaded: 100},
+		},
+		{
+			desc:     "disabled without account status",
+			minRatio: 0,
+		},
+		{
+			desc:      "no account status",
+			minRatio:  1,
+			wantNoAdd: true,
+		},
+		{
+			desc:      "invalid account status",
+			minRatio:  1,
+			status:    &site.Status{},
+			wantNoAdd: true,
+		},
+		{
+			desc:     "no downloaded",
+			minRatio: 1,
+			status:   &site.Status{UserName: "test", UserUploaded: 0, UserDownloaded: 0},
+		},
+		{
+			desc:     "ratio above min",
+			minRatio: 1,
+			status:   &site.Status{UserUploaded: 200, UserDownloaded: 100},
+		},
+		{
+			desc:      "ratio below min",
+			minRatio:  1,
+			status:    &site.Status{UserUploaded: 95, UserDownloaded: 100},
+			wantNoAdd: true,
+		},
+		{
+			desc:         "ratio below critical",
+			minRatio:     1,
+			status:       &site.Status{UserUploaded: 85, UserDownloaded: 100},
+			wantNoAdd:    true,
+			wantCritical: true,
+		},
+		{
+			desc:           "projected ratio below min",
+			minRatio:       1,
+			status:         &site.Status{UserUploaded: 105, UserDownloaded: 100},
+			clientTorrents: []*client.Torrent{incomplete("test", 20, 10)},
+			wantNoAdd:      true,
+		},
+		{
+			desc:           "projected ratio below critical",
+			minRatio:       1,
+			status:         &site.Status{UserUploaded: 105, UserDownloaded: 100},
+			clientTorrents: []*client.Torrent{incomplete("test", 50, 0)},
+			wantNoAdd:      true,
+			wantCritical:   true,
+		},
+		{
+			desc:     "other site and complete torrents are not counted",
+			minRatio: 1,
+			status:   &site.Status{UserUploaded: 105, UserDownloaded: 100},
+			clientTorrents: []*client.Torrent{
+				incomplete("other", 50, 0),
+				incomplete("test", 50, 50),
+			},
+		},
+	}
+	for _, tt := range tests {
+		siteOption := &BrushSiteOptionStruct{Site: "test", MinAccountRatio: tt.minRatio, AccountStatus: tt.status}
+		guard := checkAccountRatio(tt.clientTorrents, siteOption)
+		if guard.noAdd != tt.wantNoAdd || guard.critical != tt.wantCritical {
+			t.Errorf("%s: got noAdd=%t critical=%t, want noAdd=%t critical=%t",
+				tt.desc, guard.noAdd, guard.critical, tt.wantNoAdd, tt.wantCritical)
+		}
+		if (guard.msg != "") != tt.wantNoAdd {
+			t.Errorf("%s: unexpected msg %q", tt.desc, guard.msg)
+		}
+	}
+}
+
+func TestIsTorrentFree(t *testing.T) {
+	const now = 10000
+	tests := []struct {
+		desc        string
+		dcet        int64
+		siteTorrent *site.Torrent
+		want        bool
+	}{
+		{"no discount info", 0, nil, true},
+		{"free torrent without end time missing from site list", -1, nil, true},
+		{"discount expired", now - 1, nil, false},
+		{"discount active", now + 3600, nil, true},
+		{"site torrent free forever", 0, &site.Torrent{DownloadMultiplier: 0}, true},
+		{"site torrent free", 0, &site.Torrent{DownloadMultiplier: 0, DiscountEndTime: now + 3600}, true},
+		{"site torrent free expired", now + 3600, &site.Torrent{DownloadMultiplier: 0, DiscountEndTime: now - 1}, false},
+		{"site discount removed early", now + 3600, &site.Torrent{DownloadMultiplier: 1}, false},
+	}
+	for _, tt := range tests {
+		torrent := &client.Torrent{Meta: map[string]int64{"dcet": tt.dcet}}
+		if got := isTorrentFree(torrent, tt.siteTorrent, now); got != tt.want {
+			t.Errorf("%s: got %t, want %t", tt.desc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package strategy
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+)
+
+// Name of the built-in brush algorithm, which is always applied before user defined rules.
+const DEFAULT_RULE = "default"
+
+// User defined brush rule, parsed from config.BrushRuleConfigStruct. Nil expression is not set.
+type BrushRule struct {
+	Name   string
+	Accept *template.Template
+	Reject *template.Template
+	Score  *template.Template
+	Delete *template.Template
+}
+
+// Data of site torrent rule expressions. Fields & methods of site.Torrent can be used directly,
+// e.g. ".Seeders", `.MatchFilter "foo"`.
+type SiteTorrentRuleContext struct {
+	*site.Torrent
+	Now   int64
+	Age   int64   // seconds since torrent was published
+	Score float64 // score of default algorithm and previous rules. 0 means rejected
+}
+
+// Data of client torrent rule expressions. Fields & methods of client.Torrent can be used directly,
+// e.g. ".UploadSpeed", ".IsComplete", `.Meta.stt`.
+type ClientTorrentRuleContext struct {
+	*client.Torrent
+	Now int64
+	Age int64 // seconds since torrent was added to client
+}
+
+func ParseBrushRules(ruleConfigs []*config.BrushRuleConfigStruct) ([]*BrushRule, error) {
+	var rules []*BrushRule
+	for i, ruleConfig := range ruleConfigs {
+		rule := &BrushRule{Name: ruleConfig.Name}
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("#%d", i+1)
+		}
+		for _, expression := range []struct {
+			tpl  **template.Template
+			name string
+			expr string
+		}{
+			{&rule.Accept, "accept", ruleConfig.Accept},
+			{&rule.Reject, "reject", ruleConfig.Reject},
+			{&rule.Score, "score", ruleConfig.Score},
+			{&rule.Delete, "delete", ruleConfig.Delete},
+		} {
+			if strings.TrimSpace(expression.expr) == "" {
+				continue
+			}
+			tpl, err := template.New(rule.Name + "." + expression.name).Funcs(sprig.FuncMap()).
+				Parse("{{" + expression.expr + "}}")
+			if err != nil {
+				return nil, fmt.Errorf("invalid brush rule %s %s expression: %w", rule.Name, expression.name, err)
+			}
+			*expression.tpl = tpl
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Apply rules to site torrent. Return the final score and the name of the rule that decided it.
+// Rules are applied in order, each one sees the score of previous ones; a rejection stops the evaluation.
+// A torrent already rejected by the default algorithm (score 0) is not evaluated.
+// Expression evaluation errors are logged and the failed expression is ignored.
+func applySiteTorrentRules(rules []*BrushRule, siteTorrent *site.Torrent, now int64, score float64) (
+	float64, string) {
+	decidedRule := DEFAULT_RULE
+	if score <= 0 {
+		return score, decidedRule
+	}
+	for _, rule := range rules {
+		data := &SiteTorrentRuleContext{Torrent: siteTorrent, Now: now, Age: now - siteTorrent.Time, Score: score}
+		if rule.Accept != nil {
+			if accepted, err := evalBool(rule.Accept, data); err != nil {
+				log.Warnf("brush rule %s: %v", rule.Name, err)
+			} else if !accepted {
+				return 0, rule.Name
+			}
+		}
+		if rule.Reject != nil {
+			if rejected, err := evalBool(rule.Reject, data); err != nil {
+				log.Warnf("brush rule %s: %v", rule.Name, err)
+			} else if rejected {
+				return 0, rule.Name
+			}
+		}
+		if rule.Score != nil {
+			if value, err := evalFloat(rule.Score, data); err != nil {
+				log.Warnf("brush rule %s: %v", rule.Name, err)
+			} else {
+				score = max(value, 0)
+				decidedRule = rule.Name
+			}
+		}
+	}
+	return score, decidedRule
+}
+
+// Return the first rule whose delete condition matches the client torrent, or nil if none matches.
+func matchDeleteRule(rules []*BrushRule, torrent *client.Torrent, now int64) *BrushRule {
+	for _, rule := range rules {
+		if rule.Delete == nil {
+			continue
+		}
+		data := &ClientTorrentRuleContext{Torrent: torrent, Now: now, Age: now - torrent.Atime}
+		if matched, err := evalBool(rule.Delete, data); err != nil {
+			log.Warnf("brush rule %s: %v", rule.Name, err)
+		} else if matched {
+			return rule
+		}
+	}
+	return nil
+}
+
+func evalExpression(tpl *template.Template, data any) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func evalBool(tpl *template.Template, data any) (bool, error) {
+	output, err := evalExpression(tpl, data)
+	if err != nil {
+		return false, err
+	}
+	value, err := strconv.ParseBool(output)
+	if err != nil {
+		return false, fmt.Errorf("%s expression result %q is not a bool", tpl.Name(), output)
+	}
+	return value, nil
+}
+
+func evalFloat(tpl *template.Template, data any) (float64, error) {
+	output, err := evalExpression(tpl, data)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseFloat(output, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s expression result %q is not a number", tpl.Name(), output)
+	}
+	return value, nil
+}
@@ -0,0 +1,161 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+)
+
+func TestParseBrushRules(t *testing.T) {
+	tests := []struct {
+		desc      string
+		configs   []*config.BrushRuleConfigStruct
+		wantNames []string
+		wantErr   bool
+	}{
+		{"no rules", nil, nil, false},
+		{
+			"named and unnamed rules",
+			[]*config.BrushRuleConfigStruct{
+				{Name: "big", Reject: "gt .Size 1073741824"},
+				{Score: "mulf .Score 2"},
+			},
+			[]string{"big", "#2"},
+			false,
+		},
+		{
+			"blank expressions are not set",
+			[]*config.BrushRuleConfigStruct{{Name: "empty", Accept: " ", Delete: ""}},
+			[]string{"empty"},
+			false,
+		},
+		{
+			"invalid expression",
+			[]*config.BrushRuleConfigStruct{{Name: "bad", Accept: "gt .Size"}, {Delete: "and ("}},
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		rules, err := ParseBrushRules(tt.configs)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error", tt.desc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+			continue
+		}
+		if len(rules) != len(tt.wantNames) {
+			t.Errorf("%s: got %d rules, want %d", tt.desc, len(rules), len(tt.wantNames))
+			continue
+		}
+		for i, rule := range rules {
+			if rule.Name != tt.wantNames[i] {
+				t.Errorf("%s: rule %d: got name %q, want %q", tt.desc, i, rule.Name, tt.wantNames[i])
+			}
+		}
+	}
+
+	rules, _ := ParseBrushRules([]*config.BrushRuleConfigStruct{{Accept: " ", Score: ".Score"}})
+	if rules[0].Accept != nil || rules[0].Reject != nil || rules[0].Delete != nil || rules[0].Score == nil {
+		t.Errorf("only non-blank expressions should be set: %+v", rules[0])
+	}
+}
+
+func TestApplySiteTorrentRules(t *testing.T) {
+	const now = 100000
+	parse := func(configs ...*config.BrushRuleConfigStruct) []*BrushRule {
+		rules, err := ParseBrushRules(configs)
+		if err != nil {
+			t.Fatalf("failed to parse rules: %v", err)
+		}
+		return rules
+	}
+	siteTorrent := &site.Torrent{Name: "Test", Size: 10 * GiB, Leechers: 100, Time: now - 600}
+	tests := []struct {
+		desc      string
+		rules     []*BrushRule
+		score     float64
+		wantScore float64
+		wantRule  string
+	}{
+		{"no rules", nil, 10, 10, DEFAULT_RULE},
+		{"accept passes", parse(&config.BrushRuleConfigStruct{Name: "a", Accept: "gt .Leechers 50"}), 10, 10,
+			DEFAULT_RULE},
+		{"accept fails", parse(&config.BrushRuleConfigStruct{Name: "a", Accept: "gt .Leechers 500"}), 10, 0, "a"},
+		{"reject matches", parse(&config.BrushRuleConfigStruct{Name: "r", Reject: "lt .Age 3600"}), 10, 0, "r"},
+		{"score", parse(&config.BrushRuleConfigStruct{Name: "s", Score: "mulf .Score 2"}), 10, 20, "s"},
+		{"negative score", parse(&config.BrushRuleConfigStruct{Name: "s", Score: "-5"}), 10, 0, "s"},
+		{
+			"rules are chained",
+			parse(
+				&config.BrushRuleConfigStruct{Name: "s1", Score: "addf .Score 5"},
+				&config.BrushRuleConfigStruct{Name: "s2", Score: "mulf .Score 2"},
+			),
+			10, 30, "s2",
+		},
+		{
+			"rejection stops evaluation",
+			parse(
+				&config.BrushRuleConfigStruct{Name: "r", Reject: "true"},
+				&config.BrushRuleConfigStruct{Name: "s", Score: "100"},
+			),
+			10, 0, "r",
+		},
+		{"rejected torrent is not revived", parse(&config.BrushRuleConfigStruct{Name: "s", Score: "100"}), 0, 0,
+			DEFAULT_RULE},
+		{"invalid result is ignored", parse(&config.BrushRuleConfigStruct{Name: "s", Score: `"abc"`}), 10, 10,
+			DEFAULT_RULE},
+	}
+	for _, tt := range tests {
+		score, rule := applySiteTorrentRules(tt.rules, siteTorrent, now, tt.score)
+		if score != tt.wantScore || rule != tt.wantRule {
+			t.Errorf("%s: got score %f rule %q, want %f %q", tt.desc, score, rule, tt.wantScore, tt.wantRule)
+		}
+	}
+}
+
+func TestMatchDeleteRule(t *testing.T) {
+	const now = 100000
+	rules, err := ParseBrushRules([]*config.BrushRuleConfigStruct{
+		{Name: "score only", Score: ".Score"},
+		{Name: "old incomplete", Delete: "and (not .IsComplete) (gt .Age 86400)"},
+		{Name: "stalled", Delete: "gt (index .Meta \"stt\") 0"},
+		{Name: "invalid", Delete: `"abc"`},
+	})
+	if err != nil {
+		t.Fatalf("failed to parse rules: %v", err)
+	}
+	tests := []struct {
+		desc    string
+		torrent *client.Torrent
+		want    string // name of matched rule, "" for none
+	}{
+		{"new torrent", &client.Torrent{Atime: now - 60, Size: 100, Meta: map[string]int64{}}, ""},
+		{"old incomplete torrent", &client.Torrent{Atime: now - 90000, Size: 100, Meta: map[string]int64{}},
+			"old incomplete"},
+		{"old complete torrent", &client.Torrent{Atime: now - 90000, Size: 100, SizeCompleted: 100,
+			Meta: map[string]int64{}}, ""},
+		{"first matched rule", &client.Torrent{Atime: now - 90000, Size: 100, Meta: map[string]int64{"stt": 1}},
+			"old incomplete"},
+		{"meta", &client.Torrent{Atime: now - 60, Size: 100, Meta: map[string]int64{"stt": 1}}, "stalled"},
+	}
+	for _, tt := range tests {
+		rule := matchDeleteRule(rules, tt.torrent, now)
+		got := ""
+		if rule != nil {
+			got = rule.Name
+		}
+		if got != tt.want {
+			t.Errorf("%s: got rule %q, want %q", tt.desc, got, tt.want)
+		}
+	}
+	if rule := matchDeleteRule(nil, &client.Torrent{}, now); rule != nil {
+		t.Errorf("no rules: got rule %q, want nil", rule.Name)
+	}
+}
@@ -0,0 +1,70 @@
+package strategy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/site"
+)
+
+// Snapshot of the inputs of one Decide call (a site of a brush run).
+// Recorded by "brush --snapshot-file" and replayed by "brush simulate".
+type Snapshot struct {
+	Time           int64
+	Client         string
+	Site           string
+	ClientStatus   *client.Status
+	ClientTorrents []*client.Torrent
+	SiteTorrents   []*site.Torrent
+	SiteOption     *BrushSiteOptionStruct
+	ClientOption   *BrushClientOptionStruct
+	// Rules of options are parsed templates which can not be serialized, so the rule configs are saved instead.
+	SiteRules   []*config.BrushRuleConfigStruct `json:",omitempty"`
+	ClientRules []*config.BrushRuleConfigStruct `json:",omitempty"`
+}
+
+// Append snapshot to file, one json object per line.
+func AppendSnapshot(file string, snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, constants.PERM)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Load all snapshots from file, in the order they were recorded.
+func LoadSnapshots(file string) ([]*Snapshot, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var snapshots []*Snapshot
+	decoder := json.NewDecoder(f)
+	for {
+		snapshot := &Snapshot{}
+		if err := decoder.Decode(snapshot); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("invalid snapshot #%d: %w", len(snapshots)+1, err)
+		}
+		if snapshot.ClientStatus == nil || snapshot.SiteOption == nil || snapshot.ClientOption == nil {
+			return nil, fmt.Errorf("invalid snapshot #%d: incomplete data", len(snapshots)+1)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,87 @@
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/site"
+)
+
+func TestSnapshots(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "snapshots.jsonl")
+	for i, name := range []string{"a", "b"} {
+		err := AppendSnapshot(file, &Snapshot{
+			Time:           int64(1000 + i*600),
+			Client:         "local",
+			Site:           name,
+			ClientStatus:   &client.Status{FreeSpaceOnDisk: 100 * GiB, UploadSpeedLimit: 10 * MiB},
+			ClientTorrents: []*client.Torrent{{InfoHash: "aaa", Name: "A", Meta: map[string]int64{"dcet": 2000}}},
+			SiteTorrents:   []*site.Torrent{{Name: "A", Size: GiB, Leechers: 10}},
+			SiteOption:     &BrushSiteOptionStruct{Site: name, Now: int64(1000 + i*600), Excludes: []string{"x"}},
+			ClientOption:   &BrushClientOptionStruct{MaxTorrents: 100, MinRatio: 0.2},
+			SiteRules:      []*config.BrushRuleConfigStruct{{Name: "r", Reject: "lt .Leechers 5"}},
+		})
+		if err != nil {
+			t.Fatalf("failed to append snapshot %d: %v", i, err)
+		}
+	}
+
+	snapshots, err := LoadSnapshots(file)
+	if err != nil {
+		t.Fatalf("failed to load snapshots: %v", err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("got %d snapshots, want 2", len(snapshots))
+	}
+	for i, snapshot := range snapshots {
+		if snapshot.Time != int64(1000+i*600) || snapshot.Client != "local" || snapshot.Site != []string{"a", "b"}[i] {
+			t.Errorf("snapshot %d: unexpected time / client / site: %d %s %s", i,
+				snapshot.Time, snapshot.Client, snapshot.Site)
+		}
+		if snapshot.ClientStatus.FreeSpaceOnDisk != 100*GiB || snapshot.ClientOption.MaxTorrents != 100 ||
+			snapshot.SiteOption.Site != snapshot.Site || len(snapshot.SiteOption.Excludes) != 1 {
+			t.Errorf("snapshot %d: unexpected status / options", i)
+		}
+		if len(snapshot.ClientTorrents) != 1 || snapshot.ClientTorrents[0].Meta["dcet"] != 2000 ||
+			len(snapshot.SiteTorrents) != 1 || snapshot.SiteTorrents[0].Leechers != 10 {
+			t.Errorf("snapshot %d: unexpected torrents", i)
+		}
+		if len(snapshot.SiteRules) != 1 || snapshot.SiteRules[0].Reject != "lt .Leechers 5" ||
+			snapshot.ClientRules != nil {
+			t.Errorf("snapshot %d: unexpected rules %v / %v", i, snapshot.SiteRules, snapshot.ClientRules)
+		}
+	}
+
+	tests := []struct {
+		desc    string
+		content string
+		wantErr string
+	}{
+		{"empty file", "", ""},
+		{
+			"invalid json",
+			`{"Time":1,"ClientStatus":{},"SiteOption":{},"ClientOption":{}}` + "\n{",
+			"invalid snapshot #2",
+		},
+		{"incomplete data", `{"Time":1,"ClientStatus":{},"SiteOption":{}}`, "incomplete data"},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(t.TempDir(), "snapshots.jsonl")
+		if err := os.WriteFile(file, []byte(tt.content), constants.PERM); err != nil {
+			t.Fatalf("%s: failed to write file: %v", tt.desc, err)
+		}
+		_, err := LoadSnapshots(file)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.desc, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: got error %v, want %q", tt.desc, err, tt.wantErr)
+		}
+	}
+}
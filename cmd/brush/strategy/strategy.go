@@ -3,11 +3,13 @@ package strategy
 import (
 	"fmt"
 	"math"
+	"slices"
 	"sort"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/config"
 	"github.com/sagan/ptool/site"
 	"github.com/sagan/ptool/util"
 )
@@ -43,6 +45,10 @@ type BrushSiteOptionStruct struct {
 	ExcludeTags             []string
 	AllowAddTorrents        int64
 	AcceptAnyFree           bool
+	Site                    string
+	Rules                   []*BrushRule `json:"-"` // site rules. Delete conditions only apply to torrents of this site
+	MinAccountRatio         float64      // 0: do not check site account ratio
+	AccountStatus           *site.Status `json:",omitempty"` // nil: unknown
 }
 
 type BrushClientOptionStruct struct {
@@ -52,11 +58,15 @@ type BrushClientOptionStruct struct {
 	MaxTorrents             int64
 	MinRatio                float64
 	DefaultUploadSpeedLimit int64
+	Rules                   []*BrushRule `json:"-"`
+	// If not empty, new torrents are added to the one with most headroom of free space
+	SavePaths []*BrushSavePathStruct `json:",omitempty"`
 }
 
 type AlgorithmAddTorrent struct {
 	DownloadUrl string
 	Name        string
+	SavePath    string // empty: client default save path
 	Meta        map[string]int64
 	Msg         string
 }
@@ -143,14 +153,18 @@ func isTorrentStalled(torrent *client.Torrent) bool {
  *   * Use the current seeders / leechers info of torrent when make decisions
  */
 func Decide(clientStatus *client.Status, clientTorrents []*client.Torrent, siteTorrents []*site.Torrent,
-	siteOption *BrushSiteOptionStruct, clientOption *BrushClientOptionStruct) (result *AlgorithmResult) {
+	siteOption *BrushSiteOptionStruct, clientOption *BrushClientOptionStruct,
+	histories map[string]TorrentHistory) (result *AlgorithmResult) {
 	result = &AlgorithmResult{}
+	accountRatioGuard := checkAccountRatio(clientTorrents, siteOption)
+	result.Msg = accountRatioGuard.msg
 
 	cntTorrents := int64(len(clientTorrents))
 	cntDownloadingTorrents := int64(0)
-	freespace := clientStatus.FreeSpaceOnDisk
-	freespaceChange := int64(0)
-	freespaceTarget := min(clientOption.MinDiskSpace*2, clientOption.MinDiskSpace+DELETE_TORRENTS_FREE_DISK_SPACE_TIER)
+	diskSpaces := getDiskSpaces(clientStatus, clientOption)
+	torrentDiskSpace := func(torrent *client.Torrent) *diskSpaceStruct {
+		return getTorrentDiskSpace(diskSpaces, clientOption.SavePaths, torrent)
+	}
 	estimateUploadSpeed := clientStatus.UploadSpeed
 
 	var candidateTorrents []candidateTorrentStruct
@@ -175,8 +189,9 @@ func Decide(clientStatus *client.Status, clientTorrents []*client.Torrent, siteT
 		siteTorrentsMap[siteTorrent.InfoHash] = siteTorrents[i]
 	}
 
+	rules := append(slices.Clone(siteOption.Rules), clientOption.Rules...)
 	for _, siteTorrent := range siteTorrents {
-		score, predictionUploadSpeed, _ := RateSiteTorrent(siteTorrent, siteOption)
+		score, predictionUploadSpeed, _ := rateSiteTorrent(siteTorrent, siteOption, rules)
 		if score > 0 {
 			candidateTorrent := candidateTorrentStruct{
 				Name:                  siteTorrent.Name,
@@ -196,6 +211,40 @@ func Decide(clientStatus *client.Status, clientTorrents []*client.Torrent, siteT
 		return candidateTorrents[i].Score > candidateTorrents[j].Score
 	})
 
+	// stall slow torrent if it's upload / download ratio is too low, and add it to delete candidates.
+	// futureValue: predicted upload speed of torrent
+	markSlowTorrent := func(torrent *client.Torrent, futureValue int64, msg string) {
+		if canStallTorrent(torrent) &&
+			torrent.DownloadSpeed >= RATIO_CHECK_MIN_DOWNLOAD_SPEED &&
+			float64(torrent.UploadSpeed)/float64(torrent.DownloadSpeed) < clientOption.MinRatio &&
+			siteOption.Now-torrent.Atime >= NEW_TORRENTS_STALL_EXEMPTION_TIMESPAN {
+			meta := util.CopyMap(torrent.Meta, true)
+			meta["stt"] = siteOption.Now
+			stallTorrents = append(stallTorrents, AlgorithmModifyTorrent{
+				InfoHash: torrent.InfoHash,
+				Name:     torrent.Name,
+				Msg:      "low upload / download ratio",
+				Meta:     meta,
+			})
+			clientTorrentsMap[torrent.InfoHash].StallFlag = true
+		}
+		score := -float64(futureValue)
+		if torrent.Ctime <= 0 {
+			if torrent.Meta["stt"] > 0 {
+				score += float64(siteOption.Now) - float64(torrent.Meta["stt"])
+			}
+		} else {
+			score += math.Min(float64(siteOption.Now-torrent.Ctime), 86400)
+		}
+		deleteCandidateTorrents = append(deleteCandidateTorrents, candidateClientTorrentStruct{
+			InfoHash:    torrent.InfoHash,
+			Score:       score,
+			FutureValue: futureValue,
+			Msg:         msg,
+		})
+		clientTorrentsMap[torrent.InfoHash].DeleteCandidateFlag = true
+	}
+
 	// mark torrents
 	for _, torrent := range clientTorrents {
 		if countAsDownloading(torrent, siteOption.Now) {
@@ -217,13 +266,43 @@ func Decide(clientStatus *client.Status, clientTorrents []*client.Torrent, siteT
 			}
 		}
 
+		// delete incomplete torrents of the site that are no longer free if account ratio is critically low
+		if accountRatioGuard.critical && torrent.GetSiteFromTag() == siteOption.Site && !torrent.IsComplete() &&
+			!isTorrentFree(torrent, siteTorrentsMap[torrent.InfoHash], siteOption.Now) {
+			deleteCandidateTorrents = append(deleteCandidateTorrents, candidateClientTorrentStruct{
+				InfoHash:    torrent.InfoHash,
+				Score:       DELETE_TORRENT_IMMEDIATELY_SCORE,
+				FutureValue: 0,
+				Msg:         "discount expired while site account ratio is too low",
+			})
+			clientTorrentsMap[torrent.InfoHash].DeleteCandidateFlag = true
+			continue
+		}
+
 		// skip new added torrents
 		if siteOption.Now-torrent.Atime <= NEW_TORRENTS_TIMESPAN {
 			continue
 		}
 
+		// user defined delete conditions
+		deleteRules := clientOption.Rules
+		if torrent.GetSiteFromTag() == siteOption.Site {
+			deleteRules = rules
+		}
+		if rule := matchDeleteRule(deleteRules, torrent, siteOption.Now); rule != nil {
+			deleteCandidateTorrents = append(deleteCandidateTorrents, candidateClientTorrentStruct{
+				InfoHash:    torrent.InfoHash,
+				Score:       DELETE_TORRENT_IMMEDIATELY_SCORE,
+				FutureValue: 0,
+				Msg:         fmt.Sprintf("brush rule %s delete condition matches", rule.Name),
+			})
+			clientTorrentsMap[torrent.InfoHash].DeleteCandidateFlag = true
+			continue
+		}
+
 		if torrent.State == "error" && (torrent.UploadSpeed < clientOption.SlowUploadSpeedTier ||
-			torrent.UploadSpeed < clientOption.SlowUploadSpeedTier*2 && freespace == 0) &&
+			torrent.UploadSpeed < clientOption.SlowUploadSpeedTier*2 &&
+				torrentDiskSpace(torrent).freespace == 0) &&
 			len(candidateTorrents) > 0 {
 			deleteCandidateTorrents = append(deleteCandidateTorrents, candidateClientTorrentStruct{
 				InfoHash:    torrent.InfoHash,
@@ -243,41 +322,20 @@ func Decide(clientStatus *client.Status, clientTorrents []*client.Torrent, siteT
 				clientTorrentsMap[torrent.InfoHash].DeleteCandidateFlag = true
 			}
 		} else if torrent.UploadSpeed < clientOption.SlowUploadSpeedTier {
-			// check slow torrents, add it to watch list first time and mark as deleteCandidate second time
-			if torrent.Meta["sct"] > 0 { // second encounter on slow torrent
+			// check slow torrents using the moving average upload speed of history if available.
+			// Otherwise add it to watch list first time and mark as deleteCandidate second time
+			history := histories[torrent.InfoHash]
+			if averageUploadSpeed, ok := history.AverageUploadSpeed(SLOW_TORRENTS_CHECK_TIMESPAN); ok {
+				if averageUploadSpeed < clientOption.SlowUploadSpeedTier {
+					futureValue, _ := history.PredictUploadSpeed()
+					markSlowTorrent(torrent, futureValue, "slow average uploading speed")
+				}
+			} else if torrent.Meta["sct"] > 0 { // second encounter on slow torrent
 				if siteOption.Now-torrent.Meta["sct"] >= SLOW_TORRENTS_CHECK_TIMESPAN {
 					averageUploadSpeedSinceSct := (torrent.Uploaded - torrent.Meta["sctu"]) /
 						(siteOption.Now - torrent.Meta["sct"])
 					if averageUploadSpeedSinceSct < clientOption.SlowUploadSpeedTier {
-						if canStallTorrent(torrent) &&
-							torrent.DownloadSpeed >= RATIO_CHECK_MIN_DOWNLOAD_SPEED &&
-							float64(torrent.UploadSpeed)/float64(torrent.DownloadSpeed) < clientOption.MinRatio &&
-							siteOption.Now-torrent.Atime >= NEW_TORRENTS_STALL_EXEMPTION_TIMESPAN {
-							meta := util.CopyMap(torrent.Meta, true)
-							meta["stt"] = siteOption.Now
-							stallTorrents = append(stallTorrents, AlgorithmModifyTorrent{
-								InfoHash: torrent.InfoHash,
-								Name:     torrent.Name,
-								Msg:      "low upload / download ratio",
-								Meta:     meta,
-							})
-							clientTorrentsMap[torrent.InfoHash].StallFlag = true
-						}
-						score := -float64(torrent.UploadSpeed)
-						if torrent.Ctime <= 0 {
-							if torrent.Meta["stt"] > 0 {
-								score += float64(siteOption.Now) - float64(torrent.Meta["stt"])
-							}
-						} else {
-							score += math.Min(float64(siteOption.Now-torrent.Ctime), 86400)
-						}
-						deleteCandidateTorrents = append(deleteCandidateTorrents, candidateClientTorrentStruct{
-							InfoHash:    torrent.InfoHash,
-							Score:       score,
-							FutureValue: torrent.UploadSpeed,
-							Msg:         "slow uploading speed",
-						})
-						clientTorrentsMap[torrent.InfoHash].DeleteCandidateFlag = true
+						markSlowTorrent(torrent, torrent.UploadSpeed, "slow uploading speed")
 					} else {
 						meta := util.CopyMap(torrent.Meta, true)
 						meta["sct"] = siteOption.Now
@@ -325,8 +383,7 @@ func Decide(clientStatus *client.Status, clientTorrents []*client.Torrent, siteT
 	for _, deleteTorrent := range deleteCandidateTorrents {
 		torrent := clientTorrentsMap[deleteTorrent.InfoHash].Torrent
 		shouldDelete := false
-		if deleteTorrent.Score >= DELETE_TORRENT_IMMEDIATELY_SCORE ||
-			(freespace >= 0 && freespace <= clientOption.MinDiskSpace && freespace+freespaceChange <= freespaceTarget) {
+		if deleteTorrent.Score >= DELETE_TORRENT_IMMEDIATELY_SCORE || torrentDiskSpace(torrent).shouldFreeUp() {
 			shouldDelete = true
 		} else if torrent.Ctime <= 0 &&
 			torrent.Meta["stt"] > 0 &&
@@ -341,7 +398,7 @@ func Decide(clientStatus *client.Status, clientTorrents []*client.Torrent, siteT
 			Name:     torrent.Name,
 			Msg:      deleteTorrent.Msg,
 		})
-		freespaceChange += torrent.SizeCompleted
+		torrentDiskSpace(torrent).freespaceChange += torrent.SizeCompleted
 		estimateUploadSpeed -= torrent.UploadSpeed
 		clientTorrentsMap[torrent.InfoHash].DeleteFlag = true
 		if countAsDownloading(torrent, siteOption.Now) {
@@ -350,25 +407,27 @@ func Decide(clientStatus *client.Status, clientTorrents []*client.Torrent, siteT
 		cntTorrents--
 	}
 
-	// if still not enough free space, delete ALL stalled incomplete torrents
-	if freespace >= 0 && freespace <= clientOption.MinDiskSpace && freespace+freespaceChange <= freespaceTarget {
-		for _, torrent := range clientTorrents {
-			if clientTorrentsMap[torrent.InfoHash].DeleteFlag || !isTorrentStalled(torrent) {
-				continue
-			}
-			result.DeleteTorrents = append(result.DeleteTorrents, AlgorithmOperationTorrent{
-				InfoHash: torrent.InfoHash,
-				Name:     torrent.Name,
-				Msg:      "delete stalled incomplete torrents due to insufficient disk space",
-			})
-			freespaceChange += torrent.SizeCompleted
-			estimateUploadSpeed -= torrent.UploadSpeed
-			clientTorrentsMap[torrent.InfoHash].DeleteFlag = true
-			if countAsDownloading(torrent, siteOption.Now) {
-				cntDownloadingTorrents--
-			}
-			cntTorrents--
+	// if still not enough free space, delete ALL stalled incomplete torrents (of that path)
+	freeUpDiskSpaces := util.Filter(diskSpaces, func(ds *diskSpaceStruct) bool {
+		return ds.shouldFreeUp()
+	})
+	for _, torrent := range clientTorrents {
+		if clientTorrentsMap[torrent.InfoHash].DeleteFlag || !isTorrentStalled(torrent) ||
+			!slices.Contains(freeUpDiskSpaces, torrentDiskSpace(torrent)) {
+			continue
 		}
+		result.DeleteTorrents = append(result.DeleteTorrents, AlgorithmOperationTorrent{
+			InfoHash: torrent.InfoHash,
+			Name:     torrent.Name,
+			Msg:      "delete stalled incomplete torrents due to insufficient disk space",
+		})
+		torrentDiskSpace(torrent).freespaceChange += torrent.SizeCompleted
+		estimateUploadSpeed -= torrent.UploadSpeed
+		clientTorrentsMap[torrent.InfoHash].DeleteFlag = true
+		if countAsDownloading(torrent, siteOption.Now) {
+			cntDownloadingTorrents--
+		}
+		cntTorrents--
 	}
 
 	// delete torrents due to max brush torrents limit
@@ -384,7 +443,7 @@ func Decide(clientStatus *client.Status, clientTorrents []*client.Torrent, siteT
 				Name:     torrent.Name,
 				Msg:      deleteTorrent.Msg + " (delete due to max torrents limit)",
 			})
-			freespaceChange += torrent.SizeCompleted
+			torrentDiskSpace(torrent).freespaceChange += torrent.SizeCompleted
 			estimateUploadSpeed -= torrent.UploadSpeed
 			clientTorrentsMap[torrent.InfoHash].DeleteFlag = true
 			if countAsDownloading(torrent, siteOption.Now) {
@@ -398,42 +457,40 @@ func Decide(clientStatus *client.Status, clientTorrents []*client.Torrent, siteT
 		}
 	}
 
-	// if still not enough free space, mark ALL torrents as stall
-	if freespace >= 0 && freespace+freespaceChange < clientOption.MinDiskSpace {
-		for _, torrent := range clientTorrents {
-			if clientTorrentsMap[torrent.InfoHash].DeleteFlag || clientTorrentsMap[torrent.InfoHash].StallFlag {
-				continue
-			}
-			if canStallTorrent(torrent) {
-				meta := util.CopyMap(torrent.Meta, true)
-				meta["stt"] = siteOption.Now
-				stallTorrents = append(stallTorrents, AlgorithmModifyTorrent{
-					InfoHash: torrent.InfoHash,
-					Name:     torrent.Name,
-					Msg:      "stall all torrents due to insufficient free disk space",
-					Meta:     meta,
-				})
-				clientTorrentsMap[torrent.InfoHash].StallFlag = true
-			}
+	// if still not enough free space, mark ALL torrents (of that path) as stall
+	for _, torrent := range clientTorrents {
+		if clientTorrentsMap[torrent.InfoHash].DeleteFlag || clientTorrentsMap[torrent.InfoHash].StallFlag ||
+			!torrentDiskSpace(torrent).isInsufficient() {
+			continue
 		}
-	}
-
-	// mark torrents as resume
-	if freespace+freespaceChange >= max(clientOption.MinDiskSpace, RESUME_TORRENTS_FREE_DISK_SPACE_TIER) {
-		for _, torrent := range clientTorrents {
-			if torrent.State != "error" || torrent.UploadSpeed < clientOption.SlowUploadSpeedTier*4 ||
-				isTorrentStalled(torrent) || clientTorrentsMap[torrent.InfoHash].ResumeFlag {
-				continue
-			}
-			resumeTorrents = append(resumeTorrents, AlgorithmOperationTorrent{
+		if canStallTorrent(torrent) {
+			meta := util.CopyMap(torrent.Meta, true)
+			meta["stt"] = siteOption.Now
+			stallTorrents = append(stallTorrents, AlgorithmModifyTorrent{
 				InfoHash: torrent.InfoHash,
 				Name:     torrent.Name,
-				Msg:      "resume fast uploading errored torrent",
+				Msg:      "stall all torrents due to insufficient free disk space",
+				Meta:     meta,
 			})
-			clientTorrentsMap[torrent.InfoHash].ResumeFlag = true
+			clientTorrentsMap[torrent.InfoHash].StallFlag = true
 		}
 	}
 
+	// mark torrents as resume
+	for _, torrent := range clientTorrents {
+		if torrent.State != "error" || torrent.UploadSpeed < clientOption.SlowUploadSpeedTier*4 ||
+			isTorrentStalled(torrent) || clientTorrentsMap[torrent.InfoHash].ResumeFlag ||
+			!torrentDiskSpace(torrent).canResume() {
+			continue
+		}
+		resumeTorrents = append(resumeTorrents, AlgorithmOperationTorrent{
+			InfoHash: torrent.InfoHash,
+			Name:     torrent.Name,
+			Msg:      "resume fast uploading errored torrent",
+		})
+		clientTorrentsMap[torrent.InfoHash].ResumeFlag = true
+	}
+
 	// stall torrents
 	for _, stallTorrent := range stallTorrents {
 		if clientTorrentsMap[stallTorrent.InfoHash].DeleteFlag {
@@ -465,21 +522,23 @@ func Decide(clientStatus *client.Status, clientTorrents []*client.Torrent, siteT
 		result.ModifyTorrents = append(result.ModifyTorrents, modifyTorrent)
 	}
 
-	// add new torrents
-	if (freespace == -1 || freespace+freespaceChange > clientOption.MinDiskSpace) &&
-		cntTorrents <= clientOption.MaxTorrents {
+	// add new torrents, each to the save path with most headroom
+	if !accountRatioGuard.noAdd && getAddDiskSpace(diskSpaces) != nil && cntTorrents <= clientOption.MaxTorrents {
 		var added int64
 		for cntDownloadingTorrents < clientOption.MaxDownloadingTorrents &&
 			estimateUploadSpeed <= targetUploadSpeed*2 && len(candidateTorrents) > 0 &&
 			added < siteOption.AllowAddTorrents {
 			candidateTorrent := candidateTorrents[0]
 			candidateTorrents = candidateTorrents[1:]
+			ds := getAddDiskSpace(diskSpaces)
 			result.AddTorrents = append(result.AddTorrents, AlgorithmAddTorrent{
 				DownloadUrl: candidateTorrent.DownloadUrl,
 				Name:        candidateTorrent.Name,
+				SavePath:    ds.path,
 				Meta:        candidateTorrent.Meta,
 				Msg:         fmt.Sprintf("new torrrent of score %.0f", candidateTorrent.Score),
 			})
+			ds.addedSize += candidateTorrent.Size
 			added++
 			cntTorrents++
 			cntDownloadingTorrents++
@@ -487,33 +546,52 @@ func Decide(clientStatus *client.Status, clientTorrents []*client.Torrent, siteT
 		}
 	}
 
-	result.FreeSpaceChange = freespaceChange
+	for _, ds := range diskSpaces {
+		result.FreeSpaceChange += ds.freespaceChange
+	}
 
 	if cntTorrents <= clientOption.MaxTorrents &&
 		cntDownloadingTorrents < clientOption.MaxDownloadingTorrents &&
-		estimateUploadSpeed <= targetUploadSpeed*2 &&
-		(freespace == -1 || freespace+freespaceChange > clientOption.MinDiskSpace) {
+		estimateUploadSpeed <= targetUploadSpeed*2 && getAddDiskSpace(diskSpaces) != nil {
 		result.CanAddMore = true
 	}
 
 	return
 }
 
+// Rate site torrent using default algorithm and site rules of siteOption.
+// Return the score (0 means rejected), the prediction upload speed and the name of the rule that decided the score.
 func RateSiteTorrent(siteTorrent *site.Torrent, siteOption *BrushSiteOptionStruct) (
-	score float64, predictionUploadSpeed int64, note string) {
+	score float64, predictionUploadSpeed int64, rule string) {
+	return rateSiteTorrent(siteTorrent, siteOption, siteOption.Rules)
+}
+
+func rateSiteTorrent(siteTorrent *site.Torrent, siteOption *BrushSiteOptionStruct, rules []*BrushRule) (
+	score float64, predictionUploadSpeed int64, rule string) {
+	score, note := rateSiteTorrentDefault(siteTorrent, siteOption)
+	score, rule = applySiteTorrentRules(rules, siteTorrent, siteOption.Now, score)
 	if log.GetLevel() >= log.TraceLevel {
-		defer func() {
-			log.Tracef("rateSiteTorrent score=%0.0f name=%s, free=%t, rtime=%d, seeders=%d, leechers=%d, note=%s",
-				score,
-				siteTorrent.Name,
-				siteTorrent.DownloadMultiplier == 0,
-				siteOption.Now-siteTorrent.Time,
-				siteTorrent.Seeders,
-				siteTorrent.Leechers,
-				note,
-			)
-		}()
+		log.Tracef("rateSiteTorrent score=%0.0f name=%s, free=%t, rtime=%d, seeders=%d, leechers=%d, "+
+			"rule=%s, note=%s",
+			score,
+			siteTorrent.Name,
+			siteTorrent.DownloadMultiplier == 0,
+			siteOption.Now-siteTorrent.Time,
+			siteTorrent.Seeders,
+			siteTorrent.Leechers,
+			rule,
+			note,
+		)
+	}
+	if score > 0 {
+		predictionUploadSpeed = min(siteTorrent.Leechers*100*1024, siteOption.TorrentUploadSpeedLimit)
 	}
+	return
+}
+
+// The default (built-in) brush algorithm.
+func rateSiteTorrentDefault(siteTorrent *site.Torrent, siteOption *BrushSiteOptionStruct) (
+	score float64, note string) {
 	if siteTorrent.IsActive || siteTorrent.UploadMultiplier == 0 ||
 		(!siteOption.AllowHr && siteTorrent.HasHnR) ||
 		(!siteOption.AllowNoneFree && siteTorrent.DownloadMultiplier != 0) ||
@@ -549,11 +627,6 @@ func RateSiteTorrent(siteTorrent *site.Torrent, siteOption *BrushSiteOptionStruc
 		}
 	}
 
-	predictionUploadSpeed = siteTorrent.Leechers * 100 * 1024
-	if predictionUploadSpeed > siteOption.TorrentUploadSpeedLimit {
-		predictionUploadSpeed = siteOption.TorrentUploadSpeedLimit
-	}
-
 	if siteTorrent.Seeders <= 1 {
 		score = 50
 	} else if siteTorrent.Seeders <= 3 {
@@ -593,7 +666,11 @@ func RateSiteTorrent(siteTorrent *site.Torrent, siteOption *BrushSiteOptionStruc
 	return
 }
 
-func GetBrushSiteOptions(siteInstance site.Site, ts int64) *BrushSiteOptionStruct {
+func GetBrushSiteOptions(siteInstance site.Site, ts int64) (*BrushSiteOptionStruct, error) {
+	rules, err := ParseBrushRules(siteInstance.GetSiteConfig().BrushRules)
+	if err != nil {
+		return nil, fmt.Errorf("site %s: %w", siteInstance.GetName(), err)
+	}
 	return &BrushSiteOptionStruct{
 		TorrentMinSizeLimit:     siteInstance.GetSiteConfig().BrushTorrentMinSizeLimitValue,
 		TorrentMaxSizeLimit:     siteInstance.GetSiteConfig().BrushTorrentMaxSizeLimitValue,
@@ -606,10 +683,17 @@ func GetBrushSiteOptions(siteInstance site.Site, ts int64) *BrushSiteOptionStruc
 		Excludes:                siteInstance.GetSiteConfig().BrushExcludes,
 		ExcludeTags:             siteInstance.GetSiteConfig().BrushExcludeTags,
 		Now:                     ts,
-	}
+		Site:                    siteInstance.GetName(),
+		Rules:                   rules,
+		MinAccountRatio:         siteInstance.GetSiteConfig().BrushMinAccountRatio,
+	}, nil
 }
 
-func GetBrushClientOptions(clientInstance client.Client) *BrushClientOptionStruct {
+func GetBrushClientOptions(clientInstance client.Client) (*BrushClientOptionStruct, error) {
+	rules, err := ParseBrushRules(clientInstance.GetClientConfig().BrushRules)
+	if err != nil {
+		return nil, fmt.Errorf("client %s: %w", clientInstance.GetName(), err)
+	}
 	return &BrushClientOptionStruct{
 		MinDiskSpace:            clientInstance.GetClientConfig().BrushMinDiskSpaceValue,
 		SlowUploadSpeedTier:     clientInstance.GetClientConfig().BrushSlowUploadSpeedTierValue,
@@ -617,5 +701,14 @@ func GetBrushClientOptions(clientInstance client.Client) *BrushClientOptionStruc
 		MaxTorrents:             clientInstance.GetClientConfig().BrushMaxTorrents,
 		MinRatio:                clientInstance.GetClientConfig().BrushMinRatio,
 		DefaultUploadSpeedLimit: clientInstance.GetClientConfig().BrushDefaultUploadSpeedLimitValue,
-	}
+		Rules:                   rules,
+		SavePaths: util.Map(clientInstance.GetClientConfig().BrushSavePaths,
+			func(savePath *config.BrushSavePathConfigStruct) *BrushSavePathStruct {
+				return &BrushSavePathStruct{
+					Path:         savePath.Path,
+					MinDiskSpace: savePath.MinDiskSpaceValue,
+					FreeSpace:    -1,
+				}
+			}),
+	}, nil
 }
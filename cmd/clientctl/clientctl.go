@@ -47,12 +47,20 @@ var (
 		{"global_upload_speed", 1, true, false, "Current global upload speed (/s)"},
 		{"free_disk_space", 2, true, false, "Current free disk space of default save path"},
 		{"save_path", 0, false, false, "Default save path"},
+		{"alt_speed_enabled", 0, false, false, "Whether alternative speed limits are enabled (qb / tr only)"},
 		{"qb_*", 0, false, false, "The qBittorrent specific preferences. " +
 			"For full list see https://github.com/qbittorrent/qBittorrent/wiki/" +
 			"WebUI-API-(qBittorrent-4.1)#get-application-preferences . E.g. qb_start_paused_enabled"},
 		{"tr_*", 0, false, false, "The transmission specific preferences. " +
 			"For full list see https://github.com/transmission/transmission/blob/3.00/extras/rpc-spec.txt#L482 . " +
 			"Convert argument name to snake_case. E.g. tr_config_dir"},
+		{"de_*", 0, false, false, "The Deluge specific core config. " +
+			"For full list see https://deluge.readthedocs.io/en/latest/reference/api.html . E.g. de_max_connections_global"},
+		{"rt_*", 0, false, false, "The rTorrent specific settings. Use the rTorrent command name. " +
+			"For full list see https://rtorrent-docs.readthedocs.io/en/latest/cmd-ref.html . E.g. rt_network.max_open_files"},
+		{"mock_*", 0, false, false, "The mock client simulation parameters: mock_time (read-only virtual clock), " +
+			"mock_tick (advance virtual clock by seconds), mock_disk_size, mock_download_speed, mock_upload_speed, " +
+			"mock_time_scale"},
 	}
 	showRaw        = false
 	showValuesOnly = false
@@ -110,7 +118,10 @@ func clientctl(cmd *cobra.Command, args []string) error {
 		value := ""
 		var err error
 		if (clientInstance.GetClientConfig().Type == "qbittorrent" && strings.HasPrefix(variable, "qb_") ||
-			clientInstance.GetClientConfig().Type == "transmission" && strings.HasPrefix(variable, "tr_")) &&
+			clientInstance.GetClientConfig().Type == "transmission" && strings.HasPrefix(variable, "tr_") ||
+			clientInstance.GetClientConfig().Type == "deluge" && strings.HasPrefix(variable, "de_") ||
+			clientInstance.GetClientConfig().Type == "rtorrent" && strings.HasPrefix(variable, "rt_") ||
+			clientInstance.GetClientConfig().Type == "mock" && strings.HasPrefix(variable, "mock_")) &&
 			len(variable) > 3 {
 			if len(s) == 1 {
 				value, err = clientInstance.GetConfig(name)
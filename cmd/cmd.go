@@ -19,6 +19,7 @@ import (
 	"github.com/sagan/ptool/constants"
 	"github.com/sagan/ptool/flags"
 	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/site/tpl"
 	"github.com/sagan/ptool/util/osutil"
 )
 
@@ -77,6 +78,7 @@ func Execute() {
 		log.Debugf("ptool start: %v", os.Args)
 		log.Debugf("tty=%t, width=%d, height=%d", isTty, width, height)
 		log.Infof("config file: %s/%s", config.ConfigDir, config.ConfigFile)
+		tpl.LoadExternalSites()
 		if config.GlobalLock {
 			if config.LockFile != "" {
 				log.Fatalf("--lock and --global-lock flags are NOT compatible")
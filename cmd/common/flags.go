@@ -69,6 +69,7 @@ var pureFlags = []string{
 	"all",
 	"allow-filename-restricted-characters",
 	"allow-long-name",
+	"anti-leech",
 	"append",
 	"backup",
 	"bindable",
@@ -85,16 +86,19 @@ var pureFlags = []string{
 	"dry-run",
 	"force",
 	"force-local",
+	"folder",
 	"fork",
 	"free",
 	"help",
 	"include-downloaded",
+	"include-private",
 	"insecure",
 	"json",
 	"largest",
 	"latest",
 	"lock-or-exit",
 	"newest",
+	"no-check",
 	"no-clean",
 	"no-cover",
 	"no-hr",
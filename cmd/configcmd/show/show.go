@@ -12,7 +12,7 @@ import (
 )
 
 var command = &cobra.Command{
-	Use:   "show {site | client | group | cookiecloud_profile | alias}...",
+	Use:   "show {site | client | group | client_group | cookiecloud_profile | alias}...",
 	Short: "Show effective config of config items.",
 	Long: `Show effective config of config items.
 It prints output in toml format.`,
@@ -49,6 +49,13 @@ func show(cmd *cobra.Command, args []string) error {
 				continue
 			}
 			fmt.Printf("# %s\n[[groups]]\n%s\n", name, str)
+		} else if clientGroupConfig := config.GetClientGroupConfig(name); clientGroupConfig != nil {
+			str, err := toml.Marshal(util.StructToMap(*clientGroupConfig, true, true))
+			if err != nil {
+				fmt.Printf("# %s : failed to get detailed configuration: %v\n", name, err)
+				continue
+			}
+			fmt.Printf("# %s\n[[clientGroups]]\n%s\n", name, str)
 		} else if cookiecloudConfig := config.GetCookiecloudConfig(name); cookiecloudConfig != nil {
 			str, err := toml.Marshal(util.StructToMap(*cookiecloudConfig, true, true))
 			if err != nil {
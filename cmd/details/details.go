@@ -0,0 +1,85 @@
+package details
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+var command = &cobra.Command{
+	Use:         "details {torrentId}... [--site site]",
+	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "details"},
+	Short:       "Show details of site torrents.",
+	Long: `Show details of site torrents.
+Args is torrent list that each one is a site torrent id (e.g. "mteam.488424").
+If "--site" flag is set, pure number torrent id (e.g. "488424") is also accepted.
+
+It displays torrent infos in site details page, including description (简介),
+uploader, info hash, IMDb / Douban links, discount status and files list.
+Currently supported site types: nexusphp, mtorrent.
+
+If "--json" flag is set, it prints the details in json object format instead.`,
+	Args: cobra.MatchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
+	RunE: details,
+}
+
+var (
+	showJson    = false
+	defaultSite = ""
+)
+
+func init() {
+	command.Flags().BoolVarP(&showJson, "json", "", false, "Show output in json format")
+	command.Flags().StringVarP(&defaultSite, "site", "", "", "Set default site of torrents")
+	cmd.RootCmd.AddCommand(command)
+}
+
+func details(cmd *cobra.Command, args []string) error {
+	errorCnt := int64(0)
+	for i, torrent := range args {
+		sitename, id := defaultSite, torrent
+		if _sitename, _id, found := strings.Cut(torrent, "."); found {
+			sitename, id = _sitename, _id
+		}
+		if sitename == "" {
+			fmt.Printf("✕ %s: no site specified\n", torrent)
+			errorCnt++
+			continue
+		}
+		siteInstance, err := site.CreateSite(sitename)
+		if err != nil {
+			fmt.Printf("✕ %s: failed to create site: %v\n", torrent, err)
+			errorCnt++
+			continue
+		}
+		torrentDetails, err := siteInstance.GetTorrentDetails(id)
+		if err != nil {
+			fmt.Printf("✕ %s: failed to get details: %v\n", torrent, err)
+			errorCnt++
+			continue
+		}
+		log.Debugf("Got torrent %s details: %s", torrent, torrentDetails.Url)
+		if showJson {
+			if err := util.PrintJson(os.Stdout, torrentDetails); err != nil {
+				log.Errorf("%s: failed to print json: %v", torrent, err)
+				errorCnt++
+			}
+			continue
+		}
+		if i > 0 {
+			fmt.Printf("\n")
+		}
+		torrentDetails.Print(os.Stdout)
+	}
+	if errorCnt > 0 {
+		return fmt.Errorf("%d errors", errorCnt)
+	}
+	return nil
+}
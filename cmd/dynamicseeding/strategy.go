@@ -70,7 +70,7 @@ func (result *Result) Print(output io.Writer) {
 	}
 	if len(result.AddTorrents) > 0 {
 		fmt.Fprintf(output, "\nAdd %d torents to client:\n", len(result.AddTorrents))
-		site.PrintTorrents(os.Stdout, result.AddTorrents, "", result.Timestamp, false, false, nil)
+		site.PrintTorrents(os.Stdout, result.AddTorrents, "", result.Timestamp, false, false, nil, nil)
 	}
 	fmt.Fprintf(output, "\nLog:\n%s\n", result.Log)
 }
@@ -295,7 +295,7 @@ site_outer:
 		return
 	}
 	fmt.Fprintf(os.Stderr, "site candidate torrents:\n")
-	site.PrintTorrents(os.Stderr, siteTorrents, "", timestamp, false, false, nil)
+	site.PrintTorrents(os.Stderr, siteTorrents, "", timestamp, false, false, nil, nil)
 
 	availableSpace = siteInstance.GetSiteConfig().DynamicSeedingSizeValue -
 		statistics.SuccessSize - statistics.FailureSize
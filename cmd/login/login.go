@@ -0,0 +1,73 @@
+package login
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+)
+
+var command = &cobra.Command{
+	Use:         "login {site}...",
+	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "login"},
+	Short:       "Login sites using username & password to obtain cookie.",
+	Long: `Login sites using username & password to obtain cookie (or auth token).
+{site}: name of a site or group.
+
+The site must have "username" and "password" configured in ptool.toml.
+If site account enables two-factor authentication (2FA), also configure "totpSecret" (base32 format)
+so that ptool can generate the TOTP code.
+The obtained cookie (or token) is saved to "login-<site>.json" file in config dir, and will be used
+by all following ptool commands instead of the "cookie" in ptool.toml.
+When site cookie expires (requests got redirected to login page), ptool will also re-login automatically.
+
+Currently supported site types: nexusphp, unit3d, gazelle, mtorrent.
+Sites that require captcha in login form are not supported.`,
+	Args: cobra.MatchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
+	RunE: login,
+}
+
+var (
+	noCheck = false
+)
+
+func init() {
+	command.Flags().BoolVarP(&noCheck, "no-check", "", false, "Do not check site status after login")
+	cmd.RootCmd.AddCommand(command)
+}
+
+func login(cmd *cobra.Command, args []string) error {
+	sitenames := config.ParseGroupAndOtherNames(args...)
+	errorCnt := int64(0)
+	for _, sitename := range sitenames {
+		siteInstance, err := site.CreateSite(sitename)
+		if err != nil {
+			fmt.Printf("✕ %s: failed to create site: %v\n", sitename, err)
+			errorCnt++
+			continue
+		}
+		if _, err := site.Login(siteInstance); err != nil {
+			fmt.Printf("✕ %s: %v\n", sitename, err)
+			errorCnt++
+			continue
+		}
+		if noCheck {
+			fmt.Printf("✓ %s: login success\n", sitename)
+			continue
+		}
+		status, err := siteInstance.GetStatus()
+		if err != nil || !status.IsOk() {
+			fmt.Printf("✕ %s: login success, but failed to get site status: %v\n", sitename, err)
+			errorCnt++
+			continue
+		}
+		fmt.Printf("✓ %s: login success (username: %s)\n", sitename, status.UserName)
+	}
+	if errorCnt > 0 {
+		return fmt.Errorf("%d errors", errorCnt)
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package modifytorrent
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -34,7 +36,8 @@ Available "modifying" flags (at least one of them must be set):
 * --ratio-limit : Set torrent ratio share limit. qb ratioLimit.
   For now, -2 means the global limit should be used, -1 means no limit.
 * --seeding-time-limit : Set torrent seeding time share limit. qb seedingTimeLimit (but in seconds instead of minutes).
-  For now, -2 means the global limit should be used, -1 means no limit.`, constants.HELP_INFOHASH_ARGS),
+  For now, -2 means the global limit should be used, -1 means no limit.
+* --share-limit-action : Set action when share limits are reached. qBittorrent 5.1+ only.`, constants.HELP_INFOHASH_ARGS),
 	Args: cobra.MatchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
 	RunE: modifytorrent,
 }
@@ -49,6 +52,7 @@ var (
 	setSavePath      = ""
 	addTags          = ""
 	removeTags       = ""
+	shareLimitAction = ""
 )
 
 func init() {
@@ -59,6 +63,9 @@ func init() {
 	command.Flags().Float64VarP(&ratioLimit, "ratio-limit", "", 0,
 		`(qBittorrent only) If != 0, set ratio share limit of torrents. `+
 			`Positive value: the max ratio (Up/Dl) the torrent should be seeded until. Negative value has special meaning`)
+	command.Flags().StringVarP(&shareLimitAction, "share-limit-action", "", "",
+		`(qBittorrent 5.1+ only) Set action when share limits are reached: `+
+			strings.Join(client.ShareLimitActions, "|"))
 	command.Flags().StringVarP(&filter, "filter", "", "", constants.HELP_ARG_FILTER_TORRENT)
 	command.Flags().StringVarP(&category, "category", "", "", constants.HELP_ARG_CATEGORY)
 	command.Flags().StringVarP(&tag, "tag", "", "", constants.HELP_ARG_TAG)
@@ -71,9 +78,13 @@ func init() {
 }
 
 func modifytorrent(cmd *cobra.Command, args []string) error {
-	if util.CountNonZeroVariables(setCategory, setSavePath, addTags, removeTags, seedingTimeLimit, ratioLimit) == 0 {
+	if util.CountNonZeroVariables(setCategory, setSavePath, addTags, removeTags, seedingTimeLimit, ratioLimit,
+		shareLimitAction) == 0 {
 		return fmt.Errorf(`at least one modifying flag must be provided`)
 	}
+	if shareLimitAction != "" && !slices.Contains(client.ShareLimitActions, shareLimitAction) {
+		return fmt.Errorf("invalid share-limit-action %q", shareLimitAction)
+	}
 	clientName := args[0]
 	infoHashes := args[1:]
 	if category == "" && tag == "" && filter == "" {
@@ -151,7 +162,26 @@ func modifytorrent(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if seedingTimeLimit != 0 || ratioLimit != 0 {
+	if shareLimitAction != "" {
+		// share limit action is set along with ratio / seeding time limits
+		if infoHashes == nil {
+			torrents, err := clientInstance.GetTorrents("", "", true)
+			if err != nil {
+				return err
+			}
+			infoHashes = util.Map(torrents, func(t *client.Torrent) string { return t.InfoHash })
+		}
+		for _, infoHash := range infoHashes {
+			err = clientInstance.ModifyTorrent(infoHash, &client.TorrentOption{
+				RatioLimit:       ratioLimit,
+				SeedingTimeLimit: seedingTimeLimit,
+				ShareLimitAction: shareLimitAction,
+			}, nil)
+			if err != nil {
+				return err
+			}
+		}
+	} else if seedingTimeLimit != 0 || ratioLimit != 0 {
 		if infoHashes == nil {
 			err = clientInstance.SetAllTorrentsShareLimits(ratioLimit, seedingTimeLimit)
 			if err != nil {
@@ -0,0 +1,188 @@
+package peers
+
+import (
+	"fmt"
+	"regexp"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/util"
+	"github.com/sagan/ptool/util/helper"
+	"github.com/sagan/ptool/util/torrentutil"
+)
+
+// Default regexp of peer client name or peer id of known leeching clients,
+// which download from others but (almost) never upload.
+const DEFAULT_LEECH_CLIENTS = `(?i)^(-(XL|SD|XF|QD|BN|DL|TS|FG)\d+)|xunlei|thunder|qqdownload|xfplay|` +
+	`dandelion|offline-download|stellarplayer|torrentstorm`
+
+// A peer whose uploaded data from us exceeds what its progress could explain by this margin is considered
+// reporting fake progress: max(torrent size * ratio, min bytes).
+const (
+	FAKE_PROGRESS_TOLERANCE_RATIO = 0.05
+	FAKE_PROGRESS_TOLERANCE_MIN   = 10 * 1024 * 1024
+)
+
+var command = &cobra.Command{
+	Use:         "peers {client} [--category category] [--tag tag] [--filter filter] [infoHash]...",
+	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "peers"},
+	Short:       "Show or ban connected peers of torrents in client.",
+	Long: fmt.Sprintf(`Show or ban connected peers of torrents in client.
+%s.
+
+By default it displays connected peers of torrents.
+
+If "--anti-leech" flag is set, it bans leeching peers of public torrents instead:
+- Peers which client name or peer id matches "--ban-client" regexp (default matches some known leeching clients).
+- Peers which reported progress is less than what we have uploaded to them
+  (only if the client reports per peer uploaded data, e.g. qBittorrent and rTorrent).
+Private torrents are skipped unless "--include-private" flag is set.
+Torrents which private flag can not be determined (.torrent file can not be exported) are also skipped.
+
+Banning peers is currently supported by qBittorrent and rTorrent only.`, constants.HELP_INFOHASH_ARGS),
+	Args: cobra.MatchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
+	RunE: peers,
+}
+
+var (
+	antiLeech      = false
+	dryRun         = false
+	includePrivate = false
+	showRaw        = false
+	category       = ""
+	tag            = ""
+	filter         = ""
+	banClient      = ""
+)
+
+func init() {
+	command.Flags().BoolVarP(&antiLeech, "anti-leech", "", false, "Ban leeching peers of public torrents")
+	command.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Dry run. Do NOT actually ban peers")
+	command.Flags().BoolVarP(&includePrivate, "include-private", "", false,
+		`Used with "--anti-leech". Also check peers of private torrents`)
+	command.Flags().BoolVarP(&showRaw, "raw", "", false, "Show raw speeds and sizes in bytes")
+	command.Flags().StringVarP(&filter, "filter", "", "", constants.HELP_ARG_FILTER_TORRENT)
+	command.Flags().StringVarP(&category, "category", "", "", constants.HELP_ARG_CATEGORY)
+	command.Flags().StringVarP(&tag, "tag", "", "", constants.HELP_ARG_TAG)
+	command.Flags().StringVarP(&banClient, "ban-client", "", DEFAULT_LEECH_CLIENTS,
+		`Used with "--anti-leech". Regexp of peer client name or peer id to ban`)
+	cmd.RootCmd.AddCommand(command)
+}
+
+func peers(cmd *cobra.Command, args []string) error {
+	clientName := args[0]
+	infoHashes := args[1:]
+	if category == "" && tag == "" && filter == "" {
+		if _infoHashes, err := helper.ParseInfoHashesFromArgs(infoHashes); err != nil {
+			return err
+		} else {
+			infoHashes = _infoHashes
+		}
+	}
+	var banClientRegexp *regexp.Regexp
+	if antiLeech && banClient != "" {
+		var err error
+		if banClientRegexp, err = regexp.Compile(banClient); err != nil {
+			return fmt.Errorf("invalid ban-client regexp: %w", err)
+		}
+	}
+	clientInstance, err := client.CreateClient(clientName)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	if antiLeech && len(infoHashes) == 0 && category == "" && tag == "" && filter == "" {
+		// by default only check torrents that are currently transferring data
+		infoHashes = []string{"_active"}
+	}
+	torrents, err := client.QueryTorrents(clientInstance, category, tag, filter, infoHashes...)
+	if err != nil {
+		return fmt.Errorf("failed to query client torrents: %w", err)
+	}
+
+	errorCnt := int64(0)
+	banPeers := []string{}
+	for i, torrent := range torrents {
+		if antiLeech && !includePrivate {
+			isPrivate, err := isPrivateTorrent(clientInstance, torrent.InfoHash)
+			if err != nil {
+				log.Warnf("Skip torrent %s (%s): failed to determine private flag: %v",
+					torrent.InfoHash, torrent.Name, err)
+				continue
+			}
+			if isPrivate {
+				log.Debugf("Skip private torrent %s (%s)", torrent.InfoHash, torrent.Name)
+				continue
+			}
+		}
+		peers, err := clientInstance.GetTorrentPeers(torrent.InfoHash)
+		if err != nil {
+			log.Errorf("Failed to get torrent %s peers: %v", torrent.InfoHash, err)
+			errorCnt++
+			continue
+		}
+		if !antiLeech {
+			if i > 0 {
+				fmt.Printf("\n")
+			}
+			fmt.Printf("Torrent %s (%s)\n", torrent.InfoHash, torrent.Name)
+			client.PrintTorrentPeers(peers, showRaw)
+			continue
+		}
+		for _, peer := range peers {
+			if reason := leechReason(torrent, peer, banClientRegexp); reason != "" {
+				fmt.Printf("Ban peer %s (%s) of torrent %s (%s): %s\n",
+					peer.Address, peer.Client, torrent.InfoHash, torrent.Name, reason)
+				banPeers = append(banPeers, peer.Address)
+			}
+		}
+	}
+	if antiLeech {
+		banPeers = util.UniqueSlice(banPeers)
+		fmt.Printf("Found %d leeching peers\n", len(banPeers))
+		if len(banPeers) > 0 && !dryRun {
+			if err := clientInstance.BanPeers(banPeers); err != nil {
+				return fmt.Errorf("failed to ban peers: %w", err)
+			}
+		}
+	}
+	if errorCnt > 0 {
+		return fmt.Errorf("%d errors", errorCnt)
+	}
+	return nil
+}
+
+// Return the reason why the peer is considered leeching. Return empty string if it's not.
+func leechReason(torrent *client.Torrent, peer *client.TorrentPeer, banClientRegexp *regexp.Regexp) string {
+	if banClientRegexp != nil {
+		if banClientRegexp.MatchString(peer.Client) {
+			return "client name matches"
+		}
+		if peer.PeerId != "" && banClientRegexp.MatchString(peer.PeerId) {
+			return "peer id matches"
+		}
+	}
+	if peer.Uploaded > 0 && torrent.SizeTotal > 0 {
+		tolerance := max(int64(float64(torrent.SizeTotal)*FAKE_PROGRESS_TOLERANCE_RATIO), FAKE_PROGRESS_TOLERANCE_MIN)
+		if peer.Uploaded > int64(float64(torrent.SizeTotal)*peer.Progress)+tolerance {
+			return fmt.Sprintf("fake progress (progress %.2f%%, uploaded to it %s)",
+				peer.Progress*100, util.BytesSize(float64(peer.Uploaded)))
+		}
+	}
+	return ""
+}
+
+func isPrivateTorrent(clientInstance client.Client, infoHash string) (bool, error) {
+	contents, err := clientInstance.ExportTorrentFile(infoHash)
+	if err != nil {
+		return false, err
+	}
+	tinfo, err := torrentutil.ParseTorrent(contents)
+	if err != nil {
+		return false, err
+	}
+	return tinfo.IsPrivate(), nil
+}
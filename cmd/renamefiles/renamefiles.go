@@ -0,0 +1,225 @@
+package renamefiles
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/util/helper"
+)
+
+var command = &cobra.Command{
+	Use: "renamefiles {client} {--search regexp --replace replacement | --template template} " +
+		"[--category category] [--tag tag] [--filter filter] [infoHash]...",
+	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "renamefiles"},
+	Short:       "Rename files or root folder of torrents in client.",
+	Long: fmt.Sprintf(`Rename files or root folder of torrents in client.
+%s.
+
+The new name of each file is generated by either:
+- "--search" regexp & "--replace" replacement: replace all matches of regexp in the file path
+  ("$1", "${name}" etc. in replacement are expanded to the submatches).
+- "--template" template: render the template to get the new file path. Available variables:
+  path (full file path in torrent), dir, name (file name), basename (file name without ext), ext (e.g. ".mkv"),
+  index, infohash, category, torrent_name, torrent (the client.Torrent object).
+
+File paths are relative to torrent save path and use "/" as separator, e.g. "Foo.2024/Foo.2024.mkv".
+If "--folder" flag is set, it renames the root folder of multi-file torrents instead,
+in which case "path" and "name" are both the root folder name.
+Files whose new path are the same as old one are skipped.
+
+Note Transmission can only rename the last component of a path and rTorrent does not support renaming.
+Use "--dry-run" to preview the renames first.`, constants.HELP_INFOHASH_ARGS),
+	Args: cobra.MatchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
+	RunE: renamefiles,
+}
+
+var (
+	dryRun      = false
+	renameRoot  = false
+	category    = ""
+	tag         = ""
+	filter      = ""
+	search      = ""
+	replace     = ""
+	templateStr = ""
+)
+
+func init() {
+	command.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Dry run. Only display the renames")
+	command.Flags().BoolVarP(&renameRoot, "folder", "", false, "Rename root folder of torrents instead of files")
+	command.Flags().StringVarP(&filter, "filter", "", "", constants.HELP_ARG_FILTER_TORRENT)
+	command.Flags().StringVarP(&category, "category", "", "", constants.HELP_ARG_CATEGORY)
+	command.Flags().StringVarP(&tag, "tag", "", "", constants.HELP_ARG_TAG)
+	command.Flags().StringVarP(&search, "search", "", "", "Regexp to search in file path")
+	command.Flags().StringVarP(&replace, "replace", "", "", `Used with "--search". Replacement string`)
+	command.Flags().StringVarP(&templateStr, "template", "", "", "Template of new file path. "+
+		constants.HELP_ARG_TEMPLATE)
+	cmd.RootCmd.AddCommand(command)
+}
+
+func renamefiles(cmd *cobra.Command, args []string) error {
+	clientName := args[0]
+	infoHashes := args[1:]
+	if category == "" && tag == "" && filter == "" {
+		if _infoHashes, err := helper.ParseInfoHashesFromArgs(infoHashes); err != nil {
+			return err
+		} else {
+			infoHashes = _infoHashes
+		}
+	}
+	if (search == "") == (templateStr == "") {
+		return fmt.Errorf("exactly one of --search or --template flag must be set")
+	}
+	var searchRegexp *regexp.Regexp
+	var renameTemplate *template.Template
+	var err error
+	if search != "" {
+		if searchRegexp, err = regexp.Compile(search); err != nil {
+			return fmt.Errorf("invalid search regexp: %w", err)
+		}
+	} else {
+		if renameTemplate, err = helper.GetTemplate(templateStr); err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+	}
+	clientInstance, err := client.CreateClient(clientName)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	torrents, err := client.QueryTorrents(clientInstance, category, tag, filter, infoHashes...)
+	if err != nil {
+		return fmt.Errorf("failed to query client torrents: %w", err)
+	}
+
+	errorCnt := int64(0)
+	cntAll := len(torrents)
+	for i, torrent := range torrents {
+		files, err := clientInstance.GetTorrentContents(torrent.InfoHash)
+		if err != nil {
+			fmt.Printf("✕ %s : failed to get contents: %v (%d/%d)\n", torrent.InfoHash, err, i+1, cntAll)
+			errorCnt++
+			continue
+		}
+		renames, err := getRenames(torrent, files, searchRegexp, renameTemplate)
+		if err != nil {
+			fmt.Printf("✕ %s : %v (%d/%d)\n", torrent.InfoHash, err, i+1, cntAll)
+			errorCnt++
+			continue
+		}
+		if len(renames) == 0 {
+			fmt.Printf("- %s : no files need to be renamed (%d/%d)\n", torrent.InfoHash, i+1, cntAll)
+			continue
+		}
+		for _, rename := range renames {
+			if dryRun {
+				fmt.Printf("_ %s : %q => %q (%d/%d) (dry run)\n",
+					torrent.InfoHash, rename[0], rename[1], i+1, cntAll)
+				continue
+			}
+			if renameRoot {
+				err = clientInstance.RenameTorrentFolder(torrent.InfoHash, rename[0], rename[1])
+			} else {
+				err = clientInstance.RenameTorrentFile(torrent.InfoHash, rename[0], rename[1])
+			}
+			if err != nil {
+				fmt.Printf("✕ %s : %q => %q: %v (%d/%d)\n", torrent.InfoHash, rename[0], rename[1], err, i+1, cntAll)
+				errorCnt++
+			} else {
+				fmt.Printf("✓ %s : %q => %q (%d/%d)\n", torrent.InfoHash, rename[0], rename[1], i+1, cntAll)
+			}
+		}
+	}
+	if errorCnt > 0 {
+		return fmt.Errorf("%d errors", errorCnt)
+	}
+	return nil
+}
+
+// Return the [oldPath, newPath] list of torrent that need to be renamed.
+func getRenames(torrent *client.Torrent, files []*client.TorrentContentFile,
+	searchRegexp *regexp.Regexp, renameTemplate *template.Template) (renames [][2]string, err error) {
+	type item struct {
+		index int64
+		path  string
+	}
+	items := []item{}
+	if renameRoot {
+		root := rootFolder(files)
+		if root == "" {
+			return nil, nil
+		}
+		items = append(items, item{-1, root})
+	} else {
+		for _, file := range files {
+			items = append(items, item{file.Index, file.Path})
+		}
+	}
+	newPaths := map[string]string{}
+	for _, item := range items {
+		newPath := ""
+		if searchRegexp != nil {
+			newPath = searchRegexp.ReplaceAllString(item.path, replace)
+		} else {
+			ext := path.Ext(item.path)
+			if renameRoot {
+				ext = ""
+			}
+			name := path.Base(item.path)
+			data := map[string]any{
+				"path":         item.path,
+				"dir":          path.Dir(item.path),
+				"name":         name,
+				"basename":     strings.TrimSuffix(name, ext),
+				"ext":          ext,
+				"index":        item.index,
+				"infohash":     torrent.InfoHash,
+				"category":     torrent.Category,
+				"torrent_name": torrent.Name,
+				"torrent":      torrent,
+			}
+			buf := &bytes.Buffer{}
+			if err = renameTemplate.Execute(buf, data); err != nil {
+				return nil, fmt.Errorf("failed to render template: %w", err)
+			}
+			newPath = strings.TrimSpace(buf.String())
+		}
+		newPath = strings.Trim(path.Clean(newPath), "/")
+		if newPath == "" || newPath == "." || strings.HasPrefix(newPath, "../") || newPath == ".." {
+			return nil, fmt.Errorf("invalid new path %q of %q", newPath, item.path)
+		}
+		if renameRoot && strings.Contains(newPath, "/") {
+			return nil, fmt.Errorf("new root folder name %q contains '/'", newPath)
+		}
+		if newPath == item.path {
+			continue
+		}
+		if oldPath, ok := newPaths[newPath]; ok {
+			return nil, fmt.Errorf("both %q and %q would be renamed to %q", oldPath, item.path, newPath)
+		}
+		newPaths[newPath] = item.path
+		renames = append(renames, [2]string{item.path, newPath})
+	}
+	return renames, nil
+}
+
+// Return the root folder name of a multi-file torrent. Return empty string if torrent does not have one.
+func rootFolder(files []*client.TorrentContentFile) string {
+	root := ""
+	for _, file := range files {
+		folder, _, found := strings.Cut(file.Path, "/")
+		if !found || root != "" && folder != root {
+			return ""
+		}
+		root = folder
+	}
+	return root
+}
@@ -285,6 +285,6 @@ func search(cmd *cobra.Command, args []string) error {
 		log.Warnf("Errors encountered: %s", errorStr)
 	}
 	fmt.Printf("\n")
-	site.PrintTorrents(os.Stdout, torrents, "", now.Unix(), false, dense, nil)
+	site.PrintTorrents(os.Stdout, torrents, "", now.Unix(), false, dense, nil, nil)
 	return nil
 }
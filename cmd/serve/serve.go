@@ -0,0 +1,20 @@
+package serve
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/cmd"
+)
+
+var Command = &cobra.Command{
+	Use:   "serve",
+	Short: "Run ptool as a local server.",
+	Long: `Run ptool as a local server.
+Available servers:
+* torznab : Torznab indexer server of configured sites, for Sonarr / Radarr and other clients.`,
+	Args: cobra.MatchAll(cobra.ExactArgs(0), cobra.OnlyValidArgs),
+}
+
+func init() {
+	cmd.RootCmd.AddCommand(Command)
+}
@@ -0,0 +1,255 @@
+package torznab
+
+// Torznab API: https://torznab.github.io/spec-1.3-draft/torznab/Specification-v1.3.html
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+// Torznab error codes
+const (
+	ERROR_INVALID_API_KEY   = 100
+	ERROR_MISSING_PARAMETER = 200
+	ERROR_INVALID_PARAMETER = 201
+	ERROR_NO_SUCH_FUNCTION  = 202
+	ERROR_UNKNOWN           = 900
+)
+
+const (
+	CATEGORY_OTHER = 8000
+	DEFAULT_LIMIT  = 100
+)
+
+type category struct {
+	Id   int
+	Name string
+	// Keywords of site torrent tags (category names), used to identify torrent category. Lowercase.
+	keywords []string
+}
+
+// Standard Torznab categories. Sub categories must precede their parent, as keywords are matched in order.
+var categories = []*category{
+	{5070, "TV/Anime", []string{"anime", "动漫", "動漫", "动画", "動畫"}},
+	{5080, "TV/Documentary", []string{"documentar", "纪录", "紀錄"}},
+	{5000, "TV", []string{"tv", "series", "剧集", "劇集", "电视剧", "電視劇", "综艺", "綜藝"}},
+	{2000, "Movies", []string{"movie", "film", "电影", "電影"}},
+	{3000, "Audio", []string{"music", "audio", "音乐", "音樂"}},
+	{4050, "PC/Games", []string{"game", "游戏", "遊戲"}},
+	{4000, "PC", []string{"software", "软件", "軟件", "軟體"}},
+	{6000, "XXX", []string{"xxx", "adult", "成人"}},
+	{7000, "Books", []string{"book", "书籍", "書籍", "电子书", "電子書"}},
+	{1000, "Console", []string{"console"}},
+	{CATEGORY_OTHER, "Other", nil},
+}
+
+type apiError struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        int      `xml:"code,attr"`
+	Description string   `xml:"description,attr"`
+}
+
+type capsCategory struct {
+	Id      int             `xml:"id,attr"`
+	Name    string          `xml:"name,attr"`
+	Subcats []*capsCategory `xml:"subcat"`
+}
+
+type capsSearching struct {
+	Available       string `xml:"available,attr"`
+	SupportedParams string `xml:"supportedParams,attr"`
+}
+
+type caps struct {
+	XMLName xml.Name `xml:"caps"`
+	Server  struct {
+		Title string `xml:"title,attr"`
+	} `xml:"server"`
+	Limits struct {
+		Default int `xml:"default,attr"`
+		Max     int `xml:"max,attr"`
+	} `xml:"limits"`
+	Searching struct {
+		Search      capsSearching `xml:"search"`
+		TvSearch    capsSearching `xml:"tv-search"`
+		MovieSearch capsSearching `xml:"movie-search"`
+	} `xml:"searching"`
+	Categories []*capsCategory `xml:"categories>category"`
+}
+
+type rssAttr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type rssEnclosure struct {
+	Url    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title      string        `xml:"title"`
+	Guid       string        `xml:"guid"`
+	Link       string        `xml:"link"`
+	PubDate    string        `xml:"pubDate,omitempty"`
+	Size       int64         `xml:"size"`
+	Categories []int         `xml:"category"`
+	Enclosure  *rssEnclosure `xml:"enclosure"`
+	Attrs      []*rssAttr    `xml:"torznab:attr"`
+}
+
+type rss struct {
+	XMLName   xml.Name `xml:"rss"`
+	Version   string   `xml:"version,attr"`
+	TorznabNs string   `xml:"xmlns:torznab,attr"`
+	Channel   struct {
+		Title       string     `xml:"title"`
+		Description string     `xml:"description"`
+		Items       []*rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+// Site torrents of a search keyword (or latest torrents if keyword is empty) cached in memory.
+type cacheEntry struct {
+	time     int64
+	torrents []*site.Torrent
+}
+
+type cache struct {
+	ttl     int64
+	entries map[string]*cacheEntry
+	mu      sync.Mutex
+}
+
+func (c *cache) get(sitename string, keyword string) []*site.Torrent {
+	if c.ttl <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := c.entries[sitename+"\n"+keyword]
+	if entry == nil || util.Now()-entry.time > c.ttl {
+		return nil
+	}
+	return entry.torrents
+}
+
+func (c *cache) set(sitename string, keyword string, torrents []*site.Torrent) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := util.Now()
+	for key, entry := range c.entries {
+		if now-entry.time > c.ttl {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[sitename+"\n"+keyword] = &cacheEntry{time: now, torrents: torrents}
+}
+
+func getCategory(id int) *category {
+	for _, cat := range categories {
+		if cat.Id == id {
+			return cat
+		}
+	}
+	return nil
+}
+
+// Return true if two categories belong to the same top level category, e.g. 5070 (TV/Anime) and 5000 (TV).
+func matchCategory(a int, b int) bool {
+	return a/1000 == b/1000
+}
+
+// Identify torrent category by its tags. If no tag matches, use the first configured category of site,
+// or CATEGORY_OTHER. known is false if the category is not identified by tags.
+func getTorrentCategory(torrent *site.Torrent, siteCategories []int) (id int, known bool) {
+	for _, cat := range categories {
+		if slices.ContainsFunc(torrent.Tags, func(tag string) bool {
+			tag = strings.ToLower(tag)
+			return slices.ContainsFunc(cat.keywords, func(keyword string) bool {
+				return strings.Contains(tag, keyword)
+			})
+		}) {
+			return cat.Id, true
+		}
+	}
+	if len(siteCategories) > 0 {
+		return siteCategories[0], true
+	}
+	return CATEGORY_OTHER, false
+}
+
+// Parse site torznabCategories config. Return nil if not configured (site provides all categories).
+func parseSiteCategories(values []string) ([]int, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	cats := []int{}
+	for _, value := range values {
+		id := int(util.ParseInt(value))
+		if id <= 0 {
+			return nil, fmt.Errorf("invalid torznab category %q", value)
+		}
+		cats = append(cats, id)
+	}
+	return cats, nil
+}
+
+// Return caps categories: all standard categories, plus non-standard categories configured by sites.
+func getCapsCategories(siteCategories map[string][]int) []*capsCategory {
+	capsCategories := []*capsCategory{}
+	add := func(id int, name string) {
+		parentId := id / 1000 * 1000
+		var parent *capsCategory
+		for _, c := range capsCategories {
+			if c.Id == parentId {
+				parent = c
+				break
+			}
+		}
+		if parent == nil {
+			parentName := fmt.Sprint(parentId)
+			if cat := getCategory(parentId); cat != nil {
+				parentName = cat.Name
+			}
+			parent = &capsCategory{Id: parentId, Name: parentName}
+			capsCategories = append(capsCategories, parent)
+		}
+		if id != parentId && !slices.ContainsFunc(parent.Subcats, func(c *capsCategory) bool {
+			return c.Id == id
+		}) {
+			parent.Subcats = append(parent.Subcats, &capsCategory{Id: id, Name: name})
+		}
+	}
+	for _, cat := range categories {
+		if cat.Id%1000 == 0 {
+			add(cat.Id, cat.Name)
+		}
+	}
+	for _, cat := range categories {
+		if cat.Id%1000 != 0 {
+			add(cat.Id, cat.Name)
+		}
+	}
+	for _, cats := range siteCategories {
+		for _, id := range cats {
+			if getCategory(id) == nil {
+				add(id, fmt.Sprint(id))
+			}
+		}
+	}
+	slices.SortFunc(capsCategories, func(a, b *capsCategory) int {
+		return a.Id - b.Id
+	})
+	return capsCategories
+}
@@ -0,0 +1,396 @@
+package torznab
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/cmd/serve"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+var command = &cobra.Command{
+	Use:   "torznab",
+	Short: "Run a Torznab indexer server of configured sites.",
+	Long: `Run a Torznab indexer server of configured sites.
+It allows Sonarr / Radarr and other Torznab clients to search site torrents through ptool,
+using ptool's site parsers and impersonation. Each site (or group) is an indexer with url:
+
+http://<addr>/torznab/<siteOrGroup>/api
+
+Use the api key in "torznabApiKey" config (or --api-key flag) as indexer API Key.
+Supported functions: caps, search, tvsearch, movie.
+Empty query returns the latest torrents of site. Search results are cached in memory for --cache-ttl seconds.
+Torrents are downloaded through ptool (http://<addr>/torznab/<site>/download), so site cookies never leave ptool.
+
+Set "torznabCategories" in site config to specify the Torznab categories the site provides, e.g. :
+
+ptool.toml
+----------
+[[sites]]
+type = 'ourbits'
+cookie = '...'
+torznabCategories = ['2000', '5000']
+----------
+
+Otherwise the site is considered to provide all categories,
+and the category of each torrent is identified by it's site category tags.`,
+	Args: cobra.MatchAll(cobra.ExactArgs(0), cobra.OnlyValidArgs),
+	RunE: torznab,
+}
+
+var (
+	addr     = ""
+	apiKey   = ""
+	baseUrl  = ""
+	sites    = ""
+	cacheTtl = int64(0)
+)
+
+func init() {
+	command.Flags().StringVarP(&addr, "addr", "", "127.0.0.1:9118", "Listening address (host:port) of server")
+	command.Flags().StringVarP(&apiKey, "api-key", "", "",
+		`API key of server. If not set, use "torznabApiKey" of config file`)
+	command.Flags().StringVarP(&baseUrl, "base-url", "", "",
+		`Base url of server used in torrent download links, e.g. "https://ptool.example.com". `+
+			`Set it if the server is behind a reverse proxy. If not set, use the host of request`)
+	command.Flags().StringVarP(&sites, "sites", "", "_all",
+		"Comma-separated list of sites or groups that are served")
+	command.Flags().Int64VarP(&cacheTtl, "cache-ttl", "", 600,
+		"Cache site search results (and latest torrents) for these seconds. 0 = disable cache")
+	serve.Command.AddCommand(command)
+}
+
+type server struct {
+	apiKey         string
+	baseUrl        string
+	sitenames      []string
+	siteInstances  map[string]site.Site
+	siteCategories map[string][]int
+	siteLocks      map[string]*sync.Mutex // site instances are not goroutine safe
+	cache          *cache
+}
+
+func torznab(cmd *cobra.Command, args []string) error {
+	if apiKey == "" {
+		apiKey = config.Get().TorznabApiKey
+	}
+	if apiKey == "" {
+		return fmt.Errorf(`api key not set. Set "torznabApiKey" in config file or use --api-key flag`)
+	}
+	srv := &server{
+		apiKey:         apiKey,
+		baseUrl:        strings.TrimSuffix(baseUrl, "/"),
+		sitenames:      config.ParseGroupAndOtherNames(util.SplitCsv(sites)...),
+		siteInstances:  map[string]site.Site{},
+		siteCategories: map[string][]int{},
+		siteLocks:      map[string]*sync.Mutex{},
+		cache:          &cache{ttl: cacheTtl, entries: map[string]*cacheEntry{}},
+	}
+	if len(srv.sitenames) == 0 {
+		return fmt.Errorf("no sites")
+	}
+	for _, sitename := range srv.sitenames {
+		siteInstance, err := site.CreateSite(sitename)
+		if err != nil {
+			return fmt.Errorf("failed to create site %s: %w", sitename, err)
+		}
+		cats, err := parseSiteCategories(siteInstance.GetSiteConfig().TorznabCategories)
+		if err != nil {
+			return fmt.Errorf("site %s: %w", sitename, err)
+		}
+		srv.siteInstances[sitename] = siteInstance
+		srv.siteCategories[sitename] = cats
+		srv.siteLocks[sitename] = &sync.Mutex{}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /torznab/{site}/api", srv.handleApi)
+	mux.HandleFunc("GET /torznab/{site}/download", srv.handleDownload)
+	log.Warnf("Torznab server listening on %s. Indexer urls:", addr)
+	for _, sitename := range srv.sitenames {
+		fmt.Printf("http://%s/torznab/%s/api\n", addr, sitename)
+	}
+	return http.ListenAndServe(addr, mux)
+}
+
+// Return served sites of siteOrGroup name in request path.
+func (srv *server) getSites(name string) []string {
+	sitenames := []string{}
+	for _, sitename := range config.ParseGroupAndOtherNames(name) {
+		if srv.siteInstances[sitename] != nil {
+			sitenames = append(sitenames, sitename)
+		}
+	}
+	return sitenames
+}
+
+func (srv *server) handleApi(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	log.Infof("Torznab request from %s: %s %s", r.RemoteAddr, r.PathValue("site"), query.Get("t"))
+	if !srv.checkApiKey(query.Get("apikey")) {
+		writeError(w, ERROR_INVALID_API_KEY, "Invalid API Key")
+		return
+	}
+	sitenames := srv.getSites(r.PathValue("site"))
+	if len(sitenames) == 0 {
+		writeError(w, ERROR_INVALID_PARAMETER, "Site not found")
+		return
+	}
+	switch t := query.Get("t"); t {
+	case "caps":
+		srv.writeCaps(w, sitenames)
+	case "search", "tvsearch", "movie":
+		keyword := strings.TrimSpace(query.Get("q"))
+		if t == "tvsearch" && keyword != "" {
+			if season := util.ParseInt(query.Get("season")); season > 0 {
+				if ep := util.ParseInt(query.Get("ep")); ep > 0 {
+					keyword += fmt.Sprintf(" S%02dE%02d", season, ep)
+				} else {
+					keyword += fmt.Sprintf(" S%02d", season)
+				}
+			}
+		}
+		requestCategories := []int{}
+		for _, str := range util.SplitCsv(query.Get("cat")) {
+			if id := int(util.ParseInt(str)); id > 0 {
+				requestCategories = append(requestCategories, id)
+			}
+		}
+		offset := int(util.ParseInt(query.Get("offset")))
+		limit := int(util.ParseInt(query.Get("limit")))
+		if limit <= 0 || limit > DEFAULT_LIMIT {
+			limit = DEFAULT_LIMIT
+		}
+		srv.search(w, r, sitenames, keyword, requestCategories, offset, limit)
+	case "":
+		writeError(w, ERROR_MISSING_PARAMETER, "Missing parameter t")
+	default:
+		writeError(w, ERROR_NO_SUCH_FUNCTION, "No such function "+t)
+	}
+}
+
+func (srv *server) writeCaps(w http.ResponseWriter, sitenames []string) {
+	c := &caps{}
+	c.Server.Title = "ptool"
+	c.Limits.Default = DEFAULT_LIMIT
+	c.Limits.Max = DEFAULT_LIMIT
+	c.Searching.Search = capsSearching{Available: "yes", SupportedParams: "q"}
+	c.Searching.TvSearch = capsSearching{Available: "yes", SupportedParams: "q,season,ep"}
+	c.Searching.MovieSearch = capsSearching{Available: "yes", SupportedParams: "q"}
+	siteCategories := map[string][]int{}
+	for _, sitename := range sitenames {
+		siteCategories[sitename] = srv.siteCategories[sitename]
+	}
+	c.Categories = getCapsCategories(siteCategories)
+	writeXml(w, c)
+}
+
+type siteResult struct {
+	sitename string
+	torrents []*site.Torrent
+	err      error
+}
+
+func (srv *server) search(w http.ResponseWriter, r *http.Request, sitenames []string, keyword string,
+	requestCategories []int, offset int, limit int) {
+	// skip sites which do not provide any requested category
+	sitenames = slices.DeleteFunc(slices.Clone(sitenames), func(sitename string) bool {
+		return len(requestCategories) > 0 && len(srv.siteCategories[sitename]) > 0 &&
+			!slices.ContainsFunc(srv.siteCategories[sitename], func(id int) bool {
+				return slices.ContainsFunc(requestCategories, func(requestId int) bool {
+					return matchCategory(id, requestId)
+				})
+			})
+	})
+	ch := make(chan *siteResult, len(sitenames))
+	for _, sitename := range sitenames {
+		go func(sitename string) {
+			torrents, err := srv.getTorrents(sitename, keyword)
+			ch <- &siteResult{sitename, torrents, err}
+		}(sitename)
+	}
+	feed := &rss{Version: "2.0", TorznabNs: "http://torznab.com/schemas/2015/feed"}
+	feed.Channel.Title = "ptool"
+	feed.Channel.Description = "ptool torznab: " + strings.Join(sitenames, ",")
+	items := []*rssItem{}
+	itemTimes := map[*rssItem]int64{}
+	errorCnt := 0
+	for range sitenames {
+		result := <-ch
+		if result.err != nil {
+			log.Errorf("Failed to get site %s torrents (keyword %q): %v", result.sitename, keyword, result.err)
+			errorCnt++
+			continue
+		}
+		for _, torrent := range result.torrents {
+			categoryId, known := getTorrentCategory(torrent, srv.siteCategories[result.sitename])
+			if known && len(requestCategories) > 0 && !slices.ContainsFunc(requestCategories, func(id int) bool {
+				return matchCategory(id, categoryId)
+			}) {
+				continue
+			}
+			item := srv.getItem(r, result.sitename, torrent, categoryId)
+			items = append(items, item)
+			itemTimes[item] = torrent.Time
+		}
+	}
+	if errorCnt > 0 && errorCnt == len(sitenames) {
+		writeError(w, ERROR_UNKNOWN, "Failed to get site torrents")
+		return
+	}
+	slices.SortStableFunc(items, func(a, b *rssItem) int {
+		return int(max(-1, min(1, itemTimes[b]-itemTimes[a])))
+	})
+	if offset > 0 {
+		items = items[min(offset, len(items)):]
+	}
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	feed.Channel.Items = items
+	writeXml(w, feed)
+}
+
+// Get site torrents of keyword, or site latest torrents if keyword is empty. Results are cached.
+func (srv *server) getTorrents(sitename string, keyword string) ([]*site.Torrent, error) {
+	if torrents := srv.cache.get(sitename, keyword); torrents != nil {
+		log.Debugf("Use cached site %s torrents of keyword %q", sitename, keyword)
+		return torrents, nil
+	}
+	srv.siteLocks[sitename].Lock()
+	defer srv.siteLocks[sitename].Unlock()
+	var torrents []*site.Torrent
+	var err error
+	if keyword == "" {
+		torrents, err = srv.siteInstances[sitename].GetLatestTorrents(true)
+	} else {
+		torrents, err = srv.siteInstances[sitename].SearchTorrents(keyword, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.set(sitename, keyword, torrents)
+	return torrents, nil
+}
+
+func (srv *server) getItem(r *http.Request, sitename string, torrent *site.Torrent, categoryId int) *rssItem {
+	id := torrent.Id
+	if id == "" {
+		id = torrent.DownloadUrl
+	}
+	base := srv.baseUrl
+	if base == "" {
+		base = "http://" + r.Host
+	}
+	downloadUrl := fmt.Sprintf("%s/torznab/%s/download?apikey=%s&id=%s",
+		base, url.PathEscape(sitename), url.QueryEscape(srv.apiKey), url.QueryEscape(id))
+	item := &rssItem{
+		Title:      torrent.Name,
+		Guid:       id,
+		Link:       downloadUrl,
+		Size:       torrent.Size,
+		Categories: []int{categoryId},
+		Enclosure: &rssEnclosure{
+			Url:    downloadUrl,
+			Length: torrent.Size,
+			Type:   "application/x-bittorrent",
+		},
+	}
+	if torrent.Time > 0 {
+		item.PubDate = time.Unix(torrent.Time, 0).Format(time.RFC1123Z)
+	}
+	attrs := [][2]string{
+		{"category", fmt.Sprint(categoryId)},
+		{"size", fmt.Sprint(torrent.Size)},
+		{"seeders", fmt.Sprint(torrent.Seeders)},
+		{"peers", fmt.Sprint(torrent.Seeders + torrent.Leechers)},
+		{"grabs", fmt.Sprint(torrent.Snatched)},
+		{"downloadvolumefactor", fmt.Sprint(torrent.DownloadMultiplier)},
+		{"uploadvolumefactor", fmt.Sprint(torrent.UploadMultiplier)},
+	}
+	if torrent.InfoHash != "" {
+		attrs = append(attrs, [2]string{"infohash", torrent.InfoHash})
+	}
+	if torrent.Description != "" {
+		attrs = append(attrs, [2]string{"description", torrent.Description})
+	}
+	for _, attr := range attrs {
+		item.Attrs = append(item.Attrs, &rssAttr{Name: attr[0], Value: attr[1]})
+	}
+	return item
+}
+
+func (srv *server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !srv.checkApiKey(query.Get("apikey")) {
+		http.Error(w, "Invalid API Key", http.StatusUnauthorized)
+		return
+	}
+	sitename := r.PathValue("site")
+	siteInstance := srv.siteInstances[sitename]
+	id := query.Get("id")
+	if siteInstance == nil || id == "" {
+		http.Error(w, "Site or torrent not found", http.StatusNotFound)
+		return
+	}
+	if !isSiteTorrentId(siteInstance, id) {
+		http.Error(w, "Invalid torrent id", http.StatusBadRequest)
+		return
+	}
+	log.Infof("Torznab download request from %s: %s %s", r.RemoteAddr, sitename, id)
+	srv.siteLocks[sitename].Lock()
+	content, filename, _, err := siteInstance.DownloadTorrent(id)
+	srv.siteLocks[sitename].Unlock()
+	if err != nil {
+		log.Errorf("Failed to download site %s torrent %s: %v", sitename, id, err)
+		http.Error(w, fmt.Sprintf("Failed to download torrent: %v", err), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-bittorrent")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Write(content)
+}
+
+func (srv *server) checkApiKey(apiKey string) bool {
+	return subtle.ConstantTimeCompare([]byte(apiKey), []byte(srv.apiKey)) == 1
+}
+
+// Check whether id is a torrent id of site, or a download url of site's own domains.
+// Other urls are rejected so the server can't be abused to fetch arbitrary urls with site cookies.
+func isSiteTorrentId(siteInstance site.Site, id string) bool {
+	if util.IsUrl(id) {
+		return config.MatchSite(util.GetUrlDomain(id), siteInstance.GetSiteConfig())
+	}
+	return !strings.Contains(id, "://")
+}
+
+func writeError(w http.ResponseWriter, code int, description string) {
+	writeXml(w, &apiError{Code: code, Description: description})
+}
+
+func writeXml(w http.ResponseWriter, v any) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write(buf.Bytes())
+}
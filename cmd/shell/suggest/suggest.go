@@ -83,6 +83,14 @@ func ClientArg(prefix string) []prompt.Suggest {
 			suggestions = append(suggestions, prompt.Suggest{Text: client.Name, Description: "<client>"})
 		}
 	}
+	for _, clientGroup := range config.Get().ClientGroups {
+		if strings.HasPrefix(clientGroup.Name, prefix) {
+			suggestions = append(suggestions, prompt.Suggest{Text: clientGroup.Name, Description: "<client group>"})
+		}
+	}
+	if strings.HasPrefix(config.ALL_CLIENTS_GROUP, prefix) {
+		suggestions = append(suggestions, prompt.Suggest{Text: config.ALL_CLIENTS_GROUP, Description: "<all clients>"})
+	}
 	return suggestions
 }
 
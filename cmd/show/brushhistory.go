@@ -0,0 +1,76 @@
+package show
+
+import (
+	"fmt"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/cmd/brush/strategy"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/stats"
+	"github.com/sagan/ptool/util"
+)
+
+// Display torrent details (if it's still in client) and it's recorded brush history.
+func showTorrentBrushHistory(clientInstance client.Client, infoHash string) error {
+	if !config.Get().BrushEnableHistory {
+		log.Warnf("brushEnableHistory is not enabled in config file, brush history will not be recorded")
+	}
+	torrent, err := clientInstance.GetTorrent(infoHash)
+	if err != nil {
+		log.Errorf("Failed to get torrent %s details: %v", infoHash, err)
+	} else if torrent != nil {
+		torrent.Print()
+		fmt.Printf("\n")
+	}
+	historyDb, err := stats.NewBrushHistoryDb(filepath.Join(config.ConfigDir, config.BRUSH_HISTORY_FILENAME))
+	if err != nil {
+		return err
+	}
+	samples, err := historyDb.GetSamples(clientInstance.GetName(), infoHash)
+	if err != nil {
+		return fmt.Errorf("failed to get brush history: %w", err)
+	}
+	history := strategy.TorrentHistory(samples[infoHash])
+	if len(history) == 0 {
+		return fmt.Errorf("no brush history of torrent %s in client %s", infoHash, clientInstance.GetName())
+	}
+	fmt.Printf("Brush history: %d samples (%s ~ %s); Site: %s\n\n", len(history),
+		util.FormatTime(history[0].Time), util.FormatTime(history[len(history)-1].Time), history[0].Site)
+	fmt.Printf("%-19s  %10s  %10s  %10s  %10s  %10s  %7s  %8s\n",
+		"Time", "Uploaded", "Downloaded", "↑Spd", "↓Spd", "Completed", "Seeders", "Leechers")
+	for _, sample := range history {
+		fmt.Printf("%-19s  %10s  %10s  %8s/s  %8s/s  %10s  %7d  %8d\n",
+			util.FormatTime(sample.Time),
+			util.BytesSizeAround(float64(sample.Uploaded)),
+			util.BytesSizeAround(float64(sample.Downloaded)),
+			util.BytesSizeAround(float64(sample.UploadSpeed)),
+			util.BytesSizeAround(float64(sample.DownloadSpeed)),
+			util.BytesSizeAround(float64(sample.SizeCompleted)),
+			sample.Seeders,
+			sample.Leechers,
+		)
+	}
+	fmt.Printf("\n")
+	for _, timespan := range []int64{strategy.HISTORY_SHORT_TIMESPAN, strategy.HISTORY_LONG_TIMESPAN} {
+		if speed, ok := history.AverageUploadSpeed(timespan); ok {
+			fmt.Printf("Average upload speed (%s): %s/s\n",
+				util.GetDurationString(timespan), util.BytesSize(float64(speed)))
+		} else {
+			fmt.Printf("Average upload speed (%s): -\n", util.GetDurationString(timespan))
+		}
+	}
+	if trend, ok := history.UploadSpeedTrend(); ok {
+		fmt.Printf("Upload speed trend: %.2f\n", trend)
+	} else {
+		fmt.Printf("Upload speed trend: -\n")
+	}
+	if speed, ok := history.PredictUploadSpeed(); ok {
+		fmt.Printf("Predicted upload speed: %s/s\n", util.BytesSize(float64(speed)))
+	} else {
+		fmt.Printf("Predicted upload speed: -\n")
+	}
+	return nil
+}
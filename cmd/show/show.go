@@ -48,6 +48,8 @@ The "State" field displays some icon texts:
 * _ : Torrent contents files are partially selected for downloading.
 
 Specially, if all args is an (1) single info-hash, it displays the details of that torrent instead of the list.
+If "--brush-history" flag is set, it also displays the recorded brush history of that torrent
+(requires "brushEnableHistory = true" in ptool.toml), even if the torrent has been deleted from client.
 
 If "--json" flag is set, it prints torrents info in json (array) format.
 
@@ -82,6 +84,7 @@ type Torrent struct {
 	Seeders            int64 // Cnt of seeders (including self client, if it's seeding), returned by tracker
 	Leechers           int64
 	Meta               map[string]int64
+	Client             string // name of the client which owns the torrent. Set by client group only
 }
 
 The template render result will be trim spaced.
@@ -121,6 +124,7 @@ var (
 	showRaw            = false
 	showJson           = false
 	showSum            = false
+	showBrushHistory   = false
 	sortFlag           string
 	orderFlag          string
 )
@@ -140,6 +144,8 @@ func init() {
 	command.Flags().BoolVarP(&showSum, "sum", "", false, "Show torrents summary only")
 	command.Flags().BoolVarP(&showTrackers, "show-trackers", "", false, "Show torrent trackers info")
 	command.Flags().BoolVarP(&showFiles, "show-files", "", false, "Show torrent content files info")
+	command.Flags().BoolVarP(&showBrushHistory, "brush-history", "", false,
+		"Show recorded brush history of the torrent. Requires exactly one info-hash arg")
 	command.Flags().BoolVarP(&partial, "partial", "", false,
 		"Only showing torrents that are partially selected for downloading")
 	command.Flags().StringVarP(&maxTotalSizeStr, "max-total-size", "", "-1",
@@ -192,6 +198,12 @@ func show(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
+	if showBrushHistory {
+		if len(infoHashes) != 1 || !client.IsValidInfoHash(infoHashes[0]) {
+			return fmt.Errorf("--brush-history flag requires exactly one info-hash arg")
+		}
+		return showTorrentBrushHistory(clientInstance, infoHashes[0])
+	}
 	if largestFlag && newestFlag {
 		return fmt.Errorf("--largest and --newest flags are NOT compatible")
 	}
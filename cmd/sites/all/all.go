@@ -3,4 +3,5 @@ package all
 import (
 	_ "github.com/sagan/ptool/cmd/sites"
 	_ "github.com/sagan/ptool/cmd/sites/show"
+	_ "github.com/sagan/ptool/cmd/sites/validate"
 )
@@ -38,7 +38,7 @@ func show(cmd *cobra.Command, args []string) error {
 			fmt.Printf("# %s : failed to get detailed configuration: %v\n", sitename, err)
 			continue
 		}
-		fmt.Printf("# %s\n[[sites]]\n%s\n", sitename, str)
+		fmt.Printf("# %s (origin: %s)\n[[sites]]\n%s\n", sitename, tpl.GetOrigin(sitename), str)
 	}
 	return nil
 }
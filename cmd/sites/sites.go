@@ -16,6 +16,9 @@ var Command = &cobra.Command{
 	Use:   "sites [--filter filter]",
 	Short: "Show internal supported PT sites list which can be used with this software.",
 	Long: `Show internal supported PT sites list which can be used with this software.
+It also includes external site templates loaded from "sites.d/*.yaml" files in config dir.
+The "Origin" column shows where a site template comes from: "internal", a sites.d file,
+or "internal+<file>" if an internal site is overrided by a sites.d file.
 By default it does NOT display obsolete / legacy site that is currently / already dead,
 unless --all flag is set.`,
 	Args: cobra.MatchAll(cobra.ExactArgs(0), cobra.OnlyValidArgs),
@@ -45,6 +48,7 @@ func sites(cmd *cobra.Command, args []string) error {
 			}
 			siteData := util.StructToMap(*tpl.SITES[name], true, false)
 			siteData["name"] = name
+			siteData["origin"] = tpl.ORIGINS[name]
 			siteDatas = append(siteDatas, siteData)
 		}
 		util.PrintJson(os.Stdout, siteDatas)
@@ -56,7 +60,8 @@ func sites(cmd *cobra.Command, args []string) error {
 	} else {
 		fmt.Printf("<applying filter '%s'>\n", filter)
 	}
-	fmt.Printf("%-15s  %-15s  %-30s  %13s  %-5s  %s\n", "Type", "Aliases", "Url", "Schema", "Flags", "Comment")
+	fmt.Printf("%-15s  %-15s  %-30s  %13s  %-5s  %-20s  %s\n",
+		"Type", "Aliases", "Url", "Schema", "Flags", "Origin", "Comment")
 	for _, name := range tpl.SITENAMES {
 		siteInfo := tpl.SITES[name]
 		if siteInfo.Dead && !showAll {
@@ -72,8 +77,8 @@ func sites(cmd *cobra.Command, args []string) error {
 		if siteInfo.GlobalHnR {
 			flags = append(flags, "!")
 		}
-		fmt.Printf("%-15s  %-15s  %-30s  %13s  %-5s  %s\n", name, strings.Join(siteInfo.Aliases, ","),
-			siteInfo.Url, siteInfo.Type, strings.Join(flags, ""), siteInfo.Comment)
+		fmt.Printf("%-15s  %-15s  %-30s  %13s  %-5s  %-20s  %s\n", name, strings.Join(siteInfo.Aliases, ","),
+			siteInfo.Url, siteInfo.Type, strings.Join(flags, ""), tpl.ORIGINS[name], siteInfo.Comment)
 	}
 	return nil
 }
@@ -0,0 +1,72 @@
+package validate
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/cmd/sites"
+	"github.com/sagan/ptool/site/tpl"
+)
+
+var command = &cobra.Command{
+	Use:   "validate [file.yaml]...",
+	Short: "Validate external site template files.",
+	Long: `Validate external site template files.
+By default it validates all "sites.d/*.yaml" (and *.yml) files in config dir.
+Each file is a map of site name => site template, which uses the same fields as site config in ptool.toml
+(type, url, domains, aliases, selectors...). E.g. :
+
+mysite:
+  type: nexusphp
+  url: https://mysite.example.com/
+  aliases: [ms]
+
+It checks site names, required fields (type & url for new sites), urls, css selectors and regexps.
+Templates of an existing site name or alias are validated after being merged into the existing one.`,
+	Args: cobra.MatchAll(cobra.ArbitraryArgs, cobra.OnlyValidArgs),
+	RunE: validate,
+}
+
+func init() {
+	sites.Command.AddCommand(command)
+}
+
+func validate(cmd *cobra.Command, args []string) error {
+	files := args
+	if len(files) == 0 {
+		var err error
+		if files, err = tpl.GetExternalSiteFiles(); err != nil {
+			return fmt.Errorf("failed to read external site templates dir: %w", err)
+		}
+		if len(files) == 0 {
+			fmt.Printf("No external site template files found\n")
+			return nil
+		}
+	}
+	errorCnt := int64(0)
+	for _, file := range files {
+		externalSites, err := tpl.ParseExternalSiteFile(file)
+		if err != nil {
+			fmt.Printf("✕ %s: failed to parse: %v\n", file, err)
+			errorCnt++
+			continue
+		}
+		for _, externalSite := range externalSites {
+			errs := tpl.ValidateSite(externalSite.Name, externalSite.Config)
+			if len(errs) == 0 {
+				fmt.Printf("✓ %s: %s\n", externalSite.File, externalSite.Name)
+				continue
+			}
+			fmt.Printf("✕ %s: %s\n", externalSite.File, externalSite.Name)
+			for _, err := range errs {
+				fmt.Printf("  - %v\n", err)
+			}
+			errorCnt++
+		}
+	}
+	if errorCnt > 0 {
+		return fmt.Errorf("%d errors", errorCnt)
+	}
+	return nil
+}
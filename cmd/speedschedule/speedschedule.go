@@ -0,0 +1,344 @@
+package speedschedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/sagan/ptool/client"
+	"github.com/sagan/ptool/cmd"
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/util"
+)
+
+var command = &cobra.Command{
+	Use:         "speedschedule {client}...",
+	Annotations: map[string]string{"cobra-prompt-dynamic-suggestions": "speedschedule"},
+	Short:       "Apply bandwidth schedules to clients.",
+	Long: `Apply bandwidth schedules to clients.
+Schedules are defined in "[[schedules]]" sections of config file. E.g.:
+
+  [[schedules]]
+  clients = ['local'] # if not set, applies to all clients
+  time = '08:00-23:00' # may cross midnight, e.g. '23:00-07:00'
+  days = 'mon,tue,wed,thu,fri' # optional
+  uploadSpeedLimit = '5MiB'
+  downloadSpeedLimit = '10MiB'
+  altSpeed = false # qb / tr only
+  noadd = true
+
+Schedules of a client are checked in order, the first one whose time window covers current time is active.
+Invalid schedules (e.g. malformed time window) are skipped with a warning.
+The settings (upload / download speed limit, alt speed, noadd) set in any schedule of a client are managed by
+this command: if active schedule does not set it or there is no active schedule, it's reset to default
+(no limit / disabled). The "noadd" setting adds or removes the "` + config.NOADD_TAG + `" flag tag of client,
+which prevents brush and other tasks from adding torrents to client.
+
+It only changes the client config that differs from the wanted value, so it's safe to run it periodically,
+e.g. every 5 minutes in cron.`,
+	Args: cobra.MatchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
+	RunE: speedschedule,
+}
+
+var (
+	dryRun = false
+)
+
+func init() {
+	command.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Dry run. Only display the changes")
+	cmd.RootCmd.AddCommand(command)
+}
+
+func speedschedule(cmd *cobra.Command, args []string) error {
+	now := time.Now()
+	errorCnt := int64(0)
+	for _, clientName := range args {
+		if err := applySchedules(clientName, now); err != nil {
+			log.Errorf("Failed to apply schedules of client %s: %v", clientName, err)
+			errorCnt++
+		}
+	}
+	if errorCnt > 0 {
+		return fmt.Errorf("%d errors", errorCnt)
+	}
+	return nil
+}
+
+func applySchedules(clientName string, now time.Time) error {
+	schedules := config.GetClientSchedules(clientName)
+	if len(schedules) == 0 {
+		return fmt.Errorf("no schedules defined")
+	}
+	schedules = util.Filter(schedules, func(schedule *config.ScheduleConfigStruct) bool {
+		if err := validateSchedule(schedule); err != nil {
+			log.Warnf("Client %s: skip invalid schedule %s: %v", clientName, scheduleName(schedule), err)
+			return false
+		}
+		return true
+	})
+	if len(schedules) == 0 {
+		return fmt.Errorf("no valid schedules defined")
+	}
+	var active *config.ScheduleConfigStruct
+	for _, schedule := range schedules {
+		if isActive, _ := scheduleActive(schedule, now); isActive {
+			active = schedule
+			break
+		}
+	}
+	if active == nil {
+		// default settings
+		active = &config.ScheduleConfigStruct{}
+		fmt.Printf("Client %s: no active schedule, reset to default\n", clientName)
+	} else {
+		fmt.Printf("Client %s: active schedule %s\n", clientName, scheduleName(active))
+	}
+	clientInstance, err := client.CreateClient(clientName)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	errorCnt := int64(0)
+	managed := func(getter func(s *config.ScheduleConfigStruct) bool) bool {
+		for _, schedule := range schedules {
+			if getter(schedule) {
+				return true
+			}
+		}
+		return false
+	}
+	if managed(func(s *config.ScheduleConfigStruct) bool { return s.DownloadSpeedLimit != "" }) {
+		if err := applySpeedLimit(clientInstance, "global_download_speed_limit", active.DownloadSpeedLimit); err != nil {
+			log.Errorf("Failed to set client %s download speed limit: %v", clientName, err)
+			errorCnt++
+		}
+	}
+	if managed(func(s *config.ScheduleConfigStruct) bool { return s.UploadSpeedLimit != "" }) {
+		if err := applySpeedLimit(clientInstance, "global_upload_speed_limit", active.UploadSpeedLimit); err != nil {
+			log.Errorf("Failed to set client %s upload speed limit: %v", clientName, err)
+			errorCnt++
+		}
+	}
+	if managed(func(s *config.ScheduleConfigStruct) bool { return s.AltSpeed }) {
+		if err := applyAltSpeed(clientInstance, active.AltSpeed); err != nil {
+			log.Errorf("Failed to set client %s alt speed: %v", clientName, err)
+			errorCnt++
+		}
+	}
+	if managed(func(s *config.ScheduleConfigStruct) bool { return s.Noadd }) {
+		if err := applyNoadd(clientInstance, active.Noadd); err != nil {
+			log.Errorf("Failed to set client %s noadd flag: %v", clientName, err)
+			errorCnt++
+		}
+	}
+	if errorCnt > 0 {
+		return fmt.Errorf("%d errors", errorCnt)
+	}
+	return nil
+}
+
+func applySpeedLimit(clientInstance client.Client, variable string, limitStr string) error {
+	limit := int64(0)
+	if limitStr != "" {
+		var err error
+		if limit, err = util.RAMInBytes(limitStr); err != nil {
+			return fmt.Errorf("invalid speed limit %q: %w", limitStr, err)
+		}
+	}
+	limit = max(limit, 0)
+	value, err := clientInstance.GetConfig(variable)
+	if err != nil {
+		return err
+	}
+	current := max(util.ParseInt(value), 0)
+	// clients may store limits in KiB/s
+	if current/1024 == limit/1024 {
+		fmt.Printf("- %s = %s (unchanged)\n", variable, speedString(current))
+		return nil
+	}
+	if !dryRun {
+		if err = clientInstance.SetConfig(variable, fmt.Sprint(limit)); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("✓ %s = %s (was %s)\n", variable, speedString(limit), speedString(current))
+	return nil
+}
+
+func applyAltSpeed(clientInstance client.Client, enabled bool) error {
+	value, err := clientInstance.GetConfig("alt_speed_enabled")
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		log.Warnf("Client %s does not support alt speed", clientInstance.GetName())
+		return nil
+	}
+	if value == fmt.Sprint(enabled) {
+		fmt.Printf("- alt_speed_enabled = %t (unchanged)\n", enabled)
+		return nil
+	}
+	if !dryRun {
+		if err = clientInstance.SetConfig("alt_speed_enabled", fmt.Sprint(enabled)); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("✓ alt_speed_enabled = %t (was %s)\n", enabled, value)
+	return nil
+}
+
+func applyNoadd(clientInstance client.Client, noadd bool) error {
+	status, err := clientInstance.GetStatus()
+	if err != nil {
+		return err
+	}
+	if status.NoAdd == noadd {
+		fmt.Printf("- noadd = %t (unchanged)\n", noadd)
+		return nil
+	}
+	if !dryRun {
+		if noadd {
+			err = clientInstance.CreateTags(config.NOADD_TAG)
+			if errors.Is(err, errors.ErrUnsupported) {
+				log.Warnf("Client %s does not support client level tags, noadd is not set", clientInstance.GetName())
+				return nil
+			}
+		} else {
+			err = clientInstance.DeleteTags(config.NOADD_TAG)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("✓ noadd = %t (was %t)\n", noadd, status.NoAdd)
+	return nil
+}
+
+// Check the time window, days and speed limits of schedule.
+func validateSchedule(schedule *config.ScheduleConfigStruct) error {
+	if schedule.Time != "" {
+		if _, _, err := parseTimeWindow(schedule.Time); err != nil {
+			return err
+		}
+	}
+	if schedule.Days != "" {
+		if _, err := parseDays(schedule.Days); err != nil {
+			return err
+		}
+	}
+	for _, limit := range []string{schedule.UploadSpeedLimit, schedule.DownloadSpeedLimit} {
+		if limit == "" {
+			continue
+		}
+		if _, err := util.RAMInBytes(limit); err != nil {
+			return fmt.Errorf("invalid speed limit %q: %w", limit, err)
+		}
+	}
+	return nil
+}
+
+// Return whether schedule is active at time now (local time).
+func scheduleActive(schedule *config.ScheduleConfigStruct, now time.Time) (bool, error) {
+	minute := now.Hour()*60 + now.Minute()
+	day := now.Weekday()
+	if schedule.Time != "" {
+		start, end, err := parseTimeWindow(schedule.Time)
+		if err != nil {
+			return false, err
+		}
+		if start <= end {
+			if minute < start || minute >= end {
+				return false, nil
+			}
+		} else {
+			if minute < start && minute >= end {
+				return false, nil
+			}
+			if minute < end {
+				// the part after midnight belongs to the window started in previous day
+				day = (day + 6) % 7
+			}
+		}
+	}
+	if schedule.Days != "" {
+		days, err := parseDays(schedule.Days)
+		if err != nil {
+			return false, err
+		}
+		if !days[day] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Parse "HH:MM-HH:MM" time window, return start and end minute of day.
+func parseTimeWindow(window string) (start int, end int, err error) {
+	startStr, endStr, found := strings.Cut(window, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid time window %q", window)
+	}
+	if start, err = parseClock(startStr); err != nil {
+		return
+	}
+	end, err = parseClock(endStr)
+	return
+}
+
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		if strings.TrimSpace(clock) == "24:00" {
+			return 24 * 60, nil
+		}
+		return 0, fmt.Errorf("invalid time %q", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func parseDays(days string) (map[time.Weekday]bool, error) {
+	result := map[time.Weekday]bool{}
+	for _, day := range util.SplitCsv(days) {
+		weekday, ok := weekdays[strings.ToLower(day)[:min(3, len(day))]]
+		if !ok {
+			return nil, fmt.Errorf("invalid day %q", day)
+		}
+		result[weekday] = true
+	}
+	return result, nil
+}
+
+func scheduleName(schedule *config.ScheduleConfigStruct) string {
+	name := schedule.Name
+	if name == "" {
+		name = "(unnamed)"
+	}
+	if schedule.Time != "" {
+		name += " " + schedule.Time
+	}
+	if schedule.Days != "" {
+		name += " " + schedule.Days
+	}
+	return name
+}
+
+func speedString(speed int64) string {
+	if speed <= 0 {
+		return "unlimited"
+	}
+	return util.BytesSize(float64(speed)) + "/s"
+}
@@ -0,0 +1,102 @@
+package speedschedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sagan/ptool/config"
+)
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		clock   string
+		want    int
+		wantErr bool
+	}{
+		{"00:00", 0, false},
+		{"08:30", 8*60 + 30, false},
+		{" 9:05 ", 9*60 + 5, false},
+		{"23:59", 23*60 + 59, false},
+		{"24:00", 24 * 60, false},
+		{"24:01", 0, true},
+		{"8", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseClock(tt.clock)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseClock(%q): expected error, got %d", tt.clock, got)
+			}
+		} else if err != nil || got != tt.want {
+			t.Errorf("parseClock(%q) = %d, %v, want %d", tt.clock, got, err, tt.want)
+		}
+	}
+}
+
+func TestScheduleActive(t *testing.T) {
+	// 2024-06-15 is Saturday
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2024, 6, day, hour, minute, 0, 0, time.Local)
+	}
+	tests := []struct {
+		desc     string
+		schedule *config.ScheduleConfigStruct
+		now      time.Time
+		want     bool
+		wantErr  bool
+	}{
+		{"always", &config.ScheduleConfigStruct{}, at(15, 12, 0), true, false},
+		{"in window", &config.ScheduleConfigStruct{Time: "08:00-18:00"}, at(15, 8, 0), true, false},
+		{"window end is exclusive", &config.ScheduleConfigStruct{Time: "08:00-18:00"}, at(15, 18, 0), false, false},
+		{"before window", &config.ScheduleConfigStruct{Time: "08:00-18:00"}, at(15, 7, 59), false, false},
+		{"until midnight", &config.ScheduleConfigStruct{Time: "20:00-24:00"}, at(15, 23, 59), true, false},
+		{"overnight before midnight", &config.ScheduleConfigStruct{Time: "23:00-07:00"}, at(15, 23, 30), true, false},
+		{"overnight after midnight", &config.ScheduleConfigStruct{Time: "23:00-07:00"}, at(16, 6, 59), true, false},
+		{"overnight outside", &config.ScheduleConfigStruct{Time: "23:00-07:00"}, at(16, 7, 0), false, false},
+		{"day matches", &config.ScheduleConfigStruct{Days: "sat,sun"}, at(15, 12, 0), true, false},
+		{"day not matches", &config.ScheduleConfigStruct{Days: "mon,fri"}, at(15, 12, 0), false, false},
+		{"weekday not matches", &config.ScheduleConfigStruct{Days: "monday, friday"}, at(15, 12, 0), false, false},
+		{
+			// the part after midnight belongs to the window started on Friday
+			"overnight window of previous day",
+			&config.ScheduleConfigStruct{Time: "22:00-02:00", Days: "fri"}, at(15, 1, 0), true, false,
+		},
+		{
+			"overnight window not of previous day",
+			&config.ScheduleConfigStruct{Time: "22:00-02:00", Days: "sat"}, at(15, 1, 0), false, false,
+		},
+		{"invalid day", &config.ScheduleConfigStruct{Days: "sat,foo"}, at(15, 12, 0), false, true},
+		{"invalid time window", &config.ScheduleConfigStruct{Time: "08:00"}, at(15, 12, 0), false, true},
+	}
+	for _, tt := range tests {
+		got, err := scheduleActive(tt.schedule, tt.now)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error", tt.desc)
+			}
+		} else if err != nil || got != tt.want {
+			t.Errorf("%s: got %t, %v, want %t", tt.desc, got, err, tt.want)
+		}
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	tests := []struct {
+		desc     string
+		schedule *config.ScheduleConfigStruct
+		wantErr  bool
+	}{
+		{"empty", &config.ScheduleConfigStruct{}, false},
+		{"valid", &config.ScheduleConfigStruct{Time: "23:00-07:00", Days: "fri,sat", UploadSpeedLimit: "5MiB"}, false},
+		{"invalid time window", &config.ScheduleConfigStruct{Time: "23:00"}, true},
+		{"invalid clock", &config.ScheduleConfigStruct{Time: "08:00-25:00"}, true},
+		{"invalid day", &config.ScheduleConfigStruct{Days: "mon,foo"}, true},
+		{"invalid speed limit", &config.ScheduleConfigStruct{DownloadSpeedLimit: "fast"}, true},
+	}
+	for _, tt := range tests {
+		if err := validateSchedule(tt.schedule); (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %t", tt.desc, err, tt.wantErr)
+		}
+	}
+}
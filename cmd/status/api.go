@@ -18,9 +18,21 @@ type StatusResponse struct {
 	SiteStatus        *site.Status
 	SiteTorrents      []*site.Torrent // latest site torrents
 	SiteTorrentScores map[string]float64
+	SiteTorrentRules  map[string]string // name of the brush rule that decided score of each site torrent
 	Error             error
 }
 
+// Status of a client or site in "--json" output.
+type JsonStatus struct {
+	Name           string
+	Kind           string            // "client" | "site"
+	ClientStatus   *client.Status    `json:",omitempty"`
+	ClientTorrents []*client.Torrent `json:",omitempty"`
+	SiteStatus     *site.Status      `json:",omitempty"`
+	SiteTorrents   []*site.Torrent   `json:",omitempty"`
+	Error          string            `json:",omitempty"`
+}
+
 func fetchClientStatus(clientInstance client.Client, showTorrents bool, showAllTorrents bool,
 	category string, ch chan *StatusResponse) {
 	response := &StatusResponse{Name: clientInstance.GetName(), Kind: 1}
@@ -76,12 +88,17 @@ func fetchSiteStatus(siteInstance site.Site, showTorrents bool, full bool, showS
 			response.Error = fmt.Errorf("cann't get site %s torrents: %w", siteInstance.GetName(), err)
 		} else {
 			if showScore {
-				brushSiteOption := strategy.GetBrushSiteOptions(siteInstance, util.Now())
-				scores := map[string]float64{}
-				for _, torrent := range siteTorrents {
-					scores[torrent.Id], _, _ = strategy.RateSiteTorrent(torrent, brushSiteOption)
+				if brushSiteOption, err := strategy.GetBrushSiteOptions(siteInstance, util.Now()); err != nil {
+					response.Error = err
+				} else {
+					scores := map[string]float64{}
+					rules := map[string]string{}
+					for _, torrent := range siteTorrents {
+						scores[torrent.Id], _, rules[torrent.Id] = strategy.RateSiteTorrent(torrent, brushSiteOption)
+					}
+					response.SiteTorrentScores = scores
+					response.SiteTorrentRules = rules
 				}
-				response.SiteTorrentScores = scores
 			}
 			response.SiteTorrents = siteTorrents
 		}
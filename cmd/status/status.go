@@ -26,6 +26,7 @@ var (
 	showAllClients = false
 	showAllSites   = false
 	showScore      = false
+	showJson       = false
 	largestFlag    = false
 	newestFlag     = false
 	filter         = ""
@@ -50,6 +51,10 @@ For client, display following status info:
 For site, display following status info:
 - ↑: : Current uploading statistics.
 - ↓: : Current downloading statstics.
+- Other account infos if available: user class, bonus points (and bonus per hour),
+  H&R count, invites and unread messages.
+
+If "--json" flag is set, it prints the status of all clients and sites in json array format instead.
 
 If "-t" flag is set, it will also show the active / latest torrents list of client / site.
 For the list format of client torrents, see help of "ptool show" command.
@@ -67,7 +72,8 @@ func init() {
 	command.Flags().BoolVarP(&showTorrents, "torrents", "t", false,
 		"Show torrents (active torrents for client / latest torrents for site)")
 	command.Flags().BoolVarP(&showFull, "full", "f", false, "Show full info of each client or site")
-	command.Flags().BoolVarP(&showScore, "score", "", false, "Show brush score of site torrents")
+	command.Flags().BoolVarP(&showScore, "score", "", false, "Show brush score of site torrents and the brush rule that decided it")
+	command.Flags().BoolVarP(&showJson, "json", "", false, "Show output in json format")
 	command.Flags().BoolVarP(&largestFlag, "largest", "l", false, `Sort torrents by size in desc order"`)
 	command.Flags().BoolVarP(&newestFlag, "newest", "n", false, `Sort torrents by time in desc order"`)
 	command.Flags().StringVarP(&filter, "filter", "", "", constants.HELP_ARG_FILTER_TORRENT)
@@ -186,6 +192,36 @@ func status(cmd *cobra.Command, args []string) error {
 		})
 	}
 
+	if showJson {
+		data := []*JsonStatus{}
+		for _, response := range responses {
+			item := &JsonStatus{
+				Name:           response.Name,
+				ClientStatus:   response.ClientStatus,
+				ClientTorrents: response.ClientTorrents,
+				SiteStatus:     response.SiteStatus,
+				SiteTorrents:   response.SiteTorrents,
+			}
+			if response.Kind == 1 {
+				item.Kind = "client"
+			} else {
+				item.Kind = "site"
+			}
+			if response.Error != nil {
+				item.Error = response.Error.Error()
+				errorCnt++
+			}
+			data = append(data, item)
+		}
+		if err := util.PrintJson(os.Stdout, data); err != nil {
+			return err
+		}
+		if errorCnt > 0 {
+			return fmt.Errorf("%d errors", errorCnt)
+		}
+		return nil
+	}
+
 	errorsStr := ""
 	for _, response := range responses {
 		if response.Kind == 1 {
@@ -232,6 +268,9 @@ func status(cmd *cobra.Command, args []string) error {
 				successSitesDownloaded += response.SiteStatus.UserDownloaded
 				additionalInfo := fmt.Sprintf("UserName: %s; Ratio: %.2f", response.SiteStatus.UserName,
 					float64(response.SiteStatus.UserUploaded)/float64(response.SiteStatus.UserDownloaded))
+				if extraInfo := response.SiteStatus.ExtraInfo(); extraInfo != "" {
+					additionalInfo += "; " + extraInfo
+				}
 				if len(response.SiteTorrents) > 0 {
 					additionalInfo += fmt.Sprintf("; Torrents: %d", len(response.SiteTorrents))
 				}
@@ -250,7 +289,8 @@ func status(cmd *cobra.Command, args []string) error {
 						return response.SiteTorrents[i].Time > response.SiteTorrents[j].Time
 					})
 				}
-				site.PrintTorrents(os.Stdout, response.SiteTorrents, filter, now, false, dense, response.SiteTorrentScores)
+				site.PrintTorrents(os.Stdout, response.SiteTorrents, filter, now, false, dense, response.SiteTorrentScores,
+					response.SiteTorrentRules)
 				fmt.Printf("\n")
 			}
 		}
@@ -40,13 +40,17 @@ const (
 	PRIVATE_TAG                = "_private"
 	PUBLIC_TAG                 = "_public"
 	STATS_FILENAME             = "ptool_stats.txt"
+	BRUSH_HISTORY_FILENAME     = "ptool_brush_history.db"
 	HISTORY_FILENAME           = "ptool_history"
 	SITE_TORRENTS_WIDTH        = 120 // min width for printing site torrents
 	CLIENT_TORRENTS_WIDTH      = 120 // min width for printing client torrents
 	GLOBAL_INTERNAL_LOCK_FILE  = "ptool.lock"
 	GLOBAL_LOCK_FILE           = "ptool-global.lock"
 	CLIENT_LOCK_FILE           = "client-%s.lock"
+	SITE_LOGIN_FILE            = "login-%s.json" // saved login credential of site
+	SITES_DIR                  = "sites.d"       // dir of external site templates (*.yaml) in config dir
 	EXAMPLE_CONFIG_FILE        = "ptool.example" // .toml , .yaml
+	ALL_CLIENTS_GROUP          = "_all_clients"  // special client group of all enabled clients
 
 	DEFAULT_EXPORT_TORRENT_RENAME = "{{.name128}}.{{.infohash16}}.torrent"
 	// New iyuu API.
@@ -90,6 +94,51 @@ type GroupConfigStruct struct {
 	Comment string   `yaml:"comment"`
 }
 
+// A client group can be used as a (virtual) client which aggregates all clients in the group.
+type ClientGroupConfigStruct struct {
+	Name    string   `yaml:"name"`
+	Clients []string `yaml:"clients"`
+	Comment string   `yaml:"comment"`
+}
+
+// Bandwidth schedule of clients. See "speedschedule" cmd.
+type ScheduleConfigStruct struct {
+	Name    string   `yaml:"name"`
+	Clients []string `yaml:"clients"` // names of clients this schedule applies to. Empty: all clients
+	// Time window in local time, "HH:MM-HH:MM" format, e.g. "08:00-23:00". It may cross midnight: "23:00-07:00".
+	// Empty: all day.
+	Time string `yaml:"time"`
+	// Comma-separated weekdays (mon,tue,wed,thu,fri,sat,sun) this schedule applies to. Empty: everyday.
+	Days               string `yaml:"days"`
+	DownloadSpeedLimit string `yaml:"downloadSpeedLimit"` // global download speed limit (/s), e.g. "10MiB". "0": no limit
+	UploadSpeedLimit   string `yaml:"uploadSpeedLimit"`   // global upload speed limit (/s)
+	AltSpeed           bool   `yaml:"altSpeed"`           // enable alternative speed limits of client (qb / tr only)
+	Noadd              bool   `yaml:"noadd"`              // add "_noadd" flag tag to client (brush etc. will not add torrents)
+	Comment            string `yaml:"comment"`
+}
+
+// User defined brush rule. See "brush" cmd.
+// Each expression is a Go text template pipeline (without the surrounding "{{ }}"), Sprig functions are available.
+// Rules are evaluated in order: site rules first, then client rules.
+type BrushRuleConfigStruct struct {
+	Name string `yaml:"name"`
+	// Site torrent predicates. If accept evaluates to false, or reject evaluates to true, the torrent is rejected.
+	Accept string `yaml:"accept"`
+	Reject string `yaml:"reject"`
+	// Site torrent score formula. ".Score" is the score of default algorithm (and previous rules). 0: reject.
+	Score string `yaml:"score"`
+	// Client brush torrent delete condition. If evaluates to true, the torrent is deleted immediately.
+	Delete  string `yaml:"delete"`
+	Comment string `yaml:"comment"`
+}
+
+// A brush save path of client. See "brush" cmd.
+type BrushSavePathConfigStruct struct {
+	Path              string `yaml:"path"`         // save path on the client host
+	MinDiskSpace      string `yaml:"minDiskSpace"` // default to brushMinDiskSpace of client
+	MinDiskSpaceValue int64
+}
+
 type AliasConfigStruct struct {
 	Name        string `yaml:"name"`
 	Cmd         string `yaml:"cmd"`
@@ -110,7 +159,9 @@ type ClientConfigStruct struct {
 	// 适用于本机上的 BT 客户端。当前客户端存放种子 (<info-hash>.torrent)的路径。各个客户端默认值：
 	// Transmission on Windows: %SystemRoot%\ServiceProfiles\LocalService\AppData\Local\transmission-daemon\Torrents .
 	// qBittorrent on Windows: %USERPROFILE%\AppData\Local\qBittorrent\BT_backup .
-	// 对于 TR 需要配置此选项才能使用部分命令（例如“导出种子”）。
+	// Deluge on Linux: ~/.config/deluge/state .
+	// rTorrent: session dir ("session.path").
+	// 对于 TR / Deluge 需要配置此选项才能使用部分命令（例如“导出种子”）。
 	// 对于 QB 此配置是可选的(因为 QB web API 提供导出种子接口)，但配置后会提高相关命令的性能。
 	LocalTorrentsPath                 string  `yaml:"localTorrentsPath"`
 	BrushMinDiskSpace                 string  `yaml:"brushMinDiskSpace"`
@@ -122,9 +173,19 @@ type ClientConfigStruct struct {
 	BrushMinDiskSpaceValue            int64
 	BrushSlowUploadSpeedTierValue     int64
 	BrushDefaultUploadSpeedLimitValue int64 ``
-	QbittorrentNoLogin                bool  `yaml:"qbittorrentNoLogin"`  // if set, will NOT send login request
-	QbittorrentNoLogout               bool  `yaml:"qbittorrentNoLogout"` // if set, will NOT send logout request
-	QbittorrentV4                     bool  `yaml:"qbittorrentV4"`       // is qbittorrent v4 (legacy) version.
+	// 刷流规则。适用于该客户端的所有刷流种子。站点配置的规则优先于客户端的规则
+	BrushRules []*BrushRuleConfigStruct `yaml:"brushRules"`
+	// 刷流种子保存路径(可以位于不同硬盘)。每个路径分别计算剩余空间，新种子添加到剩余空间最充足的路径。
+	// 未设置时使用客户端默认保存路径
+	BrushSavePaths []*BrushSavePathConfigStruct `yaml:"brushSavePaths"`
+	// 仅适用于 qBittorrent 客户端。缓存的客户端数据(种子列表、状态等)的最长有效时间(秒)。超过此时间后自动(增量)刷新。
+	// 默认 0：不自动刷新(在 ptool shell 里需手动 purge)。
+	SyncMaxAge          int64 `yaml:"syncMaxAge"`
+	QbittorrentNoLogin  bool  `yaml:"qbittorrentNoLogin"`  // if set, will NOT send login request
+	QbittorrentNoLogout bool  `yaml:"qbittorrentNoLogout"` // if set, will NOT send logout request
+	QbittorrentV4       bool  `yaml:"qbittorrentV4"`       // is qbittorrent v4 (legacy) version. Auto detected if not set
+	// mock 客户端的状态文件(JSON)路径。相对路径基于 ptool 配置文件夹。为空则仅在内存中保存数据。
+	MockStateFile string `yaml:"mockStateFile"`
 }
 
 type SiteConfigStruct struct {
@@ -136,9 +197,9 @@ type SiteConfigStruct struct {
 	Hidden                         bool       `yaml:"hidden"` // exclude from default groups (like "_all")
 	Dead                           bool       `yaml:"dead"`   // site is (currently) dead.
 	Url                            string     `yaml:"url"`
-	Domains                        []string   `yaml:"domains"` // other site domains (do not include subdomain part)
-	TorrentsUrl                    string     `yaml:"torrentsUrl"`
-	SearchUrl                      string     `yaml:"searchUrl"`
+	Domains                        []string   `yaml:"domains"`     // other site domains (do not include subdomain part)
+	TorrentsUrl                    string     `yaml:"torrentsUrl"` // "rss:<feed_url>": read torrents list from feed
+	SearchUrl                      string     `yaml:"searchUrl"`   // can use "%s" as keyword placeholder
 	DynamicSeedingTorrentsUrl      string     `yaml:"dynamicSeedingTorrentsUrl"`
 	DynamicSeedingExcludes         []string   `yaml:"dynamicSeedingExcludes"`
 	DynamicSeedingSize             string     `yaml:"dynamicSeedingSize"`
@@ -195,6 +256,12 @@ type SiteConfigStruct struct {
 	SelectorUserInfoUserName       string     `yaml:"selectorUserInfoUserName"`
 	SelectorUserInfoUploaded       string     `yaml:"selectorUserInfoUploaded"`
 	SelectorUserInfoDownloaded     string     `yaml:"selectorUserInfoDownloaded"`
+	SelectorUserInfoBonus          string     `yaml:"selectorUserInfoBonus"`        // 魔力值 / 积分
+	SelectorUserInfoBonusPerHour   string     `yaml:"selectorUserInfoBonusPerHour"` // 每小时做种魔力
+	SelectorUserInfoClass          string     `yaml:"selectorUserInfoClass"`        // 用户等级
+	SelectorUserInfoHnR            string     `yaml:"selectorUserInfoHnR"`          // H&R 数量
+	SelectorUserInfoInvites        string     `yaml:"selectorUserInfoInvites"`
+	SelectorUserInfoUnreadMessages string     `yaml:"selectorUserInfoUnreadMessages"`
 	ImageUploadUrl                 string     `yaml:"imageUploadUrl"`
 	// Additional post payload when uploading image, query string format.
 	// E.g. "foo=a&bar=b".
@@ -223,7 +290,31 @@ type SiteConfigStruct struct {
 	TorrentDownloadUrl               string `yaml:"torrentDownloadUrl"` // use {id} placeholders in url
 	TorrentDownloadUrlPrefix         string `yaml:"torrentDownloadUrlPrefix"`
 	Passkey                          string `yaml:"passkey"`
-	UseCuhash                        bool   `yaml:"useCuhash"` // hdcity 使用机制。种子下载地址里必须有cuhash参数
+	// 站点 API 密钥。UNIT3D 站点: 个人设置 - API Key (api_token)。配置后使用站点 API 获取种子列表。
+	// Gazelle 站点: 设置页面生成的 API Key，作为 Authorization header 发送(部分站点需要 "token " 前缀)
+	ApiKey string `yaml:"apiKey"`
+	// 站点 API 请求频率限制: 每 10 秒最多请求次数。0: 使用默认值(Gazelle: 5)；-1: 不限制
+	ApiRateLimit int64 `yaml:"apiRateLimit"`
+	// 站点账号密码。配置后可以使用 "ptool login" 命令登录站点获取 cookie (或 token)，
+	// 并在 cookie 失效(访问被重定向到登录页)时自动重新登录。登录结果保存在配置文件目录的 login-<site>.json 文件里
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// 站点两步验证(2FA)的 TOTP 密钥(base32 格式)。配置后登录时自动生成验证码
+	TotpSecret string `yaml:"totpSecret"`
+	// "ptool serve torznab" 使用的站点 Torznab 分类 id 列表，例如 ["2000", "5000"] (电影、剧集)。
+	// 未配置时认为站点提供所有分类。种子的分类根据其站点分类标签自动识别
+	TorznabCategories []string `yaml:"torznabCategories"`
+	// 刷流规则。使用表达式修改默认刷流算法的选种评分、增加接受/拒绝条件或定义删种条件。
+	// 站点的删种条件只适用于该站点的刷流种子
+	BrushRules []*BrushRuleConfigStruct `yaml:"brushRules"`
+	// 刷流：站点账号最小分享率。账号分享率(或计入正在下载种子的预计分享率)低于此值时不再添加新种子；
+	// 远低于此值时删除该站点优惠已过期的未完成种子。默认 0：不检查
+	BrushMinAccountRatio float64 `yaml:"brushMinAccountRatio"`
+	// cardigann 类型站点使用的 Jackett Cardigann 站点定义文件 (yaml) 路径。相对路径基于配置文件目录
+	CardigannDefinition string `yaml:"cardigannDefinition"`
+	// cardigann 站点定义里 settings 配置项的值 (name => value)。username / password / cookie 使用站点对应配置
+	CardigannSettings map[string]string `yaml:"cardigannSettings"`
+	UseCuhash         bool              `yaml:"useCuhash"` // hdcity 使用机制。种子下载地址里必须有cuhash参数
 	// ttg 使用机制。种子下载地址末段必须有4位数字校验码或Passkey参数(即使有 Cookie)
 	UseDigitHash                      bool   `yaml:"useDigitHash"`
 	UsePasskey                        bool   `yaml:"usePasskey"` // 部分站点(例如 ptt)必须使用包含 passkey 的链接下载种子
@@ -260,15 +351,20 @@ type ConfigStruct struct {
 	SiteInsecure        bool                       `yaml:"siteInsecure"` // 强制禁用所有站点 TLS 证书校验。
 	SiteH2Fingerprint   string                     `yaml:"siteH2Fingerprint"`
 	BrushEnableStats    bool                       `yaml:"brushEnableStats"`
+	BrushEnableHistory  bool                       `yaml:"brushEnableHistory"` // 记录刷流种子历史数据并用于刷流决策
 	Clients             []*ClientConfigStruct      `yaml:"clients"`
 	Sites               []*SiteConfigStruct        `yaml:"sites"`
 	Groups              []*GroupConfigStruct       `yaml:"groups"`
+	ClientGroups        []*ClientGroupConfigStruct `yaml:"clientGroups"`
 	Aliases             []*AliasConfigStruct       `yaml:"aliases"`
 	Cookieclouds        []*CookiecloudConfigStruct `yaml:"cookieclouds"`
+	Schedules           []*ScheduleConfigStruct    `yaml:"schedules"`
 	Comment             string                     `yaml:"comment"`
 	// 公网 BT 种子的分享率(Up/Dl)限制(到达后停止做种)。"add" 等命令添加公网种子到BT客户端时会自动应用此限制。
 	// 0 : unlimited。仅 qBittorrent 支持此选项。
 	PublicTorrentRatioLimit float64 `yaml:"publicTorrentRatioLimit"`
+	// "ptool serve torznab" Torznab 服务器的 API Key。Sonarr / Radarr 等客户端添加索引器时需要填写
+	TorznabApiKey string `yaml:"torznabApiKey"`
 
 	ClientsEnabled []*ClientConfigStruct
 	SitesEnabled   []*SiteConfigStruct
@@ -299,6 +395,7 @@ var (
 	sitesConfigMap        = map[string]*SiteConfigStruct{}
 	aliasesConfigMap      = map[string]*AliasConfigStruct{}
 	groupsConfigMap       = map[string]*GroupConfigStruct{}
+	clientGroupsConfigMap = map[string]*ClientGroupConfigStruct{}
 	cookiecloudsConfigMap = map[string]*CookiecloudConfigStruct{}
 	internalAliasesMap    = map[string]*AliasConfigStruct{}
 	once                  sync.Once
@@ -445,6 +542,17 @@ func Get() *ConfigStruct {
 			}
 			client.BrushDefaultUploadSpeedLimitValue = v
 
+			for _, savePath := range client.BrushSavePaths {
+				if savePath.Path == "" {
+					log.Fatalf("Invalid config file: client %s brushSavePaths path is empty", client.Name)
+				}
+				v, err = util.RAMInBytes(savePath.MinDiskSpace)
+				if err != nil || v < 0 {
+					v = client.BrushMinDiskSpaceValue
+				}
+				savePath.MinDiskSpaceValue = v
+			}
+
 			if client.Url != "" {
 				urlObj, err := url.Parse(client.Url)
 				if err != nil {
@@ -485,6 +593,20 @@ func Get() *ConfigStruct {
 			}
 			groupsConfigMap[group.Name] = group
 		}
+		for _, clientGroup := range configData.ClientGroups {
+			assertConfigItemNameIsValid("client group", clientGroup.Name, clientGroup)
+			if clientGroupsConfigMap[clientGroup.Name] != nil || clientsConfigMap[clientGroup.Name] != nil ||
+				clientGroup.Name == ALL_CLIENTS_GROUP {
+				log.Fatalf("Invalid config file: duplicate client group name %s found", clientGroup.Name)
+			}
+			for _, clientName := range clientGroup.Clients {
+				if clientsConfigMap[clientName] == nil {
+					log.Fatalf("Invalid config file: client group %s contains non-existent client %s",
+						clientGroup.Name, clientName)
+				}
+			}
+			clientGroupsConfigMap[clientGroup.Name] = clientGroup
+		}
 		for _, alias := range configData.Aliases {
 			assertConfigItemNameIsValid("alias", alias.Name, alias)
 			if alias.Name == "alias" {
@@ -536,6 +658,33 @@ func GetGroupConfig(name string) *GroupConfigStruct {
 	return groupsConfigMap[name]
 }
 
+// Return schedules of client, in the order of they are defined in config file.
+func GetClientSchedules(name string) []*ScheduleConfigStruct {
+	return util.Filter(Get().Schedules, func(schedule *ScheduleConfigStruct) bool {
+		return len(schedule.Clients) == 0 || slices.Contains(schedule.Clients, name)
+	})
+}
+
+func GetClientGroupConfig(name string) *ClientGroupConfigStruct {
+	Get()
+	if name == "" {
+		return nil
+	}
+	return clientGroupsConfigMap[name]
+}
+
+// If name is a client group, return it's clients, otherwise return nil.
+func GetClientGroupClients(name string) []string {
+	if name == ALL_CLIENTS_GROUP {
+		return util.Map(Get().ClientsEnabled, func(c *ClientConfigStruct) string { return c.Name })
+	}
+	clientGroup := GetClientGroupConfig(name)
+	if clientGroup != nil {
+		return clientGroup.Clients
+	}
+	return nil
+}
+
 func GetAliasConfig(name string) *AliasConfigStruct {
 	Get()
 	if name == "" {
@@ -604,6 +753,13 @@ func (aliasConfig *AliasConfigStruct) MatchFilter(filter string) bool {
 	return util.ContainsI(aliasConfig.Name, filter) || util.ContainsI(aliasConfig.Cmd, filter)
 }
 
+func (clientGroupConfig *ClientGroupConfigStruct) MatchFilter(filter string) bool {
+	return util.ContainsI(clientGroupConfig.Name, filter) ||
+		slices.ContainsFunc(clientGroupConfig.Clients, func(s string) bool {
+			return strings.EqualFold(s, filter)
+		})
+}
+
 func (groupConfig *GroupConfigStruct) MatchFilter(filter string) bool {
 	return util.ContainsI(groupConfig.Name, filter) ||
 		slices.ContainsFunc(groupConfig.Sites, func(s string) bool {
@@ -23,6 +23,7 @@ require (
 	github.com/Noooste/azuretls-client v1.6.4
 	github.com/PuerkitoBio/goquery v1.10.2
 	github.com/anacrolix/torrent v1.58.1
+	github.com/andybalholm/cascadia v1.3.3
 	github.com/c-bata/go-prompt v0.2.6
 	github.com/ettle/strcase v0.2.0
 	github.com/glebarez/sqlite v1.11.0
@@ -85,7 +86,6 @@ require (
 	github.com/anacrolix/missinggo v1.3.0 // indirect
 	github.com/anacrolix/missinggo/v2 v2.8.0 // indirect
 	github.com/andybalholm/brotli v1.1.1 // indirect
-	github.com/andybalholm/cascadia v1.3.3 // indirect
 	github.com/cloudflare/circl v1.6.0 // indirect
 	github.com/fsnotify/fsnotify v1.8.0 // indirect
 	github.com/glebarez/go-sqlite v1.22.0 // indirect
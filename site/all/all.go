@@ -1,11 +1,13 @@
 package all
 
 import (
+	_ "github.com/sagan/ptool/site/cardigann"
 	_ "github.com/sagan/ptool/site/discuz"
 	_ "github.com/sagan/ptool/site/gazelle"
 	_ "github.com/sagan/ptool/site/gazellepw"
 	_ "github.com/sagan/ptool/site/mtorrent"
 	_ "github.com/sagan/ptool/site/nexusphp"
+	_ "github.com/sagan/ptool/site/rss"
 	_ "github.com/sagan/ptool/site/tnode"
 	_ "github.com/sagan/ptool/site/torrenttrader"
 	_ "github.com/sagan/ptool/site/tpl"
@@ -0,0 +1,608 @@
+package cardigann
+
+// cardigann is a generic site type that scrapes site pages according to a Cardigann indexer definition file
+// (the yaml format used by Jackett: https://github.com/Jackett/Jackett/tree/master/src/Jackett.Common/Definitions).
+// Site config: cardigannDefinition: definition file path; cardigannSettings: values of definition settings.
+// The site url defaults to the first link of definition.
+// Supported: login block (post / form / get / cookie methods), search paths (html response) with inputs,
+// keywordsfilters, rows & fields selectors, filters, and download selectors.
+// Not supported: json responses, captcha login, multiple search modes (imdb / tv search...).
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Noooste/azuretls-client"
+	"github.com/PuerkitoBio/goquery"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/html/charset"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+type Site struct {
+	Name        string
+	Location    *time.Location
+	SiteConfig  *config.SiteConfigStruct
+	Config      *config.ConfigStruct
+	HttpClient  *azuretls.Session
+	HttpHeaders [][]string
+	Definition  *Definition
+}
+
+var (
+	// Extract site torrent id from details or download link.
+	torrentIdRegexp = regexp.MustCompile(
+		`(?:[?&](?:id|torrentid|tid)=|/(?:torrents?|details|download|t)/)(?P<id>\d+)\b`)
+	magnetRegexp = regexp.MustCompile(`(?i)\bxt=urn:btih:(?P<hash>[0-9a-f]{40})\b`)
+)
+
+func (cs *Site) PublishTorrent(contents []byte, metadata url.Values) (id string, err error) {
+	return "", site.ErrUnimplemented
+}
+
+func (cs *Site) GetDefaultHttpHeaders() [][]string {
+	return cs.HttpHeaders
+}
+
+func (cs *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (cs *Site) PurgeCache() {
+}
+
+func (cs *Site) GetName() string {
+	return cs.Name
+}
+
+func (cs *Site) GetSiteConfig() *config.SiteConfigStruct {
+	return cs.SiteConfig
+}
+
+func (cs *Site) GetStatus() (*site.Status, error) {
+	return nil, site.ErrUnimplemented
+}
+
+// Latest torrents are search results of empty keyword.
+func (cs *Site) GetLatestTorrents(full bool) ([]*site.Torrent, error) {
+	return cs.search("")
+}
+
+// Cardigann definitions do not support paging, all torrents are latest torrents, sorted locally.
+func (cs *Site) GetAllTorrents(sort string, desc bool, pageMarker string, baseUrl string) (
+	torrents []*site.Torrent, nextPageMarker string, err error) {
+	if torrents, err = cs.search(""); err != nil {
+		return nil, "", err
+	}
+	if err = site.SortTorrents(torrents, sort, desc); err != nil {
+		return nil, "", err
+	}
+	return torrents, "", nil
+}
+
+// baseUrl is ignored, search paths of definition are used.
+func (cs *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.Torrent, error) {
+	return cs.search(keyword)
+}
+
+func (cs *Site) DownloadTorrent(torrentUrl string) (content []byte, filename string, id string, err error) {
+	if !util.IsUrl(torrentUrl) {
+		id = strings.TrimPrefix(torrentUrl, cs.GetName()+".")
+		content, filename, err = cs.DownloadTorrentById(id)
+		return
+	}
+	if m := torrentIdRegexp.FindStringSubmatch(torrentUrl); m != nil {
+		id = m[torrentIdRegexp.SubexpIndex("id")]
+	}
+	content, filename, err = cs.download(torrentUrl, id)
+	return
+}
+
+// Download torrent by id using torrentDownloadUrl (e.g. "details.php?id={id}") of site config.
+// The url is processed by download selectors of definition as well.
+func (cs *Site) DownloadTorrentById(id string) ([]byte, string, error) {
+	if cs.SiteConfig.TorrentDownloadUrl == "" {
+		return nil, "", fmt.Errorf("torrentDownloadUrl not configured, can not download torrent by id")
+	}
+	torrentUrl := cs.SiteConfig.ParseSiteUrl(strings.ReplaceAll(cs.SiteConfig.TorrentDownloadUrl, "{id}", id), false)
+	return cs.download(torrentUrl, id)
+}
+
+// Download torrent. If definition has download selectors, the link is a (details) page that contains
+// the real torrent download link.
+func (cs *Site) download(link string, id string) ([]byte, string, error) {
+	if download := cs.Definition.Download; download != nil {
+		variables := cs.getVariables()
+		variables["DownloadUri"] = map[string]any{"AbsoluteUri": link}
+		if before := download.Before; before != nil && before.Path != "" {
+			path, err := renderTemplate(before.Path, variables)
+			if err != nil {
+				return nil, "", err
+			}
+			values, rawQuery, err := renderInputs(before.Inputs, variables)
+			if err != nil {
+				return nil, "", err
+			}
+			if _, _, err := cs.request(before.Method, cs.resolveUrl(link, path), values, rawQuery, nil); err != nil {
+				return nil, "", fmt.Errorf("failed to request before download: %w", err)
+			}
+		}
+		if selectors := download.GetSelectors(); len(selectors) > 0 {
+			doc, res, err := cs.request(http.MethodGet, link, nil, "", nil)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to get download page: %w", err)
+			}
+			downloadLink := ""
+			for _, selector := range selectors {
+				value, err := cs.handleSelector(selector, doc.Selection, variables)
+				if err == nil && value != "" {
+					downloadLink = cs.resolveUrl(res.Request.Url, value)
+					break
+				}
+			}
+			if downloadLink == "" {
+				return nil, "", fmt.Errorf("torrent download link not found in %s", link)
+			}
+			link = downloadLink
+		}
+	}
+	if strings.HasPrefix(link, "magnet:") {
+		return nil, "", fmt.Errorf("magnet link is not supported: %s", link)
+	}
+	return site.DownloadTorrentByUrl(cs, cs.HttpClient, link, id)
+}
+
+// Return template variables. Setting values: site cardigannSettings > username / password / cookie > defaults.
+func (cs *Site) getVariables() map[string]any {
+	settings := map[string]any{}
+	for _, setting := range cs.Definition.Settings {
+		value, ok := cs.SiteConfig.CardigannSettings[setting.Name]
+		if !ok {
+			switch setting.Name {
+			case "username":
+				value = cs.SiteConfig.Username
+			case "password":
+				value = cs.SiteConfig.Password
+			case "cookie":
+				value = cs.SiteConfig.Cookie
+			default:
+				value = toString(setting.Default)
+			}
+		}
+		// Jackett: checked checkbox is .True ("True"), unchecked is .False (null)
+		if setting.Type == "checkbox" {
+			if checked, _ := strconv.ParseBool(value); checked {
+				value = "True"
+			} else {
+				value = ""
+			}
+		}
+		settings[setting.Name] = value
+	}
+	return map[string]any{
+		"Config":     settings,
+		"True":       "True",
+		"False":      "",
+		"Today":      map[string]any{"Year": fmt.Sprint(time.Now().In(cs.Location).Year())},
+		"Categories": []string{},
+		"Keywords":   "",
+		"Query":      map[string]any{"Keywords": "", "Q": "", "Type": "search"},
+		"Result":     map[string]any{},
+	}
+}
+
+func (cs *Site) search(keyword string) ([]*site.Torrent, error) {
+	search := cs.Definition.Search
+	variables := cs.getVariables()
+	keywords, err := applyFilters(keyword, search.KeywordsFilters, variables, cs.Location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply keywords filters: %w", err)
+	}
+	variables["Keywords"] = keywords
+	variables["Query"] = map[string]any{"Keywords": keywords, "Q": keyword, "Type": "search"}
+	paths := search.Paths
+	if len(paths) == 0 {
+		paths = []*SearchPath{{Path: search.Path}}
+	}
+	torrents := []*site.Torrent{}
+	for _, searchPath := range paths {
+		if searchPath.Response != nil && searchPath.Response.Type != "" && searchPath.Response.Type != "html" {
+			return nil, fmt.Errorf("unsupported search response type %q", searchPath.Response.Type)
+		}
+		path, err := renderTemplate(searchPath.Path, variables)
+		if err != nil {
+			return nil, err
+		}
+		inputs := map[string]string{}
+		if searchPath.InheritInputs == nil || *searchPath.InheritInputs {
+			maps.Copy(inputs, search.Inputs)
+		}
+		maps.Copy(inputs, searchPath.Inputs)
+		values, rawQuery, err := renderInputs(inputs, variables)
+		if err != nil {
+			return nil, err
+		}
+		doc, res, err := cs.request(searchPath.Method, cs.SiteConfig.ParseSiteUrl(path, false), values, rawQuery,
+			search.Headers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch search page: %w", err)
+		}
+		if err := cs.checkErrors(search.Error, doc, variables); err != nil {
+			return nil, err
+		}
+		pageTorrents, err := cs.parseRows(doc, res.Request.Url, keywords, variables)
+		if err != nil {
+			return nil, err
+		}
+		torrents = append(torrents, pageTorrents...)
+	}
+	return torrents, nil
+}
+
+// Parse search result rows to torrents. Rows which required fields fail to parse are skipped.
+func (cs *Site) parseRows(doc *goquery.Document, pageUrl string, keywords string, variables map[string]any) (
+	[]*site.Torrent, error) {
+	search := cs.Definition.Search
+	rowsBlock := search.Rows
+	rows := doc.Find(rowsBlock.Selector.Selector)
+	if rowsBlock.Remove != "" {
+		rows.Find(rowsBlock.Remove).Remove()
+	}
+	andMatch := slices.ContainsFunc(rowsBlock.Filters, func(filter *Filter) bool {
+		return filter.Name == "andmatch"
+	})
+	torrents := []*site.Torrent{}
+	for i := 0; i < rows.Length(); i += 1 + rowsBlock.After {
+		row := rows.Eq(i)
+		for j := 1; j <= rowsBlock.After && i+j < rows.Length(); j++ {
+			row = row.AddSelection(rows.Eq(i + j))
+		}
+		result := map[string]any{}
+		variables["Result"] = result
+		ok := true
+		for _, field := range search.Fields {
+			name, _, _ := strings.Cut(field.Name, "|") // e.g. "description|append"
+			value, err := cs.handleSelector(field.Selector, row, variables)
+			if err != nil {
+				if !field.Optional {
+					log.Debugf("Skip site %s search result row %d: field %s: %v", cs.Name, i, name, err)
+					ok = false
+					break
+				}
+				if value, err = renderTemplate(field.Default, variables); err != nil {
+					return nil, err
+				}
+			}
+			result[name] = value
+		}
+		if !ok {
+			continue
+		}
+		if toString(result["date"]) == "" && rowsBlock.DateHeaders != nil {
+			header := row.First().PrevAllFiltered(rowsBlock.DateHeaders.Selector).First()
+			if header.Length() > 0 {
+				selector := *rowsBlock.DateHeaders
+				selector.Selector = ""
+				if value, err := cs.handleSelector(&selector, header, variables); err == nil {
+					result["date"] = value
+				}
+			}
+		}
+		torrent := cs.torrent(result, pageUrl)
+		if torrent == nil {
+			continue
+		}
+		if andMatch && keywords != "" && slices.ContainsFunc(strings.Fields(keywords), func(keyword string) bool {
+			return !util.ContainsI(torrent.Name, keyword)
+		}) {
+			continue
+		}
+		torrents = append(torrents, torrent)
+	}
+	return torrents, nil
+}
+
+// Extract value of selector block from element.
+func (cs *Site) handleSelector(selector *Selector, el *goquery.Selection, variables map[string]any) (
+	value string, err error) {
+	if selector.Text != "" {
+		if value, err = renderTemplate(selector.Text, variables); err != nil {
+			return "", err
+		}
+	} else {
+		selection := el
+		if selector.Selector != "" {
+			if selection = el.Find(selector.Selector); selection.Length() == 0 {
+				return "", fmt.Errorf("selector %q matches nothing", selector.Selector)
+			}
+			selection = selection.First()
+		}
+		if selector.Remove != "" {
+			selection = selection.Clone()
+			selection.Find(selector.Remove).Remove()
+		}
+		if len(selector.Case) > 0 {
+			matched := false
+			for _, c := range selector.Case {
+				if selection.Is(c.Selector) || selection.Find(c.Selector).Length() > 0 {
+					if value, err = renderTemplate(c.Value, variables); err != nil {
+						return "", err
+					}
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return "", fmt.Errorf("none of case selectors matches")
+			}
+		} else if selector.Attribute != "" {
+			var ok bool
+			if value, ok = selection.Attr(selector.Attribute); !ok {
+				return "", fmt.Errorf("attribute %q not found", selector.Attribute)
+			}
+		} else {
+			value = selection.Text()
+		}
+	}
+	return applyFilters(strings.TrimSpace(value), selector.Filters, variables, cs.Location)
+}
+
+// Check error blocks of definition against response page. Return error if any of them matches.
+func (cs *Site) checkErrors(errorBlocks []*ErrorBlock, doc *goquery.Document, variables map[string]any) error {
+	for _, errorBlock := range errorBlocks {
+		if errorBlock.Selector == "" || doc.Find(errorBlock.Selector).Length() == 0 {
+			continue
+		}
+		message := ""
+		if errorBlock.Message != nil {
+			message, _ = cs.handleSelector(errorBlock.Message, doc.Selection, variables)
+		} else {
+			message = util.DomSanitizedText(doc.Find(errorBlock.Selector).First())
+		}
+		return fmt.Errorf("site returns error: %s", message)
+	}
+	return nil
+}
+
+// Create torrent from fields of a search result row.
+func (cs *Site) torrent(result map[string]any, pageUrl string) *site.Torrent {
+	get := func(name string) string {
+		return strings.TrimSpace(toString(result[name]))
+	}
+	name := util.SanitizeText(get("title"))
+	downloadUrl := cs.resolveUrl(pageUrl, get("download"))
+	detailsUrl := cs.resolveUrl(pageUrl, get("details"))
+	if downloadUrl == "" && cs.Definition.Download != nil && len(cs.Definition.Download.GetSelectors()) > 0 {
+		downloadUrl = detailsUrl
+	}
+	if name == "" || downloadUrl == "" {
+		return nil
+	}
+	id := ""
+	for _, link := range []string{detailsUrl, downloadUrl} {
+		if m := torrentIdRegexp.FindStringSubmatch(link); m != nil {
+			id = cs.Name + "." + m[torrentIdRegexp.SubexpIndex("id")]
+			break
+		}
+	}
+	infoHash := get("infohash")
+	if m := magnetRegexp.FindStringSubmatch(get("magnet") + " " + downloadUrl); infoHash == "" && m != nil {
+		infoHash = m[magnetRegexp.SubexpIndex("hash")]
+	}
+	sizeStr := strings.ReplaceAll(get("size"), ",", "")
+	size := util.ParseInt(sizeStr)
+	isSizeAccurate := util.IsIntString(sizeStr)
+	if !isSizeAccurate {
+		size, _ = util.ExtractSizeStr(sizeStr)
+	}
+	tags := []string{}
+	if category := get("category"); category != "" && cs.Definition.Caps != nil {
+		for _, mapping := range cs.Definition.Caps.CategoryMappings {
+			if mapping.Id == category {
+				if mapping.Desc != "" {
+					tags = append(tags, mapping.Desc)
+				} else {
+					tags = append(tags, mapping.Cat)
+				}
+				break
+			}
+		}
+	}
+	if categoryDesc := get("categorydesc"); categoryDesc != "" && !slices.Contains(tags, categoryDesc) {
+		tags = append(tags, categoryDesc)
+	}
+	return &site.Torrent{
+		Name:               name,
+		Description:        get("description"),
+		Id:                 id,
+		InfoHash:           strings.ToLower(infoHash),
+		DownloadUrl:        downloadUrl,
+		DownloadMultiplier: parseFloat(get("downloadvolumefactor"), 1),
+		UploadMultiplier:   parseFloat(get("uploadvolumefactor"), 1),
+		DiscountEndTime:    -1,
+		Time:               parseTime(get("date"), cs.Location, time.Now()),
+		Size:               size,
+		IsSizeAccurate:     isSizeAccurate,
+		Seeders:            util.ParseInt(get("seeders")),
+		Leechers:           util.ParseInt(get("leechers")),
+		Snatched:           util.ParseInt(get("grabs")),
+		HasHnR:             cs.SiteConfig.GlobalHnR || util.ParseInt(get("minimumseedtime")) > 0,
+		Tags:               tags,
+	}
+}
+
+// Resolve a (relative) link of page. Return empty string if link is empty.
+func (cs *Site) resolveUrl(pageUrl string, link string) string {
+	if link == "" || strings.HasPrefix(link, "magnet:") {
+		return link
+	}
+	base, err := url.Parse(pageUrl)
+	if err != nil {
+		return cs.SiteConfig.ParseSiteUrl(link, false)
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return cs.SiteConfig.ParseSiteUrl(link, false)
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// Send a http request to site and parse response page.
+// For get requests, values & rawQuery are appended to url query string; for post requests, they are sent as form.
+// If the request got redirected to login page, re-login site (if username & password configured) and retry once.
+func (cs *Site) request(method string, pageUrl string, values url.Values, rawQuery string,
+	headers map[string][]string) (*goquery.Document, *azuretls.Response, error) {
+	doc, res, err := cs.doRequest(method, pageUrl, values, rawQuery, headers)
+	if res != nil && cs.isLoginPage(res.Request.Url) && site.Relogin(cs) {
+		doc, res, err = cs.doRequest(method, pageUrl, values, rawQuery, headers)
+	}
+	if err == nil && cs.isLoginPage(res.Request.Url) {
+		err = fmt.Errorf("not logined (cookie may has expired)")
+	}
+	return doc, res, err
+}
+
+func (cs *Site) doRequest(method string, pageUrl string, values url.Values, rawQuery string,
+	headers map[string][]string) (*goquery.Document, *azuretls.Response, error) {
+	method = strings.ToUpper(method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	query := values.Encode()
+	if rawQuery != "" {
+		query = strings.Trim(query+"&"+rawQuery, "&")
+	}
+	httpHeaders := slices.Clone(cs.HttpHeaders)
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		for _, value := range headers[name] {
+			value, err := renderTemplate(value, cs.getVariables())
+			if err != nil {
+				return nil, nil, err
+			}
+			httpHeaders = append(httpHeaders, []string{name, value})
+		}
+	}
+	req := &azuretls.Request{
+		Method:   method,
+		Url:      pageUrl,
+		NoCookie: true,
+	}
+	if method == http.MethodPost {
+		req.Body = query
+		httpHeaders = append(httpHeaders, []string{"Content-Type", "application/x-www-form-urlencoded"})
+	} else if query != "" {
+		req.Url = util.AppendUrlQueryString(pageUrl, query)
+	}
+	req.OrderedHeaders = util.GetHttpReqHeaders(httpHeaders, cs.SiteConfig.Cookie, site.GetUa(cs))
+	util.LogAzureHttpRequest(req)
+	res, err := cs.HttpClient.Do(req)
+	util.LogAzureHttpResponse(res, err)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch url: %w", err)
+	}
+	if res.StatusCode != 200 && !cs.SiteConfig.AcceptAnyHttpStatus {
+		return nil, res, fmt.Errorf("failed to fetch url: status=%d", res.StatusCode)
+	}
+	var body io.Reader = bytes.NewReader(res.Body)
+	if cs.Definition.Encoding != "" && !strings.EqualFold(cs.Definition.Encoding, "UTF-8") {
+		if body, err = charset.NewReaderLabel(cs.Definition.Encoding, body); err != nil {
+			return nil, res, fmt.Errorf("unsupported encoding %q: %w", cs.Definition.Encoding, err)
+		}
+	}
+	doc, err := goquery.NewDocumentFromReader(body)
+	if err != nil {
+		return nil, res, fmt.Errorf("failed to parse site page DOM: %w", err)
+	}
+	return doc, res, nil
+}
+
+// Return true if url is the login page of definition.
+func (cs *Site) isLoginPage(pageUrl string) bool {
+	if cs.Definition.Login == nil || cs.Definition.Login.Path == "" || cs.Definition.Login.Method == "cookie" {
+		return false
+	}
+	loginPath, _, _ := strings.Cut(cs.Definition.Login.Path, "?")
+	urlObj, err := url.Parse(pageUrl)
+	if err != nil || loginPath == "" || strings.Contains(loginPath, "{{") {
+		return false
+	}
+	return strings.HasSuffix(urlObj.Path, "/"+strings.TrimPrefix(loginPath, "/"))
+}
+
+// Render inputs. The special "$raw" input is a raw query string.
+func renderInputs(inputs map[string]string, variables map[string]any) (values url.Values, rawQuery string,
+	err error) {
+	values = url.Values{}
+	for _, name := range slices.Sorted(maps.Keys(inputs)) {
+		value, err := renderTemplate(inputs[name], variables)
+		if err != nil {
+			return nil, "", err
+		}
+		if name == "$raw" {
+			rawQuery = value
+		} else {
+			values.Set(name, value)
+		}
+	}
+	return values, rawQuery, nil
+}
+
+func parseFloat(str string, defaultValue float64) float64 {
+	if v, err := strconv.ParseFloat(str, 64); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+func NewSite(name string, siteConfig *config.SiteConfigStruct, config *config.ConfigStruct) (site.Site, error) {
+	if siteConfig.CardigannDefinition == "" {
+		return nil, fmt.Errorf("cardigannDefinition (definition file) not configured")
+	}
+	definition, err := LoadDefinition(siteConfig.CardigannDefinition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cardigann definition: %w", err)
+	}
+	if siteConfig.Url == "" {
+		if len(definition.Links) == 0 {
+			return nil, fmt.Errorf("site url not configured and definition has no links")
+		}
+		siteConfig.Url = definition.Links[0]
+	}
+	location, err := time.LoadLocation(siteConfig.GetTimezone())
+	if err != nil {
+		return nil, fmt.Errorf("invalid site timezone %s: %w", siteConfig.GetTimezone(), err)
+	}
+	httpClient, httpHeaders, err := site.CreateSiteHttpClient(siteConfig, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create site http client: %w", err)
+	}
+	site := &Site{
+		Name:        name,
+		Location:    location,
+		SiteConfig:  siteConfig,
+		Config:      config,
+		HttpClient:  httpClient,
+		HttpHeaders: httpHeaders,
+		Definition:  definition,
+	}
+	return site, nil
+}
+
+func init() {
+	site.Register(&site.RegInfo{
+		Name:    "cardigann",
+		Creator: NewSite,
+	})
+}
@@ -0,0 +1,220 @@
+package cardigann
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sagan/ptool/config"
+)
+
+// Cardigann indexer definition, as used by Jackett (src/Jackett.Common/Definitions/*.yml).
+// Only the parts used by ptool are parsed, other fields are ignored.
+// Format: https://github.com/Jackett/Jackett/wiki/Definition-format
+type Definition struct {
+	Id          string     `yaml:"id"`
+	Name        string     `yaml:"name"`
+	Description string     `yaml:"description"`
+	Type        string     `yaml:"type"`     // public, semi-private, private
+	Encoding    string     `yaml:"encoding"` // site html encoding, e.g. "windows-1251". Default is UTF-8
+	Links       []string   `yaml:"links"`
+	Settings    []*Setting `yaml:"settings"`
+	Caps        *Caps      `yaml:"caps"`
+	Login       *Login     `yaml:"login"`
+	Search      *Search    `yaml:"search"`
+	Download    *Download  `yaml:"download"`
+}
+
+type Setting struct {
+	Name    string `yaml:"name"`
+	Type    string `yaml:"type"` // text, password, checkbox, select, info...
+	Label   string `yaml:"label"`
+	Default any    `yaml:"default"`
+}
+
+type Caps struct {
+	CategoryMappings []*CategoryMapping `yaml:"categorymappings"`
+}
+
+// Site category => Torznab category mapping.
+type CategoryMapping struct {
+	Id   string `yaml:"id"`   // site category id
+	Cat  string `yaml:"cat"`  // Torznab category name, e.g. "Movies/HD"
+	Desc string `yaml:"desc"` // site category name
+}
+
+type Login struct {
+	Path           string               `yaml:"path"`
+	SubmitPath     string               `yaml:"submitpath"`
+	Method         string               `yaml:"method"` // post (default), form, get, cookie
+	Form           string               `yaml:"form"`   // login form selector of "form" method. Default: "form"
+	Inputs         map[string]string    `yaml:"inputs"`
+	SelectorInputs map[string]*Selector `yaml:"selectorinputs"`
+	Cookies        []string             `yaml:"cookies"`
+	Headers        map[string][]string  `yaml:"headers"`
+	Captcha        any                  `yaml:"captcha"`
+	Error          []*ErrorBlock        `yaml:"error"`
+	Test           *PageTest            `yaml:"test"`
+}
+
+// If selector matches in response page, the request is considered failed, message is the error message.
+type ErrorBlock struct {
+	Path     string    `yaml:"path"`
+	Selector string    `yaml:"selector"`
+	Message  *Selector `yaml:"message"`
+}
+
+// Page that is used to test whether login succeeded. selector must match in the page.
+type PageTest struct {
+	Path     string `yaml:"path"`
+	Selector string `yaml:"selector"`
+}
+
+type Search struct {
+	Path            string              `yaml:"path"`
+	Paths           []*SearchPath       `yaml:"paths"`
+	Inputs          map[string]string   `yaml:"inputs"`
+	Headers         map[string][]string `yaml:"headers"`
+	KeywordsFilters []*Filter           `yaml:"keywordsfilters"`
+	Error           []*ErrorBlock       `yaml:"error"`
+	Rows            *Rows               `yaml:"rows"`
+	Fields          Fields              `yaml:"fields"`
+}
+
+type SearchPath struct {
+	Path          string            `yaml:"path"`
+	Method        string            `yaml:"method"` // get (default) or post
+	Inputs        map[string]string `yaml:"inputs"`
+	InheritInputs *bool             `yaml:"inheritinputs"` // inherit search inputs. Default: true
+	Response      *struct {
+		Type string `yaml:"type"` // html (default) or json
+	} `yaml:"response"`
+}
+
+type Rows struct {
+	Selector `yaml:",inline"`
+	// Number of following rows that belong to the same torrent.
+	After int `yaml:"after"`
+	// Selector of previous sibling rows that contain date of torrents below it.
+	DateHeaders *Selector `yaml:"dateheaders"`
+}
+
+// A selector block. The value is extracted from the (first) element matched by selector (or the current element
+// if selector is empty), using text of element, attribute or case. If text is set, use the rendered text instead.
+type Selector struct {
+	Selector  string    `yaml:"selector"`
+	Optional  bool      `yaml:"optional"`
+	Default   string    `yaml:"default"`
+	Text      string    `yaml:"text"`
+	Attribute string    `yaml:"attribute"`
+	Remove    string    `yaml:"remove"`
+	Filters   []*Filter `yaml:"filters"`
+	Case      Cases     `yaml:"case"`
+}
+
+type Filter struct {
+	Name string `yaml:"name"`
+	Args any    `yaml:"args"` // string, number or list
+}
+
+type Download struct {
+	Selector  `yaml:",inline"` // legacy single download selector
+	Selectors []*Selector      `yaml:"selectors"`
+	Method    string           `yaml:"method"`
+	Before    *Before          `yaml:"before"`
+}
+
+// Request that should be sent before downloading torrent (e.g. "thanks" the uploader).
+type Before struct {
+	Path   string            `yaml:"path"`
+	Method string            `yaml:"method"`
+	Inputs map[string]string `yaml:"inputs"`
+}
+
+// Search result field. Fields are ordered as in definition, later fields can refer to previous ones.
+type Field struct {
+	Name string
+	*Selector
+}
+
+type Fields []*Field
+
+func (fields *Fields) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: fields must be a map", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		selector := &Selector{}
+		if err := node.Content[i+1].Decode(selector); err != nil {
+			return err
+		}
+		*fields = append(*fields, &Field{Name: node.Content[i].Value, Selector: selector})
+	}
+	return nil
+}
+
+// Case of selector block: if selector matches the element, use the value.
+type Case struct {
+	Selector string
+	Value    string
+}
+
+type Cases []*Case
+
+func (cases *Cases) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: case must be a map", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		*cases = append(*cases, &Case{Selector: node.Content[i].Value, Value: node.Content[i+1].Value})
+	}
+	return nil
+}
+
+// Return download selectors, including the legacy single one.
+func (download *Download) GetSelectors() []*Selector {
+	if len(download.Selectors) == 0 && download.Selector.Selector != "" {
+		return []*Selector{&download.Selector}
+	}
+	return download.Selectors
+}
+
+// Return args of filter as string list.
+func (filter *Filter) GetArgs() []string {
+	switch args := filter.Args.(type) {
+	case nil:
+		return nil
+	case []any:
+		strs := []string{}
+		for _, arg := range args {
+			strs = append(strs, fmt.Sprint(arg))
+		}
+		return strs
+	default:
+		return []string{fmt.Sprint(args)}
+	}
+}
+
+// Load definition file. Relative path is resolved against config dir.
+func LoadDefinition(file string) (*Definition, error) {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(config.ConfigDir, file)
+	}
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	definition := &Definition{}
+	if err := yaml.Unmarshal(contents, definition); err != nil {
+		return nil, fmt.Errorf("invalid definition: %w", err)
+	}
+	if definition.Search == nil {
+		return nil, fmt.Errorf("invalid definition: no search block")
+	}
+	if definition.Search.Rows == nil || definition.Search.Rows.Selector.Selector == "" {
+		return nil, fmt.Errorf("invalid definition: no search rows selector")
+	}
+	return definition, nil
+}
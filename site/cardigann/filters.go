@@ -0,0 +1,215 @@
+package cardigann
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/util"
+)
+
+var (
+	// e.g. "3 days ago", "1 hour, 5 mins ago", "2h 5m"
+	relativeTimeRegexp = regexp.MustCompile(`(?i)(?P<value>\d+(?:\.\d+)?)\s*` +
+		`(?P<unit>years?|yrs?|y|months?|mo|weeks?|w|days?|d|hours?|hrs?|h|minutes?|mins?|m|seconds?|secs?|s)\b`)
+	relativeTimeUnits = map[string]int64{
+		"y": 365 * 86400, "yr": 365 * 86400, "year": 365 * 86400,
+		"mo": 30 * 86400, "month": 30 * 86400,
+		"w": 7 * 86400, "week": 7 * 86400,
+		"d": 86400, "day": 86400,
+		"h": 3600, "hr": 3600, "hour": 3600,
+		"m": 60, "min": 60, "minute": 60,
+		"s": 1, "sec": 1, "second": 1,
+	}
+	// e.g. "today 12:34", "yesterday at 12:34"
+	dayTimeRegexp = regexp.MustCompile(
+		`(?i)^(?P<day>today|yesterday|tomorrow)\b\D*(?:(?P<hour>\d{1,2}):(?P<minute>\d{2}))?`)
+	timeFormats = []string{
+		time.RFC3339,
+		time.RFC1123Z,
+		time.RFC1123,
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"2006-01-02 15:04",
+		"2006/01/02 15:04:05",
+		"2006/01/02 15:04",
+		"2006/01/02",
+		"02-01-2006 15:04",
+		"02.01.2006 15:04",
+		"02.01.2006",
+		"Jan 2 2006",
+		"Jan 2, 2006",
+		"2 Jan 2006",
+		"2 Jan 2006 15:04",
+		"January 2, 2006",
+	}
+)
+
+// Apply filters to value. Supported filters: replace, re_replace, regexp, split, trim, prepend, append,
+// tolower, toupper, urldecode, urlencode, htmldecode, htmlencode, querystring, dateparse (timeparse),
+// timeago (reltime, fuzzytime), strdump. Row filter andmatch is handled by caller.
+func applyFilters(value string, filters []*Filter, variables map[string]any, location *time.Location) (
+	string, error) {
+	for _, filter := range filters {
+		args := filter.GetArgs()
+		arg := func(i int) string {
+			if i < len(args) {
+				return args[i]
+			}
+			return ""
+		}
+		switch filter.Name {
+		case "replace":
+			value = strings.ReplaceAll(value, arg(0), arg(1))
+		case "re_replace":
+			re, err := regexp.Compile(arg(0))
+			if err != nil {
+				return "", fmt.Errorf("re_replace filter: invalid regexp: %w", err)
+			}
+			value = re.ReplaceAllString(value, arg(1))
+		case "regexp":
+			re, err := regexp.Compile(arg(0))
+			if err != nil {
+				return "", fmt.Errorf("regexp filter: invalid regexp: %w", err)
+			}
+			m := re.FindStringSubmatch(value)
+			if m == nil {
+				value = ""
+			} else if len(m) > 1 {
+				value = m[1]
+			} else {
+				value = m[0]
+			}
+		case "split":
+			parts := strings.Split(value, arg(0))
+			index, _ := strconv.Atoi(arg(1))
+			if index < 0 {
+				index += len(parts)
+			}
+			if index >= 0 && index < len(parts) {
+				value = parts[index]
+			} else {
+				value = ""
+			}
+		case "trim":
+			if arg(0) != "" {
+				value = strings.Trim(value, arg(0))
+			} else {
+				value = strings.TrimSpace(value)
+			}
+		case "prepend", "append":
+			str, err := renderTemplate(arg(0), variables)
+			if err != nil {
+				return "", err
+			}
+			if filter.Name == "prepend" {
+				value = str + value
+			} else {
+				value += str
+			}
+		case "tolower":
+			value = strings.ToLower(value)
+		case "toupper":
+			value = strings.ToUpper(value)
+		case "urldecode":
+			if str, err := url.QueryUnescape(value); err == nil {
+				value = str
+			}
+		case "urlencode":
+			value = url.QueryEscape(value)
+		case "htmldecode":
+			value = html.UnescapeString(value)
+		case "htmlencode":
+			value = html.EscapeString(value)
+		case "querystring":
+			urlObj, err := url.Parse(value)
+			if err != nil {
+				return "", fmt.Errorf("querystring filter: invalid url: %w", err)
+			}
+			value = urlObj.Query().Get(arg(0))
+		case "dateparse", "timeparse":
+			t, err := parseTimeLayout(value, arg(0), location)
+			if err != nil {
+				return "", fmt.Errorf("dateparse filter: %w", err)
+			}
+			value = time.Unix(t, 0).Format(time.RFC3339)
+		case "timeago", "reltime", "fuzzytime":
+			if t := parseTime(value, location, time.Now()); t > 0 {
+				value = time.Unix(t, 0).Format(time.RFC3339)
+			}
+		case "strdump":
+			log.Debugf("cardigann strdump: %q", value)
+		case "andmatch", "diacritics", "validfilename":
+			// andmatch is a row filter. Others are not useful for ptool.
+		default:
+			return "", fmt.Errorf("unsupported filter %q", filter.Name)
+		}
+		value = strings.TrimSpace(value)
+	}
+	return value, nil
+}
+
+// Parse time str using Go time layout (e.g. "2006-01-02 15:04"). If layout is empty, guess the format.
+func parseTimeLayout(str string, layout string, location *time.Location) (int64, error) {
+	if layout == "" {
+		if t := parseTime(str, location, time.Now()); t > 0 {
+			return t, nil
+		}
+		return 0, fmt.Errorf("unknown time format: %q", str)
+	}
+	t, err := time.ParseInLocation(layout, str, location)
+	if err != nil {
+		return 0, err
+	}
+	return t.Unix(), nil
+}
+
+// Parse a absolute or relative (e.g. "3 days ago", "yesterday 12:34") time str. Return 0 if failed.
+func parseTime(str string, location *time.Location, now time.Time) int64 {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return 0
+	}
+	for _, format := range timeFormats {
+		if t, err := time.ParseInLocation(format, str, location); err == nil {
+			return t.Unix()
+		}
+	}
+	if t, err := util.ParseTimeWithNow(str, location, now); err == nil {
+		return t
+	}
+	lowerStr := strings.ToLower(str)
+	if lowerStr == "now" || lowerStr == "just now" {
+		return now.Unix()
+	}
+	if m := dayTimeRegexp.FindStringSubmatch(str); m != nil {
+		t := now.In(location)
+		switch strings.ToLower(m[dayTimeRegexp.SubexpIndex("day")]) {
+		case "yesterday":
+			t = t.AddDate(0, 0, -1)
+		case "tomorrow":
+			t = t.AddDate(0, 0, 1)
+		}
+		hour, _ := strconv.Atoi(m[dayTimeRegexp.SubexpIndex("hour")])
+		minute, _ := strconv.Atoi(m[dayTimeRegexp.SubexpIndex("minute")])
+		return time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, location).Unix()
+	}
+	seconds := float64(0)
+	for _, m := range relativeTimeRegexp.FindAllStringSubmatch(str, -1) {
+		value, _ := strconv.ParseFloat(m[relativeTimeRegexp.SubexpIndex("value")], 64)
+		unit := strings.ToLower(m[relativeTimeRegexp.SubexpIndex("unit")])
+		if relativeTimeUnits[unit] == 0 {
+			unit = strings.TrimSuffix(unit, "s")
+		}
+		seconds += value * float64(relativeTimeUnits[unit])
+	}
+	if seconds > 0 {
+		return now.Unix() - int64(seconds)
+	}
+	return 0
+}
@@ -0,0 +1,161 @@
+package cardigann
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyFilters(t *testing.T) {
+	variables := map[string]any{"Config": map[string]any{"sitelink": "https://example.com/"}}
+	tests := []struct {
+		desc    string
+		value   string
+		filters []*Filter
+		want    string
+		wantErr bool
+	}{
+		{
+			desc:    "replace",
+			value:   "1,024 MB",
+			filters: []*Filter{{Name: "replace", Args: []any{",", ""}}},
+			want:    "1024 MB",
+		},
+		{
+			desc:    "re_replace",
+			value:   "Size: 1.5 GiB",
+			filters: []*Filter{{Name: "re_replace", Args: []any{`^Size:\s*`, ""}}},
+			want:    "1.5 GiB",
+		},
+		{
+			desc:    "regexp with group",
+			value:   "details.php?id=123&hit=1",
+			filters: []*Filter{{Name: "regexp", Args: `id=(\d+)`}},
+			want:    "123",
+		},
+		{
+			desc:    "regexp not matched",
+			value:   "details.php",
+			filters: []*Filter{{Name: "regexp", Args: `id=(\d+)`}},
+			want:    "",
+		},
+		{
+			desc:    "split negative index",
+			value:   "a/b/c",
+			filters: []*Filter{{Name: "split", Args: []any{"/", -1}}},
+			want:    "c",
+		},
+		{
+			desc:    "split out of range",
+			value:   "a/b/c",
+			filters: []*Filter{{Name: "split", Args: []any{"/", 5}}},
+			want:    "",
+		},
+		{
+			desc:    "trim chars",
+			value:   "[Free]",
+			filters: []*Filter{{Name: "trim", Args: "[]"}},
+			want:    "Free",
+		},
+		{
+			desc:  "prepend template and append",
+			value: "download.php?id=1",
+			filters: []*Filter{
+				{Name: "prepend", Args: "{{ .Config.sitelink }}"},
+				{Name: "append", Args: "&passkey=x"},
+			},
+			want: "https://example.com/download.php?id=1&passkey=x",
+		},
+		{
+			desc:    "chained case and url filters",
+			value:   "Hello%20World",
+			filters: []*Filter{{Name: "urldecode"}, {Name: "toupper"}},
+			want:    "HELLO WORLD",
+		},
+		{
+			desc:    "htmldecode",
+			value:   "Tom &amp; Jerry",
+			filters: []*Filter{{Name: "htmldecode"}},
+			want:    "Tom & Jerry",
+		},
+		{
+			desc:    "querystring",
+			value:   "https://example.com/download.php?id=42&name=a",
+			filters: []*Filter{{Name: "querystring", Args: "id"}},
+			want:    "42",
+		},
+		{
+			desc:    "dateparse with layout",
+			value:   "2024-01-02 03:04",
+			filters: []*Filter{{Name: "dateparse", Args: "2006-01-02 15:04"}},
+			want:    "2024-01-02T03:04:00Z",
+		},
+		{
+			desc:    "dateparse invalid",
+			value:   "not a date",
+			filters: []*Filter{{Name: "dateparse", Args: "2006-01-02 15:04"}},
+			wantErr: true,
+		},
+		{
+			desc:    "ignored filters",
+			value:   "abc",
+			filters: []*Filter{{Name: "andmatch"}, {Name: "diacritics"}},
+			want:    "abc",
+		},
+		{
+			desc:    "unsupported filter",
+			value:   "abc",
+			filters: []*Filter{{Name: "nosuchfilter"}},
+			wantErr: true,
+		},
+		{
+			desc:    "invalid regexp",
+			value:   "abc",
+			filters: []*Filter{{Name: "regexp", Args: "("}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		got, err := applyFilters(tt.value, tt.filters, variables, time.UTC)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got %q", tt.desc, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		} else if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		desc string
+		str  string
+		want time.Time
+	}{
+		{"rfc3339", "2024-01-02T03:04:05Z", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"date time", "2024/01/02 03:04", time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)},
+		{"month name", "Jan 2, 2024", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"just now", "Just now", now},
+		{"today", "Today 08:30", time.Date(2024, 6, 15, 8, 30, 0, 0, time.UTC)},
+		{"yesterday", "yesterday at 23:15", time.Date(2024, 6, 14, 23, 15, 0, 0, time.UTC)},
+		{"days ago", "3 days ago", now.Add(-3 * 24 * time.Hour)},
+		{"combined relative", "1 hour, 5 mins ago", now.Add(-65 * time.Minute)},
+		{"short relative", "2h 5m", now.Add(-125 * time.Minute)},
+	}
+	for _, tt := range tests {
+		if got := parseTime(tt.str, time.UTC, now); got != tt.want.Unix() {
+			t.Errorf("%s: parseTime(%q) = %s, want %s", tt.desc, tt.str,
+				time.Unix(got, 0).UTC().Format(time.RFC3339), tt.want.Format(time.RFC3339))
+		}
+	}
+	for _, str := range []string{"", "unknown"} {
+		if got := parseTime(str, time.UTC, now); got != 0 {
+			t.Errorf("parseTime(%q) = %d, want 0", str, got)
+		}
+	}
+}
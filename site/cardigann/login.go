@@ -0,0 +1,173 @@
+package cardigann
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/Noooste/azuretls-client"
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+var _ site.Loginer = (*Site)(nil)
+
+// Login site according to login block of definition:
+// "post": post inputs to login path; "form": fetch login page, fill and submit the login form;
+// "get": get login path with inputs as query string; "cookie": use cookie setting, no actual login.
+// After login, the test page must match the test selector (if any).
+func (cs *Site) Login(username string, password string, otp string) (*site.LoginCredential, error) {
+	login := cs.Definition.Login
+	if login == nil {
+		return nil, fmt.Errorf("definition has no login block")
+	}
+	if login.Method == "cookie" {
+		return nil, fmt.Errorf("site uses cookie login method, configure cookie instead")
+	}
+	if login.Captcha != nil {
+		return nil, fmt.Errorf("site requires captcha to login, which is not supported")
+	}
+	variables := cs.getVariables()
+	settings := variables["Config"].(map[string]any)
+	settings["username"] = username
+	settings["password"] = password
+	if otp != "" {
+		settings["2facode"] = otp
+	}
+	path, err := renderTemplate(login.Path, variables)
+	if err != nil {
+		return nil, err
+	}
+	loginUrl := cs.SiteConfig.ParseSiteUrl(path, false)
+	cookie := site.MergeCookies("", parseCookies(login.Cookies))
+	values := url.Values{}
+	method := http.MethodPost
+	switch login.Method {
+	case "", "post":
+	case "get":
+		method = http.MethodGet
+	case "form":
+		doc, res, err := site.GetLoginPage(cs, cs.HttpClient, loginUrl, cookie)
+		if err != nil {
+			return nil, err
+		}
+		cookie = site.MergeCookies(cookie, res.Cookies)
+		formSelector := login.Form
+		if formSelector == "" {
+			formSelector = "form"
+		}
+		form := doc.Find(formSelector).First()
+		if form.Length() == 0 {
+			return nil, fmt.Errorf("login form %q not found", formSelector)
+		}
+		form.Find("input[name]").Each(func(i int, s *goquery.Selection) {
+			inputType := strings.ToLower(s.AttrOr("type", ""))
+			if (inputType == "checkbox" || inputType == "radio") && !s.Is("[checked]") {
+				return
+			}
+			if inputType != "submit" && inputType != "button" && inputType != "image" {
+				values.Set(s.AttrOr("name", ""), s.AttrOr("value", ""))
+			}
+		})
+		for _, name := range slices.Sorted(maps.Keys(login.SelectorInputs)) {
+			value, err := cs.handleSelector(login.SelectorInputs[name], doc.Selection, variables)
+			if err != nil {
+				return nil, fmt.Errorf("login selector input %s: %w", name, err)
+			}
+			values.Set(name, value)
+		}
+		if action := form.AttrOr("action", ""); action != "" {
+			loginUrl = cs.resolveUrl(res.Request.Url, action)
+		}
+		if login.SubmitPath != "" {
+			loginUrl = cs.SiteConfig.ParseSiteUrl(login.SubmitPath, false)
+		}
+		if strings.EqualFold(form.AttrOr("method", "post"), "get") {
+			method = http.MethodGet
+		}
+	default:
+		return nil, fmt.Errorf("unsupported login method %q", login.Method)
+	}
+	inputs, rawQuery, err := renderInputs(login.Inputs, variables)
+	if err != nil {
+		return nil, err
+	}
+	for name := range inputs {
+		values.Set(name, inputs.Get(name))
+	}
+	if rawValues, err := url.ParseQuery(rawQuery); err == nil {
+		for name := range rawValues {
+			values.Set(name, rawValues.Get(name))
+		}
+	}
+	var res *azuretls.Response
+	if method == http.MethodGet {
+		_, res, err = site.GetLoginPage(cs, cs.HttpClient, util.AppendUrlQueryString(loginUrl, values.Encode()),
+			cookie)
+	} else {
+		res, err = site.PostLoginForm(cs, cs.HttpClient, loginUrl, values, cookie)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cookie = site.MergeCookies(cookie, res.Cookies)
+	// follow redirects manually to collect cookies and check errors in final page
+	for i := 0; i < 5 && res.StatusCode >= 300 && res.StatusCode < 400 && res.Header.Get("Location") != ""; i++ {
+		if _, res, err = site.GetLoginPage(cs, cs.HttpClient,
+			cs.resolveUrl(res.Request.Url, res.Header.Get("Location")), cookie); err != nil {
+			return nil, err
+		}
+		cookie = site.MergeCookies(cookie, res.Cookies)
+	}
+	if doc, err := goquery.NewDocumentFromReader(bytes.NewReader(res.Body)); err == nil {
+		if err := cs.checkErrors(login.Error, doc, variables); err != nil {
+			return nil, fmt.Errorf("login failed: %w", err)
+		}
+	}
+	if err := cs.testLogin(cookie); err != nil {
+		return nil, err
+	}
+	return &site.LoginCredential{Cookie: cookie}, nil
+}
+
+func (cs *Site) ApplyLoginCredential(credential *site.LoginCredential) {
+	cs.SiteConfig.Cookie = credential.Cookie
+	cs.PurgeCache()
+}
+
+// Check login result using test block of definition.
+func (cs *Site) testLogin(cookie string) error {
+	test := cs.Definition.Login.Test
+	if test == nil || test.Path == "" {
+		return nil
+	}
+	doc, res, err := site.GetLoginPage(cs, cs.HttpClient, cs.SiteConfig.ParseSiteUrl(test.Path, false), cookie)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode >= 300 && res.StatusCode < 400 && cs.isLoginPage(
+		cs.resolveUrl(res.Request.Url, res.Header.Get("Location"))) || cs.isLoginPage(res.Request.Url) {
+		return fmt.Errorf("login failed: test page redirects to login page")
+	}
+	if test.Selector != "" && doc.Find(test.Selector).Length() == 0 {
+		return fmt.Errorf("login failed: test selector %q matches nothing", test.Selector)
+	}
+	return nil
+}
+
+// Parse definition login cookies, e.g. ["name=value"].
+func parseCookies(cookies []string) map[string]string {
+	result := map[string]string{}
+	for _, cookie := range cookies {
+		if name, value, found := strings.Cut(cookie, "="); found {
+			result[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+package cardigann
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// Cardigann templates use Go text/template syntax, e.g. "{{ if .Keywords }}{{ .Keywords }}{{ else }}{{ end }}".
+// Variables: .Config.<setting>, .Keywords, .Query.Keywords, .Query.Q, .Categories, .Result.<field>,
+// .True, .False, .Today.Year.
+var templateFuncs = template.FuncMap{
+	"join": func(items any, sep string) string {
+		switch items := items.(type) {
+		case []string:
+			return strings.Join(items, sep)
+		case nil:
+			return ""
+		default:
+			return fmt.Sprint(items)
+		}
+	},
+	"re_replace": func(str any, pattern string, replacement string) (string, error) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", err
+		}
+		return re.ReplaceAllString(toString(str), replacement), nil
+	},
+}
+
+func renderTemplate(text string, variables map[string]any) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tpl, err := template.New("").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template %q: %w", text, err)
+	}
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, variables); err != nil {
+		return "", fmt.Errorf("failed to render template %q: %w", text, err)
+	}
+	// nil interface values (e.g. missing keys of map[string]any) are rendered as "<no value>"
+	return strings.ReplaceAll(buf.String(), "<no value>", ""), nil
+}
+
+func toString(value any) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
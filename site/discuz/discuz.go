@@ -17,10 +17,27 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
 	"github.com/sagan/ptool/site"
 	"github.com/sagan/ptool/util"
 )
 
+const (
+	DEFAULT_TORRENTS_URL = "forum.php?mod=torrents"
+)
+
+var (
+	torrentIdRegexp = regexp.MustCompile(`download\.php\?id=(?P<id>\d+)\b`)
+	// Default selectors of torrents list. Size / seeders / leechers / snatched / time columns
+	// are located by table header texts.
+	defaultSelectors = &config.SiteConfigStruct{
+		SelectorTorrentBlock:        `tr:has(a[href*="mod=viewthread&tid="])`,
+		SelectorTorrent:             `a[href*="mod=viewthread&tid="]`,
+		SelectorTorrentDownloadLink: `a[href*="download.php?id="]`,
+		SelectorTorrentFree:         `img[src*="free"],img[alt*="Free"],img[title*="Free"],img[title*="免费"]`,
+	}
+)
+
 type Site struct {
 	Name        string
 	Location    *time.Location
@@ -39,6 +56,10 @@ func (dzsite *Site) GetDefaultHttpHeaders() [][]string {
 	return dzsite.HttpHeaders
 }
 
+func (dzsite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
 func (dzsite *Site) PurgeCache() {
 }
 
@@ -70,15 +91,109 @@ func (dzsite *Site) GetStatus() (*site.Status, error) {
 
 func (dzsite *Site) GetAllTorrents(sort string, desc bool, pageMarker string, baseUrl string) (
 	torrents []*site.Torrent, nextPageMarker string, err error) {
-	return nil, "", site.ErrUnimplemented
+	if sort != "" && sort != constants.NONE {
+		err = fmt.Errorf("sort is not supported by site")
+		return
+	}
+	if pageMarker == constants.NONE {
+		pageMarker = ""
+	}
+	if baseUrl == "" || baseUrl == constants.NONE {
+		baseUrl = site.GetTorrentsPageUrl(dzsite.SiteConfig)
+	}
+	if baseUrl == "" || strings.HasPrefix(baseUrl, "?") {
+		baseUrl = util.AppendUrlQueryString(DEFAULT_TORRENTS_URL, baseUrl)
+	}
+	// page starts from 1
+	page := int64(1)
+	if pageMarker != "" {
+		page = util.ParseInt(pageMarker)
+		if page < 1 {
+			err = fmt.Errorf("invalid page marker: %s", pageMarker)
+			return
+		}
+	}
+	torrents, lastPage, err := dzsite.getTorrents(baseUrl, url.Values{"page": {fmt.Sprint(page)}})
+	if err != nil {
+		return
+	}
+	if page < lastPage {
+		nextPageMarker = fmt.Sprint(page + 1)
+	}
+	return
 }
 
 func (dzsite *Site) GetLatestTorrents(full bool) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	torrentsUrl := site.GetTorrentsPageUrl(dzsite.SiteConfig)
+	if torrentsUrl == "" {
+		torrentsUrl = DEFAULT_TORRENTS_URL
+	}
+	torrents, _, err := dzsite.getTorrents(torrentsUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if full {
+		for _, extraUrl := range dzsite.SiteConfig.TorrentsExtraUrls {
+			extraTorrents, _, err := dzsite.getTorrents(extraUrl, nil)
+			if err != nil {
+				log.Errorf("Failed to get site %s extra torrents from %s: %v", dzsite.Name, extraUrl, err)
+				continue
+			}
+			torrents = append(torrents, extraTorrents...)
+		}
+	}
+	return torrents, nil
 }
 
+// baseUrl: optional search url, can use "%s" as keyword placeholder.
 func (dzsite *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	if baseUrl == "" {
+		baseUrl = dzsite.SiteConfig.SearchUrl
+	}
+	if baseUrl == "" {
+		baseUrl = DEFAULT_TORRENTS_URL
+	}
+	query := url.Values{}
+	if strings.Contains(baseUrl, "%s") {
+		baseUrl = strings.Replace(baseUrl, "%s", url.QueryEscape(keyword), 1)
+	} else {
+		searchQueryVariable := "search"
+		if dzsite.SiteConfig.SearchQueryVariable != "" {
+			searchQueryVariable = dzsite.SiteConfig.SearchQueryVariable
+		}
+		query.Set(searchQueryVariable, keyword)
+	}
+	torrents, _, err := dzsite.getTorrents(baseUrl, query)
+	return torrents, err
+}
+
+// Fetch and parse a torrents list page. query will be merged into the url. Return torrents and last page number.
+// Torrents without download link in list are skipped.
+func (dzsite *Site) getTorrents(pageUrl string, query url.Values) (
+	torrents []*site.Torrent, lastPage int64, err error) {
+	urlObj, err := url.Parse(dzsite.SiteConfig.ParseSiteUrl(pageUrl, false))
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid url: %w", err)
+	}
+	urlQuery := urlObj.Query()
+	for key, values := range query {
+		urlQuery[key] = values
+	}
+	urlObj.RawQuery = urlQuery.Encode()
+	doc, res, err := util.GetUrlDocWithAzuretls(urlObj.String(), dzsite.HttpClient,
+		dzsite.GetSiteConfig().Cookie, site.GetUa(dzsite), dzsite.GetDefaultHttpHeaders())
+	if !dzsite.SiteConfig.AcceptAnyHttpStatus && err != nil || doc == nil {
+		return nil, 0, fmt.Errorf("failed to fetch torrents page dom: %w", err)
+	}
+	if strings.Contains(res.Request.Url, "mod=logging") {
+		return nil, 0, fmt.Errorf("not logined (cookie may has expired)")
+	}
+	torrents = site.ParseTorrentsBySelectors(doc, dzsite, &site.TorrentsParserOption{
+		Location: dzsite.Location,
+		IdRegexp: torrentIdRegexp,
+		Defaults: defaultSelectors,
+	})
+	return torrents, site.ParseLastPage(doc), nil
 }
 
 func (dzsite *Site) DownloadTorrent(torrentUrl string) (content []byte, filename string, id string, err error) {
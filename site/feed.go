@@ -0,0 +1,444 @@
+package site
+
+// RSS / Atom feeds parser, with Torznab / Newznab extensions (<torznab:attr name="seeders" value="10" />) support.
+// Used by "rss" type sites, and by sites which torrentsUrl (or searchUrl) is set to "rss:<feed_url>".
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Noooste/azuretls-client"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/html/charset"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/util"
+)
+
+// Prefix of torrentsUrl / searchUrl that indicates the url is a RSS / Atom feed.
+const FEED_URL_PREFIX = "rss:"
+
+var (
+	// Extract site torrent id from feed item download or details link.
+	feedTorrentIdRegexp = regexp.MustCompile(`(?:[?&]id=|/(?:torrents?|details|download)/)(?P<id>\d+)\b`)
+	feedInfoHashRegexp  = regexp.MustCompile(`(?i)^[0-9a-f]{40}$`)
+	feedMagnetRegexp    = regexp.MustCompile(`(?i)\bxt=urn:btih:(?P<hash>[0-9a-f]{40})\b`)
+	// Size str in title, e.g. "Name [Subtitle] [12.34 GB]", used by NexusPHP torrentrss.php.
+	feedTitleSizeRegexp = regexp.MustCompile(`\[\s*(?P<size>[0-9.,]+\s*[kKmMgGtTpP]?i?B)\s*\]`)
+	feedTimeFormats     = []string{
+		time.RFC1123Z,
+		time.RFC1123,
+		time.RFC3339,
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"Mon, 2 Jan 2006 15:04:05 MST",
+		"2 Jan 2006 15:04:05 -0700",
+	}
+)
+
+type feedAttr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type feedLink struct {
+	Href string `xml:"href,attr"` // Atom
+	Rel  string `xml:"rel,attr"`
+	Url  string `xml:",chardata"` // RSS
+}
+
+type feedEnclosure struct {
+	Url    string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type feedCategory struct {
+	Term string `xml:"term,attr"` // Atom
+	Text string `xml:",chardata"` // RSS
+}
+
+// RSS <item> or Atom <entry>
+type feedItem struct {
+	Title      string          `xml:"title"`
+	Links      []*feedLink     `xml:"link"`
+	Comments   string          `xml:"comments"`
+	Guid       string          `xml:"guid"`
+	PubDate    string          `xml:"pubDate"`
+	Published  string          `xml:"published"`
+	Updated    string          `xml:"updated"`
+	Date       string          `xml:"date"` // <dc:date> of RSS 1.0
+	Size       string          `xml:"size"`
+	Enclosure  *feedEnclosure  `xml:"enclosure"`
+	Categories []*feedCategory `xml:"category"`
+	Attrs      []*feedAttr     `xml:"attr"` // <torznab:attr> or <newznab:attr>
+}
+
+type feed struct {
+	XMLName xml.Name
+	Items   []*feedItem `xml:"channel>item"` // RSS 2.0
+	Entries []*feedItem `xml:"entry"`        // Atom
+	// RSS 1.0 (RDF) items, or Torznab error (<error code="100" description="Invalid API Key" />).
+	RdfItems         []*feedItem `xml:"item"`
+	ErrorCode        string      `xml:"code,attr"`
+	ErrorDescription string      `xml:"description,attr"`
+}
+
+// Site which torrents list is provided by RSS feed (torrentsUrl = "rss:<feed_url>").
+// Other functions are provided by underlying site.
+type feedSite struct {
+	Site
+	httpClient *azuretls.Session
+}
+
+func (fs *feedSite) GetLatestTorrents(full bool) ([]*Torrent, error) {
+	return GetLatestFeedTorrents(fs.Site, fs.httpClient, full)
+}
+
+func (fs *feedSite) GetAllTorrents(sort string, desc bool, pageMarker string, baseUrl string) (
+	torrents []*Torrent, nextPageMarker string, err error) {
+	if baseUrl != "" && baseUrl != constants.NONE && !strings.HasPrefix(baseUrl, FEED_URL_PREFIX) {
+		return fs.Site.GetAllTorrents(sort, desc, pageMarker, baseUrl)
+	}
+	return GetAllFeedTorrents(fs.Site, fs.httpClient, sort, desc, baseUrl)
+}
+
+func (fs *feedSite) SearchTorrents(keyword string, baseUrl string) ([]*Torrent, error) {
+	if baseUrl == "" {
+		baseUrl = fs.GetSiteConfig().SearchUrl
+	}
+	if baseUrl != "" && !strings.HasPrefix(baseUrl, FEED_URL_PREFIX) {
+		return fs.Site.SearchTorrents(keyword, baseUrl)
+	}
+	return SearchFeedTorrents(fs.Site, fs.httpClient, keyword, baseUrl)
+}
+
+// Return the underlying site.
+func (fs *feedSite) Unwrap() Site {
+	return fs.Site
+}
+
+// Site that could provide a default feed url, used if site torrentsUrl is "rss:" without feed url.
+type FeedProvider interface {
+	GetDefaultFeedUrl() string
+}
+
+// Wrap site which torrentsUrl is "rss:<feed_url>" so its torrents list is read from the feed.
+func wrapFeedSite(siteInstance Site, siteConfig *config.SiteConfigStruct, globalConfig *config.ConfigStruct) (
+	Site, error) {
+	if siteConfig.Type == "rss" || !strings.HasPrefix(siteConfig.TorrentsUrl, FEED_URL_PREFIX) {
+		return siteInstance, nil
+	}
+	if _, ok := siteInstance.(FeedProvider); !ok && siteConfig.TorrentsUrl == FEED_URL_PREFIX {
+		return nil, fmt.Errorf("site type %s does not have a default feed url, set torrentsUrl to 'rss:<feed_url>'",
+			siteConfig.Type)
+	}
+	httpClient, _, err := CreateSiteHttpClient(siteConfig, globalConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create site http client: %w", err)
+	}
+	return &feedSite{Site: siteInstance, httpClient: httpClient}, nil
+}
+
+// Return the torrents page url of site config. Return empty if torrentsUrl is a feed ("rss:<feed_url>"),
+// in which case site backends should use their default torrents page (e.g. to read user info).
+func GetTorrentsPageUrl(siteConfig *config.SiteConfigStruct) string {
+	if strings.HasPrefix(siteConfig.TorrentsUrl, FEED_URL_PREFIX) {
+		return ""
+	}
+	return siteConfig.TorrentsUrl
+}
+
+// Return the underlying site if siteInstance is a wrapper (e.g. which torrents list is read from feed).
+func Unwrap(siteInstance Site) Site {
+	if wrapper, ok := siteInstance.(interface{ Unwrap() Site }); ok {
+		return wrapper.Unwrap()
+	}
+	return siteInstance
+}
+
+// Get torrents of site torrentsUrl feed, and of torrentsExtraUrls feeds if full is true.
+func GetLatestFeedTorrents(siteInstance Site, httpClient *azuretls.Session, full bool) ([]*Torrent, error) {
+	torrents, err := GetFeedTorrents(siteInstance, httpClient, siteInstance.GetSiteConfig().TorrentsUrl)
+	if err != nil {
+		return nil, err
+	}
+	if full {
+		for _, extraUrl := range siteInstance.GetSiteConfig().TorrentsExtraUrls {
+			extraTorrents, err := GetFeedTorrents(siteInstance, httpClient, extraUrl)
+			if err != nil {
+				log.Errorf("Failed to get site %s extra torrents from %s: %v", siteInstance.GetName(), extraUrl, err)
+				continue
+			}
+			torrents = append(torrents, extraTorrents...)
+		}
+	}
+	return torrents, nil
+}
+
+// Get all torrents of feed (site torrentsUrl if feedUrl is empty), sorted locally.
+// Feed has only one page, so nextPageMarker is always empty.
+func GetAllFeedTorrents(siteInstance Site, httpClient *azuretls.Session, sort string, desc bool, feedUrl string) (
+	[]*Torrent, string, error) {
+	if feedUrl == "" || feedUrl == constants.NONE {
+		feedUrl = siteInstance.GetSiteConfig().TorrentsUrl
+	}
+	torrents, err := GetFeedTorrents(siteInstance, httpClient, feedUrl)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := SortTorrents(torrents, sort, desc); err != nil {
+		return nil, "", err
+	}
+	return torrents, "", nil
+}
+
+// Sort torrents locally by field (name|size|time|seeders|leechers|snatched). Do nothing if sort is empty or "none".
+// Used by sites which do not support sorting (e.g. feeds).
+func SortTorrents(torrents []*Torrent, sort string, desc bool) error {
+	var field func(torrent *Torrent) string
+	var number func(torrent *Torrent) int64
+	switch sort {
+	case "", constants.NONE:
+		return nil
+	case "name":
+		field = func(torrent *Torrent) string { return torrent.Name }
+	case "size":
+		number = func(torrent *Torrent) int64 { return torrent.Size }
+	case "time":
+		number = func(torrent *Torrent) int64 { return torrent.Time }
+	case "seeders":
+		number = func(torrent *Torrent) int64 { return torrent.Seeders }
+	case "leechers":
+		number = func(torrent *Torrent) int64 { return torrent.Leechers }
+	case "snatched":
+		number = func(torrent *Torrent) int64 { return torrent.Snatched }
+	default:
+		return fmt.Errorf("unsupported sort field: %s", sort)
+	}
+	slices.SortStableFunc(torrents, func(a, b *Torrent) int {
+		result := 0
+		if field != nil {
+			result = strings.Compare(field(a), field(b))
+		} else {
+			result = int(max(-1, min(1, number(a)-number(b))))
+		}
+		if desc {
+			result = -result
+		}
+		return result
+	})
+	return nil
+}
+
+// Search torrents using searchUrl feed (can use "%s" as keyword placeholder, e.g. Torznab "api?t=search&q=%s").
+// If searchUrl is empty, filter torrents of site torrentsUrl feed by keyword locally.
+func SearchFeedTorrents(siteInstance Site, httpClient *azuretls.Session, keyword string, searchUrl string) (
+	[]*Torrent, error) {
+	if searchUrl == "" {
+		searchUrl = siteInstance.GetSiteConfig().SearchUrl
+	}
+	if strings.Contains(searchUrl, "%s") {
+		searchUrl = strings.Replace(searchUrl, "%s", url.QueryEscape(keyword), 1)
+		return GetFeedTorrents(siteInstance, httpClient, searchUrl)
+	}
+	if searchUrl == "" || searchUrl == FEED_URL_PREFIX {
+		searchUrl = siteInstance.GetSiteConfig().TorrentsUrl
+	}
+	torrents, err := GetFeedTorrents(siteInstance, httpClient, searchUrl)
+	if err != nil {
+		return nil, err
+	}
+	return util.Filter(torrents, func(torrent *Torrent) bool {
+		return torrent.MatchFilter(keyword)
+	}), nil
+}
+
+// Fetch and parse feed. feedUrl can be relative to site url, and can have the "rss:" prefix.
+// If feedUrl is "rss:", the site default feed url (e.g. "torrentrss.php" of NexusPHP) is used.
+func GetFeedTorrents(siteInstance Site, httpClient *azuretls.Session, feedUrl string) ([]*Torrent, error) {
+	feedUrl = strings.TrimPrefix(feedUrl, FEED_URL_PREFIX)
+	if feedUrl == "" {
+		if provider, ok := siteInstance.(FeedProvider); ok {
+			feedUrl = provider.GetDefaultFeedUrl()
+		}
+	}
+	if feedUrl == "" {
+		return nil, fmt.Errorf("feed url not configured")
+	}
+	feedUrl = siteInstance.GetSiteConfig().ParseSiteUrl(feedUrl, false)
+	res, _, err := util.FetchUrlWithAzuretls(feedUrl, httpClient, siteInstance.GetSiteConfig().Cookie,
+		GetUa(siteInstance), siteInstance.GetDefaultHttpHeaders())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	return ParseFeed(res.Body, siteInstance)
+}
+
+// Parse RSS / Atom feed contents to torrents.
+func ParseFeed(contents []byte, siteInstance Site) ([]*Torrent, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(contents))
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	var data feed
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to parse feed (cookie or passkey may be invalid): %w", err)
+	}
+	if data.XMLName.Local == "error" {
+		return nil, fmt.Errorf("feed error %s: %s", data.ErrorCode, data.ErrorDescription)
+	}
+	location, err := time.LoadLocation(siteInstance.GetSiteConfig().GetTimezone())
+	if err != nil {
+		location = time.Local
+	}
+	torrents := []*Torrent{}
+	for _, items := range [][]*feedItem{data.Items, data.Entries, data.RdfItems} {
+		for _, item := range items {
+			if torrent := item.torrent(siteInstance, location); torrent != nil {
+				torrents = append(torrents, torrent)
+			}
+		}
+	}
+	return torrents, nil
+}
+
+func (item *feedItem) torrent(siteInstance Site, location *time.Location) *Torrent {
+	siteConfig := siteInstance.GetSiteConfig()
+	attrs := map[string]string{}
+	tags := []string{}
+	for _, attr := range item.Attrs {
+		name := strings.ToLower(attr.Name)
+		if name == "tag" {
+			tags = append(tags, attr.Value)
+		} else if _, ok := attrs[name]; !ok {
+			attrs[name] = strings.TrimSpace(attr.Value)
+		}
+	}
+	// Torznab category attrs & elements are numeric ids, which are not useful as tags.
+	for _, category := range item.Categories {
+		for _, str := range []string{category.Term, category.Text} {
+			if str = strings.TrimSpace(str); str != "" && !util.IsIntString(str) && !slices.Contains(tags, str) {
+				tags = append(tags, str)
+			}
+		}
+	}
+
+	links := []string{}
+	downloadUrl := ""
+	if item.Enclosure != nil && item.Enclosure.Url != "" {
+		downloadUrl = item.Enclosure.Url
+	}
+	for _, link := range item.Links {
+		href := strings.TrimSpace(link.Href + link.Url)
+		if href == "" {
+			continue
+		}
+		if link.Rel == "enclosure" && downloadUrl == "" {
+			downloadUrl = href
+		}
+		links = append(links, href)
+	}
+	if downloadUrl == "" && len(links) > 0 {
+		downloadUrl = links[0]
+	}
+	if downloadUrl == "" || item.Title == "" {
+		return nil
+	}
+	downloadUrl = siteConfig.ParseSiteUrl(downloadUrl, false)
+
+	id := ""
+	for _, link := range append([]string{downloadUrl, item.Comments, item.Guid}, links...) {
+		if m := feedTorrentIdRegexp.FindStringSubmatch(link); m != nil {
+			id = siteInstance.GetName() + "." + m[feedTorrentIdRegexp.SubexpIndex("id")]
+			break
+		}
+	}
+	infoHash := attrs["infohash"]
+	if infoHash == "" {
+		if guid := strings.TrimSpace(item.Guid); feedInfoHashRegexp.MatchString(guid) {
+			infoHash = guid
+		} else if m := feedMagnetRegexp.FindStringSubmatch(attrs["magneturl"] + " " + downloadUrl); m != nil {
+			infoHash = m[feedMagnetRegexp.SubexpIndex("hash")]
+		}
+	}
+
+	size := util.ParseInt(attrs["size"])
+	if size <= 0 && item.Enclosure != nil {
+		size = util.ParseInt(item.Enclosure.Length)
+	}
+	if size <= 0 {
+		size = util.ParseInt(item.Size)
+	}
+	isSizeAccurate := size > 0
+	if size <= 0 {
+		if m := feedTitleSizeRegexp.FindStringSubmatch(item.Title); m != nil {
+			size, _ = util.ExtractSizeStr(m[feedTitleSizeRegexp.SubexpIndex("size")])
+		}
+	}
+
+	var torrentTime int64
+	for _, str := range []string{item.PubDate, item.Published, item.Updated, item.Date} {
+		if torrentTime = parseFeedTime(str, location); torrentTime > 0 {
+			break
+		}
+	}
+
+	seeders := util.ParseInt(attrs["seeders"])
+	leechers := util.ParseInt(attrs["leechers"])
+	if _, ok := attrs["leechers"]; !ok && attrs["peers"] != "" {
+		leechers = max(util.ParseInt(attrs["peers"])-seeders, 0)
+	}
+	downloadMultiplier := parseFeedFloat(attrs["downloadvolumefactor"], 1)
+	uploadMultiplier := parseFeedFloat(attrs["uploadvolumefactor"], 1)
+	if slices.ContainsFunc(tags, func(tag string) bool {
+		return strings.EqualFold(tag, "freeleech")
+	}) {
+		downloadMultiplier = 0
+	}
+	return &Torrent{
+		Name:               strings.TrimSpace(util.SanitizeText(item.Title)),
+		Id:                 id,
+		InfoHash:           strings.ToLower(infoHash),
+		DownloadUrl:        downloadUrl,
+		DownloadMultiplier: downloadMultiplier,
+		UploadMultiplier:   uploadMultiplier,
+		DiscountEndTime:    -1,
+		Time:               torrentTime,
+		Size:               size,
+		IsSizeAccurate:     isSizeAccurate,
+		Seeders:            seeders,
+		Leechers:           leechers,
+		Snatched:           util.ParseInt(attrs["grabs"]),
+		HasHnR:             siteConfig.GlobalHnR || util.ParseInt(attrs["minimumseedtime"]) > 0,
+		Tags:               tags,
+	}
+}
+
+func parseFeedTime(str string, location *time.Location) int64 {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return 0
+	}
+	for _, format := range feedTimeFormats {
+		if t, err := time.Parse(format, str); err == nil {
+			return t.Unix()
+		}
+	}
+	t, _ := util.ParseTime(str, location)
+	return t
+}
+
+func parseFeedFloat(str string, defaultValue float64) float64 {
+	if v, err := strconv.ParseFloat(str, 64); err == nil {
+		return v
+	}
+	return defaultValue
+}
@@ -0,0 +1,118 @@
+package site_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+	_ "github.com/sagan/ptool/site/rss"
+)
+
+const torznabFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:torznab="http://torznab.com/schemas/2015/feed">
+<channel>
+<item>
+  <title>Test.Movie.2024.1080p</title>
+  <guid>https://example.com/details.php?id=123</guid>
+  <comments>https://example.com/details.php?id=123</comments>
+  <pubDate>Mon, 01 Jan 2024 12:00:00 +0000</pubDate>
+  <enclosure url="https://example.com/download.php?id=123&amp;passkey=abc" length="1073741824" type="application/x-bittorrent" />
+  <category>2000</category>
+  <category>Movies</category>
+  <torznab:attr name="seeders" value="10" />
+  <torznab:attr name="peers" value="15" />
+  <torznab:attr name="grabs" value="100" />
+  <torznab:attr name="infohash" value="0123456789ABCDEF0123456789ABCDEF01234567" />
+  <torznab:attr name="downloadvolumefactor" value="0" />
+  <torznab:attr name="uploadvolumefactor" value="2" />
+</item>
+<item>
+  <title>Test.Show.S01 [Subtitle] [12.5 GB]</title>
+  <link>download.php?id=456</link>
+  <pubDate>Tue, 02 Jan 2024 08:30:00 +0800</pubDate>
+</item>
+<item>
+  <title>Item without link</title>
+</item>
+</channel>
+</rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+<entry>
+  <title>Atom Torrent</title>
+  <link rel="alternate" href="https://example.com/torrents/789" />
+  <link rel="enclosure" href="https://example.com/download/789" />
+  <published>2024-01-03T00:00:00Z</published>
+  <category term="freeleech" />
+</entry>
+</feed>`
+
+func TestParseFeed(t *testing.T) {
+	siteInstance, err := site.CreateSiteInternal("test", &config.SiteConfigStruct{
+		Type:        "rss",
+		Url:         "https://example.com/",
+		TorrentsUrl: "torrentrss.php",
+		Timezone:    "UTC",
+	}, &config.ConfigStruct{})
+	if err != nil {
+		t.Fatalf("failed to create site: %v", err)
+	}
+
+	torrents, err := site.ParseFeed([]byte(torznabFeed), siteInstance)
+	if err != nil {
+		t.Fatalf("failed to parse rss feed: %v", err)
+	}
+	if len(torrents) != 2 {
+		t.Fatalf("got %d torrents, want 2", len(torrents))
+	}
+	torrent := torrents[0]
+	if torrent.Name != "Test.Movie.2024.1080p" || torrent.Id != "test.123" ||
+		torrent.DownloadUrl != "https://example.com/download.php?id=123&passkey=abc" ||
+		torrent.InfoHash != "0123456789abcdef0123456789abcdef01234567" {
+		t.Errorf("torznab item: unexpected name / id / url / infohash: %+v", torrent)
+	}
+	if torrent.Size != 1073741824 || !torrent.IsSizeAccurate ||
+		torrent.Time != time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC).Unix() {
+		t.Errorf("torznab item: unexpected size / time: %+v", torrent)
+	}
+	if torrent.Seeders != 10 || torrent.Leechers != 5 || torrent.Snatched != 100 ||
+		torrent.DownloadMultiplier != 0 || torrent.UploadMultiplier != 2 {
+		t.Errorf("torznab item: unexpected attrs: %+v", torrent)
+	}
+	if len(torrent.Tags) != 1 || torrent.Tags[0] != "Movies" {
+		t.Errorf("torznab item: got tags %v, want [Movies]", torrent.Tags)
+	}
+	torrent = torrents[1]
+	if torrent.Id != "test.456" || torrent.DownloadUrl != "https://example.com/download.php?id=456" {
+		t.Errorf("relative link item: unexpected id / url: %+v", torrent)
+	}
+	if torrent.Size != 12.5*1024*1024*1024 || torrent.IsSizeAccurate {
+		t.Errorf("relative link item: got size %d (accurate %t), want size from title",
+			torrent.Size, torrent.IsSizeAccurate)
+	}
+	if torrent.Time != time.Date(2024, 1, 2, 0, 30, 0, 0, time.UTC).Unix() ||
+		torrent.DownloadMultiplier != 1 || torrent.UploadMultiplier != 1 {
+		t.Errorf("relative link item: unexpected time / multipliers: %+v", torrent)
+	}
+
+	torrents, err = site.ParseFeed([]byte(atomFeed), siteInstance)
+	if err != nil {
+		t.Fatalf("failed to parse atom feed: %v", err)
+	}
+	if len(torrents) != 1 {
+		t.Fatalf("got %d atom torrents, want 1", len(torrents))
+	}
+	torrent = torrents[0]
+	if torrent.DownloadUrl != "https://example.com/download/789" || torrent.Id != "test.789" ||
+		torrent.DownloadMultiplier != 0 || torrent.Time != time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC).Unix() {
+		t.Errorf("atom entry: unexpected torrent: %+v", torrent)
+	}
+
+	_, err = site.ParseFeed([]byte(`<?xml version="1.0"?><error code="100" description="Invalid API Key" />`),
+		siteInstance)
+	if err == nil {
+		t.Errorf("expected error for torznab error feed")
+	}
+}
@@ -0,0 +1,163 @@
+package gazelle
+
+// Gazelle JSON API: https://github.com/WhatCD/Gazelle/wiki/JSON-API-Documentation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type apiResponse struct {
+	Status   string          `json:"status"` // "success" or "failure"
+	Error    string          `json:"error"`
+	Response json.RawMessage `json:"response"`
+}
+
+// ajax.php?action=index
+type apiIndex struct {
+	Username string  `json:"username"`
+	Id       flexInt `json:"id"`
+	Authkey  string  `json:"authkey"`
+	Passkey  string  `json:"passkey"`
+}
+
+// ajax.php?action=browse
+type apiBrowse struct {
+	CurrentPage flexInt           `json:"currentPage"`
+	Pages       flexInt           `json:"pages"`
+	Results     []*apiBrowseGroup `json:"results"`
+}
+
+// A torrent group in browse results.
+// Music groups have torrents list; non-music groups (e.g. applications, e-books) are single torrents.
+type apiBrowseGroup struct {
+	GroupId     flexInt       `json:"groupId"`
+	GroupName   string        `json:"groupName"`
+	Artist      string        `json:"artist"`
+	Tags        []string      `json:"tags"`
+	GroupYear   flexInt       `json:"groupYear"`
+	ReleaseType string        `json:"releaseType"`
+	GroupTime   string        `json:"groupTime"`
+	Category    string        `json:"category"`
+	Torrents    []*apiTorrent `json:"torrents"`
+	apiTorrent                // non-music group torrent fields
+}
+
+// Torrent of browse results or torrentgroup response. Field names differ slightly among them.
+type apiTorrent struct {
+	TorrentId           flexInt  `json:"torrentId"` // browse
+	Id                  flexInt  `json:"id"`        // torrentgroup
+	Media               string   `json:"media"`
+	Format              string   `json:"format"`
+	Encoding            string   `json:"encoding"`
+	Remastered          flexBool `json:"remastered"`
+	RemasterYear        flexInt  `json:"remasterYear"`
+	RemasterTitle       string   `json:"remasterTitle"`
+	RemasterRecordLabel string   `json:"remasterRecordLabel"`
+	Scene               flexBool `json:"scene"`
+	HasLog              flexBool `json:"hasLog"`
+	LogScore            flexInt  `json:"logScore"`
+	HasCue              flexBool `json:"hasCue"`
+	Time                string   `json:"time"`
+	Size                flexInt  `json:"size"`
+	Snatches            flexInt  `json:"snatches"` // browse
+	Snatched            flexInt  `json:"snatched"` // torrentgroup
+	Seeders             flexInt  `json:"seeders"`
+	Leechers            flexInt  `json:"leechers"`
+	IsFreeleech         flexBool `json:"isFreeleech"`
+	IsNeutralLeech      flexBool `json:"isNeutralLeech"`
+	IsPersonalFreeleech flexBool `json:"isPersonalFreeleech"`
+	FreeTorrent         flexBool `json:"freeTorrent"` // torrentgroup
+}
+
+// ajax.php?action=torrentgroup
+type apiTorrentGroup struct {
+	Group struct {
+		Id           flexInt  `json:"id"`
+		Name         string   `json:"name"`
+		Year         flexInt  `json:"year"`
+		ReleaseType  flexInt  `json:"releaseType"`
+		CategoryName string   `json:"categoryName"`
+		Tags         []string `json:"tags"`
+		MusicInfo    struct {
+			Artists []*apiArtist `json:"artists"`
+		} `json:"musicInfo"`
+	} `json:"group"`
+	Torrents []*apiTorrent `json:"torrents"`
+}
+
+type apiArtist struct {
+	Id   flexInt `json:"id"`
+	Name string  `json:"name"`
+}
+
+func (torrent *apiTorrent) id() int64 {
+	if torrent.TorrentId > 0 {
+		return int64(torrent.TorrentId)
+	}
+	return int64(torrent.Id)
+}
+
+// Edition and format description. e.g. "2010 - Deluxe Edition / CD / FLAC / Lossless / Log (100%) / Cue".
+func (torrent *apiTorrent) description() string {
+	parts := []string{}
+	edition := ""
+	if torrent.RemasterYear > 0 {
+		edition = fmt.Sprint(torrent.RemasterYear)
+	}
+	for _, str := range []string{torrent.RemasterRecordLabel, torrent.RemasterTitle} {
+		if str != "" {
+			if edition != "" {
+				edition += " - "
+			}
+			edition += str
+		}
+	}
+	for _, str := range []string{edition, torrent.Media, torrent.Format, torrent.Encoding} {
+		if str != "" {
+			parts = append(parts, str)
+		}
+	}
+	if torrent.HasLog {
+		if torrent.LogScore != 0 {
+			parts = append(parts, fmt.Sprintf("Log (%d%%)", torrent.LogScore))
+		} else {
+			parts = append(parts, "Log")
+		}
+	}
+	if torrent.HasCue {
+		parts = append(parts, "Cue")
+	}
+	if torrent.Scene {
+		parts = append(parts, "Scene")
+	}
+	return strings.Join(parts, " / ")
+}
+
+// Int which may be encoded as number or string in json.
+type flexInt int64
+
+func (i *flexInt) UnmarshalJSON(data []byte) error {
+	str := strings.Trim(string(data), `"`)
+	if str == "" || str == "null" {
+		*i = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", data)
+	}
+	*i = flexInt(v)
+	return nil
+}
+
+// Bool which may be encoded as true / false, 1 / 0 or "1" / "0" in json.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	str := strings.Trim(string(data), `"`)
+	*b = flexBool(str != "" && str != "0" && str != "false" && str != "null")
+	return nil
+}
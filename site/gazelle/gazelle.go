@@ -5,17 +5,25 @@ package gazelle
 // 种子下载链接：https://dicmusic.club/torrents.php?action=download&id=&authkey=&torrent_pass=
 // (如果cookie有效，authkey 和 torrent_pass 可省略)
 // 注意下载时的 id 与 torrent.php 页面url里的 id 不同，后者是当前音乐专辑的 id
+// 种子列表、搜索使用 ajax.php JSON API。可以使用 cookie 或 apiKey (Authorization header) 鉴权。
+// 站点 API 有严格的请求频率限制(默认每 10 秒 5 次)。
 
 import (
+	"encoding/json"
 	"fmt"
+	"html"
+	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Noooste/azuretls-client"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
 	"github.com/sagan/ptool/site"
 	"github.com/sagan/ptool/util"
 )
@@ -27,6 +35,10 @@ type Site struct {
 	Config      *config.ConfigStruct
 	HttpClient  *azuretls.Session
 	HttpHeaders [][]string
+	index       *apiIndex // cached current user info. nil if not fetched (or failed) yet
+	// time of recent API requests, for rate limiting
+	requestTimes []time.Time
+	mu           sync.Mutex
 }
 
 // PublishTorrent implements site.Site.
@@ -38,13 +50,31 @@ const (
 	SELECTOR_USERNAME        = "#nav_userinfo"
 	SELECTOR_USER_UPLOADED   = "#stats_seeding"
 	SELECTOR_USER_DOWNLOADED = "#stats_leeching"
+	// Gazelle default API rate limit: 5 requests every 10 seconds.
+	DEFAULT_API_RATE_LIMIT = 5
+	API_RATE_LIMIT_WINDOW  = 10 * time.Second
 )
 
+var sortFields = map[string]string{
+	"time":     "time",
+	"size":     "size",
+	"seeders":  "seeders",
+	"leechers": "leechers",
+	"snatched": "snatched",
+}
+
 func (gzsite *Site) GetDefaultHttpHeaders() [][]string {
 	return gzsite.HttpHeaders
 }
 
+func (gzsite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
 func (gzsite *Site) PurgeCache() {
+	gzsite.mu.Lock()
+	defer gzsite.mu.Unlock()
+	gzsite.index = nil
 }
 
 func (gzsite *Site) GetName() string {
@@ -56,11 +86,14 @@ func (gzsite *Site) GetSiteConfig() *config.SiteConfigStruct {
 }
 
 func (gzsite *Site) GetStatus() (*site.Status, error) {
-	doc, _, err := util.GetUrlDocWithAzuretls(gzsite.SiteConfig.Url+"torrents.php", gzsite.HttpClient,
-		gzsite.GetSiteConfig().Cookie, site.GetUa(gzsite), gzsite.GetDefaultHttpHeaders())
+	doc, res, err := site.GetUrlDocWithRelogin(gzsite, gzsite.HttpClient,
+		gzsite.SiteConfig.Url+"torrents.php", "login.php")
 	if err != nil {
 		return nil, err
 	}
+	if strings.Contains(res.Request.Url, "login.php") {
+		return nil, fmt.Errorf("not logined (cookie may has expired)")
+	}
 	userNameSelector := SELECTOR_USERNAME
 	userUploadedSelector := SELECTOR_USER_UPLOADED
 	userDownloadedSelector := SELECTOR_USER_DOWNLOADED
@@ -85,17 +118,335 @@ func (gzsite *Site) GetStatus() (*site.Status, error) {
 	}, nil
 }
 
+// baseUrl: optional browse url (e.g. "ajax.php?action=browse&filter_cat[1]=1" or "torrents.php?filter_cat[1]=1")
+// or query string (e.g. "?filter_cat[1]=1"). If it's a torrent group url (torrents.php?id=123),
+// return all torrents of the group.
 func (gzsite *Site) GetAllTorrents(sort string, desc bool, pageMarker string, baseUrl string) (
 	torrents []*site.Torrent, nextPageMarker string, err error) {
-	return nil, "", site.ErrUnimplemented
+	if sort != "" && sort != constants.NONE && sortFields[sort] == "" {
+		err = fmt.Errorf("unsupported sort field: %s", sort)
+		return
+	}
+	if pageMarker == constants.NONE {
+		pageMarker = ""
+	}
+	query, err := gzsite.parseBaseUrl(baseUrl)
+	if err != nil {
+		return nil, "", err
+	}
+	if groupId := query.Get("id"); groupId != "" && query.Get("action") != "browse" {
+		torrents, err = gzsite.getGroupTorrents(groupId)
+		return
+	}
+	page := int64(1)
+	if pageMarker != "" {
+		page = util.ParseInt(pageMarker)
+		if page < 1 {
+			err = fmt.Errorf("invalid page marker: %s", pageMarker)
+			return
+		}
+	}
+	query.Set("page", fmt.Sprint(page))
+	if sort != "" && sort != constants.NONE {
+		query.Set("order_by", sortFields[sort])
+		if desc {
+			query.Set("order_way", "desc")
+		} else {
+			query.Set("order_way", "asc")
+		}
+	}
+	torrents, pages, err := gzsite.browse(query)
+	if err != nil {
+		return
+	}
+	if page < pages {
+		nextPageMarker = fmt.Sprint(page + 1)
+	}
+	return
 }
 
 func (gzsite *Site) GetLatestTorrents(full bool) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	urls := []string{gzsite.SiteConfig.TorrentsUrl}
+	if full {
+		urls = append(urls, gzsite.SiteConfig.TorrentsExtraUrls...)
+	}
+	torrents := []*site.Torrent{}
+	for i, torrentsUrl := range urls {
+		query, err := gzsite.parseBaseUrl(torrentsUrl)
+		if err == nil {
+			query.Set("order_by", "time")
+			query.Set("order_way", "desc")
+			var pageTorrents []*site.Torrent
+			if pageTorrents, _, err = gzsite.browse(query); err == nil {
+				torrents = append(torrents, pageTorrents...)
+				continue
+			}
+		}
+		if i == 0 {
+			return nil, err
+		}
+		log.Errorf("Failed to get site %s extra torrents from %s: %v", gzsite.Name, torrentsUrl, err)
+	}
+	return torrents, nil
 }
 
+// baseUrl: optional browse url or query string, can use "%s" as keyword placeholder.
 func (gzsite *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	if baseUrl == "" {
+		baseUrl = gzsite.SiteConfig.SearchUrl
+	}
+	hasPlaceholder := strings.Contains(baseUrl, "%s")
+	if hasPlaceholder {
+		baseUrl = strings.Replace(baseUrl, "%s", url.QueryEscape(keyword), 1)
+	}
+	query, err := gzsite.parseBaseUrl(baseUrl)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPlaceholder {
+		searchQueryVariable := "searchstr"
+		if gzsite.SiteConfig.SearchQueryVariable != "" {
+			searchQueryVariable = gzsite.SiteConfig.SearchQueryVariable
+		}
+		query.Set(searchQueryVariable, keyword)
+	}
+	torrents, _, err := gzsite.browse(query)
+	return torrents, err
+}
+
+// Parse browse url or query string to query params of ajax.php.
+func (gzsite *Site) parseBaseUrl(baseUrl string) (url.Values, error) {
+	if baseUrl == "" || baseUrl == constants.NONE {
+		return url.Values{}, nil
+	}
+	if strings.HasPrefix(baseUrl, "?") {
+		return url.ParseQuery(baseUrl[1:])
+	}
+	urlObj, err := url.Parse(gzsite.SiteConfig.ParseSiteUrl(baseUrl, false))
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	query := urlObj.Query()
+	query.Del("action")
+	if strings.HasSuffix(urlObj.Path, "/ajax.php") && urlObj.Query().Get("action") != "" {
+		query.Set("action", urlObj.Query().Get("action"))
+	}
+	return query, nil
+}
+
+// Browse (search) torrents. Return flattened torrents and total pages.
+func (gzsite *Site) browse(query url.Values) (torrents []*site.Torrent, pages int64, err error) {
+	var data apiBrowse
+	if err = gzsite.apiRequest("browse", query, &data); err != nil {
+		return nil, 0, err
+	}
+	torrents = []*site.Torrent{}
+	for _, group := range data.Results {
+		name := html.UnescapeString(group.GroupName)
+		if group.Artist != "" {
+			name = html.UnescapeString(group.Artist) + " - " + name
+		}
+		if group.GroupYear > 0 {
+			name += fmt.Sprintf(" [%d]", group.GroupYear)
+		}
+		if group.ReleaseType != "" {
+			name += " [" + group.ReleaseType + "]"
+		}
+		tags := slices.Clone(group.Tags)
+		if group.ReleaseType != "" {
+			tags = append(tags, group.ReleaseType)
+		}
+		if group.Category != "" {
+			tags = append(tags, group.Category)
+		}
+		if len(group.Torrents) == 0 {
+			if group.apiTorrent.id() > 0 {
+				group.apiTorrent.Time = group.GroupTime
+				torrents = append(torrents, gzsite.convertTorrent(&group.apiTorrent, name, tags))
+			}
+			continue
+		}
+		for _, torrent := range group.Torrents {
+			torrents = append(torrents, gzsite.convertTorrent(torrent, name, tags))
+		}
+	}
+	return torrents, int64(data.Pages), nil
+}
+
+// Return all torrents of a torrent group.
+func (gzsite *Site) getGroupTorrents(groupId string) ([]*site.Torrent, error) {
+	var data apiTorrentGroup
+	if err := gzsite.apiRequest("torrentgroup", url.Values{"id": {groupId}}, &data); err != nil {
+		return nil, err
+	}
+	name := html.UnescapeString(data.Group.Name)
+	if len(data.Group.MusicInfo.Artists) > 0 {
+		artists := util.Map(data.Group.MusicInfo.Artists, func(artist *apiArtist) string {
+			return html.UnescapeString(artist.Name)
+		})
+		name = strings.Join(artists, " & ") + " - " + name
+	}
+	if data.Group.Year > 0 {
+		name += fmt.Sprintf(" [%d]", data.Group.Year)
+	}
+	tags := slices.Clone(data.Group.Tags)
+	if data.Group.CategoryName != "" {
+		tags = append(tags, data.Group.CategoryName)
+	}
+	torrents := []*site.Torrent{}
+	for _, torrent := range data.Torrents {
+		torrents = append(torrents, gzsite.convertTorrent(torrent, name, tags))
+	}
+	return torrents, nil
+}
+
+func (gzsite *Site) convertTorrent(torrent *apiTorrent, name string, groupTags []string) *site.Torrent {
+	id := fmt.Sprint(torrent.id())
+	downloadMultiplier := 1.0
+	uploadMultiplier := 1.0
+	if torrent.IsFreeleech || torrent.IsPersonalFreeleech || torrent.FreeTorrent {
+		downloadMultiplier = 0
+	}
+	if torrent.IsNeutralLeech {
+		downloadMultiplier = 0
+		uploadMultiplier = 0
+	}
+	tags := slices.Clone(groupTags)
+	for _, tag := range []string{torrent.Media, torrent.Format, torrent.Encoding} {
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	snatched := int64(torrent.Snatches)
+	if snatched == 0 {
+		snatched = int64(torrent.Snatched)
+	}
+	time, _ := util.ParseTime(torrent.Time, gzsite.Location)
+	return &site.Torrent{
+		Name:               name,
+		Description:        html.UnescapeString(torrent.description()),
+		Id:                 fmt.Sprintf("%s.%s", gzsite.Name, id),
+		DownloadUrl:        gzsite.SiteConfig.ParseSiteUrl("torrents.php?action=download&id="+id, false),
+		DownloadMultiplier: downloadMultiplier,
+		UploadMultiplier:   uploadMultiplier,
+		DiscountEndTime:    -1,
+		Time:               time,
+		Size:               int64(torrent.Size),
+		IsSizeAccurate:     true,
+		Seeders:            int64(torrent.Seeders),
+		Leechers:           int64(torrent.Leechers),
+		Snatched:           snatched,
+		HasHnR:             gzsite.SiteConfig.GlobalHnR,
+		Neutral:            bool(torrent.IsNeutralLeech),
+		Tags:               tags,
+	}
+}
+
+// Return torrent download url. If user authkey & passkey are available, include them in url,
+// so the url can be downloaded without cookie. The returned url is used only in downloading,
+// it's not exposed in torrents list.
+func (gzsite *Site) getDownloadUrl(id string) string {
+	torrentUrl := gzsite.SiteConfig.ParseSiteUrl("torrents.php?action=download&id="+id, false)
+	if index, err := gzsite.getIndex(); err == nil && index.Authkey != "" && index.Passkey != "" {
+		torrentUrl += "&authkey=" + index.Authkey + "&torrent_pass=" + index.Passkey
+	}
+	return torrentUrl
+}
+
+// Get (cached) current user info from ajax.php?action=index. Only successful result is cached.
+func (gzsite *Site) getIndex() (*apiIndex, error) {
+	gzsite.mu.Lock()
+	index := gzsite.index
+	gzsite.mu.Unlock()
+	if index != nil {
+		return index, nil
+	}
+	if err := gzsite.apiRequest("index", nil, &index); err != nil {
+		return nil, err
+	}
+	if index == nil {
+		return nil, fmt.Errorf("empty index response")
+	}
+	gzsite.mu.Lock()
+	gzsite.index = index
+	gzsite.mu.Unlock()
+	return index, nil
+}
+
+// Request ajax.php?action=<action> and unmarshal the "response" field to result.
+func (gzsite *Site) apiRequest(action string, query url.Values, result any) error {
+	apiQuery := url.Values{}
+	for key, values := range query {
+		apiQuery[key] = values
+	}
+	apiQuery.Set("action", action)
+	apiUrl := gzsite.SiteConfig.ParseSiteUrl("ajax.php?"+apiQuery.Encode(), false)
+	headers := gzsite.GetDefaultHttpHeaders()
+	if gzsite.SiteConfig.ApiKey != "" {
+		headers = append(slices.Clone(headers), []string{"Authorization", gzsite.SiteConfig.ApiKey})
+	}
+	relogined := false
+request:
+	gzsite.waitRateLimit()
+	res, _, err := util.FetchUrlWithAzuretls(apiUrl, gzsite.HttpClient,
+		gzsite.SiteConfig.Cookie, site.GetUa(gzsite), headers)
+	if err != nil {
+		if res != nil && res.StatusCode == http.StatusTooManyRequests {
+			return fmt.Errorf("site API rate limit exceeded: %w", err)
+		}
+		return err
+	}
+	// cookie auth failed, got redirected to login page
+	if strings.Contains(res.Request.Url, "login.php") {
+		if !relogined && gzsite.SiteConfig.ApiKey == "" && site.Relogin(gzsite) {
+			relogined = true
+			goto request
+		}
+		return fmt.Errorf("not logined (cookie may has expired)")
+	}
+	var data apiResponse
+	if err = json.Unmarshal(res.Body, &data); err != nil {
+		return fmt.Errorf("failed to parse API response (cookie or apiKey may be invalid): %w", err)
+	}
+	if data.Status != "success" {
+		return fmt.Errorf("site API error: %s", data.Error)
+	}
+	if err = json.Unmarshal(data.Response, result); err != nil {
+		return fmt.Errorf("failed to parse API response: %w", err)
+	}
+	return nil
+}
+
+// Block until next API request is allowed by site rate limit:
+// at most apiRateLimit requests in every API_RATE_LIMIT_WINDOW.
+func (gzsite *Site) waitRateLimit() {
+	if wait := gzsite.reserveRequest(time.Now()); wait > 0 {
+		log.Debugf("Site %s API rate limit reached, wait %v", gzsite.Name, wait)
+		time.Sleep(wait)
+	}
+}
+
+// Reserve the time slot of next API request and return the duration to wait before sending it.
+// The request time is recorded in advance, so concurrent callers do not need to hold the lock while waiting.
+func (gzsite *Site) reserveRequest(now time.Time) time.Duration {
+	limit := gzsite.SiteConfig.ApiRateLimit
+	if limit == 0 {
+		limit = DEFAULT_API_RATE_LIMIT
+	} else if limit < 0 {
+		return 0
+	}
+	gzsite.mu.Lock()
+	defer gzsite.mu.Unlock()
+	for len(gzsite.requestTimes) > 0 && now.Sub(gzsite.requestTimes[0]) >= API_RATE_LIMIT_WINDOW {
+		gzsite.requestTimes = gzsite.requestTimes[1:]
+	}
+	requestTime := now
+	if int64(len(gzsite.requestTimes)) >= limit {
+		requestTime = gzsite.requestTimes[0].Add(API_RATE_LIMIT_WINDOW)
+		gzsite.requestTimes = gzsite.requestTimes[1:]
+	}
+	gzsite.requestTimes = append(gzsite.requestTimes, requestTime)
+	return requestTime.Sub(now)
 }
 
 func (gzsite *Site) DownloadTorrent(torrentUrl string) (content []byte, filename string, id string, err error) {
@@ -106,18 +457,22 @@ func (gzsite *Site) DownloadTorrent(torrentUrl string) (content []byte, filename
 	}
 	if urlObj, err := url.Parse(torrentUrl); err == nil {
 		id = urlObj.Query().Get("id")
+		// listed download url has no authkey & passkey
+		if id != "" && urlObj.Query().Get("action") == "download" && urlObj.Query().Get("torrent_pass") == "" {
+			content, filename, err = gzsite.DownloadTorrentById(id)
+			return content, filename, id, err
+		}
 	}
 	content, filename, err = site.DownloadTorrentByUrl(gzsite, gzsite.HttpClient, torrentUrl, id)
 	return
 }
 
 func (gzsite *Site) DownloadTorrentById(id string) ([]byte, string, error) {
-	torrentUrl := gzsite.SiteConfig.Url + "torrents.php?action=download&id=" + id
-	return site.DownloadTorrentByUrl(gzsite, gzsite.HttpClient, torrentUrl, id)
+	return site.DownloadTorrentByUrl(gzsite, gzsite.HttpClient, gzsite.getDownloadUrl(id), id)
 }
 
 func NewSite(name string, siteConfig *config.SiteConfigStruct, config *config.ConfigStruct) (site.Site, error) {
-	if siteConfig.Cookie == "" {
+	if siteConfig.Cookie == "" && siteConfig.ApiKey == "" {
 		log.Warnf("Site %s has no cookie provided", name)
 	}
 	location, err := time.LoadLocation(siteConfig.GetTimezone())
@@ -0,0 +1,183 @@
+package gazelle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+)
+
+const browseResponse = `{"status":"success","response":{"currentPage":1,"pages":3,"results":[
+{"groupId":10,"groupName":"Album &amp; Songs","artist":"Artist","tags":["rock"],"groupYear":2020,
+	"releaseType":"Album","groupTime":"1700000000","torrents":[
+	{"torrentId":101,"media":"CD","format":"FLAC","encoding":"Lossless","remasterYear":2021,
+		"hasLog":true,"logScore":100,"hasCue":true,"time":"2024-01-02 03:04:05","size":"524288000",
+		"snatches":30,"seeders":10,"leechers":2,"isFreeleech":true},
+	{"torrentId":"102","media":"WEB","format":"MP3","encoding":"320","time":"2024-01-03 03:04:05",
+		"size":104857600,"snatches":5,"seeders":"3","leechers":0,"isNeutralLeech":"1"}
+]},
+{"groupId":20,"groupName":"E-Book","category":"E-Books","tags":["book"],"torrentId":201,
+	"groupTime":"2024-01-04 03:04:05","size":1048576,"snatches":1,"seeders":1,"leechers":1}
+]}}`
+
+const torrentGroupResponse = `{"status":"success","response":{
+"group":{"id":10,"name":"Album","year":2020,"categoryName":"Music","tags":["rock"],
+	"musicInfo":{"artists":[{"id":1,"name":"A"},{"id":2,"name":"B"}]}},
+"torrents":[{"id":101,"media":"CD","format":"FLAC","encoding":"Lossless","time":"2024-01-02 03:04:05",
+	"size":524288000,"snatched":30,"seeders":10,"leechers":2,"freeTorrent":true}]}}`
+
+func newTestSite(t *testing.T, handler http.HandlerFunc) (*Site, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	siteInstance, err := site.CreateSiteInternal("test", &config.SiteConfigStruct{
+		Type:         "gazelle",
+		Url:          server.URL + "/",
+		ApiKey:       "key",
+		ApiRateLimit: -1,
+		Timezone:     "UTC",
+	}, &config.ConfigStruct{})
+	if err != nil {
+		server.Close()
+		t.Fatalf("failed to create site: %v", err)
+	}
+	return siteInstance.(*Site), server
+}
+
+func TestBrowse(t *testing.T) {
+	var queries []string
+	gzsite, server := newTestSite(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ajax.php" || r.Header.Get("Authorization") != "key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		queries = append(queries, r.URL.RawQuery)
+		switch r.URL.Query().Get("action") {
+		case "browse":
+			if r.URL.Query().Get("searchstr") == "fail" {
+				w.Write([]byte(`{"status":"failure","error":"search error"}`))
+				return
+			}
+			w.Write([]byte(browseResponse))
+		case "torrentgroup":
+			w.Write([]byte(torrentGroupResponse))
+		}
+	})
+	defer server.Close()
+
+	torrents, nextPageMarker, err := gzsite.GetAllTorrents("size", true, "", "torrents.php?filter_cat[1]=1")
+	if err != nil {
+		t.Fatalf("browse: %v", err)
+	}
+	if len(queries) != 1 || !strings.Contains(queries[0], "action=browse") ||
+		!strings.Contains(queries[0], "order_by=size") || !strings.Contains(queries[0], "order_way=desc") ||
+		!strings.Contains(queries[0], "page=1") || !strings.Contains(queries[0], "filter_cat%5B1%5D=1") {
+		t.Errorf("browse: unexpected queries %v", queries)
+	}
+	if nextPageMarker != "2" {
+		t.Errorf("browse: got next page marker %q, want 2", nextPageMarker)
+	}
+	if len(torrents) != 3 {
+		t.Fatalf("browse: got %d torrents, want 3", len(torrents))
+	}
+	tests := []struct {
+		name               string
+		id                 string
+		size               int64
+		seeders            int64
+		snatched           int64
+		downloadMultiplier float64
+		uploadMultiplier   float64
+		tag                string
+	}{
+		{"Artist - Album & Songs [2020] [Album]", "test.101", 500 * 1024 * 1024, 10, 30, 0, 1, "FLAC"},
+		{"Artist - Album & Songs [2020] [Album]", "test.102", 100 * 1024 * 1024, 3, 5, 0, 0, "MP3"},
+		{"E-Book", "test.201", 1024 * 1024, 1, 1, 1, 1, "E-Books"},
+	}
+	for i, want := range tests {
+		got := torrents[i]
+		if got.Name != want.name || got.Id != want.id || got.Size != want.size || got.Seeders != want.seeders ||
+			got.Snatched != want.snatched || got.DownloadMultiplier != want.downloadMultiplier ||
+			got.UploadMultiplier != want.uploadMultiplier || !slices.Contains(got.Tags, want.tag) {
+			t.Errorf("browse torrent %d: got %+v, want %+v", i, got, want)
+		}
+		if got.DownloadUrl != server.URL+"/torrents.php?action=download&id="+strings.TrimPrefix(want.id, "test.") {
+			t.Errorf("browse torrent %d: unexpected download url %q", i, got.DownloadUrl)
+		}
+	}
+	if torrents[0].Description != "2021 / CD / FLAC / Lossless / Log (100%) / Cue" {
+		t.Errorf("browse: unexpected description %q", torrents[0].Description)
+	}
+	if torrents[0].Time != time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Unix() {
+		t.Errorf("browse: unexpected time %d", torrents[0].Time)
+	}
+
+	torrents, _, err = gzsite.GetAllTorrents("", false, "", "torrents.php?id=10")
+	if err != nil {
+		t.Fatalf("torrentgroup: %v", err)
+	}
+	if len(torrents) != 1 || torrents[0].Name != "A & B - Album [2020]" || torrents[0].Id != "test.101" ||
+		torrents[0].DownloadMultiplier != 0 || torrents[0].Snatched != 30 {
+		t.Errorf("torrentgroup: unexpected torrents %v", torrents)
+	}
+
+	if _, _, err = gzsite.GetAllTorrents("", false, "", "?action=foo&id=1"); err != nil {
+		t.Errorf("torrentgroup with query string: %v", err)
+	}
+	if _, err = gzsite.SearchTorrents("fail", ""); err == nil || !strings.Contains(err.Error(), "search error") {
+		t.Errorf("site API error: got %v", err)
+	}
+}
+
+func TestGetIndex(t *testing.T) {
+	requests := 0
+	gzsite, server := newTestSite(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Write([]byte(`{"status":"failure","error":"temporary error"}`))
+			return
+		}
+		w.Write([]byte(`{"status":"success","response":{"username":"test","id":1,` +
+			`"authkey":"ak","passkey":"pk"}}`))
+	})
+	defer server.Close()
+
+	if _, err := gzsite.getIndex(); err == nil {
+		t.Fatalf("expected error of first index request")
+	}
+	index, err := gzsite.getIndex()
+	if err != nil || index.Authkey != "ak" || index.Passkey != "pk" {
+		t.Fatalf("failed index request should not be cached: got %v, %v", index, err)
+	}
+	gzsite.getIndex()
+	if requests != 2 {
+		t.Errorf("successful index should be cached: got %d requests, want 2", requests)
+	}
+	if got := gzsite.getDownloadUrl("101"); got != server.URL+
+		"/torrents.php?action=download&id=101&authkey=ak&torrent_pass=pk" {
+		t.Errorf("unexpected download url %q", got)
+	}
+}
+
+func TestReserveRequest(t *testing.T) {
+	gzsite := &Site{SiteConfig: &config.SiteConfigStruct{ApiRateLimit: 2}}
+	now := time.Now()
+	tests := []struct {
+		offset time.Duration
+		want   time.Duration
+	}{
+		{0, 0},
+		{time.Second, 0},
+		{2 * time.Second, 8 * time.Second},
+		{3 * time.Second, 8 * time.Second},
+		{20 * time.Second, 0},
+	}
+	for i, tt := range tests {
+		if got := gzsite.reserveRequest(now.Add(tt.offset)); got != tt.want {
+			t.Errorf("request %d: got wait %v, want %v", i, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package gazelle
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/sagan/ptool/site"
+)
+
+var _ site.Loginer = (*Site)(nil)
+
+// Gazelle login: POST "login.php". On success, site sets "session" cookie and redirects to index page.
+// If 2FA is enabled, site redirects to "login.php?act=2fa" which accepts the otp code.
+func (gzsite *Site) Login(username string, password string, otp string) (*site.LoginCredential, error) {
+	loginUrl := gzsite.SiteConfig.ParseSiteUrl("login.php", false)
+	form := url.Values{}
+	form.Set("username", username)
+	form.Set("password", password)
+	form.Set("keeplogged", "1")
+	res, err := site.PostLoginForm(gzsite, gzsite.HttpClient, loginUrl, form, "")
+	if err != nil {
+		return nil, err
+	}
+	cookie := site.MergeCookies("", res.Cookies)
+	location := res.Header.Get("Location")
+	if strings.Contains(location, "act=2fa") {
+		if otp == "" {
+			return nil, fmt.Errorf("site requires 2FA code, totpSecret must be configured")
+		}
+		form = url.Values{}
+		form.Set("2fa", otp)
+		res, err = site.PostLoginForm(gzsite, gzsite.HttpClient,
+			gzsite.SiteConfig.ParseSiteUrl("login.php?act=2fa", false), form, cookie)
+		if err != nil {
+			return nil, err
+		}
+		cookie = site.MergeCookies(cookie, res.Cookies)
+		location = res.Header.Get("Location")
+	}
+	if res.StatusCode < 300 || res.StatusCode >= 400 || strings.Contains(location, "login.php") {
+		return nil, fmt.Errorf("login failed (status=%d, location=%s)", res.StatusCode, location)
+	}
+	return &site.LoginCredential{Cookie: cookie}, nil
+}
+
+func (gzsite *Site) ApplyLoginCredential(credential *site.LoginCredential) {
+	gzsite.SiteConfig.Cookie = credential.Cookie
+	gzsite.PurgeCache()
+}
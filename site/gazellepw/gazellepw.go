@@ -38,6 +38,10 @@ func (gpwsite *Site) GetDefaultHttpHeaders() [][]string {
 	return gpwsite.HttpHeaders
 }
 
+func (gpwsite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
 func (gpwsite *Site) PurgeCache() {
 }
 
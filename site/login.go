@@ -0,0 +1,252 @@
+package site
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Noooste/azuretls-client"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/natefinch/atomic"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/util"
+	"github.com/sagan/ptool/util/crypto"
+)
+
+// Min interval (seconds) between automatic re-logins of a site, to avoid flooding site with failed logins.
+const RELOGIN_INTERVAL = 300
+
+var (
+	reloginTimes = map[string]int64{}
+	reloginMu    sync.Mutex
+)
+
+// Credential obtained by logining site with username & password.
+type LoginCredential struct {
+	Cookie string // cookie based sites. e.g. nexusphp, unit3d, gazelle
+	Token  string // token based sites. e.g. mtorrent
+	Time   int64  // login unix timestamp (seconds)
+}
+
+// Site that supports logining with username & password.
+type Loginer interface {
+	Site
+	// Login site, return obtained credential. otp is the 2FA code, could be empty.
+	Login(username string, password string, otp string) (*LoginCredential, error)
+	// Apply a (new or saved) credential to site, so following requests use it.
+	ApplyLoginCredential(credential *LoginCredential)
+}
+
+// Login site using username & password (and TOTP secret) in site config.
+// The obtained credential is applied to site and saved to config dir.
+func Login(siteInstance Site) (*LoginCredential, error) {
+	loginer, ok := Unwrap(siteInstance).(Loginer)
+	if !ok {
+		return nil, ErrUnimplemented
+	}
+	siteConfig := siteInstance.GetSiteConfig()
+	if siteConfig.Username == "" || siteConfig.Password == "" {
+		return nil, fmt.Errorf("site username or password not configured")
+	}
+	otp := ""
+	if siteConfig.TotpSecret != "" {
+		var err error
+		if otp, err = crypto.Totp(siteConfig.TotpSecret, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	log.Infof("Login site %s as %s", siteInstance.GetName(), siteConfig.Username)
+	credential, err := loginer.Login(siteConfig.Username, siteConfig.Password, otp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to login: %w", err)
+	}
+	credential.Time = util.Now()
+	loginer.ApplyLoginCredential(credential)
+	if err := saveLoginCredential(siteInstance.GetName(), credential); err != nil {
+		log.Warnf("Failed to save site %s login credential: %v", siteInstance.GetName(), err)
+	}
+	return credential, nil
+}
+
+// Login site if it supports login and has username & password configured.
+// Used to automatically refresh credential when site requests got redirected to login page.
+// Return true if login succeeds.
+func Relogin(siteInstance Site) bool {
+	siteConfig := siteInstance.GetSiteConfig()
+	if siteConfig.Username == "" || siteConfig.Password == "" {
+		return false
+	}
+	if _, ok := Unwrap(siteInstance).(Loginer); !ok {
+		return false
+	}
+	reloginMu.Lock()
+	now := util.Now()
+	if now-reloginTimes[siteInstance.GetName()] < RELOGIN_INTERVAL {
+		reloginMu.Unlock()
+		log.Debugf("Skip re-login site %s: tried recently", siteInstance.GetName())
+		return false
+	}
+	reloginTimes[siteInstance.GetName()] = now
+	reloginMu.Unlock()
+	log.Warnf("Site %s cookie may has expired, try to re-login", siteInstance.GetName())
+	if _, err := Login(siteInstance); err != nil {
+		log.Errorf("Failed to re-login site %s: %v", siteInstance.GetName(), err)
+		return false
+	}
+	return true
+}
+
+func loginCredentialFile(sitename string) string {
+	return filepath.Join(config.ConfigDir, fmt.Sprintf(config.SITE_LOGIN_FILE, sitename))
+}
+
+func saveLoginCredential(sitename string, credential *LoginCredential) error {
+	if err := os.MkdirAll(config.ConfigDir, constants.PERM_DIR); err != nil {
+		return err
+	}
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return err
+	}
+	return atomic.WriteFile(loginCredentialFile(sitename), bytes.NewReader(data))
+}
+
+// Load saved login credential of site. Return nil if not exists.
+func loadLoginCredential(sitename string) *LoginCredential {
+	data, err := os.ReadFile(loginCredentialFile(sitename))
+	if err != nil {
+		return nil
+	}
+	credential := &LoginCredential{}
+	if err := json.Unmarshal(data, credential); err != nil {
+		log.Warnf("Invalid site %s saved login credential: %v", sitename, err)
+		return nil
+	}
+	return credential
+}
+
+// Return true if saved login credential should be used instead of the cookie of site config:
+// no cookie is configured, or the credential is obtained after the config file was last modified.
+func preferLoginCredential(siteConfig *config.SiteConfigStruct, credential *LoginCredential) bool {
+	if siteConfig.Cookie == "" {
+		return true
+	}
+	stat, err := os.Stat(filepath.Join(config.ConfigDir, config.ConfigFile))
+	return err == nil && credential.Time > stat.ModTime().Unix()
+}
+
+// Fetch site page dom. If the request got redirected to login page (final url contains loginPath),
+// re-login site (if username & password configured) and retry once.
+func GetUrlDocWithRelogin(siteInstance Site, httpClient *azuretls.Session, pageUrl string, loginPath string) (
+	doc *goquery.Document, res *azuretls.Response, err error) {
+	doc, res, err = util.GetUrlDocWithAzuretls(pageUrl, httpClient, siteInstance.GetSiteConfig().Cookie,
+		GetUa(siteInstance), siteInstance.GetDefaultHttpHeaders())
+	if res != nil && strings.Contains(res.Request.Url, loginPath) && Relogin(siteInstance) {
+		doc, res, err = util.GetUrlDocWithAzuretls(pageUrl, httpClient, siteInstance.GetSiteConfig().Cookie,
+			GetUa(siteInstance), siteInstance.GetDefaultHttpHeaders())
+	}
+	return
+}
+
+// Post a urlencoded login form to site without following redirects.
+func PostLoginForm(siteInstance Site, httpClient *azuretls.Session, loginUrl string, form url.Values,
+	cookie string) (*azuretls.Response, error) {
+	headers := append(slices.Clone(siteInstance.GetDefaultHttpHeaders()),
+		[]string{"Content-Type", "application/x-www-form-urlencoded"})
+	req := &azuretls.Request{
+		Method:           http.MethodPost,
+		Url:              loginUrl,
+		Body:             form.Encode(),
+		NoCookie:         true,
+		DisableRedirects: true,
+		OrderedHeaders:   util.GetHttpReqHeaders(headers, cookie, GetUa(siteInstance)),
+	}
+	util.LogAzureHttpRequest(req)
+	res, err := httpClient.Do(req)
+	util.LogAzureHttpResponse(res, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post login form: %w", err)
+	}
+	return res, nil
+}
+
+// Fetch login page without following redirects. Return page dom and response.
+// Login form hidden inputs (e.g. csrf token) and response cookies are usually required by following login post.
+func GetLoginPage(siteInstance Site, httpClient *azuretls.Session, loginUrl string, cookie string) (
+	*goquery.Document, *azuretls.Response, error) {
+	req := &azuretls.Request{
+		Method:           http.MethodGet,
+		Url:              loginUrl,
+		NoCookie:         true,
+		DisableRedirects: true,
+		OrderedHeaders:   util.GetHttpReqHeaders(siteInstance.GetDefaultHttpHeaders(), cookie, GetUa(siteInstance)),
+	}
+	util.LogAzureHttpRequest(req)
+	res, err := httpClient.Do(req)
+	util.LogAzureHttpResponse(res, err)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get login page: %w", err)
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(res.Body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse login page: %w", err)
+	}
+	return doc, res, nil
+}
+
+// Return name & value of all hidden inputs of the form (e.g. csrf token).
+func GetFormHiddenInputs(form *goquery.Selection) url.Values {
+	values := url.Values{}
+	form.Find(`input[type="hidden"]`).Each(func(i int, s *goquery.Selection) {
+		if name := s.AttrOr("name", ""); name != "" {
+			values.Set(name, s.AttrOr("value", ""))
+		}
+	})
+	return values
+}
+
+// Merge cookies (e.g. parsed from response "Set-Cookie" headers) into cookie header string.
+// New cookies override existing ones of the same name. Deleted cookies are removed.
+func MergeCookies(cookie string, cookies map[string]string) string {
+	names := []string{}
+	values := map[string]string{}
+	for _, pair := range strings.Split(cookie, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if name == "" {
+			continue
+		}
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = value
+	}
+	newNames := []string{}
+	for name := range cookies {
+		newNames = append(newNames, name)
+	}
+	slices.Sort(newNames)
+	for _, name := range newNames {
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = cookies[name]
+	}
+	pairs := []string{}
+	for _, name := range names {
+		if value := values[name]; value != "" && value != "deleted" {
+			pairs = append(pairs, name+"="+value)
+		}
+	}
+	return strings.Join(pairs, "; ")
+}
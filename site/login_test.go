@@ -0,0 +1,63 @@
+package site_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
+	"github.com/sagan/ptool/site"
+	_ "github.com/sagan/ptool/site/nexusphp"
+)
+
+func TestCreateSiteUsesSavedLoginCredential(t *testing.T) {
+	configDir, configFile := config.ConfigDir, config.ConfigFile
+	defer func() {
+		config.ConfigDir, config.ConfigFile = configDir, configFile
+	}()
+	config.ConfigDir = t.TempDir()
+	config.ConfigFile = "ptool.toml"
+	configFileTime := time.Now().Add(-time.Hour)
+	configFilePath := filepath.Join(config.ConfigDir, config.ConfigFile)
+	if err := os.WriteFile(configFilePath, []byte{}, constants.PERM); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := os.Chtimes(configFilePath, configFileTime, configFileTime); err != nil {
+		t.Fatalf("failed to set config file time: %v", err)
+	}
+
+	tests := []struct {
+		desc           string
+		cookie         string
+		credentialTime time.Time
+		want           string
+	}{
+		{"no configured cookie", "", configFileTime.Add(-time.Hour), "saved=1"},
+		{"configured cookie is newer", "configured=1", configFileTime.Add(-time.Hour), "configured=1"},
+		{"saved credential is newer", "configured=1", configFileTime.Add(time.Minute), "saved=1"},
+	}
+	for _, tt := range tests {
+		data, _ := json.Marshal(&site.LoginCredential{Cookie: "saved=1", Time: tt.credentialTime.Unix()})
+		if err := os.WriteFile(filepath.Join(config.ConfigDir, fmt.Sprintf(config.SITE_LOGIN_FILE, "test")),
+			data, constants.PERM); err != nil {
+			t.Fatalf("failed to write credential file: %v", err)
+		}
+		siteInstance, err := site.CreateSiteInternal("test", &config.SiteConfigStruct{
+			Type:     "nexusphp",
+			Url:      "https://example.com/",
+			Cookie:   tt.cookie,
+			Username: "user",
+			Password: "pass",
+		}, &config.ConfigStruct{})
+		if err != nil {
+			t.Fatalf("%s: failed to create site: %v", tt.desc, err)
+		}
+		if got := siteInstance.GetSiteConfig().Cookie; got != tt.want {
+			t.Errorf("%s: got cookie %q, want %q", tt.desc, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	neturl "net/url"
+	"slices"
 	"strings"
 
 	"github.com/Noooste/azuretls-client"
@@ -19,6 +20,9 @@ const (
 	APIPath_GenerateDownloadToken = "/api/torrent/genDlToken"
 	APIPath_TorrentSearch         = "/api/torrent/search"
 	APIPath_Profile               = "/api/member/profile"
+	APIPath_TorrentDetail         = "/api/torrent/detail"
+	APIPath_TorrentFiles          = "/api/torrent/files"
+	APIPath_Login                 = "/api/login"
 )
 
 var (
@@ -43,12 +47,14 @@ var (
 )
 
 var _ site.Site = (*Site)(nil)
+var _ site.Loginer = (*Site)(nil)
 
 type Site struct {
 	Name        string
 	SiteConfig  *config.SiteConfigStruct
 	HttpClient  *azuretls.Session
 	HttpHeaders [][]string
+	token       string // auth token obtained by login
 }
 
 // PublishTorrent implements site.Site.
@@ -160,6 +166,9 @@ func (m *Site) SearchTorrents(keyword string, baseUrl string) (torrents []*site.
 	return
 }
 
+// Only bonus is provided by the profile API. User class is a numeric role id whose names are not exposed by API;
+// bonus per hour, HnR, invites and unread messages are only available in other (undocumented) APIs
+// of the site web UI, whose response formats are unstable. So they are not reported.
 func (m *Site) GetStatus() (*site.Status, error) {
 	var resp ProfileResponse
 	if err := m.do(APIPath_Profile, nil, nil, &resp); err != nil {
@@ -171,10 +180,83 @@ func (m *Site) GetStatus() (*site.Status, error) {
 			UserUploaded:        resp.Data.MemberCount.Uploaded.Value(),
 			TorrentsSeedingCnt:  0,
 			TorrentsLeechingCnt: 0,
+			UserBonus:           Float64String(resp.Data.MemberCount.Bonus).Value(),
 		}, nil
 	}
 }
 
+func (m *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	id = strings.TrimPrefix(id, m.GetName()+".")
+	q := make(neturl.Values)
+	q.Add("id", id)
+	var resp TorrentDetailResponse
+	if err := m.do(APIPath_TorrentDetail, q, nil, &resp); err != nil {
+		return nil, fmt.Errorf("%s error: %w", APIPath_TorrentDetail, err)
+	}
+	torrent := m.convertTorrents(&TorrentList{Data: []Torrent{resp.Data.Torrent}})[0]
+	torrent.InfoHash = resp.Data.InfoHash
+	details := &site.TorrentDetails{
+		Torrent:   *torrent,
+		Url:       m.SiteConfig.ParseSiteUrl("detail/"+id, false),
+		Text:      resp.Data.Descr,
+		ImdbUrl:   resp.Data.Imdb,
+		DoubanUrl: resp.Data.Douban,
+	}
+	if !resp.Data.Anonymous {
+		details.Uploader = resp.Data.Author
+	}
+	var filesResp TorrentFilesResponse
+	if err := m.do(APIPath_TorrentFiles, q, nil, &filesResp); err != nil {
+		log.Warnf("%s error: %v", APIPath_TorrentFiles, err)
+	} else {
+		for _, file := range filesResp.Data {
+			details.Files = append(details.Files, &site.TorrentDetailsFile{
+				Path: file.Name,
+				Size: file.Size.Value(),
+			})
+		}
+	}
+	return details, nil
+}
+
+// Login by posting username & password (and optional 2FA code) to API.
+// On success, API returns the auth token in "Authorization" response header.
+func (m *Site) Login(username string, password string, otp string) (*site.LoginCredential, error) {
+	form := neturl.Values{}
+	form.Set("username", username)
+	form.Set("password", password)
+	if otp != "" {
+		form.Set("otpCode", otp)
+	}
+	loginUrl, err := neturl.JoinPath(m.SiteConfig.Url, APIPath_Login)
+	if err != nil {
+		return nil, err
+	}
+	res, err := site.PostLoginForm(m, m.HttpClient, loginUrl, form, "")
+	if err != nil {
+		return nil, err
+	}
+	var resp ResponseCode
+	if err := json.Unmarshal(res.Body, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal response as json error (status=%d): %w", res.StatusCode, err)
+	}
+	if err := resp.GetError(); err != nil {
+		return nil, err
+	}
+	token := res.Header.Get("Authorization")
+	if token == "" {
+		return nil, fmt.Errorf("no auth token returned")
+	}
+	return &site.LoginCredential{Token: token, Cookie: site.MergeCookies("", res.Cookies)}, nil
+}
+
+func (m *Site) ApplyLoginCredential(credential *site.LoginCredential) {
+	m.token = credential.Token
+	if credential.Cookie != "" {
+		m.SiteConfig.Cookie = credential.Cookie
+	}
+}
+
 func (m *Site) PurgeCache() {
 }
 
@@ -188,7 +270,13 @@ func (m *Site) do(path string, query neturl.Values, body any, result any) error
 		fullPath = fullPath + "?" + query.Encode()
 	}
 
-	reqHeaders := util.GetHttpReqHeaders(m.GetDefaultHttpHeaders(), m.GetSiteConfig().Cookie, site.GetUa(m))
+	relogined := false
+request:
+	headers := m.GetDefaultHttpHeaders()
+	if m.token != "" {
+		headers = append(slices.Clone(headers), []string{"Authorization", m.token})
+	}
+	reqHeaders := util.GetHttpReqHeaders(headers, m.GetSiteConfig().Cookie, site.GetUa(m))
 	res, err := m.HttpClient.Do(&azuretls.Request{
 		Method:   http.MethodPost,
 		Url:      fullPath,
@@ -199,6 +287,10 @@ func (m *Site) do(path string, query neturl.Values, body any, result any) error
 		return fmt.Errorf("failed to fetch url: %w", err)
 	}
 	log.Tracef("Azuretls.Do response status=%d", res.StatusCode)
+	if res.StatusCode == http.StatusUnauthorized && !relogined && site.Relogin(m) {
+		relogined = true
+		goto request
+	}
 	if res.StatusCode != 200 {
 		return fmt.Errorf("failed to fetch url: status=%d", res.StatusCode)
 	}
@@ -242,7 +334,7 @@ func (m *Site) convertTorrents(list *TorrentList) []*site.Torrent {
 			IsSizeAccurate:     true,
 			Seeders:            torrent.Status.Seeders.Value(),
 			Leechers:           torrent.Status.Leechers.Value(),
-			Snatched:           0,
+			Snatched:           torrent.Status.TimesCompleted.Value(),
 			HasHnR:             false,
 			IsActive:           false, // TODO: maybe torrent.clientList[*].downloaded > 0?
 			Paid:               false,
@@ -92,6 +92,7 @@ type TorrentStatus struct {
 	DiscountEndTime *Time  `json:"discountEndTime"`
 	Leechers        Int64  `json:"leechers"`
 	Seeders         Int64  `json:"seeders"`
+	TimesCompleted  Int64  `json:"timesCompleted"`
 	Status          string `json:"status"`
 }
 
@@ -119,6 +120,31 @@ type TorrentSearchResponse struct {
 	Data TorrentList `json:"data"`
 }
 
+type TorrentDetail struct {
+	Torrent
+	Imdb      string `json:"imdb"`
+	Douban    string `json:"douban"`
+	Descr     string `json:"descr"` // bbcode
+	InfoHash  string `json:"infoHash"`
+	Author    string `json:"author"` // uploader user id
+	Anonymous bool   `json:"anonymous"`
+}
+
+type TorrentDetailResponse struct {
+	ResponseCode
+	Data TorrentDetail `json:"data"`
+}
+
+type TorrentFile struct {
+	Name string `json:"name"`
+	Size Int64  `json:"size"`
+}
+
+type TorrentFilesResponse struct {
+	ResponseCode
+	Data []TorrentFile `json:"data"`
+}
+
 type Profile struct {
 	Id               string `json:"id"`
 	CreateDate       Time   `json:"createdDate"`
@@ -0,0 +1,184 @@
+package nexusphp
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+var (
+	// row header texts of torrent details page
+	detailsSubtitleKeywords  = []string{"副标题", "副標題", "small description", "subtitle"}
+	detailsBasicInfoKeywords = []string{"基本信息", "基本資訊", "basic info"}
+	// class of discount tag in details page title. value: [downloadMultiplier, uploadMultiplier]
+	detailsDiscountClasses = map[string][2]float64{
+		"free":          {0, 1},
+		"twoup":         {1, 2},
+		"twoupfree":     {0, 2},
+		"halfdown":      {0.5, 1},
+		"twouphalfdown": {0.5, 2},
+		"thirtypercent": {0.3, 1},
+	}
+	infoHashRegexp = regexp.MustCompile(`(?i)(hash码|hash碼|info\s*hash|hash)\s*[:：]?\s*(?P<hash>[0-9a-f]{40})\b`)
+	seedersRegexp  = regexp.MustCompile(`(?i)(?P<n>\d+)\s*(个|個)?\s*(做种者|做種者|seeders?)`)
+	leechersRegexp = regexp.MustCompile(`(?i)(?P<n>\d+)\s*(个|個)?\s*(下载者|下載者|leechers?)`)
+	numberRegexp   = regexp.MustCompile(`\d+`)
+	discountRegexp = regexp.MustCompile(`(?i)(剩余|剩餘|限时|限時)(时间|時間)?\s*(?P<time>\d[\sYMDHMSymdhms年月周天小时時分种鐘秒\d]+[YMDHMSymdhms年月周天小时時分种鐘秒])`)
+)
+
+// Get torrent details from "details.php?id=<id>" page and files list from "viewfilelist.php?id=<id>".
+func (npclient *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	id = strings.TrimPrefix(id, npclient.GetName()+".")
+	if id == "" {
+		return nil, fmt.Errorf("invalid torrent id")
+	}
+	detailsUrl := npclient.SiteConfig.ParseSiteUrl("details.php?id="+id+"&hit=1", false)
+	doc, res, err := npclient.getDoc(detailsUrl)
+	if !npclient.SiteConfig.AcceptAnyHttpStatus && err != nil || doc == nil {
+		return nil, fmt.Errorf("failed to get torrent details page dom: %w", err)
+	}
+	if strings.Contains(res.Request.Url, "/login.php") {
+		return nil, fmt.Errorf("not logined (cookie may has expired)")
+	}
+	titleEl := doc.Find("h1#top").First()
+	if titleEl.Length() == 0 {
+		return nil, fmt.Errorf("torrent not found or details page format unrecognized")
+	}
+	details := &site.TorrentDetails{
+		Torrent: site.Torrent{
+			Id:                 npclient.GetName() + "." + id,
+			DownloadMultiplier: 1,
+			UploadMultiplier:   1,
+			DiscountEndTime:    -1,
+			HasHnR:             npclient.SiteConfig.GlobalHnR,
+		},
+		Url: detailsUrl,
+	}
+
+	// title: first text node of h1, followed by discount tags.
+	details.Name = strings.TrimSpace(util.SanitizeText(titleEl.Contents().First().Text()))
+	if details.Name == "" {
+		details.Name = util.DomSanitizedText(titleEl)
+	}
+	titleEl.Find("*[class]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		for _, class := range strings.Fields(s.AttrOr("class", "")) {
+			if multipliers, ok := detailsDiscountClasses[strings.ToLower(class)]; ok {
+				details.DownloadMultiplier, details.UploadMultiplier = multipliers[0], multipliers[1]
+				return false
+			}
+		}
+		return true
+	})
+	if details.DownloadMultiplier == 1 && (titleEl.Find(`*[title="免费"],*[title="免費"],*[alt="Free"],*[alt="FREE"]`).
+		Length() > 0 || domCheckTextTagExisting(titleEl, "free") || domCheckTextTagExisting(titleEl, "免费")) {
+		details.DownloadMultiplier = 0
+	}
+	if details.DownloadMultiplier != 1 || details.UploadMultiplier != 1 {
+		titleEl.Find("span[title]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+			if t, err := util.ParseTime(s.AttrOr("title", ""), npclient.Location); err == nil {
+				details.DiscountEndTime = t
+				return false
+			}
+			return true
+		})
+		if details.DiscountEndTime <= 0 {
+			if m := discountRegexp.FindStringSubmatch(util.DomRemovedSpecialCharsText(titleEl)); m != nil {
+				if t, err := util.ParseFutureTime(m[discountRegexp.SubexpIndex("time")]); err == nil {
+					details.DiscountEndTime = t
+				}
+			}
+		}
+	}
+	if titleEl.Find(`*[title="H&R"],*[alt="H&R"],*[title="Hit and Run"]`).Length() > 0 {
+		details.HasHnR = true
+	}
+
+	details.Description = util.DomSanitizedText(getDetailsRow(doc, detailsSubtitleKeywords))
+	details.Size, _ = util.ExtractSizeStr(util.DomSanitizedText(getDetailsRow(doc, detailsBasicInfoKeywords)))
+	if descrEl := doc.Find("#kdescr").First(); descrEl.Length() > 0 {
+		descrEl.Find("br").ReplaceWithHtml("\n")
+		details.Text = strings.TrimSpace(descrEl.Text())
+	}
+	details.ImdbUrl = doc.Find(`a[href*="imdb.com/title/tt"]`).First().AttrOr("href", "")
+	details.DoubanUrl = doc.Find(`a[href*="movie.douban.com/subject/"]`).First().AttrOr("href", "")
+
+	uploaderEl := doc.Find(`td.rowfollow a[href*="userdetails.php?id="]`).First()
+	details.Uploader = util.DomSanitizedText(uploaderEl)
+	timeEls := doc.Find("td.rowfollow span[title]")
+	if uploaderEl.Length() > 0 {
+		timeEls = uploaderEl.Closest("td").Find("span[title]")
+	}
+	timeEls.EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if t, err := util.ParseTime(s.AttrOr("title", ""), npclient.Location); err == nil {
+			details.Time = t
+			return false
+		}
+		return true
+	})
+
+	bodyText := util.DomSanitizedText(doc.Find("body"))
+	if m := infoHashRegexp.FindStringSubmatch(bodyText); m != nil {
+		details.InfoHash = strings.ToLower(m[infoHashRegexp.SubexpIndex("hash")])
+	}
+	peersText := bodyText
+	if peersEl := doc.Find("#peercount"); peersEl.Length() > 0 {
+		peersText = util.DomSanitizedText(peersEl)
+	}
+	if m := seedersRegexp.FindStringSubmatch(peersText); m != nil {
+		details.Seeders = util.ParseInt(m[seedersRegexp.SubexpIndex("n")])
+	}
+	if m := leechersRegexp.FindStringSubmatch(peersText); m != nil {
+		details.Leechers = util.ParseInt(m[leechersRegexp.SubexpIndex("n")])
+	}
+	details.Snatched = util.ParseInt(numberRegexp.FindString(
+		util.DomSanitizedText(doc.Find(`a[href*="viewsnatches.php?id="]`))))
+
+	files, err := npclient.getTorrentFiles(id)
+	if err != nil {
+		log.Warnf("Failed to get torrent %s files list: %v", id, err)
+	}
+	details.Files = files
+	return details, nil
+}
+
+// Parse files list from "viewfilelist.php?id=<id>", which returns a html fragment of files table.
+func (npclient *Site) getTorrentFiles(id string) ([]*site.TorrentDetailsFile, error) {
+	fileListUrl := npclient.SiteConfig.ParseSiteUrl("viewfilelist.php?id="+id, false)
+	doc, _, err := npclient.getDoc(fileListUrl)
+	if err != nil {
+		return nil, err
+	}
+	files := []*site.TorrentDetailsFile{}
+	doc.Find("tr").Each(func(i int, s *goquery.Selection) {
+		tds := s.Children().Filter("td")
+		if tds.Length() < 2 || tds.Filter(".colhead").Length() > 0 {
+			return
+		}
+		size, err := util.ExtractSizeStr(util.DomSanitizedText(tds.Last()))
+		if err != nil {
+			return
+		}
+		files = append(files, &site.TorrentDetailsFile{
+			Path: util.DomSanitizedText(tds.First()),
+			Size: size,
+		})
+	})
+	return files, nil
+}
+
+// Return the "td.rowfollow" cell of details page table row which header matches any keyword.
+func getDetailsRow(doc *goquery.Document, keywords []string) *goquery.Selection {
+	return doc.Find("td.rowhead").FilterFunction(func(i int, s *goquery.Selection) bool {
+		text := strings.ToLower(util.DomSanitizedText(s))
+		return slices.ContainsFunc(keywords, func(keyword string) bool {
+			return strings.Contains(text, keyword)
+		})
+	}).First().Next()
+}
@@ -0,0 +1,55 @@
+package nexusphp
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Noooste/azuretls-client"
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+var _ site.Loginer = (*Site)(nil)
+
+// Login by posting "takelogin.php". On success, site sets "c_secure_*" cookies and redirects to index page.
+// Sites that require captcha in login form are not supported.
+func (npclient *Site) Login(username string, password string, otp string) (*site.LoginCredential, error) {
+	form := url.Values{}
+	form.Set("username", username)
+	form.Set("password", password)
+	if otp != "" {
+		form.Set("two_step_code", otp)
+	}
+	res, err := site.PostLoginForm(npclient, npclient.HttpClient,
+		npclient.SiteConfig.ParseSiteUrl("takelogin.php", false), form, "")
+	if err != nil {
+		return nil, err
+	}
+	location := res.Header.Get("Location")
+	if res.StatusCode < 300 || res.StatusCode >= 400 || strings.Contains(location, "login") {
+		msg := ""
+		if doc, err := goquery.NewDocumentFromReader(bytes.NewReader(res.Body)); err == nil {
+			msg = util.DomSanitizedText(doc.Find("td.text"))
+		}
+		return nil, fmt.Errorf("login failed (status=%d, location=%s): %s", res.StatusCode, location, msg)
+	}
+	cookie := site.MergeCookies("", res.Cookies)
+	if cookie == "" {
+		return nil, fmt.Errorf("login failed: no cookie returned")
+	}
+	return &site.LoginCredential{Cookie: cookie}, nil
+}
+
+func (npclient *Site) ApplyLoginCredential(credential *site.LoginCredential) {
+	npclient.SiteConfig.Cookie = credential.Cookie
+	npclient.PurgeCache()
+}
+
+// Fetch site page dom, re-login if got redirected to login page.
+func (npclient *Site) getDoc(pageUrl string) (*goquery.Document, *azuretls.Response, error) {
+	return site.GetUrlDocWithRelogin(npclient, npclient.HttpClient, pageUrl, "/login.php")
+}
@@ -137,12 +137,21 @@ func (npclient *Site) GetSiteConfig() *config.SiteConfigStruct {
 	return npclient.SiteConfig
 }
 
+// Default feed url used if site torrentsUrl is "rss:". The download links of torrentrss.php include user passkey.
+func (npclient *Site) GetDefaultFeedUrl() string {
+	feedUrl := "torrentrss.php?rows=50&linktype=dl"
+	if npclient.SiteConfig.Passkey != "" {
+		feedUrl += "&passkey=" + npclient.SiteConfig.Passkey
+	}
+	return feedUrl
+}
+
 func (npclient *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.Torrent, error) {
 	if baseUrl == "" {
 		if npclient.SiteConfig.SearchUrl != "" {
 			baseUrl = npclient.SiteConfig.SearchUrl
-		} else if npclient.SiteConfig.TorrentsUrl != "" {
-			baseUrl = npclient.SiteConfig.TorrentsUrl
+		} else if torrentsUrl := site.GetTorrentsPageUrl(npclient.SiteConfig); torrentsUrl != "" {
+			baseUrl = torrentsUrl
 		} else {
 			baseUrl = DEFAULT_TORRENTS_URL
 		}
@@ -157,8 +166,7 @@ func (npclient *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.To
 	}
 	searchUrl = strings.Replace(searchUrl, "%s", url.PathEscape(keyword), 1)
 
-	doc, res, err := util.GetUrlDocWithAzuretls(searchUrl, npclient.HttpClient,
-		npclient.SiteConfig.Cookie, site.GetUa(npclient), npclient.GetDefaultHttpHeaders())
+	doc, res, err := npclient.getDoc(searchUrl)
 	if !npclient.SiteConfig.AcceptAnyHttpStatus && err != nil || doc == nil {
 		return nil, fmt.Errorf("failed to parse site page dom: %w", err)
 	}
@@ -255,8 +263,7 @@ func (npclient *Site) DownloadTorrentById(id string) ([]byte, string, error) {
 
 func (npclient *Site) getDigithash(id string) (string, error) {
 	detailsUrl := npclient.SiteConfig.ParseSiteUrl(fmt.Sprintf("t/%s/", id), false)
-	doc, _, err := util.GetUrlDocWithAzuretls(detailsUrl, npclient.HttpClient,
-		npclient.SiteConfig.Cookie, site.GetUa(npclient), npclient.GetDefaultHttpHeaders())
+	doc, _, err := npclient.getDoc(detailsUrl)
 	if err != nil {
 		return "", fmt.Errorf("failed to get torrent detail page: %w", err)
 	}
@@ -321,10 +328,8 @@ func (npclient *Site) GetAllTorrents(sort string, desc bool, pageMarker string,
 	}
 	// baseUrl is empty; or is query string, e.g. "?seeders_begin=1"
 	if baseUrl == "" || baseUrl == constants.NONE || strings.HasPrefix(baseUrl, "?") {
-		torrentsUrl := ""
-		if npclient.SiteConfig.TorrentsUrl != "" {
-			torrentsUrl = npclient.SiteConfig.TorrentsUrl
-		} else {
+		torrentsUrl := site.GetTorrentsPageUrl(npclient.SiteConfig)
+		if torrentsUrl == "" {
 			torrentsUrl = DEFAULT_TORRENTS_URL
 		}
 		if strings.HasPrefix(baseUrl, "?") {
@@ -364,8 +369,7 @@ func (npclient *Site) GetAllTorrents(sort string, desc bool, pageMarker string,
 	torrentsPageUrl = util.AppendUrlQueryStringDelimiter(torrentsPageUrlObj.String())
 	pageStr := "page=" + fmt.Sprint(page)
 	now := util.Now()
-	doc, res, _err := util.GetUrlDocWithAzuretls(torrentsPageUrl+pageStr, npclient.HttpClient,
-		npclient.SiteConfig.Cookie, site.GetUa(npclient), npclient.GetDefaultHttpHeaders())
+	doc, res, _err := npclient.getDoc(torrentsPageUrl + pageStr)
 	if !npclient.SiteConfig.AcceptAnyHttpStatus && _err != nil || doc == nil {
 		err = fmt.Errorf("failed to fetch torrents page dom: %w", _err)
 		return
@@ -393,8 +397,7 @@ labelLastPage:
 		page = lastPage
 		pageStr = "page=" + fmt.Sprint(page)
 		now = util.Now()
-		doc, res, _err = util.GetUrlDocWithAzuretls(torrentsPageUrl+pageStr, npclient.HttpClient,
-			npclient.SiteConfig.Cookie, site.GetUa(npclient), npclient.GetDefaultHttpHeaders())
+		doc, res, _err = npclient.getDoc(torrentsPageUrl + pageStr)
 		if !npclient.SiteConfig.AcceptAnyHttpStatus && _err != nil || doc == nil {
 			err = fmt.Errorf("failed to fetch torrents page dom: %w", _err)
 			return
@@ -454,13 +457,12 @@ func (npclient *Site) sync() error {
 	if npclient.datatime > 0 {
 		return nil
 	}
-	url := npclient.SiteConfig.TorrentsUrl
+	url := site.GetTorrentsPageUrl(npclient.SiteConfig)
 	if url == "" {
 		url = DEFAULT_TORRENTS_URL
 	}
 	url = npclient.SiteConfig.ParseSiteUrl(url, false)
-	doc, res, err := util.GetUrlDocWithAzuretls(url, npclient.HttpClient,
-		npclient.SiteConfig.Cookie, site.GetUa(npclient), npclient.GetDefaultHttpHeaders())
+	doc, res, err := npclient.getDoc(url)
 	if !npclient.SiteConfig.AcceptAnyHttpStatus && err != nil || doc == nil {
 		return fmt.Errorf("failed to get site page dom: %w", err)
 	}
@@ -522,6 +524,71 @@ func (npclient *Site) sync() error {
 	}
 	siteStatus.UserName = strings.TrimSpace(siteStatus.UserName)
 
+	sstr = ""
+	if npclient.SiteConfig.SelectorUserInfoBonus != "" {
+		sstr = util.DomSelectorText(html, npclient.SiteConfig.SelectorUserInfoBonus)
+	} else {
+		re := regexp.MustCompile(`(?i)(魔力值|魔力|积分|積分|憨豆|Bonus|Karma Points|Karma)\s*(\[[^\]]*\])?[：:\s]+(?P<s>[\d,]+(\.\d+)?)`)
+		m := re.FindStringSubmatch(infoTxt)
+		if m != nil {
+			sstr = m[re.SubexpIndex("s")]
+		}
+	}
+	siteStatus.UserBonus = parseFloat(sstr)
+	if npclient.SiteConfig.SelectorUserInfoBonusPerHour != "" {
+		siteStatus.UserBonusPerHour = parseFloat(
+			util.DomSelectorText(html, npclient.SiteConfig.SelectorUserInfoBonusPerHour))
+	}
+
+	if npclient.SiteConfig.SelectorUserInfoClass != "" {
+		siteStatus.UserClass = util.DomSelectorText(html, npclient.SiteConfig.SelectorUserInfoClass)
+	} else {
+		// nexusphp username link has a class of user class name, e.g. "EliteUser_Name"
+		for _, class := range strings.Fields(infoTr.Find(`*[href*="userdetails.php?"]`).First().AttrOr("class", "")) {
+			if userClass, found := strings.CutSuffix(class, "_Name"); found {
+				siteStatus.UserClass = userClass
+				break
+			}
+		}
+	}
+
+	sstr = ""
+	if npclient.SiteConfig.SelectorUserInfoHnR != "" {
+		sstr = util.DomSelectorText(html, npclient.SiteConfig.SelectorUserInfoHnR)
+	} else {
+		re := regexp.MustCompile(`(?i)(H&R|HnR)[：:\s]+(?P<s>\d+)`)
+		m := re.FindStringSubmatch(infoTxt)
+		if m != nil {
+			sstr = m[re.SubexpIndex("s")]
+		}
+	}
+	siteStatus.UserHnR = util.ParseInt(sstr)
+
+	sstr = ""
+	if npclient.SiteConfig.SelectorUserInfoInvites != "" {
+		sstr = util.DomSelectorText(html, npclient.SiteConfig.SelectorUserInfoInvites)
+	} else {
+		re := regexp.MustCompile(`(?i)(邀请|邀請|Invites?)\s*(\[[^\]]*\])?[：:\s]+(?P<s>\d+)`)
+		m := re.FindStringSubmatch(infoTxt)
+		if m != nil {
+			sstr = m[re.SubexpIndex("s")]
+		}
+	}
+	siteStatus.UserInvites = util.ParseInt(sstr)
+
+	sstr = ""
+	if npclient.SiteConfig.SelectorUserInfoUnreadMessages != "" {
+		sstr = util.DomSelectorText(html, npclient.SiteConfig.SelectorUserInfoUnreadMessages)
+	} else {
+		// e.g. "收件箱 12 (3 新)"
+		re := regexp.MustCompile(`(?i)\(\s*(?P<s>\d+)\s*(新|new)\s*\)`)
+		m := re.FindStringSubmatch(infoTxt)
+		if m != nil {
+			sstr = m[re.SubexpIndex("s")]
+		}
+	}
+	siteStatus.UserUnreadMessages = util.ParseInt(sstr)
+
 	// possibly parsing error or some problem
 	if !siteStatus.IsOk() {
 		log.TraceFn(func() []any {
@@ -545,8 +612,7 @@ func (npclient *Site) syncExtra() error {
 	}
 	extraTorrents := []*site.Torrent{}
 	for _, extraUrl := range npclient.SiteConfig.TorrentsExtraUrls {
-		doc, res, err := util.GetUrlDocWithAzuretls(npclient.SiteConfig.ParseSiteUrl(extraUrl, false), npclient.HttpClient,
-			npclient.SiteConfig.Cookie, site.GetUa(npclient), npclient.GetDefaultHttpHeaders())
+		doc, res, err := npclient.getDoc(npclient.SiteConfig.ParseSiteUrl(extraUrl, false))
 		if err != nil {
 			log.Errorf("failed to parse site page dom: %v", err)
 			continue
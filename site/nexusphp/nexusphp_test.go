@@ -0,0 +1,38 @@
+package nexusphp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+	_ "github.com/sagan/ptool/site/nexusphp"
+)
+
+func TestFeedSiteGetStatusUsesTorrentsPage(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><body><div id="info_block">` +
+			`<a href="userdetails.php?id=1" class="User_Name"><b>test</b></a>` +
+			`</div></body></html>`))
+	}))
+	defer server.Close()
+
+	siteConfig := &config.SiteConfigStruct{
+		Type:        "nexusphp",
+		Url:         server.URL + "/",
+		Cookie:      "c_secure_pass=test",
+		TorrentsUrl: "rss:torrentrss.php?rows=50",
+	}
+	siteInstance, err := site.CreateSiteInternal("test", siteConfig, &config.ConfigStruct{})
+	if err != nil {
+		t.Fatalf("failed to create site: %v", err)
+	}
+	siteInstance.GetStatus()
+	if len(paths) == 0 || paths[0] != "/torrents.php" {
+		t.Errorf("GetStatus requested %v, expected /torrents.php", paths)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -547,6 +548,12 @@ func parseCountString(str string) int64 {
 	return util.ParseInt(strings.TrimSuffix(str, "次"))
 }
 
+// Parse number string like "12,345.6". Return 0 if invalid.
+func parseFloat(str string) float64 {
+	value, _ := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(str), ",", ""), 64)
+	return value
+}
+
 func parseTorrentIdFromUrl(torrentUrl string, idRegexp *regexp.Regexp) (id string) {
 	if idRegexp != nil {
 		if m := idRegexp.FindStringSubmatch(torrentUrl); m != nil {
@@ -0,0 +1,200 @@
+package site
+
+// Generic selectors based torrents list page parser.
+// Used by site types which do not have their own dedicated parser (e.g. torrenttrader, discuz).
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/util"
+)
+
+var (
+	// Header texts of torrents list table columns, used to locate columns if selectors are not set.
+	headerSizeKeywords     = []string{"size", "大小", "体积"}
+	headerSeedersKeywords  = []string{"seed", "做种", "種子數"}
+	headerLeechersKeywords = []string{"leech", "下载中", "下載中", "正在下载", "正在下載"}
+	headerSnatchedKeywords = []string{"complete", "snatch", "完成"}
+	headerTimeKeywords     = []string{"added", "date", "time", "发布", "時間", "时间"}
+	pageRegexp             = regexp.MustCompile(`\bpage=(?P<page>\d+)`)
+)
+
+type TorrentsParserOption struct {
+	Location *time.Location
+	// Regexp that extracts torrent id from download or details link, must have a "id" named group.
+	IdRegexp *regexp.Regexp
+	// Default Selector* values of the site type. Site config Selector* values take precedence over them.
+	Defaults *config.SiteConfigStruct
+}
+
+// Parse torrents from a torrents list page using Selector* configs of site.
+// If a column (size / seeders / leechers / snatched / time) selector is not set,
+// it's located by texts of the list header row. Rows without a download link are skipped.
+func ParseTorrentsBySelectors(doc *goquery.Document, siteInstance Site, option *TorrentsParserOption) []*Torrent {
+	siteConfig := siteInstance.GetSiteConfig()
+	defaults := option.Defaults
+	if defaults == nil {
+		defaults = &config.SiteConfigStruct{}
+	}
+	selector := func(configured string, defaultSelector string) string {
+		if configured != "" {
+			return configured
+		}
+		return defaultSelector
+	}
+	downloadSelector := selector(siteConfig.SelectorTorrentDownloadLink, defaults.SelectorTorrentDownloadLink)
+	detailsSelector := selector(siteConfig.SelectorTorrentDetailsLink, defaults.SelectorTorrentDetailsLink)
+	torrentSelector := selector(siteConfig.SelectorTorrent, defaults.SelectorTorrent)
+	if torrentSelector == "" {
+		torrentSelector = detailsSelector
+	}
+	blockSelector := selector(siteConfig.SelectorTorrentBlock, defaults.SelectorTorrentBlock)
+	if blockSelector == "" {
+		blockSelector = fmt.Sprintf("tr:has(%s)", downloadSelector)
+	}
+	sizeSelector := selector(siteConfig.SelectorTorrentSize, defaults.SelectorTorrentSize)
+	seedersSelector := selector(siteConfig.SelectorTorrentSeeders, defaults.SelectorTorrentSeeders)
+	leechersSelector := selector(siteConfig.SelectorTorrentLeechers, defaults.SelectorTorrentLeechers)
+	snatchedSelector := selector(siteConfig.SelectorTorrentSnatched, defaults.SelectorTorrentSnatched)
+	timeSelector := selector(siteConfig.SelectorTorrentTime, defaults.SelectorTorrentTime)
+	freeSelector := selector(siteConfig.SelectorTorrentFree, defaults.SelectorTorrentFree)
+	noTrafficSelector := selector(siteConfig.SelectorTorrentNoTraffic, defaults.SelectorTorrentNoTraffic)
+	neutralSelector := selector(siteConfig.SelectorTorrentNeutral, defaults.SelectorTorrentNeutral)
+	hnrSelector := selector(siteConfig.SelectorTorrentHnR, defaults.SelectorTorrentHnR)
+	paidSelector := selector(siteConfig.SelectorTorrentPaid, defaults.SelectorTorrentPaid)
+	activeSelector := selector(siteConfig.SelectorTorrentActive, defaults.SelectorTorrentActive)
+	currentActiveSelector := selector(siteConfig.SelectorTorrentCurrentActive, defaults.SelectorTorrentCurrentActive)
+	discountEndTimeSelector := selector(siteConfig.SelectorTorrentDiscountEndTime,
+		defaults.SelectorTorrentDiscountEndTime)
+
+	blocks := doc.Find(blockSelector)
+	sizeIndex, seedersIndex, leechersIndex, snatchedIndex, timeIndex := -1, -1, -1, -1, -1
+	var header *goquery.Selection
+	if headerSelector := selector(siteConfig.SelectorTorrentsListHeader,
+		defaults.SelectorTorrentsListHeader); headerSelector != "" {
+		header = doc.Find(headerSelector).First()
+	} else if blocks.Length() > 0 {
+		header = blocks.First().Parent().Children().First()
+	}
+	if header != nil {
+		header.Children().Each(func(i int, s *goquery.Selection) {
+			text := strings.ToLower(util.DomSanitizedText(s) + " " + s.Find("*[title]").AttrOr("title", "") +
+				" " + s.Find("*[alt]").AttrOr("alt", ""))
+			// a column matches at most one field, in the order below.
+			// e.g. "Times Completed" is snatched column, not time column.
+			for _, column := range []struct {
+				index    *int
+				keywords []string
+			}{
+				{&sizeIndex, headerSizeKeywords},
+				{&seedersIndex, headerSeedersKeywords},
+				{&leechersIndex, headerLeechersKeywords},
+				{&snatchedIndex, headerSnatchedKeywords},
+				{&timeIndex, headerTimeKeywords},
+			} {
+				if *column.index == -1 && slices.ContainsFunc(column.keywords, func(keyword string) bool {
+					return strings.Contains(text, keyword)
+				}) {
+					*column.index = i
+					break
+				}
+			}
+		})
+	}
+	// get element by selector, or by column index if selector is not set.
+	field := func(s *goquery.Selection, selector string, index int) *goquery.Selection {
+		if selector != "" {
+			return s.Find(selector)
+		}
+		if index >= 0 {
+			return s.Children().Eq(index)
+		}
+		return s.Slice(0, 0)
+	}
+
+	torrents := []*Torrent{}
+	blocks.Each(func(i int, s *goquery.Selection) {
+		downloadEl := s.Find(downloadSelector).First()
+		nameEl := s.Find(torrentSelector).First()
+		// rows without a download link (e.g. torrent not available, or a plain forum thread) are skipped,
+		// the name / details link points to a html page which can't be downloaded as torrent.
+		href := downloadEl.AttrOr("href", "")
+		if href == "" {
+			return
+		}
+		id := ""
+		if option.IdRegexp != nil {
+			for _, href := range []string{downloadEl.AttrOr("href", ""), nameEl.AttrOr("href", "")} {
+				if m := option.IdRegexp.FindStringSubmatch(href); m != nil {
+					id = m[option.IdRegexp.SubexpIndex("id")]
+					break
+				}
+			}
+		}
+		downloadUrl := siteConfig.ParseSiteUrl(href, false)
+		name := nameEl.AttrOr("title", "")
+		if name == "" {
+			name = util.DomSanitizedText(nameEl)
+		}
+		size, _ := util.ExtractSizeStr(util.DomSanitizedText(field(s, sizeSelector, sizeIndex)))
+		downloadMultiplier := 1.0
+		uploadMultiplier := 1.0
+		if freeSelector != "" && s.Find(freeSelector).Length() > 0 {
+			downloadMultiplier = 0
+		}
+		neutral := neutralSelector != "" && s.Find(neutralSelector).Length() > 0
+		if neutral || noTrafficSelector != "" && s.Find(noTrafficSelector).Length() > 0 {
+			downloadMultiplier = 0
+			uploadMultiplier = 0
+		}
+		discountEndTime := int64(-1)
+		if discountEndTimeSelector != "" {
+			if t := util.DomTime(s.Find(discountEndTimeSelector), option.Location); t > 0 {
+				discountEndTime = t
+			}
+		}
+		isCurrentActive := currentActiveSelector != "" && s.Find(currentActiveSelector).Length() > 0
+		isActive := isCurrentActive || activeSelector != "" && s.Find(activeSelector).Length() > 0
+		tid := ""
+		if id != "" {
+			tid = fmt.Sprintf("%s.%s", siteInstance.GetName(), id)
+		}
+		torrents = append(torrents, &Torrent{
+			Name:               name,
+			Id:                 tid,
+			DownloadUrl:        downloadUrl,
+			DownloadMultiplier: downloadMultiplier,
+			UploadMultiplier:   uploadMultiplier,
+			DiscountEndTime:    discountEndTime,
+			Time:               util.DomTime(field(s, timeSelector, timeIndex), option.Location),
+			Size:               size,
+			Seeders:            util.ParseInt(util.DomSanitizedText(field(s, seedersSelector, seedersIndex))),
+			Leechers:           util.ParseInt(util.DomSanitizedText(field(s, leechersSelector, leechersIndex))),
+			Snatched:           util.ParseInt(util.DomSanitizedText(field(s, snatchedSelector, snatchedIndex))),
+			HasHnR:             siteConfig.GlobalHnR || hnrSelector != "" && s.Find(hnrSelector).Length() > 0,
+			IsActive:           isActive,
+			IsCurrentActive:    isCurrentActive,
+			Paid:               paidSelector != "" && s.Find(paidSelector).Length() > 0,
+			Neutral:            neutral,
+		})
+	})
+	return torrents
+}
+
+// Return the max page number found in pagination links ("page=N") of the page.
+func ParseLastPage(doc *goquery.Document) int64 {
+	lastPage := int64(0)
+	doc.Find(`a[href*="page="]`).Each(func(i int, s *goquery.Selection) {
+		if m := pageRegexp.FindStringSubmatch(s.AttrOr("href", "")); m != nil {
+			lastPage = max(lastPage, util.ParseInt(m[pageRegexp.SubexpIndex("page")]))
+		}
+	})
+	return lastPage
+}
@@ -0,0 +1,140 @@
+package site_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+	_ "github.com/sagan/ptool/site/rss"
+)
+
+const torrentsPage = `<html><body><table>
+<tr><td>Name</td><td>Size</td><td>Seeders</td><td>Leechers</td><td>Completed</td><td>Added</td></tr>
+<tr>
+  <td><a href="details.php?id=1" title="Torrent One">Torrent One</a> <img class="free" />
+    <a href="download.php?id=1"><img alt="download" /></a></td>
+  <td>1.5 GB</td><td>10</td><td>2</td><td>30</td><td>2024-01-02 03:04:05</td>
+</tr>
+<tr>
+  <td><a href="details.php?id=2">Torrent Without Download Link</a></td>
+  <td>2 GB</td><td>1</td><td>0</td><td>5</td><td>2024-01-03 03:04:05</td>
+</tr>
+<tr>
+  <td><a href="details.php?id=3">Torrent <b>Three</b></a> <a href="/download.php?id=3&amp;passkey=x">DL</a></td>
+  <td>512 MB</td><td>0</td><td>7</td><td>1</td><td>2024-01-04 03:04:05</td>
+</tr>
+</table></body></html>`
+
+func TestParseTorrentsBySelectors(t *testing.T) {
+	siteInstance, err := site.CreateSiteInternal("test", &config.SiteConfigStruct{
+		Type:        "rss",
+		Url:         "https://example.com/",
+		TorrentsUrl: "torrentrss.php",
+	}, &config.ConfigStruct{})
+	if err != nil {
+		t.Fatalf("failed to create site: %v", err)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(torrentsPage))
+	if err != nil {
+		t.Fatalf("failed to parse html: %v", err)
+	}
+	torrents := site.ParseTorrentsBySelectors(doc, siteInstance, &site.TorrentsParserOption{
+		Location: time.UTC,
+		IdRegexp: regexp.MustCompile(`download\.php\?id=(?P<id>\d+)\b`),
+		Defaults: &config.SiteConfigStruct{
+			SelectorTorrentBlock:        `tr:has(a[href*="details.php?id="])`,
+			SelectorTorrent:             `a[href*="details.php?id="]`,
+			SelectorTorrentDownloadLink: `a[href*="download.php?id="]`,
+			SelectorTorrentFree:         `img.free`,
+		},
+	})
+
+	// the row without download link is skipped
+	if len(torrents) != 2 {
+		t.Fatalf("got %d torrents, want 2: %v", len(torrents), torrents)
+	}
+	tests := []struct {
+		name               string
+		id                 string
+		downloadUrl        string
+		size               int64
+		seeders            int64
+		leechers           int64
+		snatched           int64
+		time               time.Time
+		downloadMultiplier float64
+	}{
+		{
+			"Torrent One", "test.1", "https://example.com/download.php?id=1",
+			1536 * 1024 * 1024, 10, 2, 30, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), 0,
+		},
+		{
+			"Torrent Three", "test.3", "https://example.com/download.php?id=3&passkey=x",
+			512 * 1024 * 1024, 0, 7, 1, time.Date(2024, 1, 4, 3, 4, 5, 0, time.UTC), 1,
+		},
+	}
+	for i, want := range tests {
+		got := torrents[i]
+		if got.Name != want.name || got.Id != want.id || got.DownloadUrl != want.downloadUrl {
+			t.Errorf("torrent %d: got name %q id %q url %q, want %q %q %q", i,
+				got.Name, got.Id, got.DownloadUrl, want.name, want.id, want.downloadUrl)
+		}
+		if got.Size != want.size || got.Seeders != want.seeders || got.Leechers != want.leechers ||
+			got.Snatched != want.snatched || got.Time != want.time.Unix() {
+			t.Errorf("torrent %d: got size %d seeders %d leechers %d snatched %d time %d, want %d %d %d %d %d", i,
+				got.Size, got.Seeders, got.Leechers, got.Snatched, got.Time,
+				want.size, want.seeders, want.leechers, want.snatched, want.time.Unix())
+		}
+		if got.DownloadMultiplier != want.downloadMultiplier {
+			t.Errorf("torrent %d: got download multiplier %f, want %f", i,
+				got.DownloadMultiplier, want.downloadMultiplier)
+		}
+	}
+}
+
+// The "下载" (download link) column must not be taken as leechers column.
+const chineseTorrentsPage = `<html><body><table>
+<tr><td>名称</td><td>下载</td><td>大小</td><td>做种</td><td>下载中</td><td>完成</td><td>发布时间</td></tr>
+<tr>
+  <td><a href="details.php?id=1">种子一</a></td><td><a href="download.php?id=1">下载</a></td>
+  <td>1 GB</td><td>10</td><td>2</td><td>30</td><td>2024-01-02 03:04:05</td>
+</tr>
+</table></body></html>`
+
+func TestParseTorrentsByHeader(t *testing.T) {
+	siteInstance, err := site.CreateSiteInternal("test", &config.SiteConfigStruct{
+		Type:        "rss",
+		Url:         "https://example.com/",
+		TorrentsUrl: "torrentrss.php",
+	}, &config.ConfigStruct{})
+	if err != nil {
+		t.Fatalf("failed to create site: %v", err)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(chineseTorrentsPage))
+	if err != nil {
+		t.Fatalf("failed to parse html: %v", err)
+	}
+	torrents := site.ParseTorrentsBySelectors(doc, siteInstance, &site.TorrentsParserOption{
+		Location: time.UTC,
+		IdRegexp: regexp.MustCompile(`download\.php\?id=(?P<id>\d+)\b`),
+		Defaults: &config.SiteConfigStruct{
+			SelectorTorrentBlock:        `tr:has(a[href*="details.php?id="])`,
+			SelectorTorrent:             `a[href*="details.php?id="]`,
+			SelectorTorrentDownloadLink: `a[href*="download.php?id="]`,
+		},
+	})
+	if len(torrents) != 1 {
+		t.Fatalf("got %d torrents, want 1: %v", len(torrents), torrents)
+	}
+	got := torrents[0]
+	if got.Name != "种子一" || got.Size != 1024*1024*1024 || got.Seeders != 10 || got.Leechers != 2 ||
+		got.Snatched != 30 || got.Time != time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Unix() {
+		t.Errorf("got name %q size %d seeders %d leechers %d snatched %d time %d", got.Name,
+			got.Size, got.Seeders, got.Leechers, got.Snatched, got.Time)
+	}
+}
@@ -0,0 +1,116 @@
+package rss
+
+// rss is a generic site type which torrents list is read from RSS / Atom feeds (including Torznab / Newznab).
+// Useful for sites that do not allow scraping torrents list pages, but provide passkey RSS feeds,
+// or for Torznab indexers like Jackett / Prowlarr.
+// torrentsUrl: feed url. searchUrl: optional search feed url, can use "%s" as keyword placeholder,
+// e.g. Torznab "https://jackett.example.com/api/v2.0/indexers/all/results/torznab/api?apikey=xxx&t=search&q=%s".
+// If searchUrl is not set, torrents of torrentsUrl feed are filtered by keyword locally.
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Noooste/azuretls-client"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+type Site struct {
+	Name        string
+	SiteConfig  *config.SiteConfigStruct
+	Config      *config.ConfigStruct
+	HttpClient  *azuretls.Session
+	HttpHeaders [][]string
+}
+
+func (rsssite *Site) PublishTorrent(contents []byte, metadata url.Values) (id string, err error) {
+	return "", site.ErrUnimplemented
+}
+
+func (rsssite *Site) GetDefaultHttpHeaders() [][]string {
+	return rsssite.HttpHeaders
+}
+
+func (rsssite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (rsssite *Site) PurgeCache() {
+}
+
+func (rsssite *Site) GetName() string {
+	return rsssite.Name
+}
+
+func (rsssite *Site) GetSiteConfig() *config.SiteConfigStruct {
+	return rsssite.SiteConfig
+}
+
+func (rsssite *Site) GetStatus() (*site.Status, error) {
+	return nil, site.ErrUnimplemented
+}
+
+func (rsssite *Site) GetLatestTorrents(full bool) ([]*site.Torrent, error) {
+	return site.GetLatestFeedTorrents(rsssite, rsssite.HttpClient, full)
+}
+
+func (rsssite *Site) GetAllTorrents(sort string, desc bool, pageMarker string, baseUrl string) (
+	torrents []*site.Torrent, nextPageMarker string, err error) {
+	return site.GetAllFeedTorrents(rsssite, rsssite.HttpClient, sort, desc, baseUrl)
+}
+
+func (rsssite *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.Torrent, error) {
+	return site.SearchFeedTorrents(rsssite, rsssite.HttpClient, keyword, baseUrl)
+}
+
+func (rsssite *Site) DownloadTorrent(torrentUrl string) (content []byte, filename string, id string, err error) {
+	if !util.IsUrl(torrentUrl) {
+		id = strings.TrimPrefix(torrentUrl, rsssite.GetName()+".")
+		content, filename, err = rsssite.DownloadTorrentById(id)
+		return
+	}
+	if urlObj, err := url.Parse(torrentUrl); err == nil {
+		id = urlObj.Query().Get("id")
+	}
+	content, filename, err = site.DownloadTorrentByUrl(rsssite, rsssite.HttpClient, torrentUrl, id)
+	return
+}
+
+// Download torrent by id using torrentDownloadUrl (e.g. "download.php?id={id}&passkey=xxx") of site config.
+func (rsssite *Site) DownloadTorrentById(id string) ([]byte, string, error) {
+	if rsssite.SiteConfig.TorrentDownloadUrl == "" {
+		return nil, "", fmt.Errorf("torrentDownloadUrl not configured, can not download torrent by id")
+	}
+	torrentUrl := rsssite.SiteConfig.ParseSiteUrl(
+		strings.ReplaceAll(rsssite.SiteConfig.TorrentDownloadUrl, "{id}", id), false)
+	return site.DownloadTorrentByUrl(rsssite, rsssite.HttpClient, torrentUrl, id)
+}
+
+func NewSite(name string, siteConfig *config.SiteConfigStruct, config *config.ConfigStruct) (site.Site, error) {
+	if strings.TrimPrefix(siteConfig.TorrentsUrl, site.FEED_URL_PREFIX) == "" {
+		return nil, fmt.Errorf("torrentsUrl (feed url) not configured")
+	}
+	httpClient, httpHeaders, err := site.CreateSiteHttpClient(siteConfig, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create site http client: %w", err)
+	}
+	site := &Site{
+		Name:        name,
+		SiteConfig:  siteConfig,
+		Config:      config,
+		HttpClient:  httpClient,
+		HttpHeaders: httpHeaders,
+	}
+	return site, nil
+}
+
+func init() {
+	site.Register(&site.RegInfo{
+		Name:    "rss",
+		Creator: NewSite,
+	})
+}
@@ -50,12 +50,35 @@ type Torrent struct {
 	Tags               []string // labels, e.g. category and other meta infos.
 }
 
+// Torrent details in site, as displayed in the torrent details page.
+type TorrentDetails struct {
+	Torrent
+	Url       string // details page url
+	Uploader  string // empty if anonymous or unknown
+	Text      string // description (简介) in plain text
+	ImdbUrl   string
+	DoubanUrl string
+	Files     []*TorrentDetailsFile // could be empty if site does not provide torrent files list
+}
+
+type TorrentDetailsFile struct {
+	Path string
+	Size int64
+}
+
+// Optional fields are zero values if not supported by site or failed to parse.
 type Status struct {
 	UserName            string
 	UserDownloaded      int64
 	UserUploaded        int64
 	TorrentsSeedingCnt  int64
 	TorrentsLeechingCnt int64
+	UserBonus           float64 // 魔力值 / 积分. optional
+	UserBonusPerHour    float64 // 每小时做种魔力. optional
+	UserClass           string  // 用户等级. optional
+	UserHnR             int64   // H&R (未达标) 数量. optional
+	UserInvites         int64   // optional
+	UserUnreadMessages  int64   // optional
 }
 
 type Site interface {
@@ -79,6 +102,8 @@ type Site interface {
 	// If metadata contains "_dryrun", use dry run mode;
 	PublishTorrent(contents []byte, metadata url.Values) (id string, err error)
 	GetStatus() (*Status, error)
+	// get torrent details by id (e.g. 12345) or sitename.id (e.g. mteam.12345)
+	GetTorrentDetails(id string) (*TorrentDetails, error)
 	PurgeCache()
 }
 
@@ -116,6 +141,44 @@ func PrintDummyStatus(f io.Writer, name string, info string) {
 	fmt.Printf(constants.STATUS_FMT, "Site", name, "-", "-", info)
 }
 
+func (details *TorrentDetails) Print(output io.Writer) {
+	now := util.Now()
+	freeStr := "✕"
+	if details.DownloadMultiplier == 0 {
+		freeStr = "✓"
+	}
+	if details.DiscountEndTime > 0 {
+		freeStr += fmt.Sprintf(" (%s)", util.FormatDuration(details.DiscountEndTime-now))
+	}
+	fmt.Fprintf(output, "Torrent name: %s\n", details.Name)
+	fmt.Fprintf(output, "- Id: %s\n", details.Id)
+	fmt.Fprintf(output, "- Url: %s\n", details.Url)
+	fmt.Fprintf(output, "- Description: %s\n", details.Description)
+	fmt.Fprintf(output, "- InfoHash: %s\n", details.InfoHash)
+	fmt.Fprintf(output, "- Size: %s (%d)\n", util.BytesSize(float64(details.Size)), details.Size)
+	fmt.Fprintf(output, "- Time: %s\n", util.FormatTime(details.Time))
+	fmt.Fprintf(output, "- Uploader: %s\n", details.Uploader)
+	fmt.Fprintf(output, "- Free: %s\n", freeStr)
+	fmt.Fprintf(output, "- Download / Upload multiplier: %.1f / %.1f\n",
+		details.DownloadMultiplier, details.UploadMultiplier)
+	fmt.Fprintf(output, "- Seeders / Leechers / Snatched: %d / %d / %d\n",
+		details.Seeders, details.Leechers, details.Snatched)
+	fmt.Fprintf(output, "- HnR / Paid / Neutral: %t / %t / %t\n", details.HasHnR, details.Paid, details.Neutral)
+	fmt.Fprintf(output, "- Tags: %s\n", strings.Join(details.Tags, ","))
+	fmt.Fprintf(output, "- IMDb: %s\n", details.ImdbUrl)
+	fmt.Fprintf(output, "- Douban: %s\n", details.DoubanUrl)
+	if details.Text != "" {
+		fmt.Fprintf(output, "- Text:\n%s\n", strings.TrimSpace(details.Text))
+	}
+	if len(details.Files) > 0 {
+		fmt.Fprintf(output, "- Files (%d):\n", len(details.Files))
+		fmt.Fprintf(output, "%-5s  %-10s  %s\n", "No.", "Size", "Path")
+		for i, file := range details.Files {
+			fmt.Fprintf(output, "%-5d  %-10s  %s\n", i+1, util.BytesSize(float64(file.Size)), file.Path)
+		}
+	}
+}
+
 // Get real (number) id, removing sitename prefix
 func (torrent *Torrent) ID() string {
 	sitename, id, found := strings.Cut(torrent.Id, ".")
@@ -146,6 +209,31 @@ func (torrent *Torrent) MatchFilter(filter string) bool {
 	return false
 }
 
+// Return a brief text of optional status fields, e.g. "Class: Elite User; Bonus: 12345.6".
+func (status *Status) ExtraInfo() string {
+	infos := []string{}
+	if status.UserClass != "" {
+		infos = append(infos, "Class: "+status.UserClass)
+	}
+	if status.UserBonus != 0 {
+		bonus := fmt.Sprintf("Bonus: %.1f", status.UserBonus)
+		if status.UserBonusPerHour != 0 {
+			bonus += fmt.Sprintf(" (+%.1f/h)", status.UserBonusPerHour)
+		}
+		infos = append(infos, bonus)
+	}
+	if status.UserHnR != 0 {
+		infos = append(infos, fmt.Sprintf("H&R: %d", status.UserHnR))
+	}
+	if status.UserInvites != 0 {
+		infos = append(infos, fmt.Sprintf("Invites: %d", status.UserInvites))
+	}
+	if status.UserUnreadMessages != 0 {
+		infos = append(infos, fmt.Sprintf("Unread: %d", status.UserUnreadMessages))
+	}
+	return strings.Join(infos, "; ")
+}
+
 // Check if (seems) as a valid site status
 func (status *Status) IsOk() bool {
 	return status.UserName != "" || status.UserDownloaded > 0 || status.UserUploaded > 0
@@ -184,7 +272,23 @@ func CreateSiteInternal(name string,
 	if regInfo == nil {
 		return nil, fmt.Errorf("unsupported site type %s", name)
 	}
-	return regInfo.Creator(name, siteConfig, config)
+	siteInstance, err := regInfo.Creator(name, siteConfig, config)
+	if err != nil {
+		return nil, err
+	}
+	// use saved credential of previous login, unless a newer cookie is configured
+	if loginer, ok := siteInstance.(Loginer); ok && siteConfig.Username != "" {
+		if credential := loadLoginCredential(name); credential != nil && preferLoginCredential(siteConfig, credential) {
+			log.Debugf("Use site %s saved login credential (login time: %s)", name, util.FormatTime(credential.Time))
+			loginer.ApplyLoginCredential(credential)
+		}
+	}
+	return wrapFeedSite(siteInstance, siteConfig, config)
+}
+
+// Return registered site type (schema or site template) info of name (or alias), or nil if not found.
+func GetRegInfo(name string) *RegInfo {
+	return registryMap[name]
 }
 
 func GetConfigSiteReginfo(name string) *RegInfo {
@@ -217,7 +321,7 @@ func CreateSite(name string) (Site, error) {
 }
 
 func PrintTorrents(output io.Writer, torrents []*Torrent, filter string, now int64,
-	noHeader bool, dense bool, scores map[string]float64) {
+	noHeader bool, dense bool, scores map[string]float64, rules map[string]string) {
 	width, _, _ := term.GetSize(int(os.Stdout.Fd()))
 	if width < config.SITE_TORRENTS_WIDTH {
 		width = config.SITE_TORRENTS_WIDTH
@@ -232,11 +336,11 @@ func PrintTorrents(output io.Writer, torrents []*Torrent, filter string, now int
 				widthName, "Name", "Size", "Free", "Time", "↑S", "↓L", "✓C", "ID", "P")
 		}
 	} else {
-		widthExcludingName = 96 // 6+11+19+4+4+4+23+5+2+9*2
+		widthExcludingName = 108 // 6+11+19+4+4+4+23+5+10+2+10*2
 		widthName = width - widthExcludingName
 		if !noHeader {
-			fmt.Fprintf(output, "%-*s  %6s  %-11s  %-19s  %4s  %4s  %4s  %-23s  %5s  %2s\n",
-				widthName, "Name", "Size", "Free", "Time", "↑S", "↓L", "✓C", "ID", "Score", "P")
+			fmt.Fprintf(output, "%-*s  %6s  %-11s  %-19s  %4s  %4s  %4s  %-23s  %5s  %-10s  %2s\n",
+				widthName, "Name", "Size", "Free", "Time", "↑S", "↓L", "✓C", "ID", "Score", "Rule", "P")
 		}
 	}
 	for _, torrent := range torrents {
@@ -297,7 +401,7 @@ func PrintTorrents(output io.Writer, torrents []*Torrent, filter string, now int
 				process,
 			)
 		} else {
-			fmt.Fprintf(output, "  %6s  %-11s  %-19s  %4s  %4s  %4s  %-23s  %5.0f  %2s\n",
+			fmt.Fprintf(output, "  %6s  %-11s  %-19s  %4s  %4s  %4s  %-23s  %5.0f  ",
 				util.BytesSizeAround(float64(torrent.Size)),
 				freeStr,
 				util.FormatTime(torrent.Time),
@@ -306,8 +410,9 @@ func PrintTorrents(output io.Writer, torrents []*Torrent, filter string, now int
 				fmt.Sprint(torrent.Snatched),
 				torrent.Id,
 				scores[torrent.Id],
-				process,
 			)
+			util.PrintStringInWidth(output, rules[torrent.Id], 10, true)
+			fmt.Fprintf(output, "  %2s\n", process)
 		}
 		if dense {
 			for {
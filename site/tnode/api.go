@@ -1,11 +1,89 @@
 package tnode
 
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
 // https://zhuque.in/api/user/getMainInfo
 type apiMainInfoResponse struct {
 	Status int64 `json:"status"`
 	Data   struct {
-		Username string `json:"username"`
-		Download int64  `json:"download"`
-		Upload   int64  `json:"upload"`
+		Username  string          `json:"username"`
+		Download  int64           `json:"download"`
+		Upload    int64           `json:"upload"`
+		Class     json.RawMessage `json:"class"` // class name, or object with "name" field
+		Bonus     flexNumber      `json:"bonus"`
+		BonusHour flexNumber      `json:"bonusHour"`
+		Invite    flexNumber      `json:"invite"`
+		Seeding   flexNumber      `json:"seeding"`
+		Leeching  flexNumber      `json:"leeching"`
+		Unread    flexNumber      `json:"unreadInbox"`
+		UnreadSys flexNumber      `json:"unreadSystem"`
+	} `json:"data"`
+}
+
+// Number which may be encoded as number or string in json. Invalid value is parsed as 0.
+type flexNumber float64
+
+func (n *flexNumber) UnmarshalJSON(data []byte) error {
+	v, _ := strconv.ParseFloat(strings.Trim(string(data), `"`), 64)
+	*n = flexNumber(v)
+	return nil
+}
+
+// Return user class name.
+func (r *apiMainInfoResponse) className() string {
+	var name string
+	if json.Unmarshal(r.Data.Class, &name) == nil {
+		return name
+	}
+	var class struct {
+		Name string `json:"name"`
+	}
+	if json.Unmarshal(r.Data.Class, &class) == nil {
+		return class.Name
+	}
+	return ""
+}
+
+// https://zhuque.in/api/torrent/advancedSearch request (POST json)
+type apiSearchRequest struct {
+	Page     int64    `json:"page"`
+	Size     int64    `json:"size"`
+	Type     string   `json:"type"` // "title"
+	Keyword  string   `json:"keyword"`
+	Tags     []string `json:"tags"`
+	Category []string `json:"category"`
+	Sorter   string   `json:"sorter"`
+	Order    string   `json:"order"` // "desc" | "asc"
+}
+
+type apiSearchResponse struct {
+	Status int64  `json:"status"`
+	Msg    string `json:"msg"`
+	Data   struct {
+		Total    int64         `json:"total"`
+		Torrents []*apiTorrent `json:"torrents"`
 	} `json:"data"`
 }
+
+type apiTorrent struct {
+	Id         int64           `json:"id"`
+	Title      string          `json:"title"`
+	Subtitle   string          `json:"subtitle"`
+	Size       int64           `json:"size"`
+	Seeding    int64           `json:"seeding"`
+	Leeching   int64           `json:"leeching"`
+	Complete   int64           `json:"complete"`
+	UploadTime json.RawMessage `json:"upload_time"` // unix timestamp or time string
+	Promotion  *apiPromotion   `json:"promotion"`   // null if torrent has no promotion
+}
+
+// Promotion (discount) of torrent. A missing rate means the normal rate (1).
+type apiPromotion struct {
+	Download *flexNumber     `json:"download"` // download rate. 0 = free
+	Upload   *flexNumber     `json:"upload"`   // upload rate. e.g. 2 = 2x upload
+	Expire   json.RawMessage `json:"expire"`   // unix timestamp or time string. null / 0 = never expire
+}
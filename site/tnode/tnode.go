@@ -3,11 +3,15 @@ package tnode
 // TNode
 // 朱雀( https://zhuque.in/index )自研架构
 // 种子下载链接：https://zhuque.in/api/torrent/download/{id}/{torrent_key} (如果cookie有效，url最后一段可省略)
+// 种子列表、搜索使用 api/torrent/advancedSearch JSON API (需要网页里的 x-csrf-token)。
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -15,10 +19,24 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
 	"github.com/sagan/ptool/site"
 	"github.com/sagan/ptool/util"
 )
 
+const (
+	API_SEARCH    = "api/torrent/advancedSearch"
+	API_PAGE_SIZE = 100
+)
+
+var sortFields = map[string]string{
+	"time":     "id",
+	"size":     "size",
+	"seeders":  "seeding",
+	"leechers": "leeching",
+	"snatched": "complete",
+}
+
 type Site struct {
 	Name        string
 	Location    *time.Location
@@ -55,6 +73,10 @@ func (tnsite *Site) syncCsrfToken() error {
 	return nil
 }
 
+func (tnsite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
 func (tnsite *Site) PurgeCache() {
 }
 
@@ -83,23 +105,243 @@ func (tnsite *Site) GetStatus() (*site.Status, error) {
 		return nil, fmt.Errorf("failed to get use status: %w", err)
 	}
 	return &site.Status{
-		UserName:       data.Data.Username,
-		UserDownloaded: data.Data.Download,
-		UserUploaded:   data.Data.Upload,
+		UserName:            data.Data.Username,
+		UserDownloaded:      data.Data.Download,
+		UserUploaded:        data.Data.Upload,
+		TorrentsSeedingCnt:  int64(data.Data.Seeding),
+		TorrentsLeechingCnt: int64(data.Data.Leeching),
+		UserBonus:           float64(data.Data.Bonus),
+		UserBonusPerHour:    float64(data.Data.BonusHour),
+		UserClass:           data.className(),
+		UserInvites:         int64(data.Data.Invite),
+		UserUnreadMessages:  int64(data.Data.Unread + data.Data.UnreadSys),
 	}, nil
 }
 
 func (tnsite *Site) GetAllTorrents(sort string, desc bool, pageMarker string, baseUrl string) (
 	torrents []*site.Torrent, nextPageMarker string, err error) {
-	return nil, "", site.ErrUnimplemented
+	if sort != "" && sort != constants.NONE && sortFields[sort] == "" {
+		err = fmt.Errorf("unsupported sort field: %s", sort)
+		return
+	}
+	if pageMarker == constants.NONE {
+		pageMarker = ""
+	}
+	// page starts from 1
+	page := int64(1)
+	if pageMarker != "" {
+		page = util.ParseInt(pageMarker)
+		if page < 1 {
+			err = fmt.Errorf("invalid page marker: %s", pageMarker)
+			return
+		}
+	}
+	if baseUrl == "" {
+		baseUrl = site.GetTorrentsPageUrl(tnsite.SiteConfig)
+	} else if baseUrl == constants.NONE {
+		baseUrl = ""
+	}
+	req := newSearchRequest()
+	if err = applySearchUrl(req, baseUrl); err != nil {
+		return
+	}
+	req.Page = page
+	if sort != "" && sort != constants.NONE {
+		req.Sorter = sortFields[sort]
+		if !desc {
+			req.Order = "asc"
+		}
+	}
+	torrents, total, err := tnsite.search(req)
+	if err != nil {
+		return
+	}
+	if page*req.Size < total {
+		nextPageMarker = fmt.Sprint(page + 1)
+	}
+	return
 }
 
 func (tnsite *Site) GetLatestTorrents(full bool) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	torrents, err := tnsite.getTorrents(site.GetTorrentsPageUrl(tnsite.SiteConfig))
+	if err != nil {
+		return nil, err
+	}
+	if full {
+		for _, extraUrl := range tnsite.SiteConfig.TorrentsExtraUrls {
+			extraTorrents, err := tnsite.getTorrents(extraUrl)
+			if err != nil {
+				log.Errorf("Failed to get site %s extra torrents from %s: %v", tnsite.Name, extraUrl, err)
+				continue
+			}
+			torrents = append(torrents, extraTorrents...)
+		}
+	}
+	return torrents, nil
 }
 
+// baseUrl: optional search url, can use "%s" as keyword placeholder. See applySearchUrl.
 func (tnsite *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	if baseUrl == "" {
+		baseUrl = tnsite.SiteConfig.SearchUrl
+	}
+	req := newSearchRequest()
+	if strings.Contains(baseUrl, "%s") {
+		baseUrl = strings.Replace(baseUrl, "%s", url.QueryEscape(keyword), 1)
+	} else {
+		req.Keyword = keyword
+	}
+	if err := applySearchUrl(req, baseUrl); err != nil {
+		return nil, err
+	}
+	torrents, _, err := tnsite.search(req)
+	return torrents, err
+}
+
+// Get first page of torrents of the torrents url.
+func (tnsite *Site) getTorrents(torrentsUrl string) ([]*site.Torrent, error) {
+	req := newSearchRequest()
+	if err := applySearchUrl(req, torrentsUrl); err != nil {
+		return nil, err
+	}
+	torrents, _, err := tnsite.search(req)
+	return torrents, err
+}
+
+// Apply search parameters in query string of a torrents / search url to advancedSearch API request.
+// The path part of url is ignored. Supported parameters:
+// keyword, type, category, tags (comma separated or repeated), sorter, order, size.
+// e.g. "api/torrent/advancedSearch?category=401,402&tags=free&sorter=size&order=desc".
+func applySearchUrl(req *apiSearchRequest, torrentsUrl string) error {
+	if torrentsUrl == "" {
+		return nil
+	}
+	urlObj, err := url.Parse(torrentsUrl)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	list := func(values []string) []string {
+		items := []string{}
+		for _, value := range values {
+			items = append(items, util.SplitCsv(value)...)
+		}
+		return items
+	}
+	for key, values := range urlObj.Query() {
+		switch key {
+		case "keyword":
+			req.Keyword = values[0]
+		case "type":
+			req.Type = values[0]
+		case "category":
+			req.Category = list(values)
+		case "tags":
+			req.Tags = list(values)
+		case "sorter":
+			req.Sorter = values[0]
+		case "order":
+			req.Order = values[0]
+		case "size":
+			if size := util.ParseInt(values[0]); size > 0 {
+				req.Size = size
+			}
+		}
+	}
+	return nil
+}
+
+func newSearchRequest() *apiSearchRequest {
+	return &apiSearchRequest{
+		Page:     1,
+		Size:     API_PAGE_SIZE,
+		Type:     "title",
+		Tags:     []string{},
+		Category: []string{},
+		Sorter:   "id",
+		Order:    "desc",
+	}
+}
+
+// Search torrents using advancedSearch API. Return torrents and total torrents count.
+func (tnsite *Site) search(req *apiSearchRequest) (torrents []*site.Torrent, total int64, err error) {
+	if err = tnsite.syncCsrfToken(); err != nil {
+		return nil, 0, fmt.Errorf("failed to get csrf token: %w", err)
+	}
+	headers := append(slices.Clone(tnsite.GetDefaultHttpHeaders()),
+		[]string{"x-csrf-token", tnsite.csrfToken}, []string{"Content-Type", "application/json"})
+	res, err := tnsite.HttpClient.Do(&azuretls.Request{
+		Method:         http.MethodPost,
+		Url:            tnsite.SiteConfig.ParseSiteUrl(API_SEARCH, false),
+		Body:           req,
+		NoCookie:       true, // disable azuretls internal cookie jar
+		OrderedHeaders: util.GetHttpReqHeaders(headers, tnsite.SiteConfig.Cookie, site.GetUa(tnsite)),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search torrents: %w", err)
+	}
+	if res.StatusCode != 200 {
+		return nil, 0, fmt.Errorf("failed to search torrents: status=%d", res.StatusCode)
+	}
+	var data apiSearchResponse
+	if err = json.Unmarshal(res.Body, &data); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse search response: %w", err)
+	}
+	if data.Status != 200 {
+		return nil, 0, fmt.Errorf("failed to search torrents: status=%d, msg=%s", data.Status, data.Msg)
+	}
+	torrents = []*site.Torrent{}
+	for _, torrent := range data.Data.Torrents {
+		id := fmt.Sprint(torrent.Id)
+		downloadMultiplier, uploadMultiplier, discountEndTime := tnsite.parsePromotion(torrent.Promotion)
+		torrents = append(torrents, &site.Torrent{
+			Name:               torrent.Title,
+			Description:        torrent.Subtitle,
+			Id:                 fmt.Sprintf("%s.%s", tnsite.Name, id),
+			DownloadUrl:        tnsite.SiteConfig.ParseSiteUrl("api/torrent/download/"+id, false),
+			DownloadMultiplier: downloadMultiplier,
+			UploadMultiplier:   uploadMultiplier,
+			DiscountEndTime:    discountEndTime,
+			Time:               tnsite.parseTime(torrent.UploadTime),
+			Size:               torrent.Size,
+			IsSizeAccurate:     true,
+			Seeders:            torrent.Seeding,
+			Leechers:           torrent.Leeching,
+			Snatched:           torrent.Complete,
+			HasHnR:             tnsite.SiteConfig.GlobalHnR,
+		})
+	}
+	return torrents, data.Data.Total, nil
+}
+
+// Return download & upload multiplier and discount end time (-1 if none) of torrent promotion.
+func (tnsite *Site) parsePromotion(promotion *apiPromotion) (
+	downloadMultiplier float64, uploadMultiplier float64, discountEndTime int64) {
+	downloadMultiplier, uploadMultiplier, discountEndTime = 1, 1, -1
+	if promotion == nil {
+		return
+	}
+	if promotion.Download != nil {
+		downloadMultiplier = float64(*promotion.Download)
+	}
+	if promotion.Upload != nil {
+		uploadMultiplier = float64(*promotion.Upload)
+	}
+	if len(promotion.Expire) > 0 && string(promotion.Expire) != "null" {
+		if t := tnsite.parseTime(promotion.Expire); t > 0 {
+			discountEndTime = t
+		}
+	}
+	return
+}
+
+// Parse time value in API response, which could be unix timestamp or time string.
+func (tnsite *Site) parseTime(value json.RawMessage) int64 {
+	str := strings.Trim(string(value), `"`)
+	if t, err := time.Parse(time.RFC3339Nano, str); err == nil {
+		return t.Unix()
+	}
+	t, _ := util.ParseTime(str, tnsite.Location)
+	return t
 }
 
 func (tnsite *Site) DownloadTorrent(torrentUrl string) (content []byte, filename string, id string, err error) {
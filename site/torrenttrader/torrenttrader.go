@@ -8,6 +8,7 @@ package torrenttrader
 import (
 	"fmt"
 	"net/url"
+	"regexp"
 	"strings"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
 	"github.com/sagan/ptool/site"
 	"github.com/sagan/ptool/util"
 )
@@ -37,12 +39,38 @@ const (
 	SELECTOR_USERNAME        = `.myBlock:has(a[href$="account.php"]) .myBlock-caption`
 	SELECTOR_USER_UPLOADED   = `.myBlock:has(a[href$="account.php"]) tr:has(td:contains("Uploaded")) td:last-child`
 	SELECTOR_USER_DOWNLOADED = `.myBlock:has(a[href$="account.php"]) tr:has(td:contains("Downloaded")) td:last-child`
+	DEFAULT_TORRENTS_URL     = "torrents.php"
+	DEFAULT_SEARCH_URL       = "torrents-search.php?"
+)
+
+var (
+	torrentIdRegexp = regexp.MustCompile(`\bid=(?P<id>\d+)\b`)
+	// Default selectors of TorrentTrader v3 torrents table. Other columns are located by table header texts.
+	defaultSelectors = &config.SiteConfigStruct{
+		SelectorTorrentsListHeader:  `table.ttable_headinner tr:first-child`,
+		SelectorTorrentBlock:        `table.ttable_headinner tr:has(a[href*="download.php?id="])`,
+		SelectorTorrent:             `a[href*="torrents-details.php?id="]`,
+		SelectorTorrentDownloadLink: `a[href*="download.php?id="]`,
+		SelectorTorrentFree:         `img[src*="free"],img[alt*="Free"],img[title*="Free"]`,
+	}
+	sortFields = map[string]string{
+		"name":     "name",
+		"time":     "id",
+		"size":     "size",
+		"seeders":  "seeders",
+		"leechers": "leechers",
+		"snatched": "completed",
+	}
 )
 
 func (usite *Site) GetDefaultHttpHeaders() [][]string {
 	return usite.HttpHeaders
 }
 
+func (usite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
 func (usite *Site) PurgeCache() {
 }
 
@@ -86,15 +114,111 @@ func (usite *Site) GetStatus() (*site.Status, error) {
 
 func (usite *Site) GetAllTorrents(sort string, desc bool, pageMarker string, baseUrl string) (
 	torrents []*site.Torrent, nextPageMarker string, err error) {
-	return nil, "", site.ErrUnimplemented
+	if sort != "" && sort != constants.NONE && sortFields[sort] == "" {
+		err = fmt.Errorf("unsupported sort field: %s", sort)
+		return
+	}
+	if pageMarker == constants.NONE {
+		pageMarker = ""
+	}
+	if baseUrl == "" || baseUrl == constants.NONE {
+		baseUrl = site.GetTorrentsPageUrl(usite.SiteConfig)
+	}
+	if baseUrl == "" || strings.HasPrefix(baseUrl, "?") {
+		baseUrl = DEFAULT_SEARCH_URL + strings.TrimPrefix(baseUrl, "?")
+	}
+	// page starts from 0
+	page := util.ParseInt(pageMarker)
+	query := url.Values{}
+	query.Set("page", fmt.Sprint(page))
+	if sort != "" && sort != constants.NONE {
+		query.Set("sort", sortFields[sort])
+		if desc {
+			query.Set("order", "desc")
+		} else {
+			query.Set("order", "asc")
+		}
+	}
+	torrents, lastPage, err := usite.getTorrents(baseUrl, query)
+	if err != nil {
+		return
+	}
+	if page < lastPage {
+		nextPageMarker = fmt.Sprint(page + 1)
+	}
+	return
 }
 
 func (usite *Site) GetLatestTorrents(full bool) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	torrentsUrl := usite.SiteConfig.TorrentsUrl
+	if torrentsUrl == "" {
+		torrentsUrl = DEFAULT_TORRENTS_URL
+	}
+	torrents, _, err := usite.getTorrents(torrentsUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if full {
+		for _, extraUrl := range usite.SiteConfig.TorrentsExtraUrls {
+			extraTorrents, _, err := usite.getTorrents(extraUrl, nil)
+			if err != nil {
+				log.Errorf("Failed to get site %s extra torrents from %s: %v", usite.Name, extraUrl, err)
+				continue
+			}
+			torrents = append(torrents, extraTorrents...)
+		}
+	}
+	return torrents, nil
 }
 
+// baseUrl: optional search url, can use "%s" as keyword placeholder.
 func (usite *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	if baseUrl == "" {
+		baseUrl = usite.SiteConfig.SearchUrl
+	}
+	if baseUrl == "" {
+		baseUrl = DEFAULT_SEARCH_URL
+	}
+	query := url.Values{}
+	if strings.Contains(baseUrl, "%s") {
+		baseUrl = strings.Replace(baseUrl, "%s", url.QueryEscape(keyword), 1)
+	} else {
+		searchQueryVariable := "search"
+		if usite.SiteConfig.SearchQueryVariable != "" {
+			searchQueryVariable = usite.SiteConfig.SearchQueryVariable
+		}
+		query.Set(searchQueryVariable, keyword)
+	}
+	torrents, _, err := usite.getTorrents(baseUrl, query)
+	return torrents, err
+}
+
+// Fetch and parse a torrents list page. query will be merged into the url. Return torrents and last page number.
+func (usite *Site) getTorrents(pageUrl string, query url.Values) (
+	torrents []*site.Torrent, lastPage int64, err error) {
+	urlObj, err := url.Parse(usite.SiteConfig.ParseSiteUrl(pageUrl, false))
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid url: %w", err)
+	}
+	urlQuery := urlObj.Query()
+	for key, values := range query {
+		urlQuery[key] = values
+	}
+	urlObj.RawQuery = urlQuery.Encode()
+	doc, res, err := util.GetUrlDocWithAzuretls(urlObj.String(), usite.HttpClient,
+		usite.GetSiteConfig().Cookie, site.GetUa(usite), usite.GetDefaultHttpHeaders())
+	if !usite.SiteConfig.AcceptAnyHttpStatus && err != nil || doc == nil {
+		return nil, 0, fmt.Errorf("failed to fetch torrents page dom: %w", err)
+	}
+	if strings.Contains(res.Request.Url, "/account-login.php") {
+		return nil, 0, fmt.Errorf("not logined (cookie may has expired)")
+	}
+	torrents = site.ParseTorrentsBySelectors(doc, usite, &site.TorrentsParserOption{
+		Location: usite.Location,
+		IdRegexp: torrentIdRegexp,
+		Defaults: defaultSelectors,
+	})
+	return torrents, site.ParseLastPage(doc), nil
 }
 
 func (usite *Site) DownloadTorrent(torrentUrl string) (content []byte, filename string, id string, err error) {
@@ -0,0 +1,233 @@
+package tpl
+
+// External site templates, loaded from yaml files in "sites.d" dir next to config file (ptool.toml).
+// Each file is a map of site name => site template, which uses the same fields as config.SiteConfigStruct. E.g. :
+//
+//	mysite:
+//	  type: nexusphp
+//	  url: https://mysite.example.com/
+//	  aliases: [ms]
+//	  comment: My Site
+//
+// Files are loaded in lexical order. A template of an existing site name (or alias) is merged into it,
+// overriding the existing fields. Otherwise it's added as a new site, and "type" & "url" are required.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/cascadia"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+const ORIGIN_INTERNAL = "internal"
+
+var (
+	// site (canonical) name => origin: "internal", and / or the sites.d files the template is loaded from.
+	// e.g. "internal+sites.d/fix.yaml"
+	ORIGINS        = map[string]string{}
+	siteNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,14}$`)
+	loadOnce       sync.Once
+)
+
+type ExternalSite struct {
+	Name   string
+	File   string // e.g. "sites.d/mysite.yaml"
+	Config *config.SiteConfigStruct
+}
+
+// Return yaml files in sites.d dir, in lexical order.
+func GetExternalSiteFiles() ([]string, error) {
+	dir := filepath.Join(config.ConfigDir, config.SITES_DIR)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	files := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && util.HasAnySuffix(strings.ToLower(entry.Name()), ".yaml", ".yml") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// Parse site templates of a yaml file. Unknown fields are treated as errors.
+func ParseExternalSiteFile(file string) ([]*ExternalSite, error) {
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	displayFile := file
+	if rel, err := filepath.Rel(config.ConfigDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+		displayFile = filepath.ToSlash(rel)
+	}
+	sites := map[string]*config.SiteConfigStruct{}
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&sites); err != nil && err != io.EOF {
+		return nil, err
+	}
+	externalSites := []*ExternalSite{}
+	for name, siteConfig := range sites {
+		if siteConfig == nil {
+			siteConfig = &config.SiteConfigStruct{}
+		}
+		externalSites = append(externalSites, &ExternalSite{Name: name, File: displayFile, Config: siteConfig})
+	}
+	slices.SortFunc(externalSites, func(a, b *ExternalSite) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return externalSites, nil
+}
+
+// Load external site templates from sites.d dir and merge them into SITES. Invalid templates are skipped.
+// It's called once on program start, after config dir is determined.
+func LoadExternalSites() {
+	loadOnce.Do(func() {
+		files, err := GetExternalSiteFiles()
+		if err != nil {
+			log.Errorf("Failed to read external site templates dir: %v", err)
+			return
+		}
+		for _, file := range files {
+			externalSites, err := ParseExternalSiteFile(file)
+			if err != nil {
+				log.Errorf("Failed to parse site templates file %s: %v", file, err)
+				continue
+			}
+			for _, externalSite := range externalSites {
+				if errs := ValidateSite(externalSite.Name, externalSite.Config); len(errs) > 0 {
+					log.Errorf("Invalid site template %s in %s: %v", externalSite.Name, externalSite.File,
+						errors.Join(errs...))
+					continue
+				}
+				addSite(externalSite)
+			}
+		}
+	})
+}
+
+// Add (or merge) an external site template into SITES and register it as a site type.
+func addSite(externalSite *ExternalSite) {
+	name := externalSite.Name
+	siteConfig := externalSite.Config
+	if existing := SITES[name]; existing != nil {
+		name = getCanonicalName(name)
+		merged := *existing
+		util.Assign(&merged, siteConfig, nil)
+		siteConfig = &merged
+		ORIGINS[name] += "+" + externalSite.File
+		log.Debugf("Override site %s template by %s", name, externalSite.File)
+	} else {
+		SITENAMES = append(SITENAMES, name)
+		slices.Sort(SITENAMES)
+		ORIGINS[name] = externalSite.File
+		log.Debugf("Add site %s template from %s", name, externalSite.File)
+	}
+	SITES[name] = siteConfig
+	for _, alias := range siteConfig.Aliases {
+		SITES[alias] = siteConfig
+	}
+	site.Register(&site.RegInfo{
+		Name:    name,
+		Aliases: siteConfig.Aliases,
+		Creator: create,
+	})
+}
+
+// Return origin of a site name or alias.
+func GetOrigin(name string) string {
+	return ORIGINS[getCanonicalName(name)]
+}
+
+// Return canonical name of an existing site name or alias.
+func getCanonicalName(name string) string {
+	if slices.Contains(SITENAMES, name) {
+		return name
+	}
+	for _, sitename := range SITENAMES {
+		if slices.Contains(SITES[sitename].Aliases, name) {
+			return sitename
+		}
+	}
+	return name
+}
+
+// Validate an external site template: name, required fields, urls, selectors and regexps.
+// The template of an existing site name (or alias) is validated after being merged into the existing one.
+func ValidateSite(name string, siteConfig *config.SiteConfigStruct) (errs []error) {
+	existing := SITES[name]
+	effective := *siteConfig
+	if existing != nil {
+		effective = *existing
+		util.Assign(&effective, siteConfig, nil)
+	}
+	for _, str := range append([]string{name}, siteConfig.Aliases...) {
+		if !siteNameRegexp.MatchString(str) {
+			errs = append(errs, fmt.Errorf("invalid name or alias %q: must be at most 15 lowercase letters, "+
+				"digits, '-' or '_'", str))
+		} else if other := SITES[str]; other != nil && other != existing {
+			errs = append(errs, fmt.Errorf("name or alias %q conflicts with another site", str))
+		} else if other == nil && site.GetRegInfo(str) != nil {
+			errs = append(errs, fmt.Errorf("name or alias %q conflicts with site type", str))
+		}
+	}
+	if effective.Type == "" {
+		errs = append(errs, fmt.Errorf("type is required"))
+	} else if site.GetRegInfo(effective.Type) == nil {
+		errs = append(errs, fmt.Errorf("unsupported type %q", effective.Type))
+	} else if SITES[effective.Type] != nil {
+		errs = append(errs, fmt.Errorf("type %q is a site template, must be a site schema type (e.g. nexusphp)",
+			effective.Type))
+	}
+	if effective.Url == "" {
+		if effective.Type != "rss" {
+			errs = append(errs, fmt.Errorf("url is required"))
+		}
+	} else if urlObj, err := url.Parse(effective.Url); err != nil || urlObj.Host == "" ||
+		(urlObj.Scheme != "http" && urlObj.Scheme != "https") {
+		errs = append(errs, fmt.Errorf("invalid url %q", effective.Url))
+	}
+	for _, domain := range effective.Domains {
+		if domain == "" || strings.ContainsAny(domain, ":/ ") {
+			errs = append(errs, fmt.Errorf("invalid domain %q", domain))
+		}
+	}
+	if effective.TorrentUrlIdRegexp != "" {
+		if _, err := regexp.Compile(effective.TorrentUrlIdRegexp); err != nil {
+			errs = append(errs, fmt.Errorf("invalid torrentUrlIdRegexp: %w", err))
+		}
+	}
+	value := reflect.ValueOf(effective)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !strings.HasPrefix(field.Name, "Selector") || field.Type.Kind() != reflect.String {
+			continue
+		}
+		if selector := value.Field(i).String(); selector != "" {
+			if _, err := cascadia.Compile(selector); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s %q: %w", field.Tag.Get("yaml"), selector, err))
+			}
+		}
+	}
+	return errs
+}
@@ -885,6 +885,7 @@ func init() {
 		for _, alias := range config.Aliases {
 			SITES[alias] = config
 		}
+		ORIGINS[name] = ORIGIN_INTERNAL
 		site.Register(&site.RegInfo{
 			Name:    name,
 			Aliases: config.Aliases,
@@ -0,0 +1,71 @@
+package unit3d
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// https://github.com/HDInnovations/UNIT3D-Community-Edition/blob/master/app/Http/Resources/TorrentResource.php
+// /api/torrents/filter response
+type apiTorrentsResponse struct {
+	Data  []*apiTorrent `json:"data"`
+	Links struct {
+		Next string `json:"next"`
+	} `json:"links"`
+	Meta struct {
+		CurrentPage int64 `json:"current_page"`
+		LastPage    int64 `json:"last_page"`
+	} `json:"meta"`
+}
+
+type apiTorrent struct {
+	Type       string      `json:"type"`
+	Id         json.Number `json:"id"`
+	Attributes struct {
+		Name            string     `json:"name"`
+		Category        string     `json:"category"`
+		Type            string     `json:"type"`
+		Resolution      string     `json:"resolution"`
+		InfoHash        string     `json:"info_hash"`
+		Size            int64      `json:"size"`
+		Freeleech       percentage `json:"freeleech"`
+		DoubleUpload    flexBool   `json:"double_upload"`
+		Featured        flexBool   `json:"featured"`
+		Internal        flexBool   `json:"internal"`
+		PersonalRelease flexBool   `json:"personal_release"`
+		Seeders         int64      `json:"seeders"`
+		Leechers        int64      `json:"leechers"`
+		TimesCompleted  int64      `json:"times_completed"`
+		CreatedAt       string     `json:"created_at"`
+		DownloadLink    string     `json:"download_link"`
+		DetailsLink     string     `json:"details_link"`
+	} `json:"attributes"`
+}
+
+// Freeleech percentage. Encoded as "100%" in json by most UNIT3D versions, some use plain number.
+type percentage float64
+
+func (p *percentage) UnmarshalJSON(data []byte) error {
+	str := strings.TrimSuffix(strings.Trim(string(data), `"`), "%")
+	if str == "" || str == "null" {
+		*p = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return fmt.Errorf("invalid percentage %s", data)
+	}
+	*p = percentage(v)
+	return nil
+}
+
+// Bool which may be encoded as true / false, 1 / 0 or "1" / "0" in json, depending on UNIT3D version.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	str := strings.Trim(string(data), `"`)
+	*b = flexBool(str != "" && str != "0" && str != "false" && str != "null")
+	return nil
+}
@@ -0,0 +1,75 @@
+package unit3d
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Noooste/azuretls-client"
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/sagan/ptool/site"
+)
+
+var _ site.Loginer = (*Site)(nil)
+
+// UNIT3D (Laravel) login: GET "login" page for csrf "_token" (and other hidden inputs) and session cookies,
+// then POST "login". If 2FA is enabled, site redirects to "two-factor-challenge" which accepts the otp code.
+func (usite *Site) Login(username string, password string, otp string) (*site.LoginCredential, error) {
+	loginUrl := usite.SiteConfig.ParseSiteUrl("login", false)
+	doc, res, err := site.GetLoginPage(usite, usite.HttpClient, loginUrl, "")
+	if err != nil {
+		return nil, err
+	}
+	cookie := site.MergeCookies("", res.Cookies)
+	form := site.GetFormHiddenInputs(findForm(doc, "login"))
+	if form.Get("_token") == "" {
+		return nil, fmt.Errorf("no csrf token found in login page")
+	}
+	form.Set("username", username)
+	form.Set("password", password)
+	form.Set("remember", "on")
+	if res, err = site.PostLoginForm(usite, usite.HttpClient, loginUrl, form, cookie); err != nil {
+		return nil, err
+	}
+	cookie = site.MergeCookies(cookie, res.Cookies)
+	location := res.Header.Get("Location")
+	if strings.Contains(location, "two-factor-challenge") {
+		if otp == "" {
+			return nil, fmt.Errorf("site requires 2FA code, totpSecret must be configured")
+		}
+		challengeUrl := usite.SiteConfig.ParseSiteUrl("two-factor-challenge", false)
+		if doc, res, err = site.GetLoginPage(usite, usite.HttpClient, challengeUrl, cookie); err != nil {
+			return nil, err
+		}
+		cookie = site.MergeCookies(cookie, res.Cookies)
+		form = site.GetFormHiddenInputs(findForm(doc, "two-factor-challenge"))
+		form.Set("code", otp)
+		if res, err = site.PostLoginForm(usite, usite.HttpClient, challengeUrl, form, cookie); err != nil {
+			return nil, err
+		}
+		cookie = site.MergeCookies(cookie, res.Cookies)
+		location = res.Header.Get("Location")
+	}
+	if res.StatusCode < 300 || res.StatusCode >= 400 ||
+		strings.Contains(location, "/login") || strings.Contains(location, "two-factor-challenge") {
+		return nil, fmt.Errorf("login failed (status=%d, location=%s)", res.StatusCode, location)
+	}
+	return &site.LoginCredential{Cookie: cookie}, nil
+}
+
+func (usite *Site) ApplyLoginCredential(credential *site.LoginCredential) {
+	usite.SiteConfig.Cookie = credential.Cookie
+}
+
+// Find the form which action contains path. Fallback to the first form of page.
+func findForm(doc *goquery.Document, path string) *goquery.Selection {
+	if form := doc.Find(fmt.Sprintf(`form[action*="%s"]`, path)).First(); form.Length() > 0 {
+		return form
+	}
+	return doc.Find("form").First()
+}
+
+// Fetch site page dom, re-login if got redirected to login page.
+func (usite *Site) getDoc(pageUrl string) (*goquery.Document, *azuretls.Response, error) {
+	return site.GetUrlDocWithRelogin(usite, usite.HttpClient, pageUrl, "/login")
+}
@@ -0,0 +1,140 @@
+package unit3d
+
+// Parse torrents from UNIT3D web torrents list page.
+// Used when the site api_token is not configured or the site disables the API.
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/sagan/ptool/site"
+	"github.com/sagan/ptool/util"
+)
+
+const (
+	SELECTOR_TORRENT_BLOCK         = `.torrent-search--list__results > tbody > tr`
+	SELECTOR_TORRENT               = `a.torrent-search--list__name`
+	SELECTOR_TORRENT_DOWNLOAD_LINK = `a[href*="/torrents/download/"],a[href*="/torrent/download/"]`
+	SELECTOR_TORRENT_TIME          = `.torrent-search--list__age time`
+	SELECTOR_TORRENT_SEEDERS       = `.torrent-search--list__seeders`
+	SELECTOR_TORRENT_LEECHERS      = `.torrent-search--list__leechers`
+	SELECTOR_TORRENT_SNATCHED      = `.torrent-search--list__completed`
+	SELECTOR_TORRENT_SIZE          = `.torrent-search--list__size`
+	SELECTOR_TORRENT_FREE          = `.torrent-icons__freeleech`
+	SELECTOR_TORRENT_DOUBLE_UPLOAD = `.torrent-icons__double-upload`
+	SELECTOR_TORRENT_FEATURED      = `.torrent-icons__featured`
+)
+
+var (
+	torrentIdRegexp  = regexp.MustCompile(`/torrents?/(?:download/)?(?P<id>\d+)\b`)
+	percentageRegexp = regexp.MustCompile(`(?P<percentage>\d+)\s*%`)
+)
+
+func selector(configured string, defaultSelector string) string {
+	if configured != "" {
+		return configured
+	}
+	return defaultSelector
+}
+
+func (usite *Site) parseTorrents(doc *goquery.Document) []*site.Torrent {
+	torrents := []*site.Torrent{}
+	siteConfig := usite.SiteConfig
+	doc.Find(selector(siteConfig.SelectorTorrentBlock, SELECTOR_TORRENT_BLOCK)).Each(func(i int, s *goquery.Selection) {
+		nameEl := s.Find(selector(siteConfig.SelectorTorrent, SELECTOR_TORRENT))
+		downloadEl := s.Find(selector(siteConfig.SelectorTorrentDownloadLink, SELECTOR_TORRENT_DOWNLOAD_LINK))
+		id := ""
+		for _, href := range []string{nameEl.AttrOr("href", ""), downloadEl.AttrOr("href", "")} {
+			if m := torrentIdRegexp.FindStringSubmatch(href); m != nil {
+				id = m[torrentIdRegexp.SubexpIndex("id")]
+				break
+			}
+		}
+		if id == "" {
+			return
+		}
+		downloadUrl := siteConfig.ParseSiteUrl(downloadEl.AttrOr("href", ""), false)
+		if downloadUrl == "" {
+			downloadUrl = siteConfig.ParseSiteUrl("torrents/download/"+id, false)
+		}
+		size, _ := util.ExtractSizeStr(util.DomSanitizedText(
+			s.Find(selector(siteConfig.SelectorTorrentSize, SELECTOR_TORRENT_SIZE))))
+		timeEl := s.Find(selector(siteConfig.SelectorTorrentTime, SELECTOR_TORRENT_TIME))
+		time := int64(0)
+		for _, str := range []string{timeEl.AttrOr("datetime", ""), timeEl.AttrOr("title", "")} {
+			if str != "" {
+				if time = usite.parseTime(str); time > 0 {
+					break
+				}
+			}
+		}
+		if time == 0 {
+			time = util.DomTime(timeEl, usite.Location)
+		}
+		freeleech := float64(0)
+		if freeEl := s.Find(selector(siteConfig.SelectorTorrentFree, SELECTOR_TORRENT_FREE)); freeEl.Length() > 0 {
+			freeleech = 100
+			freeText := freeEl.AttrOr("title", "") + " " + util.DomSanitizedText(freeEl)
+			if m := percentageRegexp.FindStringSubmatch(freeText); m != nil {
+				freeleech = float64(util.ParseInt(m[percentageRegexp.SubexpIndex("percentage")]))
+			}
+		}
+		doubleUpload := s.Find(SELECTOR_TORRENT_DOUBLE_UPLOAD).Length() > 0
+		featured := s.Find(SELECTOR_TORRENT_FEATURED).Length() > 0
+		downloadMultiplier, uploadMultiplier := getMultipliers(freeleech, doubleUpload, featured)
+		tags := []string{}
+		if featured {
+			tags = append(tags, "featured")
+		}
+		isActive := false
+		if siteConfig.SelectorTorrentActive != "" {
+			isActive = s.Find(siteConfig.SelectorTorrentActive).Length() > 0
+		}
+		isCurrentActive := false
+		if siteConfig.SelectorTorrentCurrentActive != "" {
+			isCurrentActive = s.Find(siteConfig.SelectorTorrentCurrentActive).Length() > 0
+		}
+		hnr := siteConfig.GlobalHnR
+		if !hnr && siteConfig.SelectorTorrentHnR != "" {
+			hnr = s.Find(siteConfig.SelectorTorrentHnR).Length() > 0
+		}
+		torrents = append(torrents, &site.Torrent{
+			Name:               util.DomSanitizedText(nameEl),
+			Id:                 fmt.Sprintf("%s.%s", usite.Name, id),
+			DownloadUrl:        downloadUrl,
+			DownloadMultiplier: downloadMultiplier,
+			UploadMultiplier:   uploadMultiplier,
+			DiscountEndTime:    -1,
+			Time:               time,
+			Size:               size,
+			Seeders: util.ParseInt(util.DomSanitizedText(
+				s.Find(selector(siteConfig.SelectorTorrentSeeders, SELECTOR_TORRENT_SEEDERS)))),
+			Leechers: util.ParseInt(util.DomSanitizedText(
+				s.Find(selector(siteConfig.SelectorTorrentLeechers, SELECTOR_TORRENT_LEECHERS)))),
+			Snatched: util.ParseInt(util.DomSanitizedText(
+				s.Find(selector(siteConfig.SelectorTorrentSnatched, SELECTOR_TORRENT_SNATCHED)))),
+			HasHnR:          hnr,
+			IsActive:        isActive || isCurrentActive,
+			IsCurrentActive: isCurrentActive,
+			Tags:            tags,
+		})
+	})
+	return torrents
+}
+
+// Return download & upload multipliers of a torrent.
+// Featured torrents are always 100% freeleech and double upload in UNIT3D.
+func getMultipliers(freeleech float64, doubleUpload bool, featured bool) (
+	downloadMultiplier float64, uploadMultiplier float64) {
+	if featured {
+		return 0, 2
+	}
+	downloadMultiplier = max(1-freeleech/100, 0)
+	uploadMultiplier = 1
+	if doubleUpload {
+		uploadMultiplier = 2
+	}
+	return
+}
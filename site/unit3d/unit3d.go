@@ -3,11 +3,15 @@ package unit3d
 // UNIT3D ( https://github.com/HDInnovations/UNIT3D-Community-Edition )
 // JptvClub、莫妮卡、普斯特等站使用架构
 // 种子下载链接格式：https://jptv.club/torrents/download/39683
+// 配置了 apiKey (api_token) 时使用 /api/torrents/filter API 获取种子列表，否则解析网页种子列表。
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -15,6 +19,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/sagan/ptool/config"
+	"github.com/sagan/ptool/constants"
 	"github.com/sagan/ptool/site"
 	"github.com/sagan/ptool/util"
 )
@@ -26,6 +31,7 @@ type Site struct {
 	Config      *config.ConfigStruct
 	HttpClient  *azuretls.Session
 	HttpHeaders [][]string
+	apiDisabled bool // API returns 404. Fallback to parse web page
 }
 
 // PublishTorrent implements site.Site.
@@ -37,12 +43,30 @@ const (
 	SELECTOR_USERNAME        = ".top-nav__username"
 	SELECTOR_USER_UPLOADED   = ".ratio-bar__uploaded"
 	SELECTOR_USER_DOWNLOADED = ".ratio-bar__downloaded"
+	DEFAULT_TORRENTS_URL     = "torrents"
+	API_TORRENTS_FILTER      = "api/torrents/filter"
+	API_PER_PAGE             = 100
 )
 
+var sortFields = map[string]string{
+	"name":     "name",
+	"time":     "created_at",
+	"size":     "size",
+	"seeders":  "seeders",
+	"leechers": "leechers",
+	"snatched": "times_completed",
+}
+
+var errApiDisabled = fmt.Errorf("site API is disabled")
+
 func (usite *Site) GetDefaultHttpHeaders() [][]string {
 	return usite.HttpHeaders
 }
 
+func (usite *Site) GetTorrentDetails(id string) (*site.TorrentDetails, error) {
+	return nil, site.ErrUnimplemented
+}
+
 func (usite *Site) PurgeCache() {
 }
 
@@ -55,8 +79,7 @@ func (usite *Site) GetSiteConfig() *config.SiteConfigStruct {
 }
 
 func (usite *Site) GetStatus() (*site.Status, error) {
-	doc, res, err := util.GetUrlDocWithAzuretls(usite.SiteConfig.Url+"torrents", usite.HttpClient,
-		usite.GetSiteConfig().Cookie, site.GetUa(usite), usite.GetDefaultHttpHeaders())
+	doc, res, err := usite.getDoc(usite.SiteConfig.Url + "torrents")
 	if err != nil {
 		return nil, err
 	}
@@ -89,15 +112,213 @@ func (usite *Site) GetStatus() (*site.Status, error) {
 
 func (usite *Site) GetAllTorrents(sort string, desc bool, pageMarker string, baseUrl string) (
 	torrents []*site.Torrent, nextPageMarker string, err error) {
-	return nil, "", site.ErrUnimplemented
+	if sort != "" && sort != constants.NONE && sortFields[sort] == "" {
+		err = fmt.Errorf("unsupported sort field: %s", sort)
+		return
+	}
+	if pageMarker == constants.NONE {
+		pageMarker = ""
+	}
+	// page starts from 1
+	page := int64(1)
+	if pageMarker != "" {
+		page = util.ParseInt(pageMarker)
+		if page < 1 {
+			err = fmt.Errorf("invalid page marker: %s", pageMarker)
+			return
+		}
+	}
+	query := url.Values{}
+	query.Set("page", fmt.Sprint(page))
+	if sort != "" && sort != constants.NONE {
+		query.Set("sortField", sortFields[sort])
+		if desc {
+			query.Set("sortDirection", "desc")
+		} else {
+			query.Set("sortDirection", "asc")
+		}
+	}
+	torrents, lastPage, err := usite.getTorrents(baseUrl, query)
+	if err != nil {
+		return
+	}
+	if page < lastPage {
+		nextPageMarker = fmt.Sprint(page + 1)
+	}
+	return
 }
 
 func (usite *Site) GetLatestTorrents(full bool) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	query := url.Values{}
+	query.Set("sortField", "created_at")
+	query.Set("sortDirection", "desc")
+	torrents, _, err := usite.getTorrents(usite.SiteConfig.TorrentsUrl, query)
+	if err != nil {
+		return nil, err
+	}
+	if full {
+		for _, extraUrl := range usite.SiteConfig.TorrentsExtraUrls {
+			extraTorrents, _, err := usite.getTorrents(extraUrl, query)
+			if err != nil {
+				log.Errorf("Failed to get site %s extra torrents from %s: %v", usite.Name, extraUrl, err)
+				continue
+			}
+			torrents = append(torrents, extraTorrents...)
+		}
+	}
+	return torrents, nil
 }
 
+// baseUrl: optional search url, can use "%s" as keyword placeholder.
 func (usite *Site) SearchTorrents(keyword string, baseUrl string) ([]*site.Torrent, error) {
-	return nil, site.ErrUnimplemented
+	if baseUrl == "" {
+		baseUrl = usite.SiteConfig.SearchUrl
+	}
+	query := url.Values{}
+	if strings.Contains(baseUrl, "%s") {
+		baseUrl = strings.Replace(baseUrl, "%s", url.QueryEscape(keyword), 1)
+	} else {
+		searchQueryVariable := "name"
+		if usite.SiteConfig.SearchQueryVariable != "" {
+			searchQueryVariable = usite.SiteConfig.SearchQueryVariable
+		}
+		query.Set(searchQueryVariable, keyword)
+	}
+	torrents, _, err := usite.getTorrents(baseUrl, query)
+	return torrents, err
+}
+
+// Get torrents from API or web torrents page. Return torrents and the last page number.
+// baseUrl: API url, web page url, query string (e.g. "?categories[]=1") or empty (use default).
+// query will be merged into the url.
+func (usite *Site) getTorrents(baseUrl string, query url.Values) (
+	torrents []*site.Torrent, lastPage int64, err error) {
+	pageUrl := ""
+	if baseUrl == "" || baseUrl == constants.NONE || strings.HasPrefix(baseUrl, "?") {
+		defaultUrl := DEFAULT_TORRENTS_URL
+		if usite.SiteConfig.ApiKey != "" && !usite.apiDisabled {
+			defaultUrl = API_TORRENTS_FILTER
+		}
+		if strings.HasPrefix(baseUrl, "?") {
+			pageUrl = util.AppendUrlQueryString(defaultUrl, baseUrl)
+		} else {
+			pageUrl = defaultUrl
+		}
+	} else {
+		pageUrl = baseUrl
+	}
+	urlObj, err := url.Parse(usite.SiteConfig.ParseSiteUrl(pageUrl, false))
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid url: %w", err)
+	}
+	urlQuery := urlObj.Query()
+	for key, values := range query {
+		urlQuery[key] = values
+	}
+	isApi := strings.Contains(urlObj.Path, "/api/")
+	if isApi {
+		if usite.SiteConfig.ApiKey == "" {
+			return nil, 0, fmt.Errorf("site %s apiKey (api_token) is not configured", usite.Name)
+		}
+		urlQuery.Set("api_token", usite.SiteConfig.ApiKey)
+		if urlQuery.Get("perPage") == "" {
+			urlQuery.Set("perPage", fmt.Sprint(API_PER_PAGE))
+		}
+	}
+	urlObj.RawQuery = urlQuery.Encode()
+	if !isApi {
+		return usite.getTorrentsFromPage(urlObj.String())
+	}
+	torrents, lastPage, err = usite.getTorrentsFromApi(urlObj.String())
+	if err == errApiDisabled && (baseUrl == "" || baseUrl == constants.NONE || strings.HasPrefix(baseUrl, "?")) {
+		log.Warnf("Site %s API seems to be disabled, fallback to parse web torrents page", usite.Name)
+		usite.apiDisabled = true
+		return usite.getTorrents(baseUrl, query)
+	}
+	return
+}
+
+func (usite *Site) getTorrentsFromApi(apiUrl string) (torrents []*site.Torrent, lastPage int64, err error) {
+	headers := append(slices.Clone(usite.GetDefaultHttpHeaders()), []string{"Accept", "application/json"})
+	res, _, err := util.FetchUrlWithAzuretls(apiUrl, usite.HttpClient, "", site.GetUa(usite), headers)
+	if err != nil {
+		if res != nil && res.StatusCode == http.StatusNotFound {
+			return nil, 0, errApiDisabled
+		}
+		return nil, 0, err
+	}
+	var data apiTorrentsResponse
+	if err = json.Unmarshal(res.Body, &data); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse API response: %w", err)
+	}
+	for _, apiTorrent := range data.Data {
+		attributes := &apiTorrent.Attributes
+		downloadMultiplier, uploadMultiplier := getMultipliers(float64(attributes.Freeleech),
+			bool(attributes.DoubleUpload), bool(attributes.Featured))
+		downloadUrl := attributes.DownloadLink
+		if downloadUrl == "" {
+			downloadUrl = usite.SiteConfig.ParseSiteUrl("torrents/download/"+apiTorrent.Id.String(), false)
+		}
+		tags := []string{}
+		for _, tag := range []string{attributes.Category, attributes.Type, attributes.Resolution} {
+			if tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		if attributes.Featured {
+			tags = append(tags, "featured")
+		}
+		if attributes.Internal {
+			tags = append(tags, "internal")
+		}
+		if attributes.PersonalRelease {
+			tags = append(tags, "personal")
+		}
+		torrents = append(torrents, &site.Torrent{
+			Name:               attributes.Name,
+			Id:                 fmt.Sprintf("%s.%s", usite.Name, apiTorrent.Id),
+			InfoHash:           strings.ToLower(attributes.InfoHash),
+			DownloadUrl:        downloadUrl,
+			DownloadMultiplier: downloadMultiplier,
+			UploadMultiplier:   uploadMultiplier,
+			DiscountEndTime:    -1,
+			Time:               usite.parseTime(attributes.CreatedAt),
+			Size:               attributes.Size,
+			IsSizeAccurate:     true,
+			Seeders:            attributes.Seeders,
+			Leechers:           attributes.Leechers,
+			Snatched:           attributes.TimesCompleted,
+			// UNIT3D hit and run rule applies to all torrents, it's enabled or disabled site-wide.
+			HasHnR: usite.SiteConfig.GlobalHnR,
+			Tags:   tags,
+		})
+	}
+	lastPage = data.Meta.LastPage
+	if lastPage == 0 && data.Links.Next != "" {
+		lastPage = data.Meta.CurrentPage + 1
+	}
+	return torrents, lastPage, nil
+}
+
+func (usite *Site) getTorrentsFromPage(pageUrl string) (torrents []*site.Torrent, lastPage int64, err error) {
+	doc, res, err := usite.getDoc(pageUrl)
+	if !usite.SiteConfig.AcceptAnyHttpStatus && err != nil || doc == nil {
+		return nil, 0, fmt.Errorf("failed to fetch torrents page dom: %w", err)
+	}
+	if strings.Contains(res.Request.Url, "/login") {
+		return nil, 0, fmt.Errorf("not logined (cookie may has expired)")
+	}
+	return usite.parseTorrents(doc), site.ParseLastPage(doc), nil
+}
+
+// Parse time string in API response or web page.
+// e.g. "2024-01-02T03:04:05.000000Z" or "2024-01-02 03:04:05" (in site timezone).
+func (usite *Site) parseTime(str string) int64 {
+	if t, err := time.Parse(time.RFC3339Nano, str); err == nil {
+		return t.Unix()
+	}
+	t, _ := util.ParseTime(str, usite.Location)
+	return t
 }
 
 func (usite *Site) DownloadTorrent(torrentUrl string) (content []byte, filename string, id string, err error) {
@@ -128,7 +349,7 @@ func (usite *Site) DownloadTorrentById(id string) ([]byte, string, error) {
 }
 
 func NewSite(name string, siteConfig *config.SiteConfigStruct, config *config.ConfigStruct) (site.Site, error) {
-	if siteConfig.Cookie == "" {
+	if siteConfig.Cookie == "" && siteConfig.ApiKey == "" {
 		log.Warnf("Site %s has no cookie provided", name)
 	}
 	location, err := time.LoadLocation(siteConfig.GetTimezone())
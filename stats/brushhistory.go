@@ -0,0 +1,72 @@
+package stats
+
+import (
+	"fmt"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Samples older than this will be purged.
+const BRUSH_HISTORY_RETENTION = int64(30 * 86400)
+
+// gorm "brush_torrent_samples" table. A sample of a brush torrent's state, recorded in each brush run.
+type BrushTorrentSample struct {
+	Client        string `gorm:"primaryKey"`
+	InfoHash      string `gorm:"primaryKey"`
+	Time          int64  `gorm:"primaryKey;index"`
+	Site          string
+	Uploaded      int64
+	Downloaded    int64
+	SizeCompleted int64
+	UploadSpeed   int64
+	DownloadSpeed int64
+	Seeders       int64
+	Leechers      int64
+}
+
+type BrushHistoryDb struct {
+	sqldb *gorm.DB
+}
+
+func NewBrushHistoryDb(file string) (*BrushHistoryDb, error) {
+	// multiple brush tasks of different clients may access the db concurrently
+	sqldb, err := gorm.Open(sqlite.Open(file+"?_pragma=busy_timeout(10000)"), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("error open brush history db: %w", err)
+	}
+	if err = sqldb.AutoMigrate(&BrushTorrentSample{}); err != nil {
+		return nil, fmt.Errorf("brush history db schema init error: %w", err)
+	}
+	return &BrushHistoryDb{sqldb: sqldb}, nil
+}
+
+// Add samples and purge expired ones.
+func (db *BrushHistoryDb) AddSamples(samples []*BrushTorrentSample) error {
+	if len(samples) > 0 {
+		if err := db.sqldb.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(samples, 100).Error; err != nil {
+			return err
+		}
+		return db.sqldb.Where("time < ?", samples[0].Time-BRUSH_HISTORY_RETENTION).
+			Delete(&BrushTorrentSample{}).Error
+	}
+	return nil
+}
+
+// Get samples of torrents of client, in time order. Return a infoHash => samples map.
+func (db *BrushHistoryDb) GetSamples(client string, infoHashes ...string) (
+	map[string][]*BrushTorrentSample, error) {
+	histories := map[string][]*BrushTorrentSample{}
+	for i := 0; i < len(infoHashes); i += 500 {
+		var samples []*BrushTorrentSample
+		if err := db.sqldb.Where("client = ? AND info_hash IN ?", client, infoHashes[i:min(i+500, len(infoHashes))]).
+			Order("time").Find(&samples).Error; err != nil {
+			return nil, err
+		}
+		for _, sample := range samples {
+			histories[sample.InfoHash] = append(histories[sample.InfoHash], sample)
+		}
+	}
+	return histories, nil
+}
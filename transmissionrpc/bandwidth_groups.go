@@ -0,0 +1,60 @@
+package transmissionrpc
+
+import (
+	"context"
+	"fmt"
+)
+
+/*
+	Bandwidth Groups
+	https://github.com/transmission/transmission/blob/4.0.0/docs/rpc-spec.md#48-bandwidth-groups
+*/
+
+// GroupGet returns the bandwidth groups of the session. If names is empty, all groups are returned.
+// Requires RPC v17 (Transmission 4.0.0).
+// https://github.com/transmission/transmission/blob/4.0.0/docs/rpc-spec.md#482-bandwidth-group-accessor-group-get
+func (c *Client) GroupGet(ctx context.Context, names []string) (groups []*BandwidthGroup, err error) {
+	var payload interface{} // omit arguments if no names specified
+	if len(names) > 0 {
+		payload = &groupGetPayload{Group: names}
+	}
+	var result groupGetResults
+	if err = c.rpcCall(ctx, "group-get", payload, &result); err != nil {
+		err = fmt.Errorf("'group-get' rpc method failed: %w", err)
+		return
+	}
+	groups = result.Group
+	return
+}
+
+// GroupSet creates or updates a bandwidth group.
+// Requires RPC v17 (Transmission 4.0.0).
+// https://github.com/transmission/transmission/blob/4.0.0/docs/rpc-spec.md#481-bandwidth-group-mutator-group-set
+func (c *Client) GroupSet(ctx context.Context, group BandwidthGroup) (err error) {
+	if group.Name == "" {
+		return fmt.Errorf("group name can not be empty")
+	}
+	if err = c.rpcCall(ctx, "group-set", group, nil); err != nil {
+		err = fmt.Errorf("'group-set' rpc method failed: %w", err)
+	}
+	return
+}
+
+type groupGetPayload struct {
+	Group []string `json:"group"`
+}
+
+type groupGetResults struct {
+	Group []*BandwidthGroup `json:"group"`
+}
+
+// BandwidthGroup represents a bandwidth group of session. Speed limits are in KB/s.
+// https://github.com/transmission/transmission/blob/4.0.0/docs/rpc-spec.md#481-bandwidth-group-mutator-group-set
+type BandwidthGroup struct {
+	HonorsSessionLimits   bool   `json:"honorsSessionLimits"`      // true if session upload limits are honored
+	Name                  string `json:"name"`                     // Bandwidth group name
+	SpeedLimitDown        int64  `json:"speed-limit-down"`         // max global download speed (KBps)
+	SpeedLimitDownEnabled bool   `json:"speed-limit-down-enabled"` // true means enabled
+	SpeedLimitUp          int64  `json:"speed-limit-up"`           // max global upload speed (KBps)
+	SpeedLimitUpEnabled   bool   `json:"speed-limit-up-enabled"`   // true means enabled
+}
@@ -142,8 +142,10 @@ type Torrent struct {
 	ErrorString             *string            `json:"errorString"`
 	Eta                     *int64             `json:"eta"`
 	EtaIdle                 *int64             `json:"etaIdle"`
+	FileCount               *int64             `json:"file-count"` // RPC v17
 	Files                   []*TorrentFile     `json:"files"`
 	FileStats               []*TorrentFileStat `json:"fileStats"`
+	Group                   *string            `json:"group"` // RPC v17: name of bandwidth group
 	HashString              *string            `json:"hashString"`
 	HaveUnchecked           *int64             `json:"haveUnchecked"`
 	HaveValid               *int64             `json:"haveValid"`
@@ -180,10 +182,12 @@ type Torrent struct {
 	SeedIdleMode            *int64             `json:"seedIdleMode"`
 	SeedRatioLimit          *float64           `json:"seedRatioLimit"`
 	SeedRatioMode           *SeedRatioMode     `json:"seedRatioMode"`
+	SequentialDownload      *bool              `json:"sequential_download"` // RPC v18
 	SizeWhenDone            *cunits.Bits       `json:"sizeWhenDone"`
 	StartDate               *time.Time         `json:"startDate"`
 	Status                  *TorrentStatus     `json:"status"`
 	Trackers                []*Tracker         `json:"trackers"`
+	TrackerList             *string            `json:"trackerList"` // RPC v17: announce URLs, one per line, blank line between tiers
 	TrackerStats            []*TrackerStats    `json:"trackerStats"`
 	TotalSize               *cunits.Bits       `json:"totalSize"`
 	TorrentFile             *string            `json:"torrentFile"`
@@ -36,6 +36,7 @@ type TorrentSetPayload struct {
 	DownloadLimited     *bool          `json:"downloadLimited"`     // true if "downloadLimit" is honored
 	FilesWanted         []int64        `json:"files-wanted"`        // indices of file(s) to download
 	FilesUnwanted       []int64        `json:"files-unwanted"`      // indices of file(s) to not download
+	Group               *string        `json:"group"`               // RPC v17: the name of this torrent's bandwidth group
 	HonorsSessionLimits *bool          `json:"honorsSessionLimits"` // true if session upload limits are honored
 	IDs                 []int64        `json:"ids"`                 // torrent list
 	Labels              []string       `json:"labels"`              // RPC v16: strings of user-defined labels
@@ -49,7 +50,9 @@ type TorrentSetPayload struct {
 	SeedIdleMode        *int64         `json:"seedIdleMode"`        // which seeding inactivity to use
 	SeedRatioLimit      *float64       `json:"seedRatioLimit"`      // torrent-level seeding ratio
 	SeedRatioMode       *SeedRatioMode `json:"seedRatioMode"`       // which ratio mode to use
+	SequentialDownload  *bool          `json:"sequential_download"` // RPC v18: download torrent pieces sequentially
 	TrackerAdd          []string       `json:"trackerAdd"`          // strings of announce URLs to add
+	TrackerList         *string        `json:"trackerList"`         // RPC v17: string of announce URLs, one per line
 	TrackerRemove       []int64        `json:"trackerRemove"`       // ids of trackers to remove
 	TrackerReplace      []interface{}  `json:"trackerReplace"`      // pairs of <trackerId/new announce URLs> (TODO: validate string value usable as is)
 	UploadLimit         *int64         `json:"uploadLimit"`         // maximum upload speed (KBps)
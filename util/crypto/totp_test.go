@@ -0,0 +1,42 @@
+package crypto_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sagan/ptool/util/crypto"
+)
+
+// RFC 6238 Appendix B test vectors (SHA1), truncated to 6 digits.
+// Secret is ASCII "12345678901234567890" in base32.
+func TestTotp(t *testing.T) {
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	tests := []struct {
+		desc   string
+		secret string
+		time   int64
+		want   string
+	}{
+		{"t=59", secret, 59, "287082"},
+		{"t=1111111109", secret, 1111111109, "081804"},
+		{"t=1111111111", secret, 1111111111, "050471"},
+		{"t=1234567890", secret, 1234567890, "005924"},
+		{"t=2000000000", secret, 2000000000, "279037"},
+		{"t=20000000000", secret, 20000000000, "353130"},
+		{"lowercase secret with spaces", "gezd gnbv gy3t qojq gezd gnbv gy3t qojq", 59, "287082"},
+	}
+	for _, tt := range tests {
+		got, err := crypto.Totp(tt.secret, time.Unix(tt.time, 0))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		} else if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestTotpInvalidSecret(t *testing.T) {
+	if _, err := crypto.Totp("not a base32 secret!", time.Unix(59, 0)); err == nil {
+		t.Errorf("expected error for invalid secret")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !windows
+// +build !linux,!darwin,!freebsd,!windows
+
+package osutil
+
+import (
+	"errors"
+)
+
+// Dummy (placeholder). Not supported on current platform.
+func GetFreeSpace(path string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,17 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package osutil
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// Get free disk space (available to unprivileged user) of the file system that path is on.
+func GetFreeSpace(path string) (int64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
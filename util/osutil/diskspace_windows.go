@@ -0,0 +1,21 @@
+//go:build windows
+// +build windows
+
+package osutil
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// Get free disk space (available to current user) of the volume that path is on.
+func GetFreeSpace(path string) (int64, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var freeBytesAvailable uint64
+	if err := windows.GetDiskFreeSpaceEx(pathPtr, &freeBytesAvailable, nil, nil); err != nil {
+		return 0, err
+	}
+	return int64(freeBytesAvailable), nil
+}